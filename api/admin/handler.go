@@ -0,0 +1,427 @@
+// Package admin uses for managing requests and users of all users
+package admin
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/stats"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/admin"
+	auditsrv "github.com/Hargeon/videocmprs/pkg/service/audit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/jsonapi"
+	"go.uber.org/zap"
+)
+
+const (
+	IDBase    = 10
+	IDBitSize = 64
+)
+
+type Handler struct {
+	srv    service.Admin
+	audit  service.Audit
+	logger *zap.Logger
+}
+
+func NewHandler(db *sql.DB, pb service.Publisher, logger *zap.Logger) *Handler {
+	auditSrv := auditsrv.NewService(audit.NewRepository(db), logger)
+	srv := admin.NewService(request.NewRepository(db), user.NewRepository(db),
+		token.NewRepository(db), stats.NewRepository(db), pb, auditSrv)
+
+	return &Handler{srv: srv, audit: auditSrv, logger: logger}
+}
+
+func (h *Handler) InitRoutes() *fiber.App {
+	router := fiber.New()
+	router.Get("/requests", h.listRequests)
+	router.Get("/requests/:id", h.retrieveRequest)
+	router.Post("/requests/:id/fail", h.failRequest)
+	router.Post("/requests/:id/cancel", h.cancelRequest)
+	router.Post("/requests/:id/retry", h.retryRequest)
+	router.Post("/users/:id/disable", h.disableUser)
+	router.Post("/users/:id/enable", h.enableUser)
+	router.Delete("/users/:id", h.deleteUser)
+	router.Get("/stats", h.stats)
+	router.Get("/audit-events", h.listAuditEvents)
+	router.Get("/audit-events/export", h.exportAuditEvents)
+
+	return router
+}
+
+func (h *Handler) listRequests(c *fiber.Ctx) error {
+	pageNumI, err := strconv.Atoi(c.Query("page[number]", "0"))
+	if err != nil {
+		errors := []string{"Invalid page number params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if pageNumI == 1 {
+		pageNumI = 0
+	}
+
+	pageSizeI, err := strconv.Atoi(c.Query("page[size]", "10"))
+	if err != nil {
+		errors := []string{"Invalid page size params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	filter := &query.RequestFilter{Status: c.Query("filter[status]")}
+
+	if userID := c.Query("filter[user_id]"); userID != "" {
+		filter.UserID, err = strconv.ParseInt(userID, IDBase, IDBitSize)
+		if err != nil || filter.UserID <= 0 {
+			errors := []string{"Invalid user ID filter"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
+	if orgID := c.Query("filter[organization_id]"); orgID != "" {
+		filter.OrganizationID, err = strconv.ParseInt(orgID, IDBase, IDBitSize)
+		if err != nil || filter.OrganizationID <= 0 {
+			errors := []string{"Invalid organization ID filter"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
+	if after := c.Query("filter[created_after]"); after != "" {
+		filter.CreatedAfter, err = time.Parse(time.RFC3339, after)
+		if err != nil {
+			errors := []string{"Invalid created_after filter, RFC 3339 time expected"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
+	if before := c.Query("filter[created_before]"); before != "" {
+		filter.CreatedBefore, err = time.Parse(time.RFC3339, before)
+		if err != nil {
+			errors := []string{"Invalid created_before filter, RFC 3339 time expected"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
+	q := &query.Params{
+		PageNumber: uint64(pageNumI),
+		PageSize:   uint64(pageSizeI),
+	}
+
+	res, err := h.srv.ListRequests(c.Context(), q, filter)
+	if err != nil {
+		h.logger.Error("List requests of all users", zap.Error(err))
+
+		errors := []string{"Can not fetch requests"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+func (h *Handler) retrieveRequest(c *fiber.Ctx) error {
+	id, ok := h.id(c)
+	if !ok {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res, err := h.srv.RetrieveRequest(c.Context(), id)
+
+	return h.requestResponse(c, id, res, err)
+}
+
+// failRequest marks request as failed, optional details attribute of
+// request body is used as reason
+func (h *Handler) failRequest(c *fiber.Ctx) error {
+	id, ok := h.id(c)
+	if !ok {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	req := new(request.Resource)
+
+	if len(c.Body()) > 0 {
+		if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), req); err != nil {
+			errors := []string{"Request is not in jsonapi format"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
+	res, err := h.srv.FailRequest(c.Context(), id, req.Details)
+
+	return h.requestResponse(c, id, res, err)
+}
+
+func (h *Handler) cancelRequest(c *fiber.Ctx) error {
+	id, ok := h.id(c)
+	if !ok {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res, err := h.srv.CancelRequest(c.Context(), id)
+
+	return h.requestResponse(c, id, res, err)
+}
+
+func (h *Handler) retryRequest(c *fiber.Ctx) error {
+	id, ok := h.id(c)
+	if !ok {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res, err := h.srv.RetryRequest(c.Context(), id)
+
+	return h.requestResponse(c, id, res, err)
+}
+
+func (h *Handler) disableUser(c *fiber.Ctx) error {
+	return h.setDisabled(c, true)
+}
+
+func (h *Handler) enableUser(c *fiber.Ctx) error {
+	return h.setDisabled(c, false)
+}
+
+func (h *Handler) setDisabled(c *fiber.Ctx, disabled bool) error {
+	id, ok := h.id(c)
+	if !ok {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if h.self(c, id) {
+		errors := []string{"Admin can't disable own account"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	err := h.srv.DisableUser(c.Context(), id, disabled)
+
+	return h.userResponse(c, id, err)
+}
+
+func (h *Handler) deleteUser(c *fiber.Ctx) error {
+	id, ok := h.id(c)
+	if !ok {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if h.self(c, id) {
+		errors := []string{"Admin can't delete own account"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	err := h.srv.DeleteUser(c.Context(), id)
+
+	return h.userResponse(c, id, err)
+}
+
+func (h *Handler) stats(c *fiber.Ctx) error {
+	res, err := h.srv.Stats(c.Context())
+	if err != nil {
+		h.logger.Error("Retrieve stats", zap.Error(err))
+
+		errors := []string{"Can not fetch stats"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+func (h *Handler) listAuditEvents(c *fiber.Ctx) error {
+	pageNumI, err := strconv.Atoi(c.Query("page[number]", "0"))
+	if err != nil {
+		errors := []string{"Invalid page number params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if pageNumI == 1 {
+		pageNumI = 0
+	}
+
+	pageSizeI, err := strconv.Atoi(c.Query("page[size]", "10"))
+	if err != nil {
+		errors := []string{"Invalid page size params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	filter, msg := h.auditFilter(c)
+	if msg != "" {
+		errors := []string{msg}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	q := &query.Params{
+		PageNumber: uint64(pageNumI),
+		PageSize:   uint64(pageSizeI),
+	}
+
+	res, err := h.audit.List(c.Context(), q, filter)
+	if err != nil {
+		h.logger.Error("List audit events", zap.Error(err))
+
+		errors := []string{"Can not fetch audit events"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+// exportAuditEvents returns all audit events matching filters as CSV file
+func (h *Handler) exportAuditEvents(c *fiber.Ctx) error {
+	filter, msg := h.auditFilter(c)
+	if msg != "" {
+		errors := []string{msg}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := h.audit.Export(c.Context(), filter, buf); err != nil {
+		h.logger.Error("Export audit events", zap.Error(err))
+
+		errors := []string{"Can not export audit events"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Attachment("audit-events.csv")
+
+	return c.Status(http.StatusOK).Send(buf.Bytes())
+}
+
+// auditFilter parses filters of audit events, returns error message when
+// some filter is invalid
+func (h *Handler) auditFilter(c *fiber.Ctx) (*query.AuditFilter, string) {
+	var err error
+
+	filter := &query.AuditFilter{
+		Action:       c.Query("filter[action]"),
+		ResourceType: c.Query("filter[resource_type]"),
+		IP:           c.Query("filter[ip]"),
+	}
+
+	if userID := c.Query("filter[user_id]"); userID != "" {
+		filter.UserID, err = strconv.ParseInt(userID, IDBase, IDBitSize)
+		if err != nil || filter.UserID <= 0 {
+			return nil, "Invalid user ID filter"
+		}
+	}
+
+	if resourceID := c.Query("filter[resource_id]"); resourceID != "" {
+		filter.ResourceID, err = strconv.ParseInt(resourceID, IDBase, IDBitSize)
+		if err != nil || filter.ResourceID <= 0 {
+			return nil, "Invalid resource ID filter"
+		}
+	}
+
+	if after := c.Query("filter[created_after]"); after != "" {
+		filter.CreatedAfter, err = time.Parse(time.RFC3339, after)
+		if err != nil {
+			return nil, "Invalid created_after filter, RFC 3339 time expected"
+		}
+	}
+
+	if before := c.Query("filter[created_before]"); before != "" {
+		filter.CreatedBefore, err = time.Parse(time.RFC3339, before)
+		if err != nil {
+			return nil, "Invalid created_before filter, RFC 3339 time expected"
+		}
+	}
+
+	return filter, ""
+}
+
+// requestResponse writes request or maps error of admin service to response
+func (h *Handler) requestResponse(c *fiber.Ctx, id int64, res jsonapi.Linkable, err error) error {
+	switch {
+	case errors.Is(err, admin.ErrRequestNotPresent):
+		errors := []string{"Request not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	case errors.Is(err, admin.ErrInvalidTransition), errors.Is(err, admin.ErrStatusChanged):
+		errors := []string{"Action is not allowed in current status of request"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	case errors.Is(err, admin.ErrOriginalVideoMissing):
+		errors := []string{"Original video is missing"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	case err != nil:
+		h.logger.Error("Admin request action", zap.Error(err), zap.Int64("Request ID", id))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+// userResponse maps error of admin service to response
+func (h *Handler) userResponse(c *fiber.Ctx, id int64, err error) error {
+	if errors.Is(err, admin.ErrUserNotPresent) {
+		errors := []string{"User not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Admin user action", zap.Error(err), zap.Int64("User ID", id))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *Handler) id(c *fiber.Ctx) (int64, bool) {
+	id, err := strconv.ParseInt(c.Params("id"), IDBase, IDBitSize)
+
+	return id, err == nil && id > 0
+}
+
+// self checks if admin tries to disable or delete own account
+func (h *Handler) self(c *fiber.Ctx, id int64) bool {
+	uID, ok := c.Locals("user_id").(int64)
+
+	return ok && uID == id
+}
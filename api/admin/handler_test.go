@@ -0,0 +1,294 @@
+package admin
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type rabbitSuccess struct{}
+
+func (r *rabbitSuccess) Publish(body []byte) error {
+	return nil
+}
+
+func (r *rabbitSuccess) Ping() error {
+	return nil
+}
+
+func requestRows(status string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
+		"requests.details", "requests.bitrate", "requests.resolution_x",
+		"requests.resolution_y", "requests.ratio_x", "requests.ratio_y",
+		"requests.video_name", "origin_video.id", "origin_video.name",
+		"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
+		"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
+		"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
+		"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
+		"converted_video.resolution_y", "converted_video.ratio_x",
+		"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
+		4, 2, status, "", 64000, 0, 0, 0, 0, "new_video", 1, "new_video", 15000,
+		78000, 1200, 800, 6, 5, "new_service_id", nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil)
+}
+
+var auditColumns = []string{"id", "user_id", "action", "resource_type", "resource_id", "ip", "user_agent",
+	"details", "created_at"}
+
+func TestHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, &rabbitSuccess{}, logger)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Mount("/admin", h.InitRoutes())
+
+	cases := []struct {
+		name           string
+		mock           func()
+		requestMock    func() *http.Request
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "List requests with filter",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests (.+) WHERE \\(requests.deleted_at IS NULL AND requests.user_id = \\$1 AND requests.status = \\$2\\)").
+					WithArgs(2, "failed").
+					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
+						"requests.details", "requests.bitrate", "requests.resolution_x", "requests.resolution_y",
+						"requests.ratio_x", "requests.ratio_y", "requests.video_name", "origin_video.id",
+						"origin_video.name", "origin_video.size", "origin_video.service_id", "converted_video.id",
+						"converted_video.name", "converted_video.size", "converted_video.service_id"}).
+						AddRow(4, 2, "failed", "Failed connection to worker", 64000, 0, 0, 0, 0, "video",
+							1, "video", 1000, "service_id", nil, nil, nil, nil))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/admin/requests?filter[user_id]=2&filter[status]=failed", nil)
+			},
+			expectedBody:   `"user_id":2`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "List requests with invalid time filter",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/admin/requests?filter[created_after]=yesterday", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid created_after filter, RFC 3339 time expected"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Retrieve request of other user",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(4).
+					WillReturnRows(requestRows("success"))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/admin/requests/4", nil)
+			},
+			expectedBody:   `"status":"success"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Cancel finished request",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(4).
+					WillReturnRows(requestRows("success"))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/admin/requests/4/cancel", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Action is not allowed in current status of request"}]}` + "\n",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "Fail request with reason",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(4).
+					WillReturnRows(requestRows("original_in_review"))
+
+				mock.ExpectExec("UPDATE requests SET details = (.+), status = (.+) WHERE").
+					WithArgs("Stuck in worker", "failed", 4, "original_in_review").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(4).
+					WillReturnRows(requestRows("failed"))
+
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(1, audit.ActionAdminRequestFail, "requests", 4, "", "", `{"reason":"Stuck in worker"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"requests","attributes":{"details":"Stuck in worker"}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/admin/requests/4/fail", strings.NewReader(body))
+			},
+			expectedBody:   `"status":"failed"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Retry missing request",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(4).
+					WillReturnError(sql.ErrNoRows)
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/admin/requests/4/retry", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Request not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Disable user",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET disabled_at").
+					WithArgs(sqlmock.AnyArg(), 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(1, audit.ActionAdminUserDisable, "users", 2, "", "", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked_at").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/admin/users/2/disable", nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Enable unknown user",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET disabled_at").
+					WithArgs(nil, 3).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/admin/users/3/enable", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"User not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Delete own account",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/admin/users/1", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Admin can't delete own account"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Stats",
+			mock: func() {
+				mock.ExpectQuery("SELECT \\(SELECT COUNT\\(\\*\\) FROM users").
+					WillReturnRows(sqlmock.NewRows([]string{"users", "disabled_users", "stored_videos", "stored_bytes"}).
+						AddRow(10, 1, 25, 1048576))
+				mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) FROM requests").
+					WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+						AddRow("original_in_review", 3).
+						AddRow("success", 12))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+			},
+			expectedBody:   `"stored_bytes":1048576`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "List audit events with filter",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM audit_events WHERE \\(user_id = \\$1 AND action = \\$2\\) ORDER BY created_at DESC, id DESC LIMIT 10 OFFSET 0").
+					WithArgs(2, audit.ActionLoginFailed).
+					WillReturnRows(sqlmock.NewRows(auditColumns).
+						AddRow(7, 2, audit.ActionLoginFailed, "users", 2, "127.0.0.1", "curl/7.79.1", "",
+							time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/admin/audit-events?filter[user_id]=2&filter[action]=login.failed", nil)
+			},
+			expectedBody:   `"type":"audit-events","id":"7","attributes":{"action":"login.failed","created_at":"2021-10-01T12:00:00Z","ip":"127.0.0.1"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "List audit events with invalid resource filter",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/admin/audit-events?filter[resource_id]=video", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid resource ID filter"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Export audit events",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM audit_events WHERE \\(ip = \\$1\\)").
+					WithArgs("127.0.0.1").
+					WillReturnRows(sqlmock.NewRows(auditColumns).
+						AddRow(7, nil, audit.ActionLoginFailed, "", nil, "127.0.0.1", "curl/7.79.1",
+							`{"email":"check@check.com"}`, time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/admin/audit-events/export?filter[ip]=127.0.0.1", nil)
+			},
+			expectedBody:   "7,2021-10-01T12:00:00Z,,login.failed,,,127.0.0.1,curl/7.79.1,\"{\"\"email\"\":\"\"check@check.com\"\"}\"\n",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+
+			resp, err := app.Test(testCase.requestMock())
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code. expected: %d, got: %d\n", testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading response body, error: %s\n", err.Error())
+			}
+
+			if !strings.Contains(string(body), testCase.expectedBody) {
+				t.Errorf("Invalid body\nexpected: %s\ngot: %s\n", testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
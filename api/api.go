@@ -6,12 +6,25 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/Hargeon/videocmprs/api/admin"
+	"github.com/Hargeon/videocmprs/api/apikey"
 	"github.com/Hargeon/videocmprs/api/auth"
+	"github.com/Hargeon/videocmprs/api/files"
 	"github.com/Hargeon/videocmprs/api/middleware"
+	"github.com/Hargeon/videocmprs/api/organization"
 	"github.com/Hargeon/videocmprs/api/request"
+	"github.com/Hargeon/videocmprs/api/share"
 	"github.com/Hargeon/videocmprs/api/user"
 	"github.com/Hargeon/videocmprs/api/video"
+	keyrepo "github.com/Hargeon/videocmprs/pkg/repository/apikey"
+	limitrepo "github.com/Hargeon/videocmprs/pkg/repository/ratelimit"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
 	"github.com/Hargeon/videocmprs/pkg/service"
+	keysrv "github.com/Hargeon/videocmprs/pkg/service/apikey"
+	"github.com/Hargeon/videocmprs/pkg/service/cloud"
+	"github.com/Hargeon/videocmprs/pkg/service/jwt"
+	"github.com/Hargeon/videocmprs/pkg/service/ratelimit"
+	"github.com/Hargeon/videocmprs/pkg/service/rbac"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -38,7 +51,21 @@ func (h *Handler) InitRoutes() *fiber.App {
 	app.Use(cors.New())
 	app.Use(logger.New())
 	app.Use(recover.New())
+	app.Use(middleware.ClientInfo)
 	app.Static("/docs/v1", "./docs/v1")
+	app.Get("/.well-known/jwks.json", h.jwks)
+
+	// buckets are shared by replicas only with RATE_LIMIT_STORE=postgres
+	limiter := ratelimit.NewEnvService(limitrepo.NewRepository(h.db))
+
+	// share links are opened by clients without account, so they are
+	// resolved outside of json api. Passwords of links are limited per ip
+	// for every method
+	sh := share.NewHandler(h.db, h.cs, h.logger)
+	app.Use("/share", middleware.RateLimit(limiter, ratelimit.GroupAuth))
+	app.Mount("/share", sh.PublicRoutes())
+	// downloads of files which can't be presigned by storage, access is granted by signature of url
+	app.Mount(cloud.FilesPath, files.NewHandler(h.db, h.cs, h.logger).InitRoutes())
 
 	api := app.Group("/api")
 
@@ -48,18 +75,60 @@ func (h *Handler) InitRoutes() *fiber.App {
 
 	api.Get("/health", h.health)
 
+	identify := middleware.UserIdentify(token.NewRepository(h.db), keysrv.NewService(keyrepo.NewRepository(h.db)))
+	apiLimit := middleware.RateLimit(limiter, ratelimit.GroupAPI)
+	videosAccess := middleware.RequireAccess(rbac.VideosRead, rbac.VideosWrite)
+
 	v1 := api.Group("/v1")
+	ah := auth.NewHandler(h.db, h.logger)
+	// single sign-on is opened by browser redirects, so it is mounted before Accept check
+	v1.Mount("/auth/oidc", ah.OIDCRoutes())
+	vh := video.NewHandler(h.db, h.cs, h.logger)
+	// video files are streamed to players, which don't accept json:api
+	v1.Mount("/videos", vh.ContentRoutes(identify, apiLimit, videosAccess))
 	v1.Use(middleware.AcceptHeader)
+	// clients without account are limited per ip
+	v1.Use("/users", middleware.RateLimit(limiter, ratelimit.GroupAuth))
+	v1.Use("/auth/sign-in", middleware.RateLimit(limiter, ratelimit.GroupAuth))
+	v1.Use("/auth/refresh", middleware.RateLimit(limiter, ratelimit.GroupAuth))
+	v1.Use("/auth/password", middleware.RateLimit(limiter, ratelimit.GroupAuth))
+	v1.Use("/auth/verify-email", middleware.RateLimit(limiter, ratelimit.GroupAuth))
 	v1.Mount("/users", user.NewHandler(h.db, h.logger).InitRoutes())
-	v1.Mount("/auth", auth.NewHandler(h.db, h.logger).InitRoutes())
-	v1.Use(middleware.UserIdentify)
+	v1.Mount("/auth", ah.InitRoutes())
+	v1.Use(identify)
+	v1.Use(apiLimit)
+	v1.Post("/requests", middleware.RateLimit(limiter, ratelimit.GroupUploads))
+	v1.Use("/requests", middleware.RequireAccess(rbac.RequestsRead, rbac.RequestsWrite))
+	v1.Use("/videos", videosAccess)
+	v1.Use("/share-links", middleware.RequireAccess(rbac.VideosRead, rbac.VideosWrite))
+	v1.Use("/api-keys", middleware.RequireAccess(rbac.APIKeysRead, rbac.APIKeysWrite))
+	v1.Use("/organizations", middleware.RequireAccess(rbac.OrgsRead, rbac.OrgsWrite))
+	v1.Use("/admin", middleware.RequirePermission(rbac.Admin))
 
 	v1.Mount("/requests", request.NewHandler(h.db, h.cs, h.publisher, h.logger).InitRoutes())
-	v1.Mount("/videos", video.NewHandler(h.db, h.cs, h.logger).InitRoutes())
+	v1.Mount("/videos", vh.InitRoutes())
+	v1.Mount("/share-links", sh.InitRoutes())
+	v1.Mount("/api-keys", apikey.NewHandler(h.db, h.logger).InitRoutes())
+	v1.Mount("/organizations", organization.NewHandler(h.db, h.logger).InitRoutes())
+	v1.Mount("/admin", admin.NewHandler(h.db, h.publisher, h.logger).InitRoutes())
 
 	return app
 }
 
+// jwks returns public keys used for access token verification
+func (h *Handler) jwks(c *fiber.Ctx) error {
+	m, err := jwt.Default()
+	if err != nil {
+		h.logger.Error("jwt.Default()", zap.String("Error", err.Error()))
+
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.Status(http.StatusOK).JSON(m.JWKS())
+}
+
 func (h *Handler) health(c *fiber.Ctx) error {
 	dbStatus := "OK"
 	if err := h.db.Ping(); err != nil {
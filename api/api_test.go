@@ -8,17 +8,20 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/Hargeon/videocmprs/pkg/service"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/jsonapi"
 	"go.uber.org/zap"
 )
 
 type cloudMock struct{}
 
-func (c *cloudMock) Upload(ctx context.Context, header *multipart.FileHeader) (string, error) {
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
 	if header.Filename == "failed" {
 		return "", errors.New("failed connection")
 	}
@@ -30,6 +33,26 @@ func (c *cloudMock) URL(filename string) (string, error) {
 	return filename, nil
 }
 
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	if filename == "error" {
+		return errors.New("mock error")
+	}
+
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	return &service.CloudObject{Name: filename}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
 type rabbitSuccess struct{}
 
 func (r *rabbitSuccess) Publish(body []byte) error {
@@ -143,3 +166,209 @@ func TestHealth(t *testing.T) {
 		})
 	}
 }
+
+func TestJWKS(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, new(rabbitSuccess), new(cloudMock), logger)
+
+	app := h.InitRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Unexpected error when creating a stub request, error: %s\n",
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Invalid status code. expected: %d, got: %d\n",
+			http.StatusOK, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error when reading response body, error: %s\n", err.Error())
+	}
+
+	// shared secret is never published
+	if expected := `{"keys":[]}`; string(body) != expected {
+		t.Errorf("Invalid body\nexpected: %s\ngot: %s\n", expected, string(body))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	os.Setenv("RATE_LIMIT_AUTH", "1/1h")
+	defer os.Unsetenv("RATE_LIMIT_AUTH")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, new(rabbitSuccess), new(cloudMock), logger)
+
+	cases := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		mock    func()
+		// status of the first request, the second one is limited
+		expectedStatus int
+	}{
+		{
+			// invalid body is rejected by handler, so db isn't used
+			name:           "Sign in",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/sign-in",
+			mock:           func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Second step of sign in",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/sign-in/mfa",
+			mock:           func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Refresh",
+			method:         http.MethodPost,
+			path:           "/api/v1/auth/refresh",
+			mock:           func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Password of share link",
+			method: http.MethodPost,
+			path:   "/share/qwe",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM share_links").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:    "Share link opened with password header",
+			method:  http.MethodGet,
+			path:    "/share/qwe",
+			headers: map[string]string{"X-Share-Password": "qwe"},
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM share_links").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+
+			// every app has its own buckets
+			app := h.InitRoutes()
+
+			for _, expected := range []int{testCase.expectedStatus, http.StatusTooManyRequests} {
+				req := httptest.NewRequest(testCase.method, testCase.path, strings.NewReader("{}"))
+				req.Header.Set("Accept", jsonapi.MediaType)
+
+				for name, value := range testCase.headers {
+					req.Header.Set(name, value)
+				}
+
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("Unexpected error when creating a stub request, error: %s\n",
+						err.Error())
+				}
+
+				if resp.StatusCode != expected {
+					t.Errorf("Invalid status code. expected: %d, got: %d\n", expected, resp.StatusCode)
+				}
+
+				if limit := resp.Header.Get("RateLimit-Limit"); limit != "1" {
+					t.Errorf("Invalid RateLimit-Limit, expected: 1, got: %q\n", limit)
+				}
+			}
+		})
+	}
+}
+
+func TestBrowserRoutes(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	app := NewHandler(db, new(rabbitSuccess), new(cloudMock), logger).InitRoutes()
+
+	cases := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			// single sign-on isn't configured without OIDC_ISSUER
+			name:           "OIDC login",
+			path:           "/api/v1/auth/oidc/login",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "OIDC callback",
+			path:           "/api/v1/auth/oidc/callback?state=qwe&code=qwe",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			// players don't send json:api Accept header, errors are sent as text
+			name:           "Video content",
+			path:           "/api/v1/videos/1/content",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "Should be Bearer token\n",
+		},
+		{
+			name:           "Json api route",
+			path:           "/api/v1/auth/me",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+			req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code. expected: %d, got: %d\n", testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading response body, error: %s\n", err.Error())
+			}
+
+			if testCase.expectedBody != "" && string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body, expected: %q, got: %q\n", testCase.expectedBody, string(body))
+			}
+		})
+	}
+}
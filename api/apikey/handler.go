@@ -0,0 +1,200 @@
+package apikey
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/api/response"
+	keyrepo "github.com/Hargeon/videocmprs/pkg/repository/apikey"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/apikey"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/jsonapi"
+	"go.uber.org/zap"
+)
+
+const (
+	IDBase    = 10
+	IDBitSize = 64
+)
+
+type Handler struct {
+	srv    service.APIKey
+	logger *zap.Logger
+}
+
+func NewHandler(db *sql.DB, logger *zap.Logger) *Handler {
+	srv := apikey.NewService(keyrepo.NewRepository(db))
+
+	return &Handler{srv: srv, logger: logger}
+}
+
+func (h *Handler) InitRoutes() *fiber.App {
+	router := fiber.New()
+	router.Use(h.withoutAPIKey)
+	router.Post("/", h.create)
+	router.Get("/", h.list)
+	router.Delete("/:id", h.delete)
+
+	return router
+}
+
+// withoutAPIKey rejects requests authenticated by API key, so a leaked
+// key can't be used for creating new ones
+func (h *Handler) withoutAPIKey(c *fiber.Ctx) error {
+	if _, ok := c.Locals("api_key_id").(int64); ok {
+		errors := []string{"API keys can't be managed with API key"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
+	}
+
+	return c.Next()
+}
+
+func (h *Handler) create(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res := new(keyrepo.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), res); err != nil {
+		h.logger.Error("Can't unmarshal request for creating api key", zap.Error(err),
+			zap.Int64("User ID", uID))
+
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	validation := validator.New()
+
+	if err := validation.Struct(res); err != nil {
+		h.logger.Error("Validation Failed", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res.UserID = uID
+	key, err := h.srv.Create(c.Context(), res)
+
+	switch {
+	case errors.Is(err, apikey.ErrInvalidScope):
+		errors := []string{"Invalid scope"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	case errors.Is(err, apikey.ErrExpiresInPast):
+		errors := []string{"Expiration time is in the past"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	case err != nil:
+		h.logger.Error("Create api key", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not create api key"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), key)
+}
+
+func (h *Handler) list(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	pageNumI, err := strconv.Atoi(c.Query("page[number]", "0"))
+	if err != nil {
+		errors := []string{"Invalid page number params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if pageNumI == 1 {
+		pageNumI = 0
+	}
+
+	pageSizeI, err := strconv.Atoi(c.Query("page[size]", "10"))
+	if err != nil {
+		errors := []string{"Invalid page size params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	q := &query.Params{
+		RelationID: uID,
+		PageNumber: uint64(pageNumI),
+		PageSize:   uint64(pageSizeI),
+	}
+
+	res, err := h.srv.List(c.Context(), q)
+
+	if err != nil {
+		h.logger.Error("List api keys", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not fetch api keys"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+// delete revokes api key
+func (h *Handler) delete(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), IDBase, IDBitSize)
+
+	if err != nil || id <= 0 {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	err = h.srv.Delete(c.Context(), uID, id)
+
+	if errors.Is(err, apikey.ErrAPIKeyNotPresent) {
+		errors := []string{"API key not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Revoke api key", zap.Error(err), zap.Int64("API key ID", id))
+
+		errors := []string{"Can not revoke api key"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
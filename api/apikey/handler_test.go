@@ -0,0 +1,170 @@
+package apikey
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	keyrepo "github.com/Hargeon/videocmprs/pkg/repository/apikey"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+func TestHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, logger)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		if c.Get("X-Test-Api-Key") != "" {
+			c.Locals("api_key_id", int64(7))
+		}
+
+		return c.Next()
+	})
+	app.Mount("/api-keys", h.InitRoutes())
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name           string
+		mock           func()
+		requestMock    func() *http.Request
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Create key",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", keyrepo.TableName)).
+					WithArgs(1, "ci", sqlmock.AnyArg(), sqlmock.AnyArg(), "requests:write", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"api-keys","attributes":{"name":"ci","scopes":["requests:write"]}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(body))
+			},
+			expectedBody:   `"key":"vcp_`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Create key with unknown scope",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"api-keys","attributes":{"name":"ci","scopes":["admin"]}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(body))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid scope"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Create key without name",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"api-keys","attributes":{}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(body))
+			},
+			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Create key with api key",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"api-keys","attributes":{"name":"ci"}}}`
+				req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(body))
+				req.Header.Set("X-Test-Api-Key", "1")
+
+				return req
+			},
+			expectedBody:   `{"errors":[{"title":"API keys can't be managed with API key"}]}` + "\n",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "List keys",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", keyrepo.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "prefix", "scopes",
+						"expires_at", "last_used_at", "created_at"}).
+						AddRow(3, 1, "ci", "abcd1234", "requests:write", nil, createdAt, createdAt))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/api-keys", nil)
+			},
+			expectedBody:   `"prefix":"abcd1234"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Revoke unknown key",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", keyrepo.TableName)).
+					WithArgs(sqlmock.AnyArg(), 3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/api-keys/3", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"API key not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Revoke key",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", keyrepo.TableName)).
+					WithArgs(sqlmock.AnyArg(), 3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/api-keys/3", nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+
+			resp, err := app.Test(testCase.requestMock())
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code. expected: %d, got: %d\n", testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading response body, error: %s\n", err.Error())
+			}
+
+			if !strings.Contains(string(body), testCase.expectedBody) {
+				t.Errorf("Invalid body\nexpected: %s\ngot: %s\n", testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -3,14 +3,27 @@ package auth
 import (
 	"bytes"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Hargeon/videocmprs/api/middleware"
 	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/attempt"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
 	"github.com/Hargeon/videocmprs/pkg/service"
+	auditsrv "github.com/Hargeon/videocmprs/pkg/service/audit"
 	"github.com/Hargeon/videocmprs/pkg/service/auth"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+	"github.com/Hargeon/videocmprs/pkg/service/lockout"
+	"github.com/Hargeon/videocmprs/pkg/service/oidc"
+	"github.com/Hargeon/videocmprs/pkg/service/password"
+	"github.com/Hargeon/videocmprs/pkg/service/quota"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -19,22 +32,49 @@ import (
 )
 
 type Handler struct {
-	srv    service.Tokenable
-	logger *zap.Logger
+	srv      service.Tokenable
+	account  service.Account
+	mfa      service.MFA
+	sso      service.SSO
+	limiter  service.LoginLimiter
+	quota    service.Quota
+	denylist service.TokenDenylist
+	audit    service.AuditRecorder
+	policy   service.PasswordPolicy
+	logger   *zap.Logger
 }
 
 func NewHandler(db *sql.DB, logger *zap.Logger) *Handler {
 	repo := user.NewRepository(db)
-	srv := auth.NewService(repo)
+	tokens := token.NewRepository(db)
+	hasher := encryption.NewPasswordHasher(encryption.DefaultArgon2Params)
+	srv := auth.NewEnvService(repo, tokens, hasher)
+	quotas := quota.NewEnvService(repo)
+	auditRepo := audit.NewRepository(db)
+	limiter := lockout.NewEnvService(attempt.NewRepository(db), auditRepo)
+	sso := oidc.NewEnvService(repo, tokens, srv, hasher)
 
-	return &Handler{srv: srv, logger: logger}
+	return &Handler{srv: srv, account: srv, mfa: srv, sso: sso, limiter: limiter, quota: quotas, denylist: tokens,
+		audit: auditsrv.NewService(auditRepo, logger), policy: password.NewEnvService(logger), logger: logger}
 }
 
 func (h *Handler) InitRoutes() *fiber.App {
 	router := fiber.New()
 	router.Post("/sign-in", h.signIn)
-	router.Use(middleware.UserIdentify)
+	router.Post("/sign-in/mfa", h.signInMFA)
+	router.Post("/refresh", h.refresh)
+	router.Post("/verify-email", h.verifyEmail)
+	router.Post("/verify-email/resend", h.resendVerification)
+	router.Post("/password/forgot", h.forgotPassword)
+	router.Post("/password/reset", h.resetPassword)
+	router.Use(middleware.UserIdentify(h.denylist, nil))
+	router.Post("/logout", h.logout)
 	router.Get("/me", h.retrieve)
+	router.Get("/me/usage", h.usage)
+	router.Post("/mfa/totp", h.enrollTOTP)
+	router.Post("/mfa/totp/confirm", h.confirmTOTP)
+	router.Delete("/mfa/totp", h.disableTOTP)
+	router.Post("/mfa/recovery-codes", h.regenerateRecoveryCodes)
 
 	return router
 }
@@ -60,18 +100,67 @@ func (h *Handler) signIn(c *fiber.Ctx) error {
 		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
 	}
 
+	wait, err := h.limiter.Check(c.Context(), u.Email, c.IP())
+	if err != nil {
+		h.logger.Error("Check failed sign in attempts", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	if wait > 0 {
+		c.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+
+		errors := []string{"Too many failed sign in attempts, retry later"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusTooManyRequests, errors)
+	}
+
 	resource, err := h.srv.GenerateToken(c.Context(), u)
 	if err != nil {
-		if errors.Is(err, service.ErrUserNotExists) {
-			errors := []string{"User is not exists"}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			if err = h.limiter.Fail(c.Context(), u.Email, c.IP()); err != nil {
+				h.logger.Error("Count failed sign in attempt", zap.Error(err))
+			}
 
-			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+			h.recordSignIn(c, audit.ActionLoginFailed, 0, map[string]string{"email": u.Email, "method": "password"})
+
+			errors := []string{"Invalid credentials"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+		}
+
+		if errors.Is(err, service.ErrUserDisabled) {
+			errors := []string{"User is disabled"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
+		}
+
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			errors := []string{"Email is not verified"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
 		}
+
 		errors := []string{"Something went wrong"}
 
 		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
 	}
 
+	if usr, ok := resource.(*user.Resource); ok && usr.MFAToken != "" {
+		// failed attempts are reset only after the second factor
+		return jsonapi.MarshalPayload(c.Status(http.StatusAccepted), resource)
+	}
+
+	if err = h.limiter.Succeed(c.Context(), u.Email); err != nil {
+		h.logger.Error("Reset failed sign in attempts", zap.Error(err))
+	}
+
+	if usr, ok := resource.(*user.Resource); ok {
+		h.recordSignIn(c, audit.ActionLoginSucceeded, usr.ID, map[string]string{"method": "password"})
+	}
+
 	err = jsonapi.MarshalPayload(c.Status(http.StatusCreated), resource)
 	if err != nil {
 		h.logger.Error("Invalid response marshaling", zap.Error(err))
@@ -83,6 +172,229 @@ func (h *Handler) signIn(c *fiber.Ctx) error {
 	return nil
 }
 
+// recordSignIn adds sign in attempt to audit log, id is zero when user is unknown
+func (h *Handler) recordSignIn(c *fiber.Ctx, action string, id int64, details map[string]string) {
+	e := &audit.Event{Action: action, UserID: id}
+
+	if id != 0 {
+		e.ResourceType, e.ResourceID = user.TableName, id
+	}
+
+	if body, err := json.Marshal(details); err == nil {
+		e.Details = string(body)
+	}
+
+	h.audit.Record(c.Context(), e)
+}
+
+// refresh exchanges refresh token for a new pair of tokens
+func (h *Handler) refresh(c *fiber.Ctx) error {
+	u := new(user.Resource)
+	bodyReader := bytes.NewReader(c.Body())
+
+	if err := jsonapi.UnmarshalPayload(bodyReader, u); err != nil {
+		h.logger.Error("Can't unmarshal request for refreshing token", zap.Error(err))
+
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if u.RefreshToken == "" {
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	resource, err := h.srv.Refresh(c.Context(), u.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			errors := []string{"Invalid refresh token"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+		}
+
+		if errors.Is(err, service.ErrUserDisabled) {
+			errors := []string{"User is disabled"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
+		}
+
+		h.logger.Error("Refresh token", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), resource)
+}
+
+// verifyEmail confirms email of user with token from verification email
+func (h *Handler) verifyEmail(c *fiber.Ctx) error {
+	u := new(user.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), u); err != nil {
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	err := h.account.VerifyEmail(c.Context(), u.Token)
+	if errors.Is(err, service.ErrInvalidEmailToken) {
+		errors := []string{"Invalid or expired token"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Verify email", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// resendVerification sends verification email again. Response doesn't
+// depend on existence of user
+func (h *Handler) resendVerification(c *fiber.Ctx) error {
+	u := new(user.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), u); err != nil {
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	validation := validator.New()
+	if err := validation.StructPartial(u, "Email"); err != nil {
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if err := h.account.SendVerification(c.Context(), u.Email); err != nil {
+		h.logger.Error("Send verification email", zap.Error(err))
+	}
+
+	return c.SendStatus(http.StatusAccepted)
+}
+
+// forgotPassword sends password reset email. Response doesn't depend on
+// existence of user
+func (h *Handler) forgotPassword(c *fiber.Ctx) error {
+	u := new(user.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), u); err != nil {
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	validation := validator.New()
+	if err := validation.StructPartial(u, "Email"); err != nil {
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if err := h.account.RequestPasswordReset(c.Context(), u.Email); err != nil {
+		h.logger.Error("Send password reset email", zap.Error(err))
+	}
+
+	return c.SendStatus(http.StatusAccepted)
+}
+
+// resetPassword sets new password with token from password reset email
+func (h *Handler) resetPassword(c *fiber.Ctx) error {
+	u := new(user.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), u); err != nil {
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	validation := response.NewValidator()
+	if err := validation.StructPartial(u, "Password", "PasswordConfirmation"); err != nil {
+		return response.ValidationErrorJsonApiResponse(c, response.FieldErrors(err))
+	}
+
+	email, err := h.account.PasswordResetEmail(c.Context(), u.Token)
+	if errors.Is(err, service.ErrInvalidEmailToken) {
+		errors := []string{"Invalid or expired token"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Retrieve user of password reset token", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	if violations := h.policy.Check(email, u.Password); len(violations) > 0 {
+		return response.ValidationErrorJsonApiResponse(c, response.AttributeErrors("password", violations))
+	}
+
+	err = h.account.ResetPassword(c.Context(), u.Token, u.Password)
+	if errors.Is(err, service.ErrInvalidEmailToken) {
+		errors := []string{"Invalid or expired token"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Reset password", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// logout revokes current access token and refresh token from request body
+func (h *Handler) logout(c *fiber.Ctx) error {
+	id, ok := c.Locals("user_id").(int64)
+	if !ok {
+		errors := []string{"Invalid type assertion for token user_id"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	jti, _ := c.Locals("token_id").(string)
+	expiresAt, _ := c.Locals("token_expires_at").(time.Time)
+
+	u := new(user.Resource)
+
+	if len(c.Body()) > 0 {
+		if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), u); err != nil {
+			h.logger.Error("Can't unmarshal request for logout", zap.Error(err))
+
+			errors := []string{"Request is not in jsonapi format"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
+	if err := h.srv.Logout(c.Context(), id, u.RefreshToken, jti, expiresAt); err != nil {
+		h.logger.Error("Logout", zap.Error(err), zap.Int64("User ID", id))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
 // retrieve return user params
 func (h *Handler) retrieve(c *fiber.Ctx) error {
 	id, ok := c.Locals("user_id").(int64)
@@ -102,3 +414,25 @@ func (h *Handler) retrieve(c *fiber.Ctx) error {
 
 	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
 }
+
+// usage return user quota usage and limits
+func (h *Handler) usage(c *fiber.Ctx) error {
+	id, ok := c.Locals("user_id").(int64)
+	if !ok {
+		errors := []string{"Invalid type assertion for token user_id"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res, err := h.quota.Usage(c.Context(), id)
+
+	if err != nil {
+		h.logger.Error("Can't retrieve usage", zap.Error(err), zap.Int64("User ID", id))
+
+		errors := []string{"Can't retrieve usage"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
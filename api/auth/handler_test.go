@@ -3,13 +3,16 @@ package auth
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
 	"github.com/Hargeon/videocmprs/pkg/service/encryption"
 
@@ -84,10 +87,9 @@ func TestSignIn(t *testing.T) {
 			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
 		},
 		{
-			name: "With invalid password",
+			name: "Without password",
 			user: &user.Resource{
-				Email:    "check@check.com",
-				Password: "qwe",
+				Email: "check@check.com",
 			},
 			marshalUser: func(user interface{}) []byte {
 				var reqBody []byte
@@ -120,17 +122,72 @@ func TestSignIn(t *testing.T) {
 				return reqBuf.Bytes()
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT count").
-					WithArgs("check@check.com").
-					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at", "locked_until"}))
 
 				hashPass := encryption.GenerateHash([]byte("qweqweqwe"))
-				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", user.TableName)).
-					WithArgs("check@check.com", fmt.Sprintf("%x", hashPass)).
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).AddRow(1, fmt.Sprintf("%x", hashPass), "user", nil, time.Now(), nil))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password_hash", user.TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mock.ExpectExec("DELETE FROM login_attempts").
+					WithArgs("email:check@check.com").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(1, audit.ActionLoginSucceeded, user.TableName, 1, "", "", `{"method":"password"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name: "Invalid password",
+			user: &user.Resource{
+				Email:    "check@check.com",
+				Password: "qweqweqwe",
+			},
+			marshalUser: func(user interface{}) []byte {
+				var reqBody []byte
+				reqBuf := bytes.NewBuffer(reqBody)
+				err := jsonapi.MarshalPayload(reqBuf, user)
+				if err != nil {
+					t.Fatalf("Error occured when marshaling user, error: %s\n", err.Error())
+				}
+
+				return reqBuf.Bytes()
+			},
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at", "locked_until"}))
+
+				hashPass := encryption.GenerateHash([]byte("other_password"))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).AddRow(1, fmt.Sprintf("%x", hashPass), "user", nil, time.Now(), nil))
+
+				for i := 0; i < 2; i++ {
+					mock.ExpectQuery("INSERT INTO login_attempts").
+						WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).
+							AddRow(1, time.Now(), nil))
+				}
+
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(nil, audit.ActionLoginFailed, "", nil, "", "", `{"email":"check@check.com","method":"password"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Invalid credentials"}]}` + "\n",
+		},
 		{
 			name: "User is not exists",
 			user: &user.Resource{
@@ -148,12 +205,26 @@ func TestSignIn(t *testing.T) {
 				return reqBuf.Bytes()
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT count").
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at", "locked_until"}))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
 					WithArgs("check@check.com").
-					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}))
+
+				for i := 0; i < 2; i++ {
+					mock.ExpectQuery("INSERT INTO login_attempts").
+						WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).
+							AddRow(1, time.Now(), nil))
+				}
+
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(nil, audit.ActionLoginFailed, "", nil, "", "", `{"email":"check@check.com","method":"password"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":[{"title":"User is not exists"}]}` + "\n",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Invalid credentials"}]}` + "\n",
 		},
 		{
 			name: "With invalid db connection",
@@ -172,18 +243,42 @@ func TestSignIn(t *testing.T) {
 				return reqBuf.Bytes()
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT count").
-					WithArgs("check@check.com").
-					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at", "locked_until"}))
 
-				hashPass := encryption.GenerateHash([]byte("qweqweqwe"))
-				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", user.TableName)).
-					WithArgs("check@check.com", fmt.Sprintf("%x", hashPass)).
-					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnError(errors.New("connection refused"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   `{"errors":[{"title":"Something went wrong"}]}` + "\n",
 		},
+		{
+			name: "Locked out",
+			user: &user.Resource{
+				Email:    "check@check.com",
+				Password: "qweqweqwe",
+			},
+			marshalUser: func(user interface{}) []byte {
+				var reqBody []byte
+				reqBuf := bytes.NewBuffer(reqBody)
+				err := jsonapi.MarshalPayload(reqBuf, user)
+				if err != nil {
+					t.Fatalf("Error occured when marshaling user, error: %s\n", err.Error())
+				}
+
+				return reqBuf.Bytes()
+			},
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at", "locked_until"}).
+						AddRow("email:check@check.com", 5, time.Now(), time.Now().Add(10*time.Minute)))
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `{"errors":[{"title":"Too many failed sign in attempts, retry later"}]}` + "\n",
+		},
 	}
 
 	for _, testCase := range cases {
@@ -250,25 +345,25 @@ func TestRetrieve(t *testing.T) {
 		{
 			name: "Should find user",
 			mock: func() {
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s", user.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("1", "check@check.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow("1", "check@check.com", "user"))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/", nil)
 
 				return req
 			},
-			expectedBody:   `{"data":{"type":"users","id":"1","attributes":{"email":"check@check.com"},"links":{"self":"/api/v1/auth/me"}}}` + "\n",
+			expectedBody:   `{"data":{"type":"users","id":"1","attributes":{"email":"check@check.com","role":"user"},"links":{"self":"/api/v1/auth/me"}}}` + "\n",
 			expectedStatus: http.StatusOK,
 		},
 
 		{
 			name: "Should not find user",
 			mock: func() {
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s", user.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -307,3 +402,332 @@ func TestRetrieve(t *testing.T) {
 		})
 	}
 }
+
+func TestUsage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	handler := NewHandler(db, logger)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Get("/", handler.usage)
+
+	cases := []struct {
+		name           string
+		mock           func()
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Should return usage",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"quota_storage_bytes", "quota_max_file_size",
+						"quota_active_requests", "quota_monthly_minutes"}).AddRow(4096, nil, 3, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows([]string{"stored_bytes", "active_requests", "monthly_minutes"}).
+						AddRow(1024, 1, 2.5))
+			},
+			expectedBody: `{"data":{"type":"usages","id":"1","attributes":{"active_requests":1,"active_requests_limit":3,` +
+				`"max_file_size":0,"monthly_minutes":2.5,"monthly_minutes_limit":0,"storage_bytes_limit":4096,` +
+				`"stored_bytes":1024},"links":{"self":"/api/v1/auth/me/usage"}}}` + "\n",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Database error",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnError(errors.New("some error"))
+			},
+			expectedBody:   `{"errors":[{"title":"Can't retrieve usage"}]}` + "\n",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if res.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invaid status code, expected: %d, got: %d\n",
+					testCase.expectedStatus, res.StatusCode)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a response body, error: %s\n", err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body,\nexpected: %#v\ngot: %#v\n",
+					testCase.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	handler := NewHandler(db, logger)
+	app := fiber.New()
+	app.Post("/", handler.refresh)
+
+	cases := []struct {
+		name           string
+		body           string
+		mock           func()
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "Without refresh token",
+			body:           `{"data":{"type":"users","attributes":{}}}`,
+			mock:           func() {},
+			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid refresh token",
+			body: `{"data":{"type":"users","attributes":{"refresh_token":"qwe"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM refresh_tokens").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "revoked_at"}))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid refresh token"}]}` + "\n",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Disabled user",
+			body: `{"data":{"type":"users","attributes":{"refresh_token":"qwe"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM refresh_tokens").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "revoked_at"}).
+						AddRow(3, 1, "hash", time.Now().Add(time.Hour), nil))
+				mock.ExpectQuery("SELECT id, email, role FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(1, "check@check.com", "user"))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at",
+						"email_verified_at", "totp_enabled_at"}).AddRow(1, "hash", "user", time.Now(), nil, nil))
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked_at").
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedBody:   `{"errors":[{"title":"User is disabled"}]}` + "\n",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(testCase.body))
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if res.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invaid status code, expected: %d, got: %d\n",
+					testCase.expectedStatus, res.StatusCode)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a response body, error: %s\n", err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body,\nexpected: %#v\ngot: %#v\n",
+					testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestEmailTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	handler := NewHandler(db, logger)
+	app := fiber.New()
+	app.Mount("/", handler.InitRoutes())
+
+	cases := []struct {
+		name           string
+		path           string
+		body           string
+		mock           func()
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Verify email",
+			path: "/verify-email",
+			body: `{"data":{"type":"users","attributes":{"token":"qwe"}}}`,
+			mock: func() {
+				mock.ExpectQuery("UPDATE email_tokens SET used_at").
+					WithArgs(sqlmock.AnyArg(), "verify_email", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+				mock.ExpectExec("UPDATE users SET email_verified_at").
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Verify email with used token",
+			path: "/verify-email",
+			body: `{"data":{"type":"users","attributes":{"token":"qwe"}}}`,
+			mock: func() {
+				mock.ExpectQuery("UPDATE email_tokens SET used_at").
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid or expired token"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Forgot password",
+			path:           "/password/forgot",
+			body:           `{"data":{"type":"users","attributes":{"email":"check@check.com"}}}`,
+			mock:           func() {},
+			expectedBody:   "Accepted",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Forgot password without email",
+			path:           "/password/forgot",
+			body:           `{"data":{"type":"users","attributes":{}}}`,
+			mock:           func() {},
+			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Reset password with mismatched confirmation",
+			path: "/password/reset",
+			body: `{"data":{"type":"users","attributes":{"token":"qwe","password":"qweqweqwe",` +
+				`"password_confirmation":"asdasdasd"}}}`,
+			mock: func() {},
+			expectedBody: `{"errors":[{"title":"Validation failed","detail":"Password confirmation doesn't match password",` +
+				`"source":{"pointer":"/data/attributes/password_confirmation"}}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Reset password with breached password",
+			path: "/password/reset",
+			body: `{"data":{"type":"users","attributes":{"token":"qwe","password":"qwerty123",` +
+				`"password_confirmation":"qwerty123"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT user_id FROM email_tokens").
+					WithArgs("reset_password", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(1, "check@check.com", "user"))
+			},
+			expectedBody: `{"errors":[{"title":"Validation failed",` +
+				`"detail":"Password has appeared in a data breach, choose another one",` +
+				`"source":{"pointer":"/data/attributes/password"}}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Reset password containing email of user",
+			path: "/password/reset",
+			body: `{"data":{"type":"users","attributes":{"token":"qwe","password":"my-check-pass",` +
+				`"password_confirmation":"my-check-pass"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT user_id FROM email_tokens").
+					WithArgs("reset_password", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(1, "check@check.com", "user"))
+			},
+			expectedBody: `{"errors":[{"title":"Validation failed","detail":"Password must not contain email",` +
+				`"source":{"pointer":"/data/attributes/password"}}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Reset password with expired token",
+			path: "/password/reset",
+			body: `{"data":{"type":"users","attributes":{"token":"qwe","password":"qweqweqwe",` +
+				`"password_confirmation":"qweqweqwe"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT user_id FROM email_tokens").
+					WithArgs("reset_password", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid or expired token"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			req := httptest.NewRequest(http.MethodPost, testCase.path, bytes.NewBufferString(testCase.body))
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if res.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invaid status code, expected: %d, got: %d\n",
+					testCase.expectedStatus, res.StatusCode)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a response body, error: %s\n", err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body,\nexpected: %#v\ngot: %#v\n",
+					testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
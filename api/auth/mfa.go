@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/jsonapi"
+	"go.uber.org/zap"
+)
+
+// signInMFA exchanges MFA challenge from sign in and code for tokens
+func (h *Handler) signInMFA(c *fiber.Ctx) error {
+	u := new(user.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), u); err != nil {
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if u.MFAToken == "" || u.Code == "" {
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	resource, err := h.mfa.VerifyMFA(c.Context(), u.MFAToken, u.Code)
+	usr, _ := resource.(*user.Resource)
+
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMFAChallenge) {
+			errors := []string{"Invalid or expired MFA token"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+		}
+
+		if errors.Is(err, service.ErrInvalidMFACode) {
+			if usr != nil {
+				if err = h.limiter.Fail(c.Context(), usr.Email, c.IP()); err != nil {
+					h.logger.Error("Count failed sign in attempt", zap.Error(err))
+				}
+
+				h.recordSignIn(c, audit.ActionLoginFailed, usr.ID, map[string]string{"method": "mfa"})
+			}
+
+			errors := []string{"Invalid two-factor code"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+		}
+
+		h.logger.Error("Verify MFA", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	if usr != nil {
+		if err = h.limiter.Succeed(c.Context(), usr.Email); err != nil {
+			h.logger.Error("Reset failed sign in attempts", zap.Error(err))
+		}
+
+		h.recordSignIn(c, audit.ActionLoginSucceeded, usr.ID, map[string]string{"method": "mfa"})
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), resource)
+}
+
+// enrollTOTP generates TOTP secret of current user
+func (h *Handler) enrollTOTP(c *fiber.Ctx) error {
+	id, ok := c.Locals("user_id").(int64)
+	if !ok {
+		errors := []string{"Invalid type assertion for token user_id"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res, err := h.mfa.EnrollTOTP(c.Context(), id)
+	if err != nil {
+		return h.mfaError(c, id, err)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), res)
+}
+
+// confirmTOTP enables two-factor authentication and returns recovery codes
+func (h *Handler) confirmTOTP(c *fiber.Ctx) error {
+	return h.withMFACode(c, http.StatusOK, h.mfa.ConfirmTOTP)
+}
+
+// disableTOTP turns two-factor authentication off
+func (h *Handler) disableTOTP(c *fiber.Ctx) error {
+	disable := func(ctx context.Context, id int64, code string) (jsonapi.Linkable, error) {
+		return nil, h.mfa.DisableTOTP(ctx, id, code)
+	}
+
+	return h.withMFACode(c, http.StatusNoContent, disable)
+}
+
+// regenerateRecoveryCodes replaces recovery codes of current user
+func (h *Handler) regenerateRecoveryCodes(c *fiber.Ctx) error {
+	return h.withMFACode(c, http.StatusCreated, h.mfa.RegenerateRecoveryCodes)
+}
+
+// withMFACode runs action of current user with code from request body and
+// sends its result with status
+func (h *Handler) withMFACode(c *fiber.Ctx, status int, action func(ctx context.Context, id int64, code string) (jsonapi.Linkable, error)) error { //nolint:lll
+	id, ok := c.Locals("user_id").(int64)
+	if !ok {
+		errors := []string{"Invalid type assertion for token user_id"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	t := new(user.TOTP)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), t); err != nil {
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if t.Code == "" {
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res, err := action(c.Context(), id, t.Code)
+	if err != nil {
+		return h.mfaError(c, id, err)
+	}
+
+	if res == nil {
+		return c.SendStatus(status)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(status), res)
+}
+
+// mfaError sends response for error of two-factor management
+func (h *Handler) mfaError(c *fiber.Ctx, id int64, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode):
+		errors := []string{"Invalid two-factor code"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		errors := []string{"Two-factor authentication is already enabled"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	case errors.Is(err, service.ErrMFANotEnabled):
+		errors := []string{"Two-factor authentication is not enabled"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	case errors.Is(err, service.ErrMFANotEnrolled):
+		errors := []string{"Two-factor enrollment is not started"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	}
+
+	h.logger.Error("Two-factor authentication", zap.Error(err), zap.Int64("User ID", id))
+
+	errors := []string{"Something went wrong"}
+
+	return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+}
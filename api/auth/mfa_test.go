@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+func TestMFA(t *testing.T) {
+	db, mock, err := sqlmock.New()
+
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	handler := NewHandler(db, logger)
+	app := fiber.New()
+	app.Post("/sign-in/mfa", handler.signInMFA)
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Post("/mfa/totp/confirm", handler.confirmTOTP)
+	app.Delete("/mfa/totp", handler.disableTOTP)
+
+	totpColumns := []string{"email", "role", "totp_secret", "totp_enabled_at", "totp_last_step"}
+
+	cases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mock           func()
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "Sign in without code",
+			method:         http.MethodPost,
+			path:           "/sign-in/mfa",
+			body:           `{"data":{"type":"users","attributes":{"mfa_token":"qwe"}}}`,
+			mock:           func() {},
+			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Sign in with expired challenge",
+			method: http.MethodPost,
+			path:   "/sign-in/mfa",
+			body:   `{"data":{"type":"users","attributes":{"mfa_token":"qwe","code":"123456"}}}`,
+			mock: func() {
+				mock.ExpectQuery("UPDATE email_tokens SET used_at").
+					WithArgs(sqlmock.AnyArg(), "mfa_challenge", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid or expired MFA token"}]}` + "\n",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "Sign in with invalid code",
+			method: http.MethodPost,
+			path:   "/sign-in/mfa",
+			body:   `{"data":{"type":"users","attributes":{"mfa_token":"qwe","code":"abcde-fghij"}}}`,
+			mock: func() {
+				mock.ExpectQuery("UPDATE email_tokens SET used_at").
+					WithArgs(sqlmock.AnyArg(), "mfa_challenge", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", "JBSWY3DPEHPK3PXP", time.Now(), nil))
+				mock.ExpectExec("UPDATE recovery_codes SET used_at").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				for i := 0; i < 2; i++ {
+					mock.ExpectQuery("INSERT INTO login_attempts").
+						WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).
+							AddRow(1, time.Now(), nil))
+				}
+
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(1, audit.ActionLoginFailed, "users", 1, "", "", `{"method":"mfa"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid two-factor code"}]}` + "\n",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "Confirm enabled",
+			method: http.MethodPost,
+			path:   "/mfa/totp/confirm",
+			body:   `{"data":{"type":"totp","attributes":{"code":"123456"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", "JBSWY3DPEHPK3PXP", time.Now(), nil))
+			},
+			expectedBody:   `{"errors":[{"title":"Two-factor authentication is already enabled"}]}` + "\n",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "Disable with invalid code",
+			method: http.MethodDelete,
+			path:   "/mfa/totp",
+			body:   `{"data":{"type":"totp","attributes":{"code":"abcde-fghij"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", "JBSWY3DPEHPK3PXP", time.Now(), nil))
+				mock.ExpectExec("UPDATE recovery_codes SET used_at").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid two-factor code"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Disable with recovery code",
+			method: http.MethodDelete,
+			path:   "/mfa/totp",
+			body:   `{"data":{"type":"totp","attributes":{"code":"abcde-fghij"}}}`,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", "JBSWY3DPEHPK3PXP", time.Now(), nil))
+				mock.ExpectExec("UPDATE recovery_codes SET used_at").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users SET totp_secret").
+					WithArgs(nil, nil, nil, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM recovery_codes").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 9))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			req := httptest.NewRequest(testCase.method, testCase.path, bytes.NewBufferString(testCase.body))
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if res.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invaid status code, expected: %d, got: %d\n",
+					testCase.expectedStatus, res.StatusCode)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a response body, error: %s\n", err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body,\nexpected: %#v\ngot: %#v\n",
+					testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/oidc"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/jsonapi"
+	"go.uber.org/zap"
+)
+
+// oidcStateCookie keeps state of login in browser which started it, so
+// callback with state of another browser is rejected
+const oidcStateCookie = "oidc_state"
+
+// OIDCRoutes returns routes of single sign-on. They are opened by browser
+// redirects, so they don't require json api Accept header
+func (h *Handler) OIDCRoutes() *fiber.App {
+	router := fiber.New()
+	router.Get("/login", h.oidcLogin)
+	router.Get("/callback", h.oidcCallback)
+
+	return router
+}
+
+// oidcLogin redirects to login page of OpenID Connect provider
+func (h *Handler) oidcLogin(c *fiber.Ctx) error {
+	url, state, err := h.sso.AuthURL(c.Context())
+	if err != nil {
+		if errors.Is(err, oidc.ErrNotConfigured) {
+			errors := []string{"Single sign-on is not configured"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+		}
+
+		h.logger.Error("Single sign-on login", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	setStateCookie(c, state, time.Now().Add(oidc.StateTD))
+
+	return c.Redirect(url, http.StatusFound)
+}
+
+// oidcCallback completes login with response of OpenID Connect provider and
+// returns tokens
+func (h *Handler) oidcCallback(c *fiber.Ctx) error {
+	if providerErr := c.Query("error"); providerErr != "" {
+		h.logger.Info("Single sign-on rejected by provider", zap.String("error", providerErr),
+			zap.String("description", c.Query("error_description")))
+
+		errors := []string{"Single sign-on failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+	}
+
+	browserState := c.Cookies(oidcStateCookie)
+	// state is single-use, so cookie isn't needed after callback
+	setStateCookie(c, "", time.Unix(0, 0))
+
+	resource, err := h.sso.Callback(c.Context(), c.Query("state"), browserState, c.Query("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrNotConfigured):
+			errors := []string{"Single sign-on is not configured"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+		case errors.Is(err, service.ErrInvalidSSOState), errors.Is(err, oidc.ErrExchange),
+			errors.Is(err, oidc.ErrInvalidIDToken):
+			h.logger.Info("Single sign-on failed", zap.Error(err))
+
+			errors := []string{"Single sign-on failed"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+		case errors.Is(err, service.ErrSSOUserNotFound):
+			errors := []string{"User is not registered"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
+		case errors.Is(err, service.ErrUserDisabled):
+			errors := []string{"User is disabled"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
+		}
+
+		h.logger.Error("Single sign-on callback", zap.Error(err))
+
+		errors := []string{"Something went wrong"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	usr, ok := resource.(*user.Resource)
+	if ok && usr.MFAToken != "" {
+		// sign in is recorded after the second factor
+		return jsonapi.MarshalPayload(c.Status(http.StatusAccepted), resource)
+	}
+
+	if ok {
+		h.recordSignIn(c, audit.ActionLoginSucceeded, usr.ID, map[string]string{"method": "oidc"})
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), resource)
+}
+
+// setStateCookie sets state of login for callback route only. Lax cookie is
+// sent on redirect from provider, but not on cross-site requests of other sites
+func setStateCookie(c *fiber.Ctx, state string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     strings.TrimSuffix(strings.TrimSuffix(c.Path(), "/login"), "/callback") + "/callback",
+		Expires:  expires,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service/auth"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+	"github.com/Hargeon/videocmprs/pkg/service/oidc"
+	"github.com/Hargeon/videocmprs/pkg/service/oidc/oidctest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// capture matches any argument and remembers it
+type capture struct {
+	value driver.Value
+}
+
+func (c *capture) Match(v driver.Value) bool {
+	c.value = v
+
+	return true
+}
+
+func TestOIDC(t *testing.T) {
+	provider, err := oidctest.NewServer("videocmprs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	defer provider.Close()
+
+	provider.Subject = "sub"
+	provider.Email = "check@check.com"
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	handler := NewHandler(db, logger)
+	app := fiber.New()
+	app.Mount("/oidc", handler.OIDCRoutes())
+	app.Mount("/", handler.InitRoutes())
+
+	// without OIDC_ISSUER single sign-on is disabled
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+	if err != nil {
+		t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+	}
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Invaid status code, expected: %d, got: %d\n", http.StatusNotFound, res.StatusCode)
+	}
+
+	users := user.NewRepository(db)
+	tokens := token.NewRepository(db)
+	hasher := encryption.NewPasswordHasher(encryption.DefaultArgon2Params)
+	handler.sso = oidc.NewService(oidc.NewProvider(provider.URL, "videocmprs", "", "http://localhost/callback",
+		[]string{"openid", "email"}), users, tokens, auth.NewService(users, tokens, hasher), hasher, false)
+
+	// login returns captured nonce and verifier, query of callback and state cookie
+	login := func() (*capture, *capture, string, string) {
+		nonce, verifier := new(capture), new(capture)
+		mock.ExpectExec("INSERT INTO oidc_states").
+			WithArgs(sqlmock.AnyArg(), nonce, verifier, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+		if err != nil {
+			t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+		}
+
+		location := res.Header.Get("Location")
+		if res.StatusCode != http.StatusFound || !strings.HasPrefix(location, provider.URL+"/authorize?") {
+			t.Fatalf("Should redirect to provider, got: %d %s\n", res.StatusCode, location)
+		}
+
+		cookie := res.Header.Get("Set-Cookie")
+		for _, attr := range []string{"path=/oidc/callback", "HttpOnly", "SameSite=Lax"} {
+			if !strings.Contains(cookie, attr) {
+				t.Errorf("State cookie should have %s, got: %s\n", attr, cookie)
+			}
+		}
+
+		redirect, err := provider.Login(location)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+
+		callback, err := url.Parse(redirect)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+
+		return nonce, verifier, callback.RawQuery, strings.Split(cookie, ";")[0]
+	}
+
+	nonce, verifier, query, stateCookie := login()
+	mfaNonce, mfaVerifier, mfaQuery, mfaCookie := login()
+
+	cases := []struct {
+		name           string
+		query          string
+		cookie         string
+		mock           func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Valid callback",
+			query:  query,
+			cookie: stateCookie,
+			mock: func() {
+				mock.ExpectQuery("DELETE FROM oidc_states").
+					WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}).
+						AddRow(nonce.value, verifier.value, time.Now().Add(time.Minute)))
+				mock.ExpectQuery("SELECT users.email FROM user_identities").
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("check@check.com"))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).
+						AddRow(1, "hash", "user", nil, time.Now(), nil))
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(1, audit.ActionLoginSucceeded, "users", 1, "", "", `{"method":"oidc"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Callback in another browser",
+			query:          query,
+			mock:           func() {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Single sign-on failed"}]}` + "\n",
+		},
+		{
+			name:   "User with two-factor authentication",
+			query:  mfaQuery,
+			cookie: mfaCookie,
+			mock: func() {
+				mock.ExpectQuery("DELETE FROM oidc_states").
+					WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}).
+						AddRow(mfaNonce.value, mfaVerifier.value, time.Now().Add(time.Minute)))
+				mock.ExpectQuery("SELECT users.email FROM user_identities").
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("check@check.com"))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).
+						AddRow(1, "hash", "user", nil, time.Now(), time.Now()))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE email_tokens SET used_at").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO email_tokens").
+					WithArgs(1, token.PurposeMFAChallenge, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:   "Used state",
+			query:  query,
+			cookie: stateCookie,
+			mock: func() {
+				mock.ExpectQuery("DELETE FROM oidc_states").
+					WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}))
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Single sign-on failed"}]}` + "\n",
+		},
+		{
+			name:           "Rejected by provider",
+			query:          "error=access_denied&state=qwe",
+			mock:           func() {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Single sign-on failed"}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+
+			req := httptest.NewRequest(http.MethodGet, "/oidc/callback?"+testCase.query, nil)
+			req.Header.Set("Cookie", testCase.cookie)
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if res.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invaid status code, expected: %d, got: %d\n", testCase.expectedStatus, res.StatusCode)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a response body, error: %s\n", err.Error())
+			}
+
+			if testCase.expectedStatus == http.StatusCreated && !strings.Contains(string(body), `"token":"`) {
+				t.Errorf("Response should contain token, got: %s\n", body)
+			}
+
+			if testCase.expectedStatus == http.StatusAccepted && (!strings.Contains(string(body), `"mfa_token":"`) ||
+				strings.Contains(string(body), `"token":"`)) {
+				t.Errorf("Response should contain only mfa token, got: %s\n", body)
+			}
+
+			if testCase.expectedBody != "" && string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body,\nexpected: %#v\ngot: %#v\n", testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
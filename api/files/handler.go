@@ -0,0 +1,129 @@
+// Package files serves files of storage by signed urls. It is used for downloads
+// of files which can't be downloaded from storage directly, e.g. encrypted files
+// of local storage or S3 objects encrypted with SSE-C
+package files
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	auditsrv "github.com/Hargeon/videocmprs/pkg/service/audit"
+	"github.com/Hargeon/videocmprs/pkg/service/cloud"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// uuidPrefixLen is length of uuid and underscore prepended to names of stored files
+const uuidPrefixLen = 37
+
+type Handler struct {
+	cs     service.CloudStorage
+	signer service.URLVerifier
+	audit  service.AuditRecorder
+	logger *zap.Logger
+}
+
+// NewHandler returns Handler, files aren't served if STORAGE_URL_SECRET isn't set
+func NewHandler(db *sql.DB, cs service.CloudStorage, logger *zap.Logger) *Handler {
+	h := &Handler{cs: cs, audit: auditsrv.NewService(audit.NewRepository(db), logger), logger: logger}
+
+	if signer := cloud.NewEnvURLSigner(); signer != nil {
+		h.signer = signer
+	}
+
+	return h
+}
+
+// InitRoutes returns routes for downloading files by signed urls
+func (h *Handler) InitRoutes() *fiber.App {
+	router := fiber.New()
+	router.Get("/:name", h.download)
+
+	return router
+}
+
+// download streams decrypted content of file
+func (h *Handler) download(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+
+	name, err := url.PathUnescape(c.Params("name"))
+
+	if err != nil || h.signer == nil || !h.signer.Verify(name, c.Query("expires"), c.Query("signature")) {
+		errors := []string{"Invalid or expired link"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
+	}
+
+	obj, err := h.cs.Stat(c.Context(), name)
+	if errors.Is(err, cloud.ErrNotFound) {
+		errors := []string{"File not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Stat file", zap.Error(err), zap.String("Name", name))
+
+		errors := []string{"Can not fetch file"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	var body io.ReadCloser
+
+	if obj.Size > 0 {
+		body, err = h.cs.Open(c.Context(), name, 0, obj.Size)
+		if errors.Is(err, cloud.ErrNotFound) {
+			errors := []string{"File not found"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+		}
+
+		if err != nil {
+			h.logger.Error("Open file", zap.Error(err), zap.String("Name", name))
+
+			errors := []string{"Can not fetch file"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+		}
+	}
+
+	response.SetAttachment(c, originalName(name))
+	h.record(c, name)
+
+	if body == nil {
+		return c.SendStatus(http.StatusOK)
+	}
+
+	return c.Status(http.StatusOK).SendStream(body, int(obj.Size))
+}
+
+// record download of file in audit log. Signed url doesn't identify user, so
+// only ip and user agent of client are known
+func (h *Handler) record(c *fiber.Ctx, name string) {
+	e := &audit.Event{Action: audit.ActionFileDownload, ResourceType: "files"}
+
+	if details, err := json.Marshal(map[string]string{"file": name}); err == nil {
+		e.Details = string(details)
+	}
+
+	h.audit.Record(c.Context(), e)
+}
+
+// originalName returns name of uploaded file without prefix added by storage
+func originalName(name string) string {
+	if len(name) > uuidPrefixLen && name[uuidPrefixLen-1] == '_' {
+		return name[uuidPrefixLen:]
+	}
+
+	return name
+}
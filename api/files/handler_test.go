@@ -0,0 +1,148 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/service/cloud"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+)
+
+func TestDownload(t *testing.T) {
+	os.Setenv("STORAGE_URL_SECRET", "secret")
+	defer os.Unsetenv("STORAGE_URL_SECRET")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	part, err := writer.CreateFormFile("video", "my video.mp4")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = part.Write([]byte("video content")); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	upload := httptest.NewRequest(http.MethodPost, "/", buf)
+	upload.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, header, err := upload.FormFile("video")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	storage := cloud.NewLocalStorage(t.TempDir(), nil, cloud.NewEnvURLSigner())
+
+	name, err := storage.Upload(context.Background(), 1, header)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	signed, err := storage.URL(name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	other := cloud.NewURLSigner([]byte("secret"), "", time.Minute).URL("missing.mp4")
+
+	cases := []struct {
+		name                string
+		target              string
+		expectedStatus      int
+		expectedBody        string
+		expectedDisposition string
+		mock                func()
+	}{
+		{
+			name:                "Valid url",
+			target:              "/" + u.EscapedPath()[len(cloud.FilesPath)+1:] + "?" + u.RawQuery,
+			expectedStatus:      http.StatusOK,
+			expectedBody:        "video content",
+			expectedDisposition: `attachment; filename="my video.mp4"`,
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(sql.NullInt64{}, audit.ActionFileDownload, "files", sql.NullInt64{}, "", "",
+						`{"file":"`+name+`"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:           "Invalid signature",
+			target:         "/" + u.EscapedPath()[len(cloud.FilesPath)+1:] + "?expires=9999999999&signature=qwe",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"errors":[{"title":"Invalid or expired link"}]}` + "\n",
+			mock:           func() {},
+		},
+		{
+			name:           "Missing file",
+			target:         other[len(cloud.FilesPath):],
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"errors":[{"title":"File not found"}]}` + "\n",
+			mock:           func() {},
+		},
+	}
+
+	app := NewHandler(db, storage, logger).InitRoutes()
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+
+			res, err := app.Test(httptest.NewRequest(http.MethodGet, testCase.target, nil))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if res.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n", testCase.expectedStatus, res.StatusCode)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body\nexpected: %s\ngot: %s\n", testCase.expectedBody, body)
+			}
+
+			if d := res.Header.Get("Content-Disposition"); d != testCase.expectedDisposition && testCase.expectedDisposition != "" {
+				t.Errorf("Invalid disposition, expected: %s, got: %s\n", testCase.expectedDisposition, d)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
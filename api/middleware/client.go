@@ -0,0 +1,12 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// ClientInfo stores ip and user agent of client in locals, services read
+// them from context for audit events
+func ClientInfo(c *fiber.Ctx) error {
+	c.Locals("ip", c.IP())
+	c.Locals("user_agent", c.Get(fiber.HeaderUserAgent))
+
+	return c.Next()
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestClientInfo(t *testing.T) {
+	app := fiber.New()
+
+	app.Use(ClientInfo)
+	app.Get("/", func(c *fiber.Ctx) error {
+		// services read locals from context
+		ip, _ := c.Context().Value("ip").(string)
+		userAgent, _ := c.Context().Value("user_agent").(string)
+
+		return c.Status(http.StatusOK).SendString(ip + " " + userAgent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderUserAgent, "curl/7.79.1")
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if expected := "0.0.0.0 curl/7.79.1"; string(body) != expected {
+		t.Errorf("Invalid client info, expected: %s, got: %s\n", expected, string(body))
+	}
+}
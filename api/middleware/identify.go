@@ -1,34 +1,102 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/apikey"
 	"github.com/Hargeon/videocmprs/pkg/service/jwt"
+	"github.com/Hargeon/videocmprs/pkg/service/rbac"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 const tokenPrefix = "Bearer "
 
-func UserIdentify(c *fiber.Ctx) error {
-	header := string(c.Request().Header.Peek("Authorization"))
-	if !strings.HasPrefix(header, tokenPrefix) {
-		errors := []string{"Should be Bearer token"}
+// UserIdentify returns middleware which authenticates user by Bearer token.
+// Tokens from denylist and tokens of disabled or deleted users are rejected.
+// Bearer token with apikey.KeyPrefix is checked as API key, API keys are
+// rejected if keys is nil
+func UserIdentify(denylist service.TokenDenylist, keys service.APIKeyIdentifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := string(c.Request().Header.Peek("Authorization"))
+		if !strings.HasPrefix(header, tokenPrefix) {
+			errors := []string{"Should be Bearer token"}
+
+			return response.NegotiateErrorResponse(c, http.StatusUnauthorized, errors)
+		}
+
+		token := strings.TrimPrefix(header, tokenPrefix)
+		if apikey.IsKey(token) {
+			return identifyKey(c, keys, token)
+		}
+
+		claims, err := jwt.ParseToken(token)
+
+		if err != nil {
+			errors := []string{err.Error()}
+
+			return response.NegotiateErrorResponse(c, http.StatusUnauthorized, errors)
+		}
+
+		// tokens of disabled and deleted users are denied as well as revoked ones
+		denied, err := denylist.Denied(c.Context(), claims.Id, claims.ID)
+		if err != nil {
+			errors := []string{"Something went wrong"}
+
+			return response.NegotiateErrorResponse(c, http.StatusInternalServerError, errors)
+		}
+
+		if denied {
+			errors := []string{"Token is revoked"}
 
-		return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+			return response.NegotiateErrorResponse(c, http.StatusUnauthorized, errors)
+		}
+
+		// tokens issued before roles were introduced have no role
+		role := claims.Role
+		if role == "" {
+			role = rbac.RoleUser
+		}
+
+		c.Locals("user_id", claims.ID)
+		c.Locals("role", role)
+		c.Locals("token_id", claims.Id)
+		c.Locals("token_expires_at", claims.ExpiresAtTime())
+
+		return c.Next()
 	}
+}
+
+// identifyKey authenticates user by API key. Role of owner and scopes of key
+// are stored in locals for RequirePermission
+func identifyKey(c *fiber.Ctx, keys service.APIKeyIdentifier, token string) error {
+	if keys == nil {
+		errors := []string{"API keys are not accepted"}
 
-	token := strings.TrimPrefix(header, tokenPrefix)
-	id, err := jwt.ParseToken(token)
+		return response.NegotiateErrorResponse(c, http.StatusUnauthorized, errors)
+	}
+
+	key, err := keys.Identify(c.Context(), token)
+	if errors.Is(err, apikey.ErrInvalidAPIKey) {
+		errors := []string{"Invalid API key"}
+
+		return response.NegotiateErrorResponse(c, http.StatusUnauthorized, errors)
+	}
 
 	if err != nil {
-		errors := []string{err.Error()}
+		errors := []string{"Something went wrong"}
 
-		return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+		return response.NegotiateErrorResponse(c, http.StatusInternalServerError, errors)
 	}
 
-	c.Locals("user_id", id)
+	c.Locals("user_id", key.UserID)
+	c.Locals("role", key.Role)
+	c.Locals("api_key_id", key.ID)
+	c.Locals("scopes", key.Scopes)
 
 	return c.Next()
 }
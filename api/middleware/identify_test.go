@@ -1,19 +1,42 @@
 package middleware
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Hargeon/videocmprs/pkg/repository/apikey"
+	keysrv "github.com/Hargeon/videocmprs/pkg/service/apikey"
 	"github.com/Hargeon/videocmprs/pkg/service/jwt"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+type denylistMock struct {
+	denied   map[string]bool
+	disabled map[int64]bool
+}
+
+func (d *denylistMock) Denied(ctx context.Context, jti string, userID int64) (bool, error) {
+	return d.denied[jti] || d.disabled[userID], nil
+}
+
+type keysMock struct{}
+
+func (k *keysMock) Identify(ctx context.Context, key string) (*apikey.Resource, error) {
+	if key != keysrv.KeyPrefix+"valid" {
+		return nil, keysrv.ErrInvalidAPIKey
+	}
+
+	return &apikey.Resource{ID: 1, UserID: 64, Scopes: []string{"requests:read"}}, nil
+}
+
 func TestUserIdentify(t *testing.T) {
+	denylist := &denylistMock{denied: make(map[string]bool), disabled: map[int64]bool{65: true}}
 	app := fiber.New()
-	app.Use(UserIdentify)
+	app.Use(UserIdentify(denylist, new(keysMock)))
 	app.All("/", func(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusOK).SendString("")
 	})
@@ -43,15 +66,64 @@ func TestUserIdentify(t *testing.T) {
 		{
 			name: "Valid Bearer token",
 			generateToken: func() string {
-				token, err := jwt.SignedString(64)
+				token, err := jwt.SignedString(64, "user")
+				if err != nil {
+					t.Fatalf("Unexpected error while generating jwt token")
+				}
+				return "Bearer " + token
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "",
+		},
+		{
+			name: "Revoked Bearer token",
+			generateToken: func() string {
+				token, err := jwt.SignedString(64, "user")
+				if err != nil {
+					t.Fatalf("Unexpected error while generating jwt token")
+				}
+
+				claims, err := jwt.ParseToken(token)
+				if err != nil {
+					t.Fatalf("Unexpected error while parsing jwt token")
+				}
+
+				denylist.denied[claims.Id] = true
+
+				return "Bearer " + token
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Token is revoked"}]}` + "\n",
+		},
+		{
+			name: "Bearer token of disabled user",
+			generateToken: func() string {
+				token, err := jwt.SignedString(65, "user")
 				if err != nil {
 					t.Fatalf("Unexpected error while generating jwt token")
 				}
+
 				return "Bearer " + token
 			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Token is revoked"}]}` + "\n",
+		},
+		{
+			name: "Valid API key",
+			generateToken: func() string {
+				return "Bearer " + keysrv.KeyPrefix + "valid"
+			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   "",
 		},
+		{
+			name: "Invalid API key",
+			generateToken: func() string {
+				return "Bearer " + keysrv.KeyPrefix + "invalid"
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"errors":[{"title":"Invalid API key"}]}` + "\n",
+		},
 	}
 
 	for _, testCase := range cases {
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/service/rbac"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission returns middleware which allows request only if role of
+// user grants permission. Requests with API key also need permission in key scopes.
+// Role and scopes are set by UserIdentify
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return authorize(c, permission)
+	}
+}
+
+// RequireAccess returns middleware which requires read permission for safe
+// methods (GET, HEAD, OPTIONS) and write permission for the others
+func RequireAccess(read, write string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return authorize(c, read)
+		default:
+			return authorize(c, write)
+		}
+	}
+}
+
+func authorize(c *fiber.Ctx, permission string) error {
+	role, _ := c.Locals("role").(string)
+	scopes, _ := c.Locals("scopes").([]string)
+
+	if !rbac.Allowed(role, scopes, permission) {
+		errors := []string{"Permission denied: " + permission}
+
+		return response.NegotiateErrorResponse(c, http.StatusForbidden, errors)
+	}
+
+	return c.Next()
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Hargeon/videocmprs/pkg/service/rbac"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequireAccess(t *testing.T) {
+	cases := []struct {
+		name           string
+		role           string
+		scopes         []string
+		method         string
+		expectedStatus int
+	}{
+		{
+			name:           "User writes requests",
+			role:           rbac.RoleUser,
+			method:         http.MethodPost,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Read-only user reads requests",
+			role:           rbac.RoleReadOnly,
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Read-only user deletes request",
+			role:           rbac.RoleReadOnly,
+			method:         http.MethodDelete,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "API key with read scope creates request",
+			role:           rbac.RoleUser,
+			scopes:         []string{rbac.RequestsRead},
+			method:         http.MethodPost,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "API key with write scope deletes request",
+			role:           rbac.RoleUser,
+			scopes:         []string{rbac.VideosRead, rbac.RequestsWrite},
+			method:         http.MethodDelete,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Without role",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("role", testCase.role)
+
+				if testCase.scopes != nil {
+					c.Locals("scopes", testCase.scopes)
+				}
+
+				return c.Next()
+			})
+			app.Use(RequireAccess(rbac.RequestsRead, rbac.RequestsWrite))
+			app.All("/", func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(testCase.method, "/", nil))
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request\n")
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n", testCase.expectedStatus,
+					resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	cases := []struct {
+		name           string
+		role           string
+		expectedStatus int
+	}{
+		{
+			name:           "Admin",
+			role:           rbac.RoleAdmin,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "User",
+			role:           rbac.RoleUser,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("role", testCase.role)
+
+				return c.Next()
+			})
+			app.Get("/", RequirePermission(rbac.Admin), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request\n")
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n", testCase.expectedStatus,
+					resp.StatusCode)
+			}
+		})
+	}
+}
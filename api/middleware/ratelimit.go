@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimit returns middleware which limits requests to route group per API key,
+// user or ip, whichever is known. State of limit is sent in RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers, rejected request gets 429
+// with Retry-After header
+func RateLimit(limiter service.RateLimiter, group string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		res, err := limiter.Allow(c.Context(), group, client(c))
+		if err != nil {
+			errors := []string{"Something went wrong"}
+
+			return response.NegotiateErrorResponse(c, http.StatusInternalServerError, errors)
+		}
+
+		if res == nil {
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+		if !res.Allowed {
+			c.Set(fiber.HeaderRetryAfter, ceilSeconds(res.RetryAfter))
+
+			errors := []string{"Too many requests, retry later"}
+
+			return response.NegotiateErrorResponse(c, http.StatusTooManyRequests, errors)
+		}
+
+		return c.Next()
+	}
+}
+
+// client returns key of API key, user or ip of request
+func client(c *fiber.Ctx) string {
+	if id, ok := c.Locals("api_key_id").(int64); ok {
+		return fmt.Sprintf("key:%d", id)
+	}
+
+	if id, ok := c.Locals("user_id").(int64); ok {
+		return fmt.Sprintf("user:%d", id)
+	}
+
+	return "ip:" + c.IP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
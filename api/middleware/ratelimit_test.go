@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/service/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.NewService(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		ratelimit.GroupAPI: {Burst: 1, Period: time.Hour},
+	})
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get("X-User") != "" {
+			c.Locals("user_id", int64(len(c.Get("X-User"))))
+		}
+
+		return c.Next()
+	})
+	app.Use("/api", RateLimit(limiter, ratelimit.GroupAPI))
+	app.Use("/uploads", RateLimit(limiter, ratelimit.GroupUploads))
+	app.All("/*", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusOK)
+	})
+
+	cases := []struct {
+		name              string
+		path              string
+		user              string
+		expectedStatus    int
+		expectedRemaining string
+		expectedBody      string
+	}{
+		{
+			name:              "First request of user",
+			path:              "/api",
+			user:              "a",
+			expectedStatus:    http.StatusOK,
+			expectedRemaining: "0",
+		},
+		{
+			name:              "Second request of user",
+			path:              "/api",
+			user:              "a",
+			expectedStatus:    http.StatusTooManyRequests,
+			expectedRemaining: "0",
+			expectedBody:      `{"errors":[{"title":"Too many requests, retry later"}]}` + "\n",
+		},
+		{
+			name:              "Other user",
+			path:              "/api",
+			user:              "ab",
+			expectedStatus:    http.StatusOK,
+			expectedRemaining: "0",
+		},
+		{
+			name:              "Request from ip",
+			path:              "/api",
+			expectedStatus:    http.StatusOK,
+			expectedRemaining: "0",
+		},
+		{
+			name:           "Unlimited group",
+			path:           "/uploads",
+			user:           "a",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+			req.Header.Set("X-User", testCase.user)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request\n")
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n", testCase.expectedStatus,
+					resp.StatusCode)
+			}
+
+			if remaining := resp.Header.Get("RateLimit-Remaining"); remaining != testCase.expectedRemaining {
+				t.Errorf("Invalid remaining, expected: %q, got: %q\n", testCase.expectedRemaining, remaining)
+			}
+
+			if retry := resp.Header.Get(fiber.HeaderRetryAfter); (retry != "") != (resp.StatusCode == http.StatusTooManyRequests) {
+				t.Errorf("Invalid Retry-After: %q\n", retry)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading response body, error: %s\n", err.Error())
+			}
+
+			if testCase.expectedBody != "" && string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body\nexpected: %s\ngot: %s\n", testCase.expectedBody, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package organization
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/api/response"
+	orgrepo "github.com/Hargeon/videocmprs/pkg/repository/organization"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/organization"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/jsonapi"
+	"go.uber.org/zap"
+)
+
+const (
+	IDBase    = 10
+	IDBitSize = 64
+)
+
+type Handler struct {
+	srv    service.Organization
+	logger *zap.Logger
+}
+
+func NewHandler(db *sql.DB, logger *zap.Logger) *Handler {
+	srv := organization.NewService(orgrepo.NewRepository(db), user.NewRepository(db))
+
+	return &Handler{srv: srv, logger: logger}
+}
+
+func (h *Handler) InitRoutes() *fiber.App {
+	router := fiber.New()
+	router.Post("/", h.create)
+	router.Get("/", h.list)
+	router.Get("/:id", h.retrieve)
+	router.Delete("/:id", h.delete)
+	router.Get("/:id/members", h.members)
+	router.Post("/:id/members", h.addMember)
+	router.Patch("/:id/members/:member_id", h.updateMember)
+	router.Delete("/:id/members/:member_id", h.removeMember)
+
+	return router
+}
+
+func (h *Handler) create(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res := new(orgrepo.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), res); err != nil {
+		h.logger.Error("Can't unmarshal request for creating organization", zap.Error(err),
+			zap.Int64("User ID", uID))
+
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	validation := validator.New()
+
+	if err := validation.Struct(res); err != nil {
+		h.logger.Error("Validation Failed", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res.UserID = uID
+	org, err := h.srv.Create(c.Context(), res)
+
+	if err != nil {
+		h.logger.Error("Create organization", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not create organization"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), org)
+}
+
+func (h *Handler) list(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	pageNumI, err := strconv.Atoi(c.Query("page[number]", "0"))
+	if err != nil {
+		errors := []string{"Invalid page number params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if pageNumI == 1 {
+		pageNumI = 0
+	}
+
+	pageSizeI, err := strconv.Atoi(c.Query("page[size]", "10"))
+	if err != nil {
+		errors := []string{"Invalid page size params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	q := &query.Params{
+		RelationID: uID,
+		PageNumber: uint64(pageNumI),
+		PageSize:   uint64(pageSizeI),
+	}
+
+	res, err := h.srv.List(c.Context(), q)
+
+	if err != nil {
+		h.logger.Error("List organizations", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not fetch organizations"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+func (h *Handler) retrieve(c *fiber.Ctx) error {
+	uID, id, titles := h.ids(c)
+	if titles != nil {
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, titles)
+	}
+
+	res, err := h.srv.Retrieve(c.Context(), uID, id)
+
+	if err != nil {
+		return h.error(c, err, "Can not fetch organization")
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+// delete organization, requests and videos stay with users who created them
+func (h *Handler) delete(c *fiber.Ctx) error {
+	uID, id, titles := h.ids(c)
+	if titles != nil {
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, titles)
+	}
+
+	if err := h.srv.Delete(c.Context(), uID, id); err != nil {
+		return h.error(c, err, "Can not delete organization")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *Handler) members(c *fiber.Ctx) error {
+	uID, id, titles := h.ids(c)
+	if titles != nil {
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, titles)
+	}
+
+	res, err := h.srv.Members(c.Context(), uID, id)
+
+	if err != nil {
+		return h.error(c, err, "Can not fetch members")
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+func (h *Handler) addMember(c *fiber.Ctx) error {
+	uID, id, titles := h.ids(c)
+	if titles != nil {
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, titles)
+	}
+
+	res := new(orgrepo.Member)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), res); err != nil {
+		h.logger.Error("Can't unmarshal request for adding member", zap.Error(err),
+			zap.Int64("User ID", uID))
+
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	validation := validator.New()
+
+	if err := validation.Struct(res); err != nil {
+		h.logger.Error("Validation Failed", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	member, err := h.srv.AddMember(c.Context(), uID, id, res)
+
+	if err != nil {
+		return h.error(c, err, "Can not add member")
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), member)
+}
+
+// updateMember changes role of member
+func (h *Handler) updateMember(c *fiber.Ctx) error {
+	uID, id, titles := h.ids(c)
+	if titles != nil {
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, titles)
+	}
+
+	memberID, err := strconv.ParseInt(c.Params("member_id"), IDBase, IDBitSize)
+
+	if err != nil || memberID <= 0 {
+		errors := []string{"Invalid member ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res := new(orgrepo.Member)
+
+	if err = jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), res); err != nil {
+		h.logger.Error("Can't unmarshal request for updating member", zap.Error(err),
+			zap.Int64("User ID", uID))
+
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if err = h.srv.UpdateMember(c.Context(), uID, id, memberID, res.Role); err != nil {
+		return h.error(c, err, "Can not update member")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// removeMember removes member from organization, member removes itself for leaving organization
+func (h *Handler) removeMember(c *fiber.Ctx) error {
+	uID, id, titles := h.ids(c)
+	if titles != nil {
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, titles)
+	}
+
+	memberID, err := strconv.ParseInt(c.Params("member_id"), IDBase, IDBitSize)
+
+	if err != nil || memberID <= 0 {
+		errors := []string{"Invalid member ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if err = h.srv.RemoveMember(c.Context(), uID, id, memberID); err != nil {
+		return h.error(c, err, "Can not remove member")
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// ids returns id of user and id of organization from route params. If ids
+// are invalid titles of errors are returned
+func (h *Handler) ids(c *fiber.Ctx) (int64, int64, []string) {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		return 0, 0, []string{"Invalid user ID"}
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), IDBase, IDBitSize)
+
+	if err != nil || id <= 0 {
+		return 0, 0, []string{"Invalid ID"}
+	}
+
+	return uID, id, nil
+}
+
+// error writes response for error returned by organization service
+func (h *Handler) error(c *fiber.Ctx, err error, title string) error {
+	switch {
+	case errors.Is(err, organization.ErrOrganizationNotPresent):
+		errors := []string{"Organization not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	case errors.Is(err, organization.ErrForbidden):
+		errors := []string{"Not enough permissions in organization"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusForbidden, errors)
+	case errors.Is(err, organization.ErrUserNotFound):
+		errors := []string{"User not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	case errors.Is(err, organization.ErrMemberNotPresent):
+		errors := []string{"Member not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	case errors.Is(err, organization.ErrAlreadyMember):
+		errors := []string{"User is already a member"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	case errors.Is(err, organization.ErrInvalidRole):
+		errors := []string{"Invalid role"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	h.logger.Error(title, zap.Error(err), zap.String("Path", c.Path()))
+
+	errors := []string{title}
+
+	return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+}
@@ -0,0 +1,260 @@
+package organization
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	orgrepo "github.com/Hargeon/videocmprs/pkg/repository/organization"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+func TestHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, logger)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Mount("/organizations", h.InitRoutes())
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	credentialsColumns := []string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}
+
+	role := func(userID int64, role string) {
+		rows := sqlmock.NewRows([]string{"role"})
+		if role != "" {
+			rows.AddRow(role)
+		}
+
+		mock.ExpectQuery(fmt.Sprintf("SELECT role FROM %s", orgrepo.MemberTableName)).
+			WithArgs(3, userID).
+			WillReturnRows(rows)
+	}
+
+	cases := []struct {
+		name           string
+		mock           func()
+		requestMock    func() *http.Request
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Create organization",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", orgrepo.TableName)).
+					WithArgs("team").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", orgrepo.MemberTableName)).
+					WithArgs(3, 1, orgrepo.RoleOwner).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"organizations","attributes":{"name":"team"}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/organizations", strings.NewReader(body))
+			},
+			expectedBody:   `"attributes":{"created_at":"2021-10-01T12:00:00Z","name":"team","role":"owner"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Create organization without name",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"organizations","attributes":{}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/organizations", strings.NewReader(body))
+			},
+			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "List organizations",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", orgrepo.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "role"}).
+						AddRow(3, "team", createdAt, orgrepo.RoleMember))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/organizations", nil)
+			},
+			expectedBody:   `"role":"member"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Retrieve organization of other users",
+			mock: func() {
+				role(1, "")
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/organizations/3", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Organization not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Delete organization by member",
+			mock: func() {
+				role(1, orgrepo.RoleMember)
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/organizations/3", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Not enough permissions in organization"}]}` + "\n",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "List members",
+			mock: func() {
+				role(1, orgrepo.RoleMember)
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", orgrepo.MemberTableName)).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "role", "created_at"}).
+						AddRow(1, "check@check.com", orgrepo.RoleMember, createdAt))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/organizations/3/members", nil)
+			},
+			expectedBody:   `"email":"check@check.com"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Add member",
+			mock: func() {
+				role(1, orgrepo.RoleOwner)
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("new@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(2, "hash", "user", nil, nil, nil))
+				role(2, "")
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", orgrepo.MemberTableName)).
+					WithArgs(3, 2, orgrepo.RoleAdmin).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"organization-members","attributes":{"email":"new@check.com","role":"admin"}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/organizations/3/members", strings.NewReader(body))
+			},
+			expectedBody:   `{"data":{"type":"organization-members","id":"2","attributes":{"created_at":"2021-10-01T12:00:00Z","email":"new@check.com","organization_id":3,"role":"admin"},"links":{"self":"/api/v1/organizations/3/members/2"}}}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Add member with owner role",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"organization-members","attributes":{"email":"new@check.com","role":"owner"}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/organizations/3/members", strings.NewReader(body))
+			},
+			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Add existing member",
+			mock: func() {
+				role(1, orgrepo.RoleAdmin)
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("new@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(2, "hash", "user", nil, nil, nil))
+				role(2, orgrepo.RoleMember)
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"organization-members","attributes":{"email":"new@check.com","role":"member"}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/organizations/3/members", strings.NewReader(body))
+			},
+			expectedBody:   `{"errors":[{"title":"User is already a member"}]}` + "\n",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "Change role of member",
+			mock: func() {
+				role(1, orgrepo.RoleOwner)
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET role", orgrepo.MemberTableName)).
+					WithArgs(orgrepo.RoleMember, 3, 2, orgrepo.RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"organization-members","attributes":{"role":"member"}}}`
+
+				return httptest.NewRequest(http.MethodPatch, "/organizations/3/members/2", strings.NewReader(body))
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Remove owner",
+			mock: func() {
+				role(1, orgrepo.RoleAdmin)
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", orgrepo.MemberTableName)).
+					WithArgs(3, 2, orgrepo.RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/organizations/3/members/2", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Member not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Leave organization",
+			mock: func() {
+				role(1, orgrepo.RoleMember)
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", orgrepo.MemberTableName)).
+					WithArgs(3, 1, orgrepo.RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/organizations/3/members/1", nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+
+			resp, err := app.Test(testCase.requestMock())
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code. expected: %d, got: %d\n", testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading response body, error: %s\n", err.Error())
+			}
+
+			if !strings.Contains(string(body), testCase.expectedBody) {
+				t.Errorf("Invalid body\nexpected: %s\ngot: %s\n", testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
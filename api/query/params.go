@@ -1,8 +1,34 @@
 package query
 
+import "time"
+
+// Params of list query. Lists are limited to RelationID (user) unless
+// OrganizationID is set. VideoID limits lists of share links to video
 type Params struct {
-	RelationID int64
+	RelationID     int64
+	OrganizationID int64
+	VideoID        int64
 
 	PageNumber uint64
 	PageSize   uint64
 }
+
+// RequestFilter filters requests of all users, zero fields are ignored
+type RequestFilter struct {
+	UserID         int64
+	OrganizationID int64
+	Status         string
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+}
+
+// AuditFilter filters audit events, zero fields are ignored
+type AuditFilter struct {
+	UserID        int64
+	Action        string
+	ResourceType  string
+	ResourceID    int64
+	IP            string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
@@ -3,16 +3,26 @@ package request
 import (
 	"bytes"
 	"database/sql"
+	"errors"
 	"net/http"
-	"regexp"
 	"strconv"
 
 	"github.com/Hargeon/videocmprs/api/query"
 	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/idempotency"
+	"github.com/Hargeon/videocmprs/pkg/repository/organization"
 	reqrepo "github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
 	"github.com/Hargeon/videocmprs/pkg/service"
+	auditsrv "github.com/Hargeon/videocmprs/pkg/service/audit"
+	idempotencysrv "github.com/Hargeon/videocmprs/pkg/service/idempotency"
+	"github.com/Hargeon/videocmprs/pkg/service/probe"
+	"github.com/Hargeon/videocmprs/pkg/service/quota"
 	"github.com/Hargeon/videocmprs/pkg/service/request"
+	"github.com/Hargeon/videocmprs/pkg/service/retention"
+	"github.com/Hargeon/videocmprs/pkg/service/scan"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -27,15 +37,23 @@ const (
 
 type Handler struct {
 	srv    service.Request
+	keys   service.Idempotency
+	probe  service.VideoProber
 	logger *zap.Logger
 }
 
 func NewHandler(db *sql.DB, cS service.CloudStorage, pb service.Publisher, logger *zap.Logger) *Handler {
 	reqRepo := reqrepo.NewRepository(db)
 	vRepo := video.NewRepository(db)
-	srv := request.NewService(reqRepo, vRepo, cS, pb, logger)
-
-	return &Handler{srv: srv, logger: logger}
+	uRepo := user.NewRepository(db)
+	policy := retention.NewEnvPolicy(uRepo)
+	quotas := quota.NewEnvService(uRepo)
+	recorder := auditsrv.NewService(audit.NewRepository(db), logger)
+	scanner := scan.NewEnvService(logger)
+	srv := request.NewService(reqRepo, vRepo, cS, pb, policy, quotas, scanner, organization.NewRepository(db), recorder, logger) //nolint:lll
+	keys := idempotencysrv.NewService(idempotency.NewRepository(db))
+
+	return &Handler{srv: srv, keys: keys, probe: probe.NewEnvService(), logger: logger}
 }
 
 func (h *Handler) InitRoutes() *fiber.App {
@@ -43,6 +61,7 @@ func (h *Handler) InitRoutes() *fiber.App {
 	router.Post("/", h.create)
 	router.Get("/", h.list)
 	router.Get("/:id", h.retrieve)
+	router.Delete("/:id", h.delete)
 
 	return router
 }
@@ -58,6 +77,15 @@ func (h *Handler) create(c *fiber.Ctx) error {
 		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
 	}
 
+	if key := c.Get(HeaderIdempotencyKey); key != "" {
+		return h.createIdempotent(c, uID, key)
+	}
+
+	return h.createRequest(c, uID)
+}
+
+// createRequest uploads video and creates request of user
+func (h *Handler) createRequest(c *fiber.Ctx, uID int64) error {
 	file, err := c.FormFile("video")
 
 	if err != nil {
@@ -69,14 +97,22 @@ func (h *Handler) create(c *fiber.Ctx) error {
 		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
 	}
 
-	ok = h.isFile(file.Header.Values("Content-Type"))
+	info, err := h.probe.Probe(c.Context(), file)
 
-	if !ok {
-		h.logger.Warn("File is not a video", zap.Int64("User ID", uID))
+	if status, title := probeError(err); status != 0 {
+		h.logger.Warn("Video rejected", zap.Error(err), zap.Int64("User ID", uID))
 
-		errors := []string{"File is not a video"}
+		errors := []string{title}
 
-		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		return response.ErrorJsonApiResponse(c, status, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Can't probe video", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not check video"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
 	}
 
 	reqData := c.FormValue("requests")
@@ -107,13 +143,37 @@ func (h *Handler) create(c *fiber.Ctx) error {
 
 	res.VideoRequest = file
 	res.OriginalVideo = &video.Resource{
-		Name:   file.Filename,
-		Size:   file.Size,
-		UserID: uID,
+		Name:        file.Filename,
+		Size:        file.Size,
+		UserID:      uID,
+		Codec:       info.Codec,
+		Duration:    info.Duration,
+		ResolutionX: info.Width,
+		ResolutionY: info.Height,
 	}
 
 	r, err := h.srv.Create(c.Context(), res)
 
+	if errors.Is(err, request.ErrNotMember) {
+		errors := []string{"Organization not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	}
+
+	if errors.Is(err, request.ErrStorageNotSupported) {
+		errors := []string{"Compression is not supported by storage"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotImplemented, errors)
+	}
+
+	if status, title := quotaError(err); status != 0 {
+		h.logger.Warn("Quota exceeded", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{title}
+
+		return response.ErrorJsonApiResponse(c, status, errors)
+	}
+
 	if err != nil {
 		h.logger.Error("Create request", zap.Error(err),
 			zap.Int64("User ID", uID))
@@ -123,6 +183,9 @@ func (h *Handler) create(c *fiber.Ctx) error {
 		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
 	}
 
+	// content type is stored with response of idempotent request and replayed
+	c.Set(fiber.HeaderContentType, jsonapi.MediaType)
+
 	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), r)
 }
 
@@ -171,8 +234,23 @@ func (h *Handler) list(c *fiber.Ctx) error {
 		PageSize:   uint64(pageSizeI),
 	}
 
+	if orgID := c.Query("filter[organization_id]"); orgID != "" {
+		q.OrganizationID, err = strconv.ParseInt(orgID, IDBase, IDBitSize)
+		if err != nil || q.OrganizationID <= 0 {
+			errors := []string{"Invalid organization ID filter"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
 	res, err := h.srv.List(c.Context(), q)
 
+	if errors.Is(err, request.ErrNotMember) {
+		errors := []string{"Organization not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	}
+
 	if err != nil {
 		h.logger.Error("List requests", zap.Error(err),
 			zap.Int64("User ID", uID))
@@ -185,28 +263,6 @@ func (h *Handler) list(c *fiber.Ctx) error {
 	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
 }
 
-func (h *Handler) isFile(types []string) bool {
-	re, err := regexp.Compile(`video/.+`)
-
-	if err != nil {
-		return false
-	}
-
-	var present bool
-
-	for _, cType := range types {
-		ok := re.MatchString(cType)
-
-		if ok {
-			present = true
-
-			break
-		}
-	}
-
-	return present
-}
-
 func (h *Handler) retrieve(c *fiber.Ctx) error {
 	uID, ok := c.Locals("user_id").(int64)
 
@@ -253,3 +309,80 @@ func (h *Handler) retrieve(c *fiber.Ctx) error {
 
 	return nil
 }
+
+// delete request with its videos
+func (h *Handler) delete(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	idStr := c.Params("id")
+	id, err := strconv.ParseInt(idStr, IDBase, IDBitSize)
+
+	if err != nil || id <= 0 {
+		h.logger.Error("Invalid request ID", zap.Error(err), zap.Int64("Request ID", id))
+
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	err = h.srv.Delete(c.Context(), uID, id)
+
+	if err != nil {
+		if errors.Is(err, request.ErrRequestNotPresent) {
+			errors := []string{"Request not found"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+		}
+
+		h.logger.Error("Delete request", zap.Error(err),
+			zap.Int64("Request ID", id))
+
+		errors := []string{"Can not delete request"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// quotaError returns http status and title for quota errors. Returns zero status
+// if err is not a quota error
+func quotaError(err error) (int, string) {
+	switch {
+	case errors.Is(err, quota.ErrFileTooLarge):
+		return http.StatusForbidden, "File is too large"
+	case errors.Is(err, quota.ErrStorageExceeded):
+		return http.StatusForbidden, "Storage quota exceeded"
+	case errors.Is(err, quota.ErrActiveRequestsExceeded):
+		return http.StatusTooManyRequests, "Too many active requests"
+	case errors.Is(err, quota.ErrMonthlyMinutesExceeded):
+		return http.StatusTooManyRequests, "Monthly processing minutes exceeded"
+	default:
+		return 0, ""
+	}
+}
+
+// probeError returns http status and title for rejected video. Returns zero status
+// if err is not a probe error
+func probeError(err error) (int, string) {
+	switch {
+	case errors.Is(err, probe.ErrNotVideo):
+		return http.StatusBadRequest, "File is not a video"
+	case errors.Is(err, probe.ErrCorrupt):
+		return http.StatusBadRequest, "Video is corrupt"
+	case errors.Is(err, probe.ErrDurationExceeded):
+		return http.StatusBadRequest, "Video duration exceeds limit"
+	case errors.Is(err, probe.ErrResolutionExceeded):
+		return http.StatusBadRequest, "Video resolution exceeds limit"
+	default:
+		return 0, ""
+	}
+}
@@ -11,9 +11,12 @@ import (
 	"net/http/httptest"
 	"net/textproto"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/probe"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v2"
@@ -23,7 +26,7 @@ import (
 
 type cloudMock struct{}
 
-func (c *cloudMock) Upload(ctx context.Context, header *multipart.FileHeader) (string, error) {
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
 	if header.Filename == "failed" {
 		return "", errors.New("failed connection")
 	}
@@ -35,6 +38,26 @@ func (c *cloudMock) URL(filename string) (string, error) {
 	return filename, nil
 }
 
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	if filename == "error" {
+		return errors.New("mock error")
+	}
+
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	return &service.CloudObject{Name: filename}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
 type rabbitSuccess struct{}
 
 func (r *rabbitSuccess) Publish(body []byte) error {
@@ -316,12 +339,25 @@ func TestCreate(t *testing.T) {
 				return req
 			},
 			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"quota_storage_bytes", "quota_max_file_size",
+						"quota_active_requests", "quota_monthly_minutes"}).AddRow(nil, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows([]string{"stored_bytes", "active_requests", "monthly_minutes"}).
+						AddRow(0, 0, 0))
+
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", request.TableName)).
-					WithArgs(64000, 800, 600, 4, 3, 1, "test_video.mkv").
+					WithArgs(64000, 800, 600, 4, 3, 1, "test_video.mkv", nil).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).
 						AddRow(1))
+				mock.ExpectCommit()
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -329,15 +365,17 @@ func TestCreate(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "original_in_review", "", 64000, 800, 600, 4, 3, "test_video.mkv", nil, nil, nil,
-						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
-			expectedBody:   `{"data":{"type":"requests","id":"1","attributes":{"bitrate":64000,"ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"status":"original_in_review","video_name":"test_video.mkv"},"links":{"self":"/api/v1/requests/1"}}}` + "\n",
+			expectedBody:   `{"data":{"type":"requests","id":"1","attributes":{"bitrate":64000,"ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"status":"original_in_review","user_id":1,"video_name":"test_video.mkv"},"links":{"self":"/api/v1/requests/1"}}}` + "\n",
 			expectedStatus: http.StatusCreated,
 		},
 		{
@@ -393,6 +431,72 @@ func TestCreate(t *testing.T) {
 			expectedBody:   `{"errors":[{"title":"Can not create request"}]}` + "\n",
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "Storage quota exceeded",
+			requestMock: func() *http.Request {
+				buf := new(bytes.Buffer)
+				writer := multipart.NewWriter(buf)
+				fMock, err := os.Open("test_video.mkv")
+				if err != nil {
+					t.Fatalf("Unexpected error while readeing image, error: %s\n", err.Error())
+				}
+
+				h := make(textproto.MIMEHeader)
+				h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="video"; filename="%s"`, fMock.Name()))
+				h.Set("Content-Type", "video/x-qwdq")
+				part, err := writer.CreatePart(h)
+				if err != nil {
+					t.Fatalf("Unexpected error when adding file to request, error: %s\n", err.Error())
+				}
+
+				if _, err = io.Copy(part, fMock); err != nil {
+					t.Fatalf("Unexpected error when copying body")
+				}
+
+				r := &request.Resource{
+					Bitrate:     64000,
+					ResolutionX: 800,
+					ResolutionY: 600,
+					RatioX:      4,
+					RatioY:      3,
+				}
+
+				bufReq := new(bytes.Buffer)
+
+				if err = jsonapi.MarshalPayload(bufReq, r); err != nil {
+					t.Fatalf("Unexpected error when marchaling request, error: %s\n", err.Error())
+				}
+
+				if err = writer.WriteField("requests", bufReq.String()); err != nil {
+					t.Fatalf("Unexpected error while adding request, error: %s\n", err.Error())
+				}
+
+				if err := writer.Close(); err != nil {
+					t.Errorf("Unexpected error when closing writter, error: %s\n", err.Error())
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/", buf)
+				req.Header.Set("Content-Type", writer.FormDataContentType())
+
+				return req
+			},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"quota_storage_bytes", "quota_max_file_size",
+						"quota_active_requests", "quota_monthly_minutes"}).AddRow(10, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows([]string{"stored_bytes", "active_requests", "monthly_minutes"}).
+						AddRow(0, 0, 0))
+				mock.ExpectRollback()
+			},
+			expectedBody:   `{"errors":[{"title":"Storage quota exceeded"}]}` + "\n",
+			expectedStatus: http.StatusForbidden,
+		},
 	}
 
 	for _, testCase := range cases {
@@ -427,31 +531,39 @@ func TestCreate(t *testing.T) {
 	}
 }
 
-func TestIsFile(t *testing.T) {
+func TestProbeError(t *testing.T) {
 	cases := []struct {
 		name           string
-		types          []string
-		expectedResult bool
+		err            error
+		expectedStatus int
+		expectedTitle  string
 	}{
 		{
-			name:           "Valid video",
-			types:          []string{"video/mp4"},
-			expectedResult: true,
+			name:           "Not a video",
+			err:            probe.ErrNotVideo,
+			expectedStatus: http.StatusBadRequest,
+			expectedTitle:  "File is not a video",
+		},
+		{
+			name:           "Wrapped corrupt video",
+			err:            fmt.Errorf("%w: moov atom not found", probe.ErrCorrupt),
+			expectedStatus: http.StatusBadRequest,
+			expectedTitle:  "Video is corrupt",
 		},
 		{
-			name:           "Invalid video",
-			types:          []string{"image/jpeg"},
-			expectedResult: false,
+			name:           "Other error",
+			err:            errors.New("exec: ffprobe not found"),
+			expectedStatus: 0,
+			expectedTitle:  "",
 		},
 	}
 
 	for _, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
-			h := new(Handler)
-			result := h.isFile(testCase.types)
-			if result != testCase.expectedResult {
-				t.Errorf("Invalid result, expectedL %v, got: %v\n",
-					testCase.expectedResult, result)
+			status, title := probeError(testCase.err)
+			if status != testCase.expectedStatus || title != testCase.expectedTitle {
+				t.Errorf("Invalid result, expected: %d %s, got: %d %s\n",
+					testCase.expectedStatus, testCase.expectedTitle, status, title)
 			}
 		})
 	}
@@ -507,10 +619,65 @@ func TestList(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 		},
 
+		{
+			name: "Invalid organization filter",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/requests?filter[organization_id]=qwe", nil)
+
+				return req
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid organization ID filter"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Organization without membership",
+			mock: func() {
+				mock.ExpectQuery("SELECT role FROM organization_members").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"role"}))
+			},
+			requestMock: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/requests?filter[organization_id]=3", nil)
+
+				return req
+			},
+			expectedBody:   `{"errors":[{"title":"Organization not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Requests of organization",
+			mock: func() {
+				mock.ExpectQuery("SELECT role FROM organization_members").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("member"))
+				mock.ExpectQuery("SELECT (.+) FROM requests (.+) WHERE requests.deleted_at IS NULL AND requests.organization_id = \\$1 ").
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
+						"requests.details", "requests.bitrate", "requests.resolution_x",
+						"requests.resolution_y", "requests.ratio_x", "requests.ratio_y",
+						"requests.video_name", "origin_video.id", "origin_video.name",
+						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
+						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
+						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
+						"converted_video.resolution_y", "converted_video.ratio_x",
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).
+						AddRow(1, "success", "", 64000, 800, 600, 4, 3, "new_video", nil, nil, nil, nil, nil,
+							nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 3))
+			},
+			requestMock: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/requests?filter[organization_id]=3", nil)
+
+				return req
+			},
+			expectedBody:   `{"data":[{"type":"requests","id":"1","attributes":{"bitrate":64000,"organization_id":3,"ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"status":"success","video_name":"new_video"},"links":{"self":"/api/v1/requests/1"}}]}` + "\n",
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name: "Zero requests",
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -518,10 +685,10 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}))
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/requests?page[number]=1&page[size]=10", nil)
@@ -534,7 +701,7 @@ func TestList(t *testing.T) {
 		{
 			name: "Without origin and converted video",
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -542,12 +709,12 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, "", "", 64000, 800, 600, 4, 3, "new_video", nil, nil, nil,
-						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/requests?page[number]=1&page[size]=10", nil)
@@ -560,7 +727,7 @@ func TestList(t *testing.T) {
 		{
 			name: "With origin video",
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -568,12 +735,12 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, "", "", 64000, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", nil, nil, nil, nil, nil, nil, nil, nil, nil))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/requests?page[number]=1&page[size]=10", nil)
@@ -586,7 +753,7 @@ func TestList(t *testing.T) {
 		{
 			name: "With origin and converted video",
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -594,13 +761,13 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, "", "", 64000, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", 2, "converted_video", 12000, 64000,
-						800, 600, 4, 3, "converted_service_id"))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, 2, "converted_video", 12000, 64000,
+						800, 600, 4, 3, "converted_service_id", nil, nil))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/requests?page[number]=1&page[size]=10", nil)
@@ -684,7 +851,7 @@ func TestRetrieve(t *testing.T) {
 			name: "Invalid db connection",
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", request.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}))
 			},
 			requestMock: func() *http.Request {
@@ -699,10 +866,10 @@ func TestRetrieve(t *testing.T) {
 			name: "Valid db connection",
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", request.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -710,20 +877,20 @@ func TestRetrieve(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "", "", 64000, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", 2, "converted_video", 12000, 64000,
-						800, 600, 4, 3, "converted_service_id"))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, 2, "converted_video", 12000, 64000,
+						800, 600, 4, 3, "converted_service_id", nil, nil))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/requests/1", nil)
 
 				return req
 			},
-			expectedBody:   `{"data":{"type":"requests","id":"1","attributes":{"bitrate":64000,"ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"video_name":"new_video"},"relationships":{"converted_video":{"data":{"type":"videos","id":"2"}},"original_video":{"data":{"type":"videos","id":"1"}}},"links":{"self":"/api/v1/requests/1"}},"included":[{"type":"videos","id":"1","attributes":{"bitrate":78000,"name":"new_video","ratio_x":6,"ratio_y":5,"resolution_x":1200,"resolution_y":800,"size":15000},"links":{"download":"/api/v1/videos/download_url/1","self":"/api/v1/videos/1"}},{"type":"videos","id":"2","attributes":{"bitrate":64000,"name":"converted_video","ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"size":12000},"links":{"download":"/api/v1/videos/download_url/2","self":"/api/v1/videos/2"}}]}` + "\n",
+			expectedBody:   `{"data":{"type":"requests","id":"1","attributes":{"bitrate":64000,"ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"user_id":1,"video_name":"new_video"},"relationships":{"converted_video":{"data":{"type":"videos","id":"2"}},"original_video":{"data":{"type":"videos","id":"1"}}},"links":{"self":"/api/v1/requests/1"}},"included":[{"type":"videos","id":"1","attributes":{"bitrate":78000,"name":"new_video","ratio_x":6,"ratio_y":5,"resolution_x":1200,"resolution_y":800,"size":15000},"links":{"download":"/api/v1/videos/download_url/1","self":"/api/v1/videos/1"}},{"type":"videos","id":"2","attributes":{"bitrate":64000,"name":"converted_video","ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"size":12000},"links":{"download":"/api/v1/videos/download_url/2","self":"/api/v1/videos/2"}}]}` + "\n",
 			expectedStatus: http.StatusOK,
 		},
 	}
@@ -761,3 +928,122 @@ func TestRetrieve(t *testing.T) {
 		})
 	}
 }
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, new(cloudMock), new(rabbitSuccess), logger)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Mount("/requests", h.InitRoutes())
+
+	cases := []struct {
+		name           string
+		mock           func()
+		requestMock    func() *http.Request
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Invalid id",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/requests/name", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid ID"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Request of other member of organization",
+			mock: func() {
+				// plain member can read the request, but only owner and admins of organization delete it
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+) role IN", request.TableName)).
+					WithArgs(2, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/requests/2", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Request not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Request not found",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", request.TableName)).
+					WithArgs(1, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/requests/1", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Request not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Should delete request",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", request.TableName)).
+					WithArgs(1, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", request.TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_file_id", "converted_file_id"}).
+						AddRow(nil, nil))
+				mock.ExpectCommit()
+				mock.ExpectExec("INSERT INTO audit_events").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/requests/1", nil)
+			},
+			expectedBody:   "",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			req := testCase.requestMock()
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n",
+					err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n",
+					testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a body, error: %s\n",
+					err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body\nexpected: %v\ngot: %v\n",
+					testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package request
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/service/idempotency"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// HeaderIdempotencyKey is header with key of request chosen by client. Request
+// repeated with the same key gets the original response instead of a new request
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// headerIdempotentReplayed marks response replayed for repeated request
+const headerIdempotentReplayed = "Idempotent-Replayed"
+
+// createIdempotent creates request once per idempotency key of user and replays
+// the response for repeated requests with the same payload
+func (h *Handler) createIdempotent(c *fiber.Ctx, uID int64, key string) error {
+	if len(key) > idempotency.MaxKeyLength {
+		errors := []string{"Invalid Idempotency-Key"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		// invalid form is rejected without reserving the key
+		return h.createRequest(c, uID)
+	}
+
+	fingerprint, err := formFingerprint(form)
+	if err != nil {
+		h.logger.Error("Fingerprint of request", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not create request"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	k, err := h.keys.Begin(c.Context(), uID, key, fingerprint)
+
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused):
+		errors := []string{"Idempotency-Key is already used for other request"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	case errors.Is(err, idempotency.ErrKeyInProgress):
+		errors := []string{"Request with this Idempotency-Key is in progress"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusConflict, errors)
+	case err != nil:
+		h.logger.Error("Begin idempotent request", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not create request"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	if k.Completed() {
+		c.Set(headerIdempotentReplayed, "true")
+
+		if k.ContentType != "" {
+			c.Set(fiber.HeaderContentType, k.ContentType)
+		}
+
+		return c.Status(k.Status).Send(k.Response)
+	}
+
+	created := false
+
+	// key is released when request fails or panics, so it can be retried
+	defer func() {
+		if created {
+			return
+		}
+
+		if err := h.keys.Release(c.Context(), k.ID); err != nil {
+			h.logger.Error("Release idempotency key", zap.Error(err), zap.Int64("User ID", uID))
+		}
+	}()
+
+	err = h.createRequest(c, uID)
+
+	// only created request is replayed, failed one can be retried with the same key
+	if status := c.Response().StatusCode(); err == nil && status == http.StatusCreated {
+		created = true
+		contentType := string(c.Response().Header.ContentType())
+		body := append([]byte(nil), c.Response().Body()...)
+
+		// request is already created, so key without stored response isn't released
+		// and stays in progress until it expires, retries can't create duplicate
+		if err = h.keys.Complete(c.Context(), k.ID, status, contentType, body); err != nil {
+			h.logger.Error("Store response of idempotent request", zap.Error(err), zap.Int64("User ID", uID))
+		}
+
+		return nil
+	}
+
+	return err
+}
+
+// formFingerprint returns hash of values and files of multipart form
+func formFingerprint(form *multipart.Form) (string, error) {
+	h := sha256.New()
+
+	values := make([]string, 0, len(form.Value))
+	for name := range form.Value {
+		values = append(values, name)
+	}
+
+	sort.Strings(values)
+
+	for _, name := range values {
+		for _, value := range form.Value[name] {
+			fmt.Fprintf(h, "value %q %q\n", name, value)
+		}
+	}
+
+	files := make([]string, 0, len(form.File))
+	for name := range form.File {
+		files = append(files, name)
+	}
+
+	sort.Strings(files)
+
+	for _, name := range files {
+		for _, file := range form.File[name] {
+			fmt.Fprintf(h, "file %q %q %d\n", name, file.Filename, file.Size)
+
+			if err := hashFile(h, file); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h hash.Hash, header *multipart.FileHeader) error {
+	f, err := header.Open()
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(h, f)
+
+	return err
+}
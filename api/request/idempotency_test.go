@@ -0,0 +1,369 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/google/jsonapi"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// videoRequest returns request for creating request with video and params
+func videoRequest(t *testing.T, key, params string) *http.Request {
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="video"; filename="test_video.mkv"`)
+	h.Set("Content-Type", "video/x-matroska")
+
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		t.Fatalf("Unexpected error when adding file to request, error: %s\n", err.Error())
+	}
+
+	// EBML header of Matroska file
+	header := append([]byte{0x1A, 0x45, 0xDF, 0xA3, 0x8B, 0x42, 0x82, 0x88}, "matroska"...)
+	if _, err = part.Write(header); err != nil {
+		t.Fatalf("Unexpected error when copying body")
+	}
+
+	if err = writer.WriteField("requests", params); err != nil {
+		t.Fatalf("Unexpected error while adding request, error: %s\n", err.Error())
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Unexpected error when closing writter, error: %s\n", err.Error())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(HeaderIdempotencyKey, key)
+
+	return req
+}
+
+var requestColumns = []string{"requests.id", "requests.user_id", "requests.status",
+	"requests.details", "requests.bitrate", "requests.resolution_x",
+	"requests.resolution_y", "requests.ratio_x", "requests.ratio_y",
+	"requests.video_name", "origin_video.id", "origin_video.name",
+	"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
+	"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
+	"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
+	"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
+	"converted_video.resolution_y", "converted_video.ratio_x",
+	"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}
+
+func TestCreateIdempotent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, new(cloudMock), new(rabbitSuccess), logger)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Post("/", h.create)
+
+	params := `{"data":{"type":"requests","attributes":{"bitrate":64000,"resolution_x":800,"resolution_y":600,"ratio_x":4,"ratio_y":3}}}`
+	created := `{"data":{"type":"requests","id":"1","attributes":{"bitrate":64000,"ratio_x":4,"ratio_y":3,"resolution_x":800,"resolution_y":600,"status":"original_in_review","user_id":1,"video_name":"test_video.mkv"},"links":{"self":"/api/v1/requests/1"}}}` + "\n"
+	keyColumns := []string{"id", "fingerprint", "status", "content_type", "response"}
+
+	// fingerprint of the first request is stored and compared with the next ones
+	var fingerprint string
+
+	cases := []struct {
+		name                string
+		key                 string
+		params              string
+		mock                func()
+		expectedBody        string
+		expectedStatus      int
+		expectedContentType string
+		replayed            bool
+	}{
+		{
+			name:   "Invalid params release key",
+			key:    "invalid",
+			params: "qweqwe",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WithArgs(1, "invalid", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectExec("DELETE FROM idempotency_keys").
+					WithArgs(2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid request params"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Request in progress",
+			key:    "key",
+			params: params,
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+					WithArgs("key", 1).
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, fingerprint, nil, nil, nil))
+			},
+			expectedBody:   `{"errors":[{"title":"Request with this Idempotency-Key is in progress"}]}` + "\n",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "Key with other payload",
+			key:    "key",
+			params: params,
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+					WithArgs("key", 1).
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, "other", 201, jsonapi.MediaType, []byte(created)))
+			},
+			expectedBody:   `{"errors":[{"title":"Idempotency-Key is already used for other request"}]}` + "\n",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "Repeated request",
+			key:    "key",
+			params: params,
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+					WithArgs("key", 1).
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, fingerprint, 201, jsonapi.MediaType, []byte(created)))
+			},
+			expectedBody:        created,
+			expectedStatus:      http.StatusCreated,
+			expectedContentType: jsonapi.MediaType,
+			replayed:            true,
+		},
+		{
+			name:   "New key stores response",
+			key:    "new",
+			params: params,
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WithArgs(1, "new", fingerprint, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"quota_storage_bytes", "quota_max_file_size",
+						"quota_active_requests", "quota_monthly_minutes"}).AddRow(nil, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows([]string{"stored_bytes", "active_requests", "monthly_minutes"}).
+						AddRow(0, 0, 0))
+				mock.ExpectQuery("INSERT INTO requests").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(requestColumns).AddRow(
+						1, 1, "original_in_review", "", 64000, 800, 600, 4, 3, "test_video.mkv", nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE idempotency_keys SET content_type = \\$1, response = \\$2, status = \\$3 WHERE id = \\$4").
+					WithArgs(jsonapi.MediaType, []byte(created), http.StatusCreated, 4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedBody:        created,
+			expectedStatus:      http.StatusCreated,
+			expectedContentType: jsonapi.MediaType,
+		},
+	}
+
+	// fingerprint doesn't depend on boundary of multipart form
+	req := videoRequest(t, "key", params)
+	if err = req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("Unexpected error when parsing form, error: %s\n", err.Error())
+	}
+
+	if fingerprint, err = formFingerprint(req.MultipartForm); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err.Error())
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+
+			resp, err := app.Test(videoRequest(t, testCase.key, testCase.params))
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code. expected: %d, got: %d\n", testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading response body, error: %s\n", err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body\nexpected: %s\ngot: %s\n", testCase.expectedBody, string(body))
+			}
+
+			if replayed := resp.Header.Get("Idempotent-Replayed") == "true"; replayed != testCase.replayed {
+				t.Errorf("Invalid Idempotent-Replayed header, expected: %v\n", testCase.replayed)
+			}
+
+			contentType := resp.Header.Get("Content-Type")
+			if testCase.expectedContentType != "" && contentType != testCase.expectedContentType {
+				t.Errorf("Invalid Content-Type, expected: %s, got: %s\n", testCase.expectedContentType, contentType)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+type panicProber struct{}
+
+func (panicProber) Probe(ctx context.Context, file *multipart.FileHeader) (*service.VideoInfo, error) {
+	panic("mock panic")
+}
+
+func TestCreateIdempotentPanic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, new(cloudMock), new(rabbitSuccess), logger)
+	h.probe = panicProber{}
+
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Post("/", h.create)
+
+	mock.ExpectQuery("INSERT INTO idempotency_keys").
+		WithArgs(1, "key", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec("DELETE FROM idempotency_keys").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err := app.Test(videoRequest(t, "key", "{}"))
+	if err != nil {
+		t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+	}
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Invalid status code. expected: %d, got: %d\n", http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
+
+func TestCreateIdempotentCompleteFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	core, logs := observer.New(zap.ErrorLevel)
+	h := NewHandler(db, new(cloudMock), new(rabbitSuccess), zap.New(core))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Post("/", h.create)
+
+	params := `{"data":{"type":"requests","attributes":{"bitrate":64000,"resolution_x":800,"resolution_y":600,"ratio_x":4,"ratio_y":3}}}`
+
+	mock.ExpectQuery("INSERT INTO idempotency_keys").
+		WithArgs(1, "key", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT (.+) FROM users").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"quota_storage_bytes", "quota_max_file_size",
+			"quota_active_requests", "quota_monthly_minutes"}).AddRow(nil, nil, nil, nil))
+	mock.ExpectQuery("SELECT (.+) FROM videos").
+		WillReturnRows(sqlmock.NewRows([]string{"stored_bytes", "active_requests", "monthly_minutes"}).
+			AddRow(0, 0, 0))
+	mock.ExpectQuery("INSERT INTO requests").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT (.+) FROM requests").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(requestColumns).AddRow(
+			1, 1, "original_in_review", "", 64000, 800, 600, 4, 3, "test_video.mkv", nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			nil, nil, nil, nil, nil, nil, nil))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE idempotency_keys").
+		WillReturnError(sql.ErrConnDone)
+
+	resp, err := app.Test(videoRequest(t, "key", params))
+	if err != nil {
+		t.Fatalf("Unexpected error when creating a stub request, error: %s\n", err.Error())
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Invalid status code. expected: %d, got: %d\n", http.StatusCreated, resp.StatusCode)
+	}
+
+	// key of created request stays reserved, so retry can't create it again
+	if released := logs.FilterMessage("Release idempotency key").Len(); released != 0 {
+		t.Errorf("Key of created request should not be released, got logs: %v\n", logs.All())
+	}
+
+	if stored := logs.FilterMessage("Store response of idempotent request").Len(); stored != 1 {
+		t.Errorf("Failed storing of response should be logged, got logs: %v\n", logs.All())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
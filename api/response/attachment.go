@@ -0,0 +1,33 @@
+package response
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetAttachment sets Content-Disposition and Content-Type headers of file with
+// name sent as attachment. Mime type is detected by file extension
+func SetAttachment(c *fiber.Ctx, name string) {
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(name))
+	c.Set(fiber.HeaderContentType, contentType(name))
+}
+
+func contentDisposition(name string) string {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name})
+	if disposition == "" {
+		return "attachment"
+	}
+
+	return disposition
+}
+
+func contentType(name string) string {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
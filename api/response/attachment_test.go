@@ -0,0 +1,55 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSetAttachment(t *testing.T) {
+	cases := []struct {
+		name                string
+		file                string
+		expectedDisposition string
+		expectedContentType string
+	}{
+		{
+			name:                "Known extension in upper case",
+			file:                "my poster.PNG",
+			expectedDisposition: `attachment; filename="my poster.PNG"`,
+			expectedContentType: "image/png",
+		},
+		{
+			name:                "Unknown extension",
+			file:                "video.qwe",
+			expectedDisposition: "attachment; filename=video.qwe",
+			expectedContentType: "application/octet-stream",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				SetAttachment(c, testCase.file)
+
+				return c.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if disposition := resp.Header.Get("Content-Disposition"); disposition != testCase.expectedDisposition {
+				t.Errorf("Invalid Content-Disposition, expected: %s, got: %s\n", testCase.expectedDisposition, disposition)
+			}
+
+			if contentType := resp.Header.Get("Content-Type"); contentType != testCase.expectedContentType {
+				t.Errorf("Invalid Content-Type, expected: %s, got: %s\n", testCase.expectedContentType, contentType)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package response
 import (
 	"bytes"
 	"net/http"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/jsonapi"
@@ -26,3 +27,19 @@ func ErrorJsonApiResponse(c *fiber.Ctx, status int, errors []string) error {
 
 	return c.Status(status).Send(errBuf.Bytes())
 }
+
+// NegotiateErrorResponse returns error response in json:api specification if client
+// accepts it, otherwise errors are sent as plain text. It is used by routes
+// which aren't json:api, e.g. streams of files opened by video players
+func NegotiateErrorResponse(c *fiber.Ctx, status int, errors []string) error {
+	accept := c.Get(fiber.HeaderAccept)
+	if accept == "" || strings.Contains(accept, jsonapi.MediaType) {
+		c.Set(fiber.HeaderContentType, jsonapi.MediaType)
+
+		return ErrorJsonApiResponse(c, status, errors)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+
+	return c.Status(status).SendString(strings.Join(errors, "\n") + "\n")
+}
@@ -88,3 +88,66 @@ func TestErrorJsonApiResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestNegotiateErrorResponse(t *testing.T) {
+	cases := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "Without Accept",
+			expectedContentType: "application/vnd.api+json",
+			expectedBody:        `{"errors":[{"title":"Video not found"}]}` + "\n",
+		},
+		{
+			name:                "Json api client",
+			accept:              "application/vnd.api+json",
+			expectedContentType: "application/vnd.api+json",
+			expectedBody:        `{"errors":[{"title":"Video not found"}]}` + "\n",
+		},
+		{
+			name:                "Video player",
+			accept:              "video/webm,video/*;q=0.9,*/*;q=0.5",
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "Video not found\n",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				return NegotiateErrorResponse(c, http.StatusNotFound, []string{"Video not found"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if testCase.accept != "" {
+				req.Header.Set("Accept", testCase.accept)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n", http.StatusNotFound, resp.StatusCode)
+			}
+
+			if contentType := resp.Header.Get("Content-Type"); contentType != testCase.expectedContentType {
+				t.Errorf("Invalid Content-Type, expected: %s, got: %s\n", testCase.expectedContentType, contentType)
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body,\nexpected: %s\ngot: %s\n", testCase.expectedBody, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ValidationFailed is title of every field error
+const ValidationFailed = "Validation failed"
+
+// FieldError is validation error of attribute of request
+type FieldError struct {
+	Attribute string
+	Detail    string
+}
+
+type fieldErrorObject struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+	Source struct {
+		Pointer string `json:"pointer"`
+	} `json:"source"`
+}
+
+// NewValidator returns validator which reports attributes by their jsonapi names
+func NewValidator() *validator.Validate {
+	validation := validator.New()
+	validation.RegisterTagNameFunc(AttributeName)
+
+	return validation
+}
+
+// AttributeName returns jsonapi name of attribute of struct field, e.g.
+// password_confirmation for `jsonapi:"attr,password_confirmation,omitempty"`
+func AttributeName(f reflect.StructField) string {
+	tag := strings.Split(f.Tag.Get("jsonapi"), ",")
+	if len(tag) < 2 || tag[0] != "attr" {
+		return f.Name
+	}
+
+	return tag[1]
+}
+
+// FieldErrors converts error of validator created by NewValidator into field
+// errors, other errors are returned as error of the whole request
+func FieldErrors(err error) []FieldError {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return []FieldError{{Detail: err.Error()}}
+	}
+
+	fields := make([]FieldError, 0, len(invalid))
+
+	for _, fe := range invalid {
+		fields = append(fields, FieldError{Attribute: fe.Field(), Detail: fieldDetail(fe)})
+	}
+
+	return fields
+}
+
+// AttributeErrors returns field error of attribute for every detail
+func AttributeErrors(attribute string, details []string) []FieldError {
+	fields := make([]FieldError, 0, len(details))
+
+	for _, detail := range details {
+		fields = append(fields, FieldError{Attribute: attribute, Detail: detail})
+	}
+
+	return fields
+}
+
+func fieldDetail(fe validator.FieldError) string {
+	name := strings.ReplaceAll(fe.Field(), "_", " ")
+	name = strings.ToUpper(name[:1]) + name[1:]
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", name)
+	case "email":
+		return fmt.Sprintf("%s is not a valid email address", name)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", name, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", name, fe.Param())
+	case "eqfield":
+		return fmt.Sprintf("%s doesn't match %s", name, strings.ToLower(fe.Param()))
+	default:
+		return fmt.Sprintf("%s is invalid", name)
+	}
+}
+
+// ValidationErrorJsonApiResponse returns error for every invalid attribute in
+// json:api specification, attribute is referenced by source pointer
+func ValidationErrorJsonApiResponse(c *fiber.Ctx, fields []FieldError) error {
+	errObjects := make([]*fieldErrorObject, 0, len(fields))
+
+	for _, field := range fields {
+		errObject := &fieldErrorObject{Title: ValidationFailed, Detail: field.Detail}
+		errObject.Source.Pointer = "/data"
+
+		if field.Attribute != "" {
+			errObject.Source.Pointer = "/data/attributes/" + field.Attribute
+		}
+
+		errObjects = append(errObjects, errObject)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"errors": errObjects})
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).SendString(err.Error())
+	}
+
+	return c.Status(http.StatusBadRequest).Send(append(body, '\n'))
+}
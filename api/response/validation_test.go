@@ -0,0 +1,87 @@
+package response
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type validated struct {
+	Email                string `jsonapi:"attr,email" validate:"required,email,max=254"`
+	Password             string `jsonapi:"attr,password,omitempty" validate:"required,min=6"`
+	PasswordConfirmation string `jsonapi:"attr,password_confirmation" validate:"eqfield=Password"`
+	Name                 string `validate:"oneof=a b"`
+}
+
+func TestValidationErrorJsonApiResponse(t *testing.T) {
+	cases := []struct {
+		name         string
+		fields       func() []FieldError
+		expectedBody string
+	}{
+		{
+			name: "With validator errors",
+			fields: func() []FieldError {
+				err := NewValidator().Struct(&validated{Email: "check", Password: "123",
+					PasswordConfirmation: "1234", Name: "c"})
+
+				return FieldErrors(err)
+			},
+			expectedBody: `{"errors":[` +
+				`{"title":"Validation failed","detail":"Email is not a valid email address",` +
+				`"source":{"pointer":"/data/attributes/email"}},` +
+				`{"title":"Validation failed","detail":"Password must be at least 6 characters",` +
+				`"source":{"pointer":"/data/attributes/password"}},` +
+				`{"title":"Validation failed","detail":"Password confirmation doesn't match password",` +
+				`"source":{"pointer":"/data/attributes/password_confirmation"}},` +
+				`{"title":"Validation failed","detail":"Name is invalid","source":{"pointer":"/data/attributes/Name"}}]}` +
+				"\n",
+		},
+		{
+			name: "With attribute errors",
+			fields: func() []FieldError {
+				return AttributeErrors("password", []string{"Password must contain a digit"})
+			},
+			expectedBody: `{"errors":[{"title":"Validation failed","detail":"Password must contain a digit",` +
+				`"source":{"pointer":"/data/attributes/password"}}]}` + "\n",
+		},
+		{
+			name: "With error of request",
+			fields: func() []FieldError {
+				return FieldErrors(NewValidator().Struct(nil))
+			},
+			expectedBody: `{"errors":[{"title":"Validation failed","detail":"validator: (nil)",` +
+				`"source":{"pointer":"/data"}}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				return ValidationErrorJsonApiResponse(c, testCase.fields())
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/", nil))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n", http.StatusBadRequest, resp.StatusCode)
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body,\nexpected: %s\ngot: %s\n", testCase.expectedBody, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package share
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	sharerepo "github.com/Hargeon/videocmprs/pkg/repository/share"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	auditsrv "github.com/Hargeon/videocmprs/pkg/service/audit"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+	"github.com/Hargeon/videocmprs/pkg/service/share"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/jsonapi"
+	"go.uber.org/zap"
+)
+
+const (
+	IDBase    = 10
+	IDBitSize = 64
+)
+
+type Handler struct {
+	srv    service.ShareLink
+	logger *zap.Logger
+}
+
+func NewHandler(db *sql.DB, cs service.CloudStorage, logger *zap.Logger) *Handler {
+	hasher := encryption.NewPasswordHasher(encryption.DefaultArgon2Params)
+	recorder := auditsrv.NewService(audit.NewRepository(db), logger)
+	srv := share.NewService(sharerepo.NewRepository(db), video.NewRepository(db), cs, hasher, recorder)
+
+	return &Handler{srv: srv, logger: logger}
+}
+
+// InitRoutes returns routes for managing share links of user
+func (h *Handler) InitRoutes() *fiber.App {
+	router := fiber.New()
+	router.Post("/", h.create)
+	router.Get("/", h.list)
+	router.Delete("/:id", h.delete)
+
+	return router
+}
+
+// PublicRoutes returns routes which resolve share links without authentication
+func (h *Handler) PublicRoutes() *fiber.App {
+	router := fiber.New()
+	router.Get("/:token", h.resolve)
+	router.Post("/:token", h.resolve)
+
+	return router
+}
+
+func (h *Handler) create(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res := new(sharerepo.Resource)
+
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(c.Body()), res); err != nil {
+		h.logger.Error("Can't unmarshal request for creating share link", zap.Error(err),
+			zap.Int64("User ID", uID))
+
+		errors := []string{"Request is not in jsonapi format"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	validation := validator.New()
+
+	if err := validation.Struct(res); err != nil {
+		h.logger.Error("Validation Failed", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Validation failed"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	res.UserID = uID
+	link, err := h.srv.Create(c.Context(), res)
+
+	switch {
+	case errors.Is(err, share.ErrVideoNotPresent):
+		errors := []string{"Video not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	case errors.Is(err, share.ErrVideoUnavailable):
+		errors := []string{"Video is not available"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	case errors.Is(err, share.ErrExpiresInPast):
+		errors := []string{"Expiration time is in the past"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	case err != nil:
+		h.logger.Error("Create share link", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not create share link"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusCreated), link)
+}
+
+func (h *Handler) list(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	pageNumI, err := strconv.Atoi(c.Query("page[number]", "0"))
+	if err != nil {
+		errors := []string{"Invalid page number params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	if pageNumI == 1 {
+		pageNumI = 0
+	}
+
+	pageSizeI, err := strconv.Atoi(c.Query("page[size]", "10"))
+	if err != nil {
+		errors := []string{"Invalid page size params"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	q := &query.Params{
+		RelationID: uID,
+		PageNumber: uint64(pageNumI),
+		PageSize:   uint64(pageSizeI),
+	}
+
+	if v := c.Query("filter[video_id]"); v != "" {
+		q.VideoID, err = strconv.ParseInt(v, IDBase, IDBitSize)
+		if err != nil || q.VideoID <= 0 {
+			errors := []string{"Invalid video ID filter"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		}
+	}
+
+	res, err := h.srv.List(c.Context(), q)
+
+	if err != nil {
+		h.logger.Error("List share links", zap.Error(err), zap.Int64("User ID", uID))
+
+		errors := []string{"Can not fetch share links"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return jsonapi.MarshalPayload(c.Status(http.StatusOK), res)
+}
+
+// delete revokes share link
+func (h *Handler) delete(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), IDBase, IDBitSize)
+
+	if err != nil || id <= 0 {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	err = h.srv.Delete(c.Context(), uID, id)
+
+	if errors.Is(err, share.ErrShareLinkNotPresent) {
+		errors := []string{"Share link not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	}
+
+	if err != nil {
+		h.logger.Error("Revoke share link", zap.Error(err), zap.Int64("Share link ID", id))
+
+		errors := []string{"Can not revoke share link"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// resolve redirects to url for downloading shared video from cloud
+func (h *Handler) resolve(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+
+	// password is accepted only from form body, so it doesn't get to logs of proxies
+	var password string
+	if c.Method() == http.MethodPost {
+		password = c.FormValue("password")
+	}
+
+	url, err := h.srv.Resolve(c.Context(), c.Params("token"), password)
+
+	switch {
+	case errors.Is(err, share.ErrInvalidShareLink):
+		errors := []string{"Share link not found"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+	case errors.Is(err, share.ErrPasswordRequired):
+		errors := []string{"Password required"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+	case errors.Is(err, share.ErrInvalidPassword):
+		errors := []string{"Invalid password"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusUnauthorized, errors)
+	case errors.Is(err, share.ErrVideoUnavailable):
+		errors := []string{"Video is not available"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusGone, errors)
+	case err != nil:
+		h.logger.Error("Resolve share link", zap.Error(err))
+
+		errors := []string{"Can not resolve share link"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	status := http.StatusFound
+	if c.Method() == http.MethodPost {
+		status = http.StatusSeeOther
+	}
+
+	return c.Redirect(url, status)
+}
@@ -0,0 +1,348 @@
+package share
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/middleware"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	sharerepo "github.com/Hargeon/videocmprs/pkg/repository/share"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+var linkColumns = []string{"id", "user_id", "video_id", "password_hash", "max_downloads", "downloads",
+	"expires_at", "created_at", "service_id", "expires_at"}
+
+type cloudMock struct{}
+
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
+	return "", nil
+}
+
+func (c *cloudMock) URL(filename string) (string, error) {
+	return "https://cloud.com/" + filename, nil
+}
+
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	return &service.CloudObject{Name: filename}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func TestHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, &cloudMock{}, logger)
+
+	app := fiber.New()
+	app.Use(middleware.ClientInfo)
+	app.Mount("/share", h.PublicRoutes())
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Mount("/share-links", h.InitRoutes())
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	sum := sha256.Sum256([]byte("token"))
+	tokenHash := hex.EncodeToString(sum[:])
+
+	passwordHash, err := encryption.NewPasswordHasher(encryption.Argon2Params{
+		Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}).Hash("secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	cases := []struct {
+		name             string
+		mock             func()
+		requestMock      func() *http.Request
+		expectedBody     string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name: "Create link",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(3, 1, 1, "owner", "admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", video.TableName)).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x",
+						"resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(3, "video.mp4", 100, 0, 0, 0, 0, 0, "video.mp4", nil))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", sharerepo.TableName)).
+					WithArgs(1, 3, sqlmock.AnyArg(), nil, 5, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, createdAt))
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"share-links","attributes":{"video_id":3,"max_downloads":5}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/share-links", strings.NewReader(body))
+			},
+			expectedBody:   `"token":"`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Create link without video",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"share-links","attributes":{"max_downloads":5}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/share-links", strings.NewReader(body))
+			},
+			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Create link for video of other user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(3, 1, 1, "owner", "admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			requestMock: func() *http.Request {
+				body := `{"data":{"type":"share-links","attributes":{"video_id":3}}}`
+
+				return httptest.NewRequest(http.MethodPost, "/share-links", strings.NewReader(body))
+			},
+			expectedBody:   `{"errors":[{"title":"Video not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "List links of video",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(1, 3, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(linkColumns[:8]).
+						AddRow(2, 1, 3, "hash", 5, 1, nil, createdAt))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/share-links?filter[video_id]=3", nil)
+			},
+			expectedBody:   `"password_protected":true`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "List links with invalid filter",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/share-links?filter[video_id]=abc", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid video ID filter"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Revoke link",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", sharerepo.TableName)).
+					WithArgs(sqlmock.AnyArg(), 2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/share-links/2", nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Revoke unknown link",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", sharerepo.TableName)).
+					WithArgs(sqlmock.AnyArg(), 2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/share-links/2", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Share link not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Resolve link",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows(linkColumns).
+						AddRow(2, 1, 3, nil, nil, 0, nil, createdAt, "video.mp4", nil))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads", sharerepo.TableName)).
+					WithArgs(2, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(nil, audit.ActionShareLinkDownload, sharerepo.TableName, 2, "0.0.0.0", "", `{"video_id":3}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/share/token", nil)
+			},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://cloud.com/video.mp4",
+		},
+		{
+			name: "Resolve protected link with form password",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows(linkColumns).
+						AddRow(2, 1, 3, passwordHash, nil, 0, nil, createdAt, "video.mp4", nil))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads", sharerepo.TableName)).
+					WithArgs(2, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(nil, audit.ActionShareLinkDownload, sharerepo.TableName, 2, "0.0.0.0", "", `{"video_id":3}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			requestMock: func() *http.Request {
+				body := url.Values{"password": {"secret"}}.Encode()
+				req := httptest.NewRequest(http.MethodPost, "/share/token", strings.NewReader(body))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+
+				return req
+			},
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: "https://cloud.com/video.mp4",
+		},
+		{
+			name: "Resolve protected link without password",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows(linkColumns).
+						AddRow(2, 1, 3, passwordHash, nil, 0, nil, createdAt, "video.mp4", nil))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/share/token", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Password required"}]}` + "\n",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Resolve protected link with invalid password",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows(linkColumns).
+						AddRow(2, 1, 3, passwordHash, nil, 0, nil, createdAt, "video.mp4", nil))
+			},
+			requestMock: func() *http.Request {
+				body := url.Values{"password": {"other"}}.Encode()
+				req := httptest.NewRequest(http.MethodPost, "/share/token", strings.NewReader(body))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+
+				return req
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid password"}]}` + "\n",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Resolve protected link with password header",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows(linkColumns).
+						AddRow(2, 1, 3, passwordHash, nil, 0, nil, createdAt, "video.mp4", nil))
+			},
+			requestMock: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/share/token", nil)
+				req.Header.Set("X-Share-Password", "secret")
+
+				return req
+			},
+			expectedBody:   `{"errors":[{"title":"Password required"}]}` + "\n",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Resolve unknown link",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows(linkColumns))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/share/token", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Share link not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Resolve link of expired video",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", sharerepo.TableName)).
+					WithArgs(tokenHash).
+					WillReturnRows(sqlmock.NewRows(linkColumns).
+						AddRow(2, 1, 3, nil, nil, 0, nil, createdAt, "video.mp4", createdAt))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/share/token", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Video is not available"}]}` + "\n",
+			expectedStatus: http.StatusGone,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			resp, err := app.Test(testCase.requestMock(), -1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			defer resp.Body.Close()
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n", testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if !strings.Contains(string(body), testCase.expectedBody) {
+				t.Errorf("Invalid body, expected to contain: %s, got: %s\n", testCase.expectedBody, string(body))
+			}
+
+			if location := resp.Header.Get(fiber.HeaderLocation); location != testCase.expectedLocation {
+				t.Errorf("Invalid location, expected: %s, got: %s\n", testCase.expectedLocation, location)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
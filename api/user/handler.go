@@ -8,27 +8,35 @@ import (
 	"net/http"
 
 	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
 	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/auth"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+	"github.com/Hargeon/videocmprs/pkg/service/mail"
+	"github.com/Hargeon/videocmprs/pkg/service/password"
 	usersrv "github.com/Hargeon/videocmprs/pkg/service/user"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/jsonapi"
 	"go.uber.org/zap"
 )
 
 type Handler struct {
-	srv    service.Creator
-	logger *zap.Logger
+	srv     service.Creator
+	account service.Account
+	policy  service.PasswordPolicy
+	logger  *zap.Logger
 }
 
 // NewHandler initialize Handler
 func NewHandler(db *sql.DB, logger *zap.Logger) *Handler {
 	repo := user.NewRepository(db)
-	srv := usersrv.NewService(repo)
+	hasher := encryption.NewPasswordHasher(encryption.DefaultArgon2Params)
+	srv := usersrv.NewService(repo, hasher)
+	account := auth.NewEnvService(repo, token.NewRepository(db), hasher)
 
-	return &Handler{srv: srv, logger: logger}
+	return &Handler{srv: srv, account: account, policy: password.NewEnvService(logger), logger: logger}
 }
 
 // InitRoutes for users
@@ -51,14 +59,17 @@ func (h *Handler) create(c *fiber.Ctx) error {
 		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
 	}
 
-	validation := validator.New()
+	validation := response.NewValidator()
 	err := validation.Struct(usr)
 
 	if err != nil {
 		h.logger.Error("Validation for creating user failed", zap.Error(err))
-		errors := []string{"Validation failed"}
 
-		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+		return response.ValidationErrorJsonApiResponse(c, response.FieldErrors(err))
+	}
+
+	if violations := h.policy.Check(usr.Email, usr.Password); len(violations) > 0 {
+		return response.ValidationErrorJsonApiResponse(c, response.AttributeErrors("password", violations))
 	}
 
 	res, err := h.srv.Create(c.Context(), usr)
@@ -75,6 +86,12 @@ func (h *Handler) create(c *fiber.Ctx) error {
 		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
 	}
 
+	// failed email doesn't prevent registration, user can request it again
+	err = h.account.SendVerification(c.Context(), usr.Email)
+	if err != nil && !errors.Is(err, mail.ErrNotConfigured) {
+		h.logger.Error("Can't send verification email", zap.Error(err))
+	}
+
 	err = jsonapi.MarshalPayload(c.Status(http.StatusCreated), res)
 
 	if err != nil {
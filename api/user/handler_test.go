@@ -2,15 +2,16 @@ package user
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
-	"github.com/Hargeon/videocmprs/pkg/service/encryption"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v2"
@@ -18,6 +19,15 @@ import (
 	"go.uber.org/zap"
 )
 
+// argon2Hash matches any argon2id password hash
+type argon2Hash struct{}
+
+func (a argon2Hash) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+
+	return ok && strings.HasPrefix(hash, "$argon2id$")
+}
+
 func TestCreate(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -82,7 +92,7 @@ func TestCreate(t *testing.T) {
 				return reqBuf.Bytes()
 			},
 			mock:           func() {},
-			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedBody:   `{"errors":[{"title":"Validation failed","detail":"Email is not a valid email address","source":{"pointer":"/data/attributes/email"}}]}` + "\n",
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
@@ -103,15 +113,57 @@ func TestCreate(t *testing.T) {
 				return reqBuf.Bytes()
 			},
 			mock:           func() {},
-			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedBody:   `{"errors":[{"title":"Validation failed","detail":"Password must be at least 8 characters","source":{"pointer":"/data/attributes/password"}}]}` + "\n",
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name: "With invalid password confirmation",
 			user: &user.Resource{
 				Email:                "check@check.com",
-				Password:             "123456789",
-				PasswordConfirmation: "123456789f",
+				Password:             "video-Compressor",
+				PasswordConfirmation: "video-Compressor2",
+			},
+			marshalUser: func(user interface{}) []byte {
+				var req []byte
+				reqBuf := bytes.NewBuffer(req)
+				err := jsonapi.MarshalPayload(reqBuf, user)
+				if err != nil {
+					t.Fatalf("Error occured when marshaling user, error: %s\n", err.Error())
+				}
+
+				return reqBuf.Bytes()
+			},
+			mock:           func() {},
+			expectedBody:   `{"errors":[{"title":"Validation failed","detail":"Password confirmation doesn't match password","source":{"pointer":"/data/attributes/password_confirmation"}}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "With breached password",
+			user: &user.Resource{
+				Email:                "check@check.com",
+				Password:             "password123",
+				PasswordConfirmation: "password123",
+			},
+			marshalUser: func(user interface{}) []byte {
+				var req []byte
+				reqBuf := bytes.NewBuffer(req)
+				err := jsonapi.MarshalPayload(reqBuf, user)
+				if err != nil {
+					t.Fatalf("Error occured when marshaling user, error: %s\n", err.Error())
+				}
+
+				return reqBuf.Bytes()
+			},
+			mock:           func() {},
+			expectedBody:   `{"errors":[{"title":"Validation failed","detail":"Password has appeared in a data breach, choose another one","source":{"pointer":"/data/attributes/password"}}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "With password containing email",
+			user: &user.Resource{
+				Email:                "check@check.com",
+				Password:             "my-Check-1234",
+				PasswordConfirmation: "my-Check-1234",
 			},
 			marshalUser: func(user interface{}) []byte {
 				var req []byte
@@ -124,15 +176,15 @@ func TestCreate(t *testing.T) {
 				return reqBuf.Bytes()
 			},
 			mock:           func() {},
-			expectedBody:   `{"errors":[{"title":"Validation failed"}]}` + "\n",
+			expectedBody:   `{"errors":[{"title":"Validation failed","detail":"Password must not contain email","source":{"pointer":"/data/attributes/password"}}]}` + "\n",
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name: "With valid params",
 			user: &user.Resource{
 				Email:                "check@check.com",
-				Password:             "123456789",
-				PasswordConfirmation: "123456789",
+				Password:             "video-Compressor",
+				PasswordConfirmation: "video-Compressor",
 			},
 			marshalUser: func(user interface{}) []byte {
 				var req []byte
@@ -149,24 +201,23 @@ func TestCreate(t *testing.T) {
 					WithArgs("check@check.com").
 					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
 
-				passHash := encryption.GenerateHash([]byte("123456789"))
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", user.TableName)).
-					WithArgs("check@check.com", fmt.Sprintf("%x", passHash)).
+					WithArgs("check@check.com", argon2Hash{}).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s", user.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("1", "check@check.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow("1", "check@check.com", "user"))
 			},
-			expectedBody:   `{"data":{"type":"users","id":"1","attributes":{"email":"check@check.com"},"links":{"self":"/api/v1/auth/me"}}}` + "\n",
+			expectedBody:   `{"data":{"type":"users","id":"1","attributes":{"email":"check@check.com","role":"user"},"links":{"self":"/api/v1/auth/me"}}}` + "\n",
 			expectedStatus: http.StatusCreated,
 		},
 		{
 			name: "User is already exists",
 			user: &user.Resource{
 				Email:                "check@check.com",
-				Password:             "123456789",
-				PasswordConfirmation: "123456789",
+				Password:             "video-Compressor",
+				PasswordConfirmation: "video-Compressor",
 			},
 			marshalUser: func(user interface{}) []byte {
 				var req []byte
@@ -190,8 +241,8 @@ func TestCreate(t *testing.T) {
 			name: "With failed db connection",
 			user: &user.Resource{
 				Email:                "check@check.com",
-				Password:             "123456789",
-				PasswordConfirmation: "123456789",
+				Password:             "video-Compressor",
+				PasswordConfirmation: "video-Compressor",
 			},
 			marshalUser: func(user interface{}) []byte {
 				var req []byte
@@ -208,9 +259,8 @@ func TestCreate(t *testing.T) {
 					WithArgs("check@check.com").
 					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
 
-				passHash := encryption.GenerateHash([]byte("123456789"))
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", user.TableName)).
-					WithArgs("check@check.com", fmt.Sprintf("%x", passHash)).
+					WithArgs("check@check.com", argon2Hash{}).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 			},
 			expectedBody:   `{"errors":[{"title":"Something went wrong"}]}` + "\n",
@@ -0,0 +1,117 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/service"
+)
+
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// byteRange represent part of file requested with Range header
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+// parseRange parses Range header for file with size. Returns nil if whole file
+// should be sent. Multiple ranges are not supported and whole file is sent
+func parseRange(header string, size int64) (*byteRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, errRangeNotSatisfiable
+	}
+
+	spec := strings.TrimSpace(strings.TrimPrefix(header, prefix))
+	if strings.Contains(spec, ",") {
+		return nil, nil
+	}
+
+	dash := strings.Index(spec, "-")
+	if dash < 0 {
+		return nil, errRangeNotSatisfiable
+	}
+
+	startStr, endStr := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+
+	// suffix range, e.g. bytes=-500 means last 500 bytes
+	if startStr == "" {
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+
+		if n > size {
+			n = size
+		}
+
+		return &byteRange{start: size - n, length: n}, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+
+	end := size - 1
+
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return nil, errRangeNotSatisfiable
+		}
+
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return &byteRange{start: start, length: end - start + 1}, nil
+}
+
+// rangeApplies checks If-Range header. Range is ignored if file was changed
+func rangeApplies(ifRange string, obj *service.CloudObject) bool {
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) {
+		return obj.ETag != "" && ifRange == obj.ETag
+	}
+
+	t, err := http.ParseTime(ifRange)
+	if err != nil {
+		return false
+	}
+
+	return obj.LastModified.Truncate(time.Second).Equal(t)
+}
+
+// notModified checks If-None-Match header
+func notModified(ifNoneMatch string, obj *service.CloudObject) bool {
+	if ifNoneMatch == "" || obj.ETag == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == obj.ETag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contentRange(r *byteRange, size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
@@ -0,0 +1,45 @@
+package video
+
+import (
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		name         string
+		header       string
+		size         int64
+		expected     *byteRange
+		errorPresent bool
+	}{
+		{name: "Without range", header: "", size: 10},
+		{name: "Full range", header: "bytes=0-9", size: 10, expected: &byteRange{start: 0, length: 10}},
+		{name: "Open range", header: "bytes=4-", size: 10, expected: &byteRange{start: 4, length: 6}},
+		{name: "End after size", header: "bytes=4-100", size: 10, expected: &byteRange{start: 4, length: 6}},
+		{name: "Suffix range", header: "bytes=-4", size: 10, expected: &byteRange{start: 6, length: 4}},
+		{name: "Suffix bigger than size", header: "bytes=-40", size: 10, expected: &byteRange{start: 0, length: 10}},
+		{name: "Multiple ranges", header: "bytes=0-1,4-5", size: 10},
+		{name: "Start after size", header: "bytes=10-", size: 10, errorPresent: true},
+		{name: "End before start", header: "bytes=5-4", size: 10, errorPresent: true},
+		{name: "Invalid unit", header: "items=0-4", size: 10, errorPresent: true},
+		{name: "Invalid number", header: "bytes=a-4", size: 10, errorPresent: true},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r, err := parseRange(testCase.header, testCase.size)
+
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if (r == nil) != (testCase.expected == nil) || (r != nil && *r != *testCase.expected) {
+				t.Errorf("Invalid range, expected: %v, got: %v\n", testCase.expected, r)
+			}
+		})
+	}
+}
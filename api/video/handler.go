@@ -2,12 +2,17 @@ package video
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/Hargeon/videocmprs/api/response"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
 	"github.com/Hargeon/videocmprs/pkg/service"
+	auditsrv "github.com/Hargeon/videocmprs/pkg/service/audit"
 	videosrv "github.com/Hargeon/videocmprs/pkg/service/video"
 
 	"github.com/gofiber/fiber/v2"
@@ -31,7 +36,7 @@ type downloadURL struct {
 
 func NewHandler(db *sql.DB, cs service.CloudStorage, logger *zap.Logger) *Handler {
 	repo := video.NewRepository(db)
-	vSrv := videosrv.NewService(repo, cs)
+	vSrv := videosrv.NewService(repo, cs, auditsrv.NewService(audit.NewRepository(db), logger))
 
 	return &Handler{srv: vSrv, logger: logger}
 }
@@ -39,11 +44,22 @@ func NewHandler(db *sql.DB, cs service.CloudStorage, logger *zap.Logger) *Handle
 func (h *Handler) InitRoutes() *fiber.App {
 	router := fiber.New()
 	router.Get("/:id", h.retrieve)
+	router.Delete("/:id", h.delete)
 	router.Get("/download_url/:id", h.downloadURL)
 
 	return router
 }
 
+// ContentRoutes returns route for streaming video files. Players don't send
+// json:api Accept header, so it is mounted apart from InitRoutes with its own
+// authentication handlers
+func (h *Handler) ContentRoutes(handlers ...fiber.Handler) *fiber.App {
+	router := fiber.New()
+	router.Get("/:id/content", append(handlers, h.content)...)
+
+	return router
+}
+
 // retrieve video by userID and videoID
 func (h *Handler) retrieve(c *fiber.Ctx) error {
 	uID, ok := c.Locals("user_id").(int64)
@@ -87,6 +103,47 @@ func (h *Handler) retrieve(c *fiber.Ctx) error {
 	return nil
 }
 
+// delete video by userID and videoID
+func (h *Handler) delete(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	idStr := c.Params("id")
+	id, err := strconv.ParseInt(idStr, IDBase, IDBitSize)
+
+	if err != nil || id <= 0 {
+		errors := []string{"Invalid ID"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
+	}
+
+	err = h.srv.Delete(c.Context(), uID, id)
+
+	if err != nil {
+		if errors.Is(err, videosrv.ErrVideoNotPresent) {
+			errors := []string{"Video not found"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusNotFound, errors)
+		}
+
+		h.logger.Error("Delete video", zap.String("Error", err.Error()),
+			zap.Int64("Video ID", id))
+
+		errors := []string{"Can not delete video"}
+
+		return response.ErrorJsonApiResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
 // downloadURL returns url for downloading video from cloud
 func (h *Handler) downloadURL(c *fiber.Ctx) error {
 	uID, ok := c.Locals("user_id").(int64)
@@ -110,6 +167,12 @@ func (h *Handler) downloadURL(c *fiber.Ctx) error {
 
 	url, err := h.srv.DownloadURL(c.Context(), uID, id)
 	if err != nil {
+		if errors.Is(err, videosrv.ErrVideoExpired) {
+			errors := []string{"Video is expired"}
+
+			return response.ErrorJsonApiResponse(c, http.StatusGone, errors)
+		}
+
 		errors := []string{"Something went wong"}
 
 		return response.ErrorJsonApiResponse(c, http.StatusBadRequest, errors)
@@ -117,3 +180,104 @@ func (h *Handler) downloadURL(c *fiber.Ctx) error {
 
 	return jsonapi.MarshalPayload(c.Status(http.StatusOK), &downloadURL{URL: url})
 }
+
+// content streams video file from cloud. Supports Range and If-Range headers.
+// It is opened by video players, so errors are json:api only if client accepts it
+func (h *Handler) content(c *fiber.Ctx) error {
+	uID, ok := c.Locals("user_id").(int64)
+
+	if !ok {
+		h.logger.Error("Invalid type assertion for User ID")
+
+		errors := []string{"Invalid user ID"}
+
+		return response.NegotiateErrorResponse(c, http.StatusBadRequest, errors)
+	}
+
+	idStr := c.Params("id")
+	id, err := strconv.ParseInt(idStr, IDBase, IDBitSize)
+
+	if err != nil || id <= 0 {
+		errors := []string{"Invalid ID"}
+
+		return response.NegotiateErrorResponse(c, http.StatusBadRequest, errors)
+	}
+
+	vid, obj, err := h.srv.Content(c.Context(), uID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, videosrv.ErrVideoNotPresent), errors.Is(err, videosrv.ErrVideoNotInCloud):
+			errors := []string{"Video not found"}
+
+			return response.NegotiateErrorResponse(c, http.StatusNotFound, errors)
+		case errors.Is(err, videosrv.ErrVideoExpired):
+			errors := []string{"Video is expired"}
+
+			return response.NegotiateErrorResponse(c, http.StatusGone, errors)
+		}
+
+		h.logger.Error("Get video content", zap.Error(err), zap.Int64("Video ID", id))
+
+		errors := []string{"Can not fetch video"}
+
+		return response.NegotiateErrorResponse(c, http.StatusInternalServerError, errors)
+	}
+
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderLastModified, obj.LastModified.UTC().Format(http.TimeFormat))
+
+	if obj.ETag != "" {
+		c.Set(fiber.HeaderETag, obj.ETag)
+	}
+
+	if notModified(c.Get(fiber.HeaderIfNoneMatch), obj) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	rng := &byteRange{start: 0, length: obj.Size}
+	status := http.StatusOK
+
+	if rangeApplies(c.Get(fiber.HeaderIfRange), obj) {
+		r, err := parseRange(c.Get(fiber.HeaderRange), obj.Size)
+		if err != nil {
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", obj.Size))
+
+			errors := []string{"Range not satisfiable"}
+
+			return response.NegotiateErrorResponse(c, http.StatusRequestedRangeNotSatisfiable, errors)
+		}
+
+		if r != nil {
+			rng = r
+			status = http.StatusPartialContent
+
+			c.Set(fiber.HeaderContentRange, contentRange(r, obj.Size))
+		}
+	}
+
+	var body io.ReadCloser
+
+	if rng.length > 0 {
+		if body, err = h.srv.OpenContent(c.Context(), vid, rng.start, rng.length); errors.Is(err, videosrv.ErrVideoNotInCloud) {
+			errors := []string{"Video not found"}
+
+			return response.NegotiateErrorResponse(c, http.StatusNotFound, errors)
+		}
+
+		if err != nil {
+			h.logger.Error("Open video content", zap.Error(err), zap.Int64("Video ID", id))
+
+			errors := []string{"Can not fetch video"}
+
+			return response.NegotiateErrorResponse(c, http.StatusInternalServerError, errors)
+		}
+	}
+
+	response.SetAttachment(c, vid.Name)
+
+	if body == nil {
+		return c.SendStatus(status)
+	}
+
+	return c.Status(status).SendStream(body, int(rng.length))
+}
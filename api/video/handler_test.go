@@ -2,15 +2,20 @@ package video
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofiber/fiber/v2"
@@ -19,7 +24,7 @@ import (
 
 type cloudMock struct{}
 
-func (c *cloudMock) Upload(ctx context.Context, header *multipart.FileHeader) (string, error) {
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
 	if header.Filename == "failed" {
 		return "", errors.New("failed connection")
 	}
@@ -35,6 +40,37 @@ func (c *cloudMock) URL(filename string) (string, error) {
 	return filename, nil
 }
 
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	if filename == "error" {
+		return errors.New("mock error")
+	}
+
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+const cloudContent = "0123456789"
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	if filename == "error" {
+		return nil, errors.New("mock error")
+	}
+
+	return &service.CloudObject{
+		Name:         filename,
+		Size:         int64(len(cloudContent)),
+		LastModified: time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC),
+		ETag:         `"mock_etag"`,
+	}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(cloudContent[offset : offset+length])), nil
+}
+
 func TestRetrieve(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -75,7 +111,7 @@ func TestRetrieve(t *testing.T) {
 			name: "Invalid db connection",
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}))
 			},
 			requestMock: func() *http.Request {
@@ -90,13 +126,13 @@ func TestRetrieve(t *testing.T) {
 			name: "Valid db connection",
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "mock_service_id", nil))
 			},
 			requestMock: func() *http.Request {
 				req := httptest.NewRequest(http.MethodGet, "/videos/1", nil)
@@ -141,3 +177,285 @@ func TestRetrieve(t *testing.T) {
 		})
 	}
 }
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, &cloudMock{}, logger)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Mount("/videos", h.InitRoutes())
+
+	cases := []struct {
+		name           string
+		mock           func()
+		requestMock    func() *http.Request
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Invalid id",
+			mock: func() {},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/videos/name", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Invalid ID"}]}` + "\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Video of other member of organization",
+			mock: func() {
+				// plain member can read the video, but only owner and admins of organization delete it
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+) role IN", video.TableName)).
+					WithArgs(2, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/videos/2", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Video not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Video not found",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/videos/1", nil)
+			},
+			expectedBody:   `{"errors":[{"title":"Video not found"}]}` + "\n",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Should delete video",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			requestMock: func() *http.Request {
+				return httptest.NewRequest(http.MethodDelete, "/videos/1", nil)
+			},
+			expectedBody:   "",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			req := testCase.requestMock()
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n",
+					err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n",
+					testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a body, error: %s\n",
+					err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body\nexpected: %v\ngot: %v\n",
+					testCase.expectedBody, string(body))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestContent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	h := NewHandler(db, &cloudMock{}, logger)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(1))
+
+		return c.Next()
+	})
+	app.Mount("/videos", h.ContentRoutes())
+
+	videoMock := func() {
+		mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+			WithArgs(1, 1, 1).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+		mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", video.TableName)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+				AddRow(1, "my name.mkv", 10, 789569, 700, 600, 4, 3, "mock_service_id", nil))
+	}
+
+	// download is recorded when file is read from the beginning
+	downloadMock := func() {
+		videoMock()
+
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(sql.NullInt64{Int64: 1, Valid: true}, audit.ActionVideoDownload, video.TableName,
+				sql.NullInt64{Int64: 1, Valid: true}, "", "", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	cases := []struct {
+		name                 string
+		mock                 func()
+		headers              map[string]string
+		expectedStatus       int
+		expectedBody         string
+		expectedContentRange string
+	}{
+		{
+			name: "Video not found",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1).
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"errors":[{"title":"Video not found"}]}` + "\n",
+		},
+		{
+			name: "Video not found for player",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1).
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			headers:        map[string]string{"Accept": "video/webm,video/*;q=0.9,*/*;q=0.5"},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Video not found\n",
+		},
+		{
+			name:           "Whole file",
+			mock:           downloadMock,
+			expectedStatus: http.StatusOK,
+			expectedBody:   cloudContent,
+		},
+		{
+			name:                 "Range",
+			mock:                 videoMock,
+			headers:              map[string]string{"Range": "bytes=2-5"},
+			expectedStatus:       http.StatusPartialContent,
+			expectedBody:         "2345",
+			expectedContentRange: "bytes 2-5/10",
+		},
+		{
+			name:                 "Range with matching If-Range",
+			mock:                 videoMock,
+			headers:              map[string]string{"Range": "bytes=-3", "If-Range": `"mock_etag"`},
+			expectedStatus:       http.StatusPartialContent,
+			expectedBody:         "789",
+			expectedContentRange: "bytes 7-9/10",
+		},
+		{
+			name:           "Range with outdated If-Range",
+			mock:           downloadMock,
+			headers:        map[string]string{"Range": "bytes=2-5", "If-Range": `"old_etag"`},
+			expectedStatus: http.StatusOK,
+			expectedBody:   cloudContent,
+		},
+		{
+			name:                 "Range not satisfiable",
+			mock:                 videoMock,
+			headers:              map[string]string{"Range": "bytes=20-"},
+			expectedStatus:       http.StatusRequestedRangeNotSatisfiable,
+			expectedBody:         `{"errors":[{"title":"Range not satisfiable"}]}` + "\n",
+			expectedContentRange: "bytes */10",
+		},
+		{
+			name:           "Not modified",
+			mock:           videoMock,
+			headers:        map[string]string{"If-None-Match": `"mock_etag"`},
+			expectedStatus: http.StatusNotModified,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			req := httptest.NewRequest(http.MethodGet, "/videos/1/content", nil)
+
+			for key, value := range testCase.headers {
+				req.Header.Set(key, value)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Unexpected error when creating a stub request, error: %s\n",
+					err.Error())
+			}
+
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Invalid status code, expected: %d, got: %d\n",
+					testCase.expectedStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error when reading a body, error: %s\n",
+					err.Error())
+			}
+
+			if string(body) != testCase.expectedBody {
+				t.Errorf("Invalid body\nexpected: %v\ngot: %v\n",
+					testCase.expectedBody, string(body))
+			}
+
+			if contentRange := resp.Header.Get("Content-Range"); contentRange != testCase.expectedContentRange {
+				t.Errorf("Invalid Content-Range, expected: %s, got: %s\n",
+					testCase.expectedContentRange, contentRange)
+			}
+
+			if resp.StatusCode == http.StatusOK {
+				if disposition := resp.Header.Get("Content-Disposition"); disposition != `attachment; filename="my name.mkv"` {
+					t.Errorf("Invalid Content-Disposition, got: %s\n", disposition)
+				}
+
+				if etag := resp.Header.Get("ETag"); etag != `"mock_etag"` {
+					t.Errorf("Invalid ETag, got: %s\n", etag)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Command reconcile reports files in cloud which are not referenced by videos
+// and videos which files are missing in cloud
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/datakey"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service/cloud"
+	"github.com/Hargeon/videocmprs/pkg/service/reconcile"
+
+	_ "github.com/jackc/pgx/stdlib"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	fix := flag.Bool("delete", false, "delete orphaned files from cloud and mark videos without files as deleted")
+	minAge := flag.Duration("min-age", 24*time.Hour, "skip files and videos younger than this")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer logger.Sync()
+
+	if err = godotenv.Load(); err != nil {
+		logger.Warn("godotenv.Load()", zap.String("Error", err.Error()))
+	}
+
+	db, err := sql.Open("pgx", os.Getenv("DB_URL"))
+	if err != nil {
+		logger.Fatal("", zap.String("Error", err.Error()))
+	}
+
+	defer db.Close()
+
+	storage, err := cloud.NewEnvStorage(datakey.NewRepository(db))
+	if err != nil {
+		logger.Fatal("Invalid storage configuration", zap.String("Error", err.Error()))
+	}
+
+	srv := reconcile.NewService(video.NewRepository(db), storage, logger)
+
+	report, err := srv.Reconcile(context.Background(), *minAge, *fix)
+	if err != nil {
+		logger.Fatal("reconcile", zap.String("Error", err.Error()))
+	}
+
+	for _, obj := range report.OrphanedObjects {
+		fmt.Printf("orphaned file: %s size: %d modified: %s\n",
+			obj.Name, obj.Size, obj.LastModified.Format(time.RFC3339))
+	}
+
+	for _, v := range report.MissingObjects {
+		fmt.Printf("missing file: video %d user %d service_id: %s\n", v.ID, v.UserID, v.ServiceID)
+	}
+
+	fmt.Printf("orphaned files: %d, videos without files: %d, deleted: %t\n",
+		len(report.OrphanedObjects), len(report.MissingObjects), *fix)
+}
@@ -7,13 +7,23 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Hargeon/videocmprs/api"
+	"github.com/Hargeon/videocmprs/pkg/repository/attempt"
+	"github.com/Hargeon/videocmprs/pkg/repository/datakey"
+	"github.com/Hargeon/videocmprs/pkg/repository/idempotency"
+	"github.com/Hargeon/videocmprs/pkg/repository/ratelimit"
 	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
 	"github.com/Hargeon/videocmprs/pkg/service/broker"
 	"github.com/Hargeon/videocmprs/pkg/service/cloud"
 	"github.com/Hargeon/videocmprs/pkg/service/compress"
+	"github.com/Hargeon/videocmprs/pkg/service/janitor"
+	"github.com/Hargeon/videocmprs/pkg/service/jwt"
+	"github.com/Hargeon/videocmprs/pkg/service/retention"
 
 	_ "github.com/jackc/pgx/stdlib"
 	"github.com/joho/godotenv"
@@ -21,7 +31,12 @@ import (
 	"go.uber.org/zap"
 )
 
-const migrationsPath = "db/migrations/common"
+const (
+	migrationsPath = "db/migrations/common"
+
+	defaultDeleteGracePeriod = 72 * time.Hour
+	defaultJanitorInterval   = time.Hour
+)
 
 func main() {
 	logger, err := zap.NewProduction()
@@ -35,6 +50,11 @@ func main() {
 		logger.Fatal("godotenv.Load()", zap.String("Error", err.Error()))
 	}
 
+	// load token signing keys once, fail fast on misconfiguration
+	if _, err = jwt.Default(); err != nil {
+		logger.Fatal("can't load jwt keys", zap.String("Error", err.Error()))
+	}
+
 	err = runMigrations()
 	if err != nil {
 		logger.Fatal("error occurred when run migrations", zap.String("Error", err.Error()))
@@ -78,7 +98,8 @@ func main() {
 
 	reqRepo := request.NewRepository(db)
 	vRepo := video.NewRepository(db)
-	srv := compress.NewService(reqRepo, vRepo, logger)
+	policy := retention.NewEnvPolicy(user.NewRepository(db))
+	srv := compress.NewService(reqRepo, vRepo, policy, logger)
 
 	go func() {
 		for d := range msgs {
@@ -95,11 +116,19 @@ func main() {
 		}
 	}()
 
-	storage := cloud.NewS3Storage(
-		os.Getenv("AWS_BUCKET_NAME"),
-		os.Getenv("AWS_REGION"),
-		os.Getenv("AWS_ACCESS_KEY"),
-		os.Getenv("AWS_SECRET_KEY"))
+	storage, err := cloud.NewEnvStorage(datakey.NewRepository(db))
+	if err != nil {
+		logger.Fatal("Invalid storage configuration", zap.String("Error", err.Error()))
+	}
+
+	// remove deleted requests and videos after the grace period and expired videos
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+
+	j := janitor.NewService(reqRepo, vRepo, token.NewRepository(db), attempt.NewRepository(db),
+		ratelimit.NewRepository(db), idempotency.NewRepository(db), storage,
+		durationEnv("DELETE_GRACE_PERIOD", defaultDeleteGracePeriod), logger)
+	go j.Run(janitorCtx, durationEnv("JANITOR_INTERVAL", defaultJanitorInterval))
 
 	h := api.NewHandler(db, publisher, storage, logger)
 	app := h.InitRoutes()
@@ -124,6 +153,16 @@ func main() {
 	logger.Info("Server Exited Properly")
 }
 
+// durationEnv parses env variable as time.Duration, returns def if variable is empty or invalid
+func durationEnv(name string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || d <= 0 {
+		return def
+	}
+
+	return d
+}
+
 func runMigrations() error {
 	dsn := os.Getenv("DB_URL")
 	db, err := sql.Open("pgx", dsn)
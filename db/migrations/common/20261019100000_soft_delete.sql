@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE requests ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE requests DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE videos DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS original_retention_days INT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS converted_retention_days INT;
+
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS expired BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS original_retention_days;
+ALTER TABLE users DROP COLUMN IF EXISTS converted_retention_days;
+
+ALTER TABLE videos DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE videos DROP COLUMN IF EXISTS expired;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_storage_bytes BIGINT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_max_file_size BIGINT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_active_requests INT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_monthly_minutes INT;
+
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS duration DOUBLE PRECISION;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS quota_storage_bytes;
+ALTER TABLE users DROP COLUMN IF EXISTS quota_max_file_size;
+ALTER TABLE users DROP COLUMN IF EXISTS quota_active_requests;
+ALTER TABLE users DROP COLUMN IF EXISTS quota_monthly_minutes;
+
+ALTER TABLE videos DROP COLUMN IF EXISTS duration;
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'admin', 'read-only'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS role;
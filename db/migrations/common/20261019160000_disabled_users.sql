@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS requests_status_idx ON requests (status);
+
+-- +goose Down
+DROP INDEX IF EXISTS requests_status_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
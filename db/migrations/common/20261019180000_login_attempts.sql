@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key VARCHAR(320) NOT NULL UNIQUE PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL NOT NULL UNIQUE PRIMARY KEY,
+    user_id BIGINT,
+    action VARCHAR(64) NOT NULL,
+    resource_type VARCHAR(32) NOT NULL DEFAULT '',
+    resource_id BIGINT,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS login_attempts;
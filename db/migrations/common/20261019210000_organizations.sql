@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS organizations (
+    id BIGSERIAL NOT NULL UNIQUE PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- role is one of owner, admin and member
+CREATE TABLE IF NOT EXISTS organization_members (
+    id BIGSERIAL NOT NULL UNIQUE PRIMARY KEY,
+    organization_id BIGINT NOT NULL REFERENCES organizations ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL DEFAULT 'member',
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_user_id_idx ON organization_members (user_id);
+
+ALTER TABLE requests ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations ON DELETE SET NULL;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS requests_organization_id_idx ON requests (organization_id);
+CREATE INDEX IF NOT EXISTS videos_organization_id_idx ON videos (organization_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS videos_organization_id_idx;
+DROP INDEX IF EXISTS requests_organization_id_idx;
+ALTER TABLE videos DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE requests DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- only hashes of token and password are stored, max_downloads is unlimited if NULL
+CREATE TABLE IF NOT EXISTS share_links (
+    id BIGSERIAL NOT NULL UNIQUE PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    video_id BIGINT NOT NULL REFERENCES videos ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    password_hash VARCHAR(255),
+    max_downloads INTEGER,
+    downloads INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS share_links_user_id_idx ON share_links (user_id);
+CREATE INDEX IF NOT EXISTS share_links_video_id_idx ON share_links (video_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS share_links_video_id_idx;
+DROP INDEX IF EXISTS share_links_user_id_idx;
+DROP TABLE IF EXISTS share_links;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- token buckets of rate limits shared by all replicas, allowed is result of the last take
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS rate_limit_buckets_updated_at_idx;
+DROP TABLE IF EXISTS rate_limit_buckets;
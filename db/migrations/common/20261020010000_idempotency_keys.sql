@@ -0,0 +1,18 @@
+-- +goose Up
+-- status and response are NULL while request with the key is in progress
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGSERIAL NOT NULL UNIQUE PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status INT,
+    response BYTEA,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idempotency_keys_created_at_idx;
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS codec VARCHAR(64);
+
+-- +goose Down
+ALTER TABLE videos DROP COLUMN IF EXISTS codec;
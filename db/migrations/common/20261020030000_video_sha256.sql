@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS sha256 CHAR(64);
+CREATE INDEX IF NOT EXISTS videos_sha256_idx ON videos (sha256) WHERE sha256 IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS videos_sha256_idx;
+ALTER TABLE videos DROP COLUMN IF EXISTS sha256;
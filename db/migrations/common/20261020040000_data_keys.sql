@@ -0,0 +1,10 @@
+-- +goose Up
+-- data keys of users encrypting their files in local storage, wrapped by master key
+CREATE TABLE IF NOT EXISTS data_keys (
+    user_id BIGINT NOT NULL PRIMARY KEY REFERENCES users ON DELETE CASCADE,
+    wrapped_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS data_keys;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- content type of stored response, it is restored when response is replayed
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS content_type VARCHAR(255);
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS content_type;
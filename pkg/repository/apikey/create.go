@@ -0,0 +1,46 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/jsonapi"
+)
+
+// Create stores API key with hash of key. Returned resource keeps plain Key
+// of given resource, so it can be shown to user once
+func (repo *Repository) Create(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
+	key, ok := resource.(*Resource)
+	if !ok {
+		return nil, errors.New("invalid type assertion *apikey.Resource in apikey repository")
+	}
+
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	dto := &DTO{UserID: key.UserID, Name: key.Name, Prefix: key.Prefix, Scopes: joinScopes(key.Scopes)}
+
+	if key.ExpiresAt != nil {
+		dto.ExpiresAt.Time, dto.ExpiresAt.Valid = *key.ExpiresAt, true
+	}
+
+	err := sq.
+		Insert(TableName).
+		Columns("user_id", "name", "prefix", "key_hash", "scopes", "expires_at").
+		Values(dto.UserID, dto.Name, dto.Prefix, key.Hash, dto.Scopes, dto.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&dto.ID, &dto.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	created := dto.BuildResource()
+	created.Key = key.Key
+
+	return created, nil
+}
@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		mock         func()
+		resource     *Resource
+		expectedID   int64
+		errorPresent bool
+	}{
+		{
+			name: "Should create key",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+) RETURNING id, created_at", TableName)).
+					WithArgs(5, "ci", "abcd1234", "hash", "requests:read,videos:read", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+			},
+			resource: &Resource{UserID: 5, Name: "ci", Prefix: "abcd1234", Hash: "hash", Key: "key",
+				Scopes: []string{"requests:read", "videos:read"}},
+			expectedID: 1,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", TableName)).
+					WillReturnError(errors.New("mock error"))
+			},
+			resource:     &Resource{UserID: 5, Name: "ci", Hash: "hash"},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			res, err := repo.Create(context.Background(), testCase.resource)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil {
+				key := res.(*Resource)
+				if key.ID != testCase.expectedID {
+					t.Errorf("Invalid id, expected: %d, got: %d\n", testCase.expectedID, key.ID)
+				}
+
+				if key.Key != testCase.resource.Key {
+					t.Errorf("Plain key should be returned once after creation\n")
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
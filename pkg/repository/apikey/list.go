@@ -0,0 +1,52 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// List returns not revoked API keys of user
+func (repo *Repository) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	keys := make([]interface{}, 0, params.PageSize)
+
+	rows, err := sq.
+		Select("id", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at").
+		From(TableName).
+		Where(sq.Eq{"user_id": params.RelationID, "revoked_at": nil}).
+		OrderBy("created_at DESC").
+		Limit(params.PageSize).
+		Offset(params.PageNumber).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		dto := new(DTO)
+
+		err = rows.Scan(&dto.ID, &dto.UserID, &dto.Name, &dto.Prefix, &dto.Scopes,
+			&dto.ExpiresAt, &dto.LastUsedAt, &dto.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, dto.BuildResource())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
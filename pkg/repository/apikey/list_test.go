@@ -0,0 +1,74 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}
+
+	cases := []struct {
+		name          string
+		mock          func()
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name: "Should return keys of user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE revoked_at IS NULL AND user_id = (.+) ORDER BY created_at DESC LIMIT 10 OFFSET 0", TableName)).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(2, 5, "deploy", "efgh5678", "", nil, createdAt, createdAt).
+						AddRow(1, 5, "ci", "abcd1234", "requests:read", createdAt, nil, createdAt))
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs(5).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			keys, err := repo.List(context.Background(), &query.Params{RelationID: 5, PageSize: 10})
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(keys) != testCase.expectedCount {
+				t.Errorf("Invalid count of keys, expected: %d, got: %d\n", testCase.expectedCount, len(keys))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
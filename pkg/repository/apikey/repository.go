@@ -0,0 +1,19 @@
+// Package apikey represent db connection to storing personal API keys of users
+package apikey
+
+import (
+	"database/sql"
+	"time"
+)
+
+const queryTimeOut = 5 * time.Second
+
+// Repository represent db connection for api_keys table
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
@@ -0,0 +1,95 @@
+package apikey
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/jsonapi"
+)
+
+// TableName is name of table in db
+const TableName = "api_keys"
+
+// scopesSeparator separates scopes stored in single column
+const scopesSeparator = ","
+
+var _ jsonapi.Linkable = (*Resource)(nil)
+
+// Resource represent api_keys table in db. Only hash of key is stored,
+// Key is filled once after creation
+type Resource struct {
+	ID     int64 `jsonapi:"primary,api-keys"`
+	UserID int64
+	Name   string   `jsonapi:"attr,name" validate:"required,max=64"`
+	Scopes []string `jsonapi:"attr,scopes"`
+	Key    string   `jsonapi:"attr,key,omitempty"`
+	Prefix string   `jsonapi:"attr,prefix,omitempty"`
+	Hash   string
+	// Role of key owner, permissions of key never exceed it
+	Role string
+
+	ExpiresAt  *time.Time `jsonapi:"attr,expires_at,iso8601,omitempty"`
+	LastUsedAt *time.Time `jsonapi:"attr,last_used_at,iso8601,omitempty"`
+	CreatedAt  *time.Time `jsonapi:"attr,created_at,iso8601,omitempty"`
+}
+
+// JSONAPILinks ...
+func (r *Resource) JSONAPILinks() *jsonapi.Links {
+	return &jsonapi.Links{
+		"self": fmt.Sprintf("%s/api/v1/api-keys/%d", os.Getenv("BASE_URL"), r.ID),
+	}
+}
+
+// Expired returns true if key has expiration time in the past
+func (r *Resource) Expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !r.ExpiresAt.After(now)
+}
+
+// DTO is used for scanning nullable columns of api_keys table
+type DTO struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Prefix     string
+	Scopes     string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+// BuildResource converts DTO to Resource
+func (dto *DTO) BuildResource() *Resource {
+	res := &Resource{
+		ID:        dto.ID,
+		UserID:    dto.UserID,
+		Name:      dto.Name,
+		Prefix:    dto.Prefix,
+		Scopes:    splitScopes(dto.Scopes),
+		CreatedAt: &dto.CreatedAt,
+	}
+
+	if dto.ExpiresAt.Valid {
+		res.ExpiresAt = &dto.ExpiresAt.Time
+	}
+
+	if dto.LastUsedAt.Valid {
+		res.LastUsedAt = &dto.LastUsedAt.Time
+	}
+
+	return res
+}
+
+func splitScopes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+
+	return strings.Split(s, scopesSeparator)
+}
+
+func joinScopes(scopes []string) string {
+	return strings.Join(scopes, scopesSeparator)
+}
@@ -0,0 +1,53 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// RetrieveByHash returns not revoked API key of enabled user by hash of key
+// with role of its owner
+func (repo *Repository) RetrieveByHash(ctx context.Context, hash string) (*Resource, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	dto := new(DTO)
+
+	var role string
+
+	err := sq.
+		Select(fmt.Sprintf("%s.id", TableName),
+			fmt.Sprintf("%s.user_id", TableName),
+			fmt.Sprintf("%s.name", TableName),
+			fmt.Sprintf("%s.prefix", TableName),
+			fmt.Sprintf("%s.scopes", TableName),
+			fmt.Sprintf("%s.expires_at", TableName),
+			fmt.Sprintf("%s.last_used_at", TableName),
+			fmt.Sprintf("%s.created_at", TableName),
+			fmt.Sprintf("%s.role", user.TableName)).
+		From(TableName).
+		Join(fmt.Sprintf("%s ON %s.user_id = %s.id", user.TableName, TableName, user.TableName)).
+		Where(sq.Eq{
+			fmt.Sprintf("%s.key_hash", TableName):         hash,
+			fmt.Sprintf("%s.revoked_at", TableName):       nil,
+			fmt.Sprintf("%s.disabled_at", user.TableName): nil,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&dto.ID, &dto.UserID, &dto.Name, &dto.Prefix, &dto.Scopes,
+			&dto.ExpiresAt, &dto.LastUsedAt, &dto.CreatedAt, &role)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := dto.BuildResource()
+	res.Role = role
+
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRetrieveByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at", "role"}
+
+	cases := []struct {
+		name           string
+		mock           func()
+		expectedUserID int64
+		expectedScopes int
+		errorPresent   bool
+	}{
+		{
+			name: "Key with scopes",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s JOIN users ON (.+) WHERE %s.key_hash = (.+) AND %s.revoked_at IS NULL",
+					TableName, TableName, TableName)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, 5, "ci", "abcd1234", "requests:read,videos:read", nil, nil, createdAt, "user"))
+			},
+			expectedUserID: 5,
+			expectedScopes: 2,
+		},
+		{
+			name: "Key without scopes",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, 5, "ci", "abcd1234", "", createdAt, createdAt, createdAt, "read-only"))
+			},
+			expectedUserID: 5,
+		},
+		{
+			name: "Key doesn't exist",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			key, err := repo.RetrieveByHash(context.Background(), "hash")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil {
+				if key.UserID != testCase.expectedUserID {
+					t.Errorf("Invalid user id, expected: %d, got: %d\n", testCase.expectedUserID, key.UserID)
+				}
+
+				if len(key.Scopes) != testCase.expectedScopes {
+					t.Errorf("Invalid scopes, expected: %d, got: %v\n", testCase.expectedScopes, key.Scopes)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Revoke marks API key of user as revoked. Returns false if key
+// doesn't belong to user or was already revoked
+func (repo *Repository) Revoke(ctx context.Context, userID, id int64) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(TableName).
+		Set("revoked_at", time.Now()).
+		Where(sq.Eq{"id": id, "user_id": userID, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,26 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Touch sets last_used_at of API key. Row is updated only if key wasn't
+// used after notAfter, it saves writes for keys used on every request
+func (repo *Repository) Touch(ctx context.Context, id int64, usedAt, notAfter time.Time) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(TableName).
+		Set("last_used_at", usedAt).
+		Where(sq.Eq{"id": id}).
+		Where(sq.Or{sq.Eq{"last_used_at": nil}, sq.Lt{"last_used_at": notAfter}}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
@@ -0,0 +1,124 @@
+package attempt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Retrieve returns failed attempts for keys, keys without failures are omitted
+func (repo *Repository) Retrieve(ctx context.Context, keys ...string) ([]*Attempt, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	rows, err := sq.
+		Select("attempt_key", "failures", "last_failed_at", "locked_until").
+		From(TableName).
+		Where(sq.Eq{"attempt_key": keys}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	attempts := make([]*Attempt, 0, len(keys))
+
+	for rows.Next() {
+		a := new(Attempt)
+		if err = rows.Scan(&a.Key, &a.Failures, &a.LastFailedAt, &a.LockedUntil); err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// Fail counts failed attempt for key. Counter starts again if previous failure
+// was before windowStart or lockout is over
+func (repo *Repository) Fail(ctx context.Context, key string, now, windowStart time.Time) (*Attempt, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	expired := fmt.Sprintf("%[1]s.last_failed_at < ? OR %[1]s.locked_until < ?", TableName)
+
+	a := &Attempt{Key: key}
+	err := sq.
+		Insert(TableName).
+		Columns("attempt_key", "failures", "last_failed_at").
+		Values(key, 1, now).
+		Suffix(fmt.Sprintf("ON CONFLICT (attempt_key) DO UPDATE SET "+
+			"failures = CASE WHEN %[1]s THEN 1 ELSE %[2]s.failures + 1 END, "+
+			"locked_until = CASE WHEN %[1]s THEN NULL ELSE %[2]s.locked_until END, "+
+			"last_failed_at = EXCLUDED.last_failed_at "+
+			"RETURNING failures, last_failed_at, locked_until", expired, TableName),
+			windowStart, now, windowStart, now).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&a.Failures, &a.LastFailedAt, &a.LockedUntil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Lock rejects sign in attempts for key until time
+func (repo *Repository) Lock(ctx context.Context, key string, until time.Time) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(TableName).
+		Set("locked_until", until).
+		Where(sq.Eq{"attempt_key": key}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// Reset removes failed attempts for key
+func (repo *Repository) Reset(ctx context.Context, key string) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Delete(TableName).
+		Where(sq.Eq{"attempt_key": key}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// Purge removes attempts which failed before and aren't locked anymore
+func (repo *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Delete(TableName).
+		Where(sq.Lt{"last_failed_at": before}).
+		Where(sq.Or{sq.Eq{"locked_until": nil}, sq.Lt{"locked_until": before}}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,153 @@
+package attempt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRetrieve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	now := time.Now()
+
+	cases := []struct {
+		name          string
+		mock          func()
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name: "Should return attempts",
+			mock: func() {
+				mock.ExpectQuery("SELECT attempt_key, failures, last_failed_at, locked_until FROM login_attempts WHERE attempt_key IN \\(\\$1,\\$2\\)").
+					WithArgs("email:check@check.com", "ip:127.0.0.1").
+					WillReturnRows(sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at", "locked_until"}).
+						AddRow("email:check@check.com", 3, now, nil))
+			},
+			expectedCount: 1,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			attempts, err := repo.Retrieve(context.Background(), "email:check@check.com", "ip:127.0.0.1")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(attempts) != testCase.expectedCount {
+				t.Errorf("Invalid count, expected: %d, got: %d\n", testCase.expectedCount, len(attempts))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-15 * time.Minute)
+
+	cases := []struct {
+		name             string
+		mock             func()
+		expectedFailures int
+		errorPresent     bool
+	}{
+		{
+			name: "Should count failure",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO login_attempts \\(attempt_key,failures,last_failed_at\\) VALUES \\(\\$1,\\$2,\\$3\\) ON CONFLICT \\(attempt_key\\) DO UPDATE SET failures = CASE WHEN login_attempts.last_failed_at < \\$4 OR login_attempts.locked_until < \\$5 THEN 1 (.+) RETURNING failures, last_failed_at, locked_until").
+					WithArgs("email:check@check.com", 1, now, windowStart, now, windowStart, now).
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).
+						AddRow(4, now, nil))
+			},
+			expectedFailures: 4,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO login_attempts").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			a, err := repo.Fail(context.Background(), "email:check@check.com", now, windowStart)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil && a.Failures != testCase.expectedFailures {
+				t.Errorf("Invalid failures, expected: %d, got: %d\n", testCase.expectedFailures, a.Failures)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	before := time.Now()
+
+	mock.ExpectExec("DELETE FROM login_attempts WHERE last_failed_at < \\$1 AND \\(locked_until IS NULL OR locked_until < \\$2\\)").
+		WithArgs(before, before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	total, err := NewRepository(db).Purge(context.Background(), before)
+	if err != nil {
+		t.Errorf("Unexpected error: %s\n", err.Error())
+	}
+
+	if total != 3 {
+		t.Errorf("Invalid total, expected: 3, got: %d\n", total)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,19 @@
+// Package attempt represent db connection to tracking failed sign in attempts
+package attempt
+
+import (
+	"database/sql"
+	"time"
+)
+
+const queryTimeOut = 5 * time.Second
+
+// Repository represent db connection for login_attempts table
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
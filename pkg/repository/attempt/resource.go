@@ -0,0 +1,17 @@
+package attempt
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TableName is name of login_attempts table in db
+const TableName = "login_attempts"
+
+// Attempt represent failed sign in attempts for key, e.g. email or ip
+type Attempt struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  sql.NullTime
+}
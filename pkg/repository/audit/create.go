@@ -0,0 +1,28 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Create appends event to audit log
+func (repo *Repository) Create(ctx context.Context, e *Event) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Insert(TableName).
+		Columns("user_id", "action", "resource_type", "resource_id", "ip", "user_agent", "details").
+		Values(nullID(e.UserID), e.Action, e.ResourceType, nullID(e.ResourceID), e.IP, e.UserAgent, e.Details).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
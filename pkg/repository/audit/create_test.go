@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		event        *Event
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name:  "Event without user",
+			event: &Event{Action: ActionLoginLocked, IP: "127.0.0.1", Details: `{"email":"check@check.com"}`},
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events \\(user_id,action,resource_type,resource_id,ip,user_agent,details\\)").
+					WithArgs(sql.NullInt64{}, ActionLoginLocked, "", sql.NullInt64{}, "127.0.0.1", "", `{"email":"check@check.com"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name: "Event with actor and target",
+			event: &Event{UserID: 1, Action: ActionVideoDownloadURL, ResourceType: "videos", ResourceID: 3,
+				IP: "127.0.0.1", UserAgent: "curl/7.79.1"},
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(sql.NullInt64{Int64: 1, Valid: true}, ActionVideoDownloadURL, "videos",
+						sql.NullInt64{Int64: 3, Valid: true}, "127.0.0.1", "curl/7.79.1", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:  "With bad db connection",
+			event: &Event{UserID: 1, Action: ActionLoginLocked},
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.Create(context.Background(), testCase.event)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// List returns page of audit events matching filter, newest first
+func (repo *Repository) List(ctx context.Context, params *query.Params, filter *query.AuditFilter) ([]interface{}, error) { //nolint:lll
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	events := make([]interface{}, 0, params.PageSize)
+
+	rows, err := selectEvents(filter).
+		Limit(params.PageSize).
+		Offset(params.PageNumber).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Export calls fn for every audit event matching filter, newest first.
+// Events are read row by row, so they aren't kept in memory
+func (repo *Repository) Export(ctx context.Context, filter *query.AuditFilter, fn func(e *Event) error) error {
+	c, cancel := context.WithTimeout(ctx, exportTimeOut)
+	defer cancel()
+
+	rows, err := selectEvents(filter).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// selectEvents builds query of audit events matching filter
+func selectEvents(filter *query.AuditFilter) sq.SelectBuilder {
+	where := sq.And{}
+
+	if filter.UserID != 0 {
+		where = append(where, sq.Eq{"user_id": filter.UserID})
+	}
+
+	if filter.Action != "" {
+		where = append(where, sq.Eq{"action": filter.Action})
+	}
+
+	if filter.ResourceType != "" {
+		where = append(where, sq.Eq{"resource_type": filter.ResourceType})
+	}
+
+	if filter.ResourceID != 0 {
+		where = append(where, sq.Eq{"resource_id": filter.ResourceID})
+	}
+
+	if filter.IP != "" {
+		where = append(where, sq.Eq{"ip": filter.IP})
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, sq.GtOrEq{"created_at": filter.CreatedAfter})
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		where = append(where, sq.Lt{"created_at": filter.CreatedBefore})
+	}
+
+	return sq.
+		Select("id", "user_id", "action", "resource_type", "resource_id", "ip", "user_agent",
+			"details", "created_at").
+		From(TableName).
+		Where(where).
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+}
+
+func scanEvent(rows sq.RowScanner) (*Event, error) {
+	e := new(Event)
+
+	var userID, resourceID sql.NullInt64
+
+	err := rows.Scan(&e.ID, &userID, &e.Action, &e.ResourceType, &resourceID, &e.IP, &e.UserAgent,
+		&e.Details, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	e.UserID, e.ResourceID = userID.Int64, resourceID.Int64
+
+	return e, nil
+}
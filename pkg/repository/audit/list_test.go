@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var eventColumns = []string{"id", "user_id", "action", "resource_type", "resource_id", "ip", "user_agent",
+	"details", "created_at"}
+
+func TestList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name          string
+		filter        *query.AuditFilter
+		mock          func()
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name:   "Without filter",
+			filter: &query.AuditFilter{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE \\(1=1\\) ORDER BY created_at DESC, id DESC LIMIT 10 OFFSET 0", TableName)).
+					WillReturnRows(sqlmock.NewRows(eventColumns).
+						AddRow(2, 1, ActionVideoDownloadURL, "videos", 3, "127.0.0.1", "curl", "", createdAt).
+						AddRow(1, nil, ActionLoginFailed, "", nil, "127.0.0.1", "curl", `{"email":"check@check.com"}`, createdAt))
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "With all filters",
+			filter: &query.AuditFilter{UserID: 1, Action: ActionVideoDownloadURL, ResourceType: "videos", ResourceID: 3,
+				IP: "127.0.0.1", CreatedAfter: createdAt, CreatedBefore: createdAt.Add(time.Hour)},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE \\(user_id = \\$1 AND action = \\$2 AND resource_type = \\$3 AND resource_id = \\$4 AND ip = \\$5 AND created_at >= \\$6 AND created_at < \\$7\\)", TableName)).
+					WithArgs(1, ActionVideoDownloadURL, "videos", 3, "127.0.0.1", createdAt, createdAt.Add(time.Hour)).
+					WillReturnRows(sqlmock.NewRows(eventColumns).
+						AddRow(2, 1, ActionVideoDownloadURL, "videos", 3, "127.0.0.1", "curl", "", createdAt))
+			},
+			expectedCount: 1,
+		},
+		{
+			name:   "With bad db connection",
+			filter: &query.AuditFilter{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			events, err := repo.List(context.Background(), &query.Params{PageSize: 10}, testCase.filter)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(events) != testCase.expectedCount {
+				t.Errorf("Invalid count of events, expected: %d, got: %d\n", testCase.expectedCount, len(events))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name          string
+		mock          func()
+		fnErr         error
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name: "Should export all events of user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE \\(user_id = \\$1\\) ORDER BY created_at DESC, id DESC$", TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(eventColumns).
+						AddRow(2, 1, ActionVideoDownloadURL, "videos", 3, "127.0.0.1", "curl", "", createdAt).
+						AddRow(1, 1, ActionLoginSucceeded, "users", 1, "127.0.0.1", "curl", "", createdAt))
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "Should stop on error of fn",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(eventColumns).
+						AddRow(2, 1, ActionVideoDownloadURL, "videos", 3, "127.0.0.1", "curl", "", createdAt).
+						AddRow(1, 1, ActionLoginSucceeded, "users", 1, "127.0.0.1", "curl", "", createdAt))
+			},
+			fnErr:         errors.New("write error"),
+			expectedCount: 1,
+			errorPresent:  true,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs(1).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+
+			count := 0
+			err := repo.Export(context.Background(), &query.AuditFilter{UserID: 1}, func(e *Event) error {
+				count++
+
+				return testCase.fnErr
+			})
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if count != testCase.expectedCount {
+				t.Errorf("Invalid count of events, expected: %d, got: %d\n", testCase.expectedCount, count)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
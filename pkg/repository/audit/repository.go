@@ -0,0 +1,24 @@
+// Package audit represent db connection to append-only audit log
+package audit
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	queryTimeOut = 5 * time.Second
+
+	// exportTimeOut limits reading of all events matching filter
+	exportTimeOut = time.Minute
+)
+
+// Repository represent db connection for audit_events table
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
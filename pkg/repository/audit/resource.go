@@ -0,0 +1,41 @@
+package audit
+
+import "time"
+
+// TableName is name of audit_events table in db
+const TableName = "audit_events"
+
+// Actions of audit events
+const (
+	ActionLoginSucceeded     = "login.succeeded"
+	ActionLoginFailed        = "login.failed"
+	ActionLoginLocked        = "login.locked"
+	ActionRequestCreated     = "request.created"
+	ActionRequestDeleted     = "request.deleted"
+	ActionRequestQuarantined = "request.quarantined"
+	ActionVideoDeleted       = "video.deleted"
+	ActionVideoDownloadURL   = "video.download_url"
+	ActionVideoDownload      = "video.download"
+	ActionFileDownload       = "file.download"
+	ActionShareLinkDownload  = "share_link.download"
+	ActionAdminRequestFail   = "admin.request.fail"
+	ActionAdminRequestCancel = "admin.request.cancel"
+	ActionAdminRequestRetry  = "admin.request.retry"
+	ActionAdminUserDisable   = "admin.user.disable"
+	ActionAdminUserEnable    = "admin.user.enable"
+	ActionAdminUserDelete    = "admin.user.delete"
+)
+
+// Event represent audit_events table in db. UserID is actor of event,
+// ResourceType and ResourceID are its target. Zero UserID and ResourceID are stored as NULL
+type Event struct {
+	ID           int64     `jsonapi:"primary,audit-events"`
+	UserID       int64     `jsonapi:"attr,user_id,omitempty"`
+	Action       string    `jsonapi:"attr,action"`
+	ResourceType string    `jsonapi:"attr,resource_type,omitempty"`
+	ResourceID   int64     `jsonapi:"attr,resource_id,omitempty"`
+	IP           string    `jsonapi:"attr,ip"`
+	UserAgent    string    `jsonapi:"attr,user_agent"`
+	Details      string    `jsonapi:"attr,details,omitempty"`
+	CreatedAt    time.Time `jsonapi:"attr,created_at,iso8601"`
+}
@@ -0,0 +1,32 @@
+package datakey
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// FindOrCreate stores wrapped data key of user if user has no key yet and
+// returns stored key, so concurrent uploads of user end up with the same key
+func (repo *Repository) FindOrCreate(ctx context.Context, userID int64, wrapped []byte) ([]byte, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var key []byte
+
+	err := sq.
+		Insert(TableName).
+		Columns("user_id", "wrapped_key").
+		Values(userID, wrapped).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id RETURNING wrapped_key").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
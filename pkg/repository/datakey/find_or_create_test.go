@@ -0,0 +1,68 @@
+package datakey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFindOrCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedKey  []byte
+		errorPresent bool
+	}{
+		{
+			name: "Should return stored key",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO data_keys \\(user_id,wrapped_key\\) VALUES \\(\\$1,\\$2\\) "+
+					"ON CONFLICT \\(user_id\\) DO UPDATE SET user_id = EXCLUDED.user_id RETURNING wrapped_key").
+					WithArgs(1, []byte("new")).
+					WillReturnRows(sqlmock.NewRows([]string{"wrapped_key"}).AddRow([]byte("stored")))
+			},
+			expectedKey: []byte("stored"),
+		},
+		{
+			name: "Invalid db connection",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO data_keys").
+					WithArgs(1, []byte("new")).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+
+			key, err := repo.FindOrCreate(context.Background(), 1, []byte("new"))
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if !bytes.Equal(key, testCase.expectedKey) {
+				t.Errorf("Invalid key, expected: %s, got: %s\n", testCase.expectedKey, key)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// Package datakey represent db connection to data keys of users which encrypt
+// their files in storage. Keys are stored wrapped by master key
+package datakey
+
+import (
+	"database/sql"
+	"time"
+)
+
+const queryTimeOut = 5 * time.Second
+
+// TableName is name of data_keys table in db
+const TableName = "data_keys"
+
+// Repository represent db connection for data_keys table
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
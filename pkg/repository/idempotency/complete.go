@@ -0,0 +1,62 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Complete stores response returned for request with key and its content type
+func (repo *Repository) Complete(ctx context.Context, id int64, status int, contentType string, response []byte) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(TableName).
+		SetMap(map[string]interface{}{
+			"status":       status,
+			"content_type": contentType,
+			"response":     response,
+		}).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// Release removes key, so request with it can be sent again
+func (repo *Repository) Release(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Delete(TableName).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// Purge removes keys created before
+func (repo *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Delete(TableName).
+		Where(sq.Lt{"created_at": before}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,105 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestComplete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Should store response",
+			mock: func() {
+				mock.ExpectExec("UPDATE idempotency_keys SET content_type = \\$1, response = \\$2, status = \\$3 WHERE id = \\$4").
+					WithArgs("application/vnd.api+json", []byte(`{"data":{}}`), 201, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectExec("UPDATE idempotency_keys").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+
+			err := repo.Complete(context.Background(), 2, 201, "application/vnd.api+json", []byte(`{"data":{}}`))
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE id = \\$1").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err = NewRepository(db).Release(context.Background(), 2); err != nil {
+		t.Errorf("Unexpected error: %s\n", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	before := time.Now()
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE created_at < \\$1").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	total, err := NewRepository(db).Purge(context.Background(), before)
+	if err != nil {
+		t.Errorf("Unexpected error: %s\n", err)
+	}
+
+	if total != 4 {
+		t.Errorf("Invalid total, expected: 4, got: %d\n", total)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,20 @@
+// Package idempotency represent db connection to storing idempotency keys of
+// requests and responses returned for them
+package idempotency
+
+import (
+	"database/sql"
+	"time"
+)
+
+const queryTimeOut = 5 * time.Second
+
+// Repository represent db connection for idempotency_keys table
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
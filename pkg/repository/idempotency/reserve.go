@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Reserve stores key with fingerprint as in progress. If key of user is already
+// stored, it is returned with false. Keys created before staleBefore are
+// reserved again as new ones
+func (repo *Repository) Reserve(ctx context.Context, k *Key, staleBefore time.Time) (*Key, bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	reserved := &Key{UserID: k.UserID, Key: k.Key, Fingerprint: k.Fingerprint}
+
+	err := sq.
+		Insert(TableName).
+		Columns("user_id", "idempotency_key", "fingerprint").
+		Values(k.UserID, k.Key, k.Fingerprint).
+		Suffix(fmt.Sprintf("ON CONFLICT (user_id, idempotency_key) DO UPDATE SET "+
+			"fingerprint = EXCLUDED.fingerprint, status = NULL, content_type = NULL, response = NULL, "+
+			"created_at = CURRENT_TIMESTAMP "+
+			"WHERE %s.created_at < ? RETURNING id", TableName), staleBefore).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&reserved.ID)
+
+	if err == nil {
+		return reserved, true, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	existing, err := repo.retrieve(c, k.UserID, k.Key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return existing, false, nil
+}
+
+func (repo *Repository) retrieve(ctx context.Context, userID int64, key string) (*Key, error) {
+	k := &Key{UserID: userID, Key: key}
+
+	var (
+		status      sql.NullInt64
+		contentType sql.NullString
+	)
+
+	err := sq.
+		Select("id", "fingerprint", "status", "content_type", "response").
+		From(TableName).
+		Where(sq.Eq{"user_id": userID, "idempotency_key": key}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(ctx).
+		Scan(&k.ID, &k.Fingerprint, &status, &contentType, &k.Response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	k.Status = int(status.Int64)
+	k.ContentType = contentType.String
+
+	return k, nil
+}
@@ -0,0 +1,109 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReserve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	staleBefore := time.Now().Add(-24 * time.Hour)
+
+	cases := []struct {
+		name                string
+		mock                func()
+		expectedReserved    bool
+		expectedID          int64
+		expectedStatus      int
+		expectedContentType string
+		errorPresent        bool
+	}{
+		{
+			name: "New key",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys \\(user_id,idempotency_key,fingerprint\\) VALUES \\(\\$1,\\$2,\\$3\\) ON CONFLICT \\(user_id, idempotency_key\\) DO UPDATE SET (.+) WHERE idempotency_keys.created_at < \\$4 RETURNING id").
+					WithArgs(1, "key", "hash", staleBefore).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			},
+			expectedReserved: true,
+			expectedID:       3,
+		},
+		{
+			name: "Completed key",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT id, fingerprint, status, content_type, response FROM idempotency_keys WHERE idempotency_key = \\$1 AND user_id = \\$2").
+					WithArgs("key", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "fingerprint", "status", "content_type", "response"}).
+						AddRow(2, "hash", 201, "application/vnd.api+json", []byte(`{"data":{}}`)))
+			},
+			expectedID:          2,
+			expectedStatus:      201,
+			expectedContentType: "application/vnd.api+json",
+		},
+		{
+			name: "Key in progress",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+					WithArgs("key", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "fingerprint", "status", "content_type", "response"}).
+						AddRow(2, "hash", nil, nil, nil))
+			},
+			expectedID: 2,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+
+			k, reserved, err := repo.Reserve(context.Background(),
+				&Key{UserID: 1, Key: "key", Fingerprint: "hash"}, staleBefore)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if reserved != testCase.expectedReserved {
+				t.Errorf("Invalid reserved, expected: %v, got: %v\n", testCase.expectedReserved, reserved)
+			}
+
+			if err == nil && (k.ID != testCase.expectedID || k.Status != testCase.expectedStatus) {
+				t.Errorf("Invalid key, expected: %d %d, got: %d %d\n", testCase.expectedID,
+					testCase.expectedStatus, k.ID, k.Status)
+			}
+
+			if err == nil && k.ContentType != testCase.expectedContentType {
+				t.Errorf("Invalid content type, expected: %s, got: %s\n", testCase.expectedContentType, k.ContentType)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package idempotency
+
+// TableName is name of idempotency_keys table in db
+const TableName = "idempotency_keys"
+
+// Key represent idempotency key of user. Fingerprint is hash of request payload,
+// zero Status means that request with the key is in progress
+type Key struct {
+	ID          int64
+	UserID      int64
+	Key         string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Response    []byte
+}
+
+// Completed reports whether response of request with the key is stored
+func (k *Key) Completed() bool {
+	return k.Status != 0
+}
@@ -0,0 +1,63 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/jsonapi"
+)
+
+// Create stores organization and adds user of resource as its owner
+func (repo *Repository) Create(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
+	org, ok := resource.(*Resource)
+	if !ok {
+		return nil, errors.New("invalid type assertion *organization.Resource in organization repository")
+	}
+
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	tx, err := repo.db.BeginTx(c, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	created := &Resource{Name: org.Name, Role: RoleOwner, UserID: org.UserID}
+	created.CreatedAt = new(time.Time)
+
+	err = sq.
+		Insert(TableName).
+		Columns("name").
+		Values(org.Name).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(c).
+		Scan(&created.ID, created.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sq.
+		Insert(MemberTableName).
+		Columns("organization_id", "user_id", "role").
+		Values(created.ID, org.UserID, RoleOwner).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
@@ -0,0 +1,86 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedID   int64
+		errorPresent bool
+	}{
+		{
+			name: "Should create organization with owner",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s \\(name\\) VALUES \\(\\$1\\) RETURNING id, created_at", TableName)).
+					WithArgs("team").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s \\(organization_id,user_id,role\\) VALUES \\(\\$1,\\$2,\\$3\\)", MemberTableName)).
+					WithArgs(3, 1, RoleOwner).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedID: 3,
+		},
+		{
+			name: "Should rollback if owner is not added",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", TableName)).
+					WithArgs("team").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", MemberTableName)).
+					WillReturnError(errors.New("mock error"))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			linkable, err := repo.Create(context.Background(), &Resource{Name: "team", UserID: 1})
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil {
+				org, ok := linkable.(*Resource)
+				if !ok {
+					t.Fatalf("Invalid type assertion *organization.Resource\n")
+				}
+
+				if org.ID != testCase.expectedID || org.Role != RoleOwner {
+					t.Errorf("Invalid organization, expected id: %d with role %s, got: %d with role %s\n",
+						testCase.expectedID, RoleOwner, org.ID, org.Role)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
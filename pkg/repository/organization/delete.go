@@ -0,0 +1,23 @@
+package organization
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Delete removes organization with its members. Requests and videos
+// of organization stay with users who created them
+func (repo *Repository) Delete(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Delete(TableName).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
@@ -0,0 +1,57 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// List returns organizations in which user is a member with role of user
+func (repo *Repository) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	organizations := make([]interface{}, 0, params.PageSize)
+
+	rows, err := sq.
+		Select(fmt.Sprintf("%s.id", TableName),
+			fmt.Sprintf("%s.name", TableName),
+			fmt.Sprintf("%s.created_at", TableName),
+			fmt.Sprintf("%s.role", MemberTableName)).
+		From(TableName).
+		Join(fmt.Sprintf("%s ON %s.organization_id = %s.id", MemberTableName, MemberTableName, TableName)).
+		Where(sq.Eq{fmt.Sprintf("%s.user_id", MemberTableName): params.RelationID}).
+		OrderBy(fmt.Sprintf("%s.id", TableName)).
+		Limit(params.PageSize).
+		Offset(params.PageNumber).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		org := &Resource{UserID: params.RelationID}
+		org.CreatedAt = new(time.Time)
+
+		if err = rows.Scan(&org.ID, &org.Name, org.CreatedAt, &org.Role); err != nil {
+			return nil, err
+		}
+
+		organizations = append(organizations, org)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return organizations, nil
+}
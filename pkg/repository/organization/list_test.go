@@ -0,0 +1,75 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "name", "created_at", "role"}
+
+	cases := []struct {
+		name          string
+		mock          func()
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name: "Should return organizations of user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT %s.id, %s.name, %s.created_at, %s.role FROM %s JOIN %s ON %s.organization_id = %s.id WHERE %s.user_id = \\$1",
+					TableName, TableName, TableName, MemberTableName, TableName, MemberTableName, MemberTableName, TableName, MemberTableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, "team", createdAt, RoleOwner).
+						AddRow(2, "other", createdAt, RoleMember))
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			organizations, err := repo.List(context.Background(), &query.Params{RelationID: 1, PageSize: 10})
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(organizations) != testCase.expectedCount {
+				t.Errorf("Invalid count of organizations, expected: %d, got: %d\n",
+					testCase.expectedCount, len(organizations))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
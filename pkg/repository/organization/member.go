@@ -0,0 +1,81 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// AddMember adds user to organization with role
+func (repo *Repository) AddMember(ctx context.Context, organizationID, userID int64, role string) (*time.Time, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	createdAt := new(time.Time)
+
+	err := sq.
+		Insert(MemberTableName).
+		Columns("organization_id", "user_id", "role").
+		Values(organizationID, userID, role).
+		Suffix("RETURNING created_at").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(createdAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return createdAt, nil
+}
+
+// UpdateMember changes role of member. Returns false if user is not
+// a member or is owner of organization
+func (repo *Repository) UpdateMember(ctx context.Context, organizationID, userID int64, role string) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	return affected(sq.
+		Update(MemberTableName).
+		Set("role", role).
+		Where(sq.And{
+			sq.Eq{"organization_id": organizationID, "user_id": userID},
+			sq.NotEq{"role": RoleOwner},
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c))
+}
+
+// RemoveMember removes user from organization. Returns false if user is
+// not a member or is owner of organization
+func (repo *Repository) RemoveMember(ctx context.Context, organizationID, userID int64) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	return affected(sq.
+		Delete(MemberTableName).
+		Where(sq.And{
+			sq.Eq{"organization_id": organizationID, "user_id": userID},
+			sq.NotEq{"role": RoleOwner},
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c))
+}
+
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
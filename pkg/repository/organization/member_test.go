@@ -0,0 +1,180 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAddMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Should add member",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s \\(organization_id,user_id,role\\) VALUES \\(\\$1,\\$2,\\$3\\) RETURNING created_at", MemberTableName)).
+					WithArgs(1, 2, RoleMember).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", MemberTableName)).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			_, err := repo.AddMember(context.Background(), 1, 2, RoleMember)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestUpdateMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expected     bool
+		errorPresent bool
+	}{
+		{
+			name: "Should change role",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET role = \\$1 WHERE \\(organization_id = \\$2 AND user_id = \\$3 AND role <> \\$4\\)", MemberTableName)).
+					WithArgs(RoleAdmin, 1, 2, RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expected: true,
+		},
+		{
+			name: "Owner or not a member",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s", MemberTableName)).
+					WithArgs(RoleAdmin, 1, 2, RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s", MemberTableName)).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			updated, err := repo.UpdateMember(context.Background(), 1, 2, RoleAdmin)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if updated != testCase.expected {
+				t.Errorf("Invalid result, expected: %v, got: %v\n", testCase.expected, updated)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expected     bool
+		errorPresent bool
+	}{
+		{
+			name: "Should remove member",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE \\(organization_id = \\$1 AND user_id = \\$2 AND role <> \\$3\\)", MemberTableName)).
+					WithArgs(1, 2, RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expected: true,
+		},
+		{
+			name: "Owner or not a member",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", MemberTableName)).
+					WithArgs(1, 2, RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			removed, err := repo.RemoveMember(context.Background(), 1, 2)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if removed != testCase.expected {
+				t.Errorf("Invalid result, expected: %v, got: %v\n", testCase.expected, removed)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Members returns members of organization with their emails
+func (repo *Repository) Members(ctx context.Context, organizationID int64) ([]interface{}, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	rows, err := sq.
+		Select(fmt.Sprintf("%s.user_id", MemberTableName),
+			fmt.Sprintf("%s.email", usersTableName),
+			fmt.Sprintf("%s.role", MemberTableName),
+			fmt.Sprintf("%s.created_at", MemberTableName)).
+		From(MemberTableName).
+		Join(fmt.Sprintf("%s ON %s.id = %s.user_id", usersTableName, usersTableName, MemberTableName)).
+		Where(sq.Eq{fmt.Sprintf("%s.organization_id", MemberTableName): organizationID}).
+		OrderBy(fmt.Sprintf("%s.id", MemberTableName)).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	members := make([]interface{}, 0)
+
+	for rows.Next() {
+		member := &Member{OrganizationID: organizationID}
+		member.CreatedAt = new(time.Time)
+
+		if err = rows.Scan(&member.ID, &member.Email, &member.Role, member.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// Membership returns role of user in organization. Returns sql.ErrNoRows
+// if user is not a member
+func (repo *Repository) Membership(ctx context.Context, organizationID, userID int64) (string, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var role string
+
+	err := sq.
+		Select("role").
+		From(MemberTableName).
+		Where(sq.Eq{"organization_id": organizationID, "user_id": userID}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&role)
+
+	return role, err
+}
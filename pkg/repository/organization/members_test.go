@@ -0,0 +1,126 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMembers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"user_id", "email", "role", "created_at"}
+
+	cases := []struct {
+		name          string
+		mock          func()
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name: "Should return members",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT %s.user_id, users.email, %s.role, %s.created_at FROM %s JOIN users ON users.id = %s.user_id WHERE %s.organization_id = \\$1",
+					MemberTableName, MemberTableName, MemberTableName, MemberTableName, MemberTableName, MemberTableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, "owner@check.com", RoleOwner, createdAt).
+						AddRow(2, "member@check.com", RoleMember, createdAt))
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", MemberTableName)).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			members, err := repo.Members(context.Background(), 1)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(members) != testCase.expectedCount {
+				t.Errorf("Invalid count of members, expected: %d, got: %d\n",
+					testCase.expectedCount, len(members))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestMembership(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedRole string
+		expectedErr  error
+	}{
+		{
+			name: "Should return role",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT role FROM %s WHERE organization_id = \\$1 AND user_id = \\$2", MemberTableName)).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(RoleAdmin))
+			},
+			expectedRole: RoleAdmin,
+		},
+		{
+			name: "User is not a member",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT role FROM %s", MemberTableName)).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"role"}))
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			role, err := repo.Membership(context.Background(), 1, 2)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if role != testCase.expectedRole {
+				t.Errorf("Invalid role, expected: %s, got: %s\n", testCase.expectedRole, role)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
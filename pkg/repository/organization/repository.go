@@ -0,0 +1,19 @@
+// Package organization represent db connection to organizations and their members
+package organization
+
+import (
+	"database/sql"
+	"time"
+)
+
+const queryTimeOut = 5 * time.Second
+
+// Repository represent db connection for organizations and organization_members tables
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
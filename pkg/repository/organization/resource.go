@@ -0,0 +1,83 @@
+package organization
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/jsonapi"
+)
+
+const (
+	// TableName is name of table in db
+	TableName = "organizations"
+	// MemberTableName is name of table with members of organizations
+	MemberTableName = "organization_members"
+
+	// usersTableName is name of users table. user package is not imported
+	// because it depends on request and video packages which use MemberOf
+	usersTableName = "users"
+)
+
+// Roles of members in organization. Owner and admins manage members,
+// only owner deletes organization
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+var (
+	_ jsonapi.Linkable = (*Resource)(nil)
+	_ jsonapi.Linkable = (*Member)(nil)
+)
+
+// Resource represent organizations table in db. Role is role of user
+// who fetched organization
+type Resource struct {
+	ID     int64  `jsonapi:"primary,organizations"`
+	Name   string `jsonapi:"attr,name" validate:"required,max=64"`
+	Role   string `jsonapi:"attr,role,omitempty"`
+	UserID int64
+
+	CreatedAt *time.Time `jsonapi:"attr,created_at,iso8601,omitempty"`
+}
+
+// JSONAPILinks ...
+func (r *Resource) JSONAPILinks() *jsonapi.Links {
+	return &jsonapi.Links{
+		"self":    fmt.Sprintf("%s/api/v1/organizations/%d", os.Getenv("BASE_URL"), r.ID),
+		"members": fmt.Sprintf("%s/api/v1/organizations/%d/members", os.Getenv("BASE_URL"), r.ID),
+	}
+}
+
+// Member represent organization_members table in db. ID is id of user
+type Member struct {
+	ID             int64  `jsonapi:"primary,organization-members"`
+	OrganizationID int64  `jsonapi:"attr,organization_id,omitempty"`
+	Email          string `jsonapi:"attr,email" validate:"required,email"`
+	Role           string `jsonapi:"attr,role" validate:"required,oneof=admin member"`
+
+	CreatedAt *time.Time `jsonapi:"attr,created_at,iso8601,omitempty"`
+}
+
+// JSONAPILinks ...
+func (m *Member) JSONAPILinks() *jsonapi.Links {
+	return &jsonapi.Links{
+		"self": fmt.Sprintf("%s/api/v1/organizations/%d/members/%d", os.Getenv("BASE_URL"), m.OrganizationID, m.ID),
+	}
+}
+
+// MemberOf returns condition matching rows which organization in column has user as member
+func MemberOf(column string, userID int64) sq.Sqlizer {
+	return sq.Expr(fmt.Sprintf("%s IN (SELECT organization_id FROM %s WHERE user_id = ?)", column, MemberTableName),
+		userID)
+}
+
+// ManagerOf returns condition matching rows which organization in column has user
+// as owner or admin
+func ManagerOf(column string, userID int64) sq.Sqlizer {
+	return sq.Expr(fmt.Sprintf("%s IN (SELECT organization_id FROM %s WHERE user_id = ? AND role IN (?, ?))",
+		column, MemberTableName), userID, RoleOwner, RoleAdmin)
+}
@@ -0,0 +1,33 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/jsonapi"
+)
+
+// Retrieve returns organization by id
+func (repo *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkable, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	org := new(Resource)
+	org.CreatedAt = new(time.Time)
+
+	err := sq.
+		Select("id", "name", "created_at").
+		From(TableName).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&org.ID, &org.Name, org.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
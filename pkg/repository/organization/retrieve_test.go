@@ -0,0 +1,68 @@
+package organization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRetrieve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedName string
+		errorPresent bool
+	}{
+		{
+			name: "Should return organization",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, created_at FROM %s WHERE id = \\$1", TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).AddRow(1, "team", createdAt))
+			},
+			expectedName: "team",
+		},
+		{
+			name: "Unknown organization",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, created_at FROM %s", TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			linkable, err := repo.Retrieve(context.Background(), 1)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil && linkable.(*Resource).Name != testCase.expectedName {
+				t.Errorf("Invalid name, expected: %s, got: %s\n", testCase.expectedName, linkable.(*Resource).Name)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// Package ratelimit represent db connection to token buckets of rate limits
+// shared by all replicas of application
+package ratelimit
+
+import (
+	"database/sql"
+	"time"
+)
+
+const queryTimeOut = 5 * time.Second
+
+// TableName is name of rate_limit_buckets table in db
+const TableName = "rate_limit_buckets"
+
+// Repository represent db connection for rate_limit_buckets table
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Take refills bucket of key with rate tokens per second up to burst and takes
+// one token from it. Returns tokens left in bucket and false if bucket had no
+// token. Refill and take are done in one statement, so concurrent requests of
+// replicas can't take the same token
+func (repo *Repository) Take(ctx context.Context, key string, burst int, rate float64, now time.Time) (float64, bool, error) { //nolint:lll
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	refill := fmt.Sprintf("LEAST(?, %[1]s.tokens + "+
+		"GREATEST(EXTRACT(EPOCH FROM (EXCLUDED.updated_at - %[1]s.updated_at)), 0) * ?)", TableName)
+
+	// refill is used four times in statement
+	var args []interface{}
+	for i := 0; i < 4; i++ {
+		args = append(args, burst, rate)
+	}
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	err := sq.
+		Insert(TableName).
+		Columns("bucket_key", "tokens", "allowed", "updated_at").
+		Values(key, float64(burst-1), burst > 0, now).
+		Suffix(fmt.Sprintf("ON CONFLICT (bucket_key) DO UPDATE SET "+
+			"tokens = CASE WHEN %[1]s >= 1 THEN %[1]s - 1 ELSE %[1]s END, "+
+			"allowed = %[1]s >= 1, "+
+			"updated_at = GREATEST(EXCLUDED.updated_at, %[2]s.updated_at) "+
+			"RETURNING tokens, allowed", refill, TableName), args...).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&tokens, &allowed)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return tokens, allowed, nil
+}
+
+// Purge removes buckets which weren't used since before, they are full again
+func (repo *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Delete(TableName).
+		Where(sq.Lt{"updated_at": before}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTake(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	now := time.Now()
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedTokens  float64
+		expectedAllowed bool
+		errorPresent    bool
+	}{
+		{
+			name: "Should take token",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO rate_limit_buckets \\(bucket_key,tokens,allowed,updated_at\\) VALUES \\(\\$1,\\$2,\\$3,\\$4\\) ON CONFLICT \\(bucket_key\\) DO UPDATE SET tokens = CASE WHEN LEAST\\(\\$5, (.+)\\) >= 1 (.+) RETURNING tokens, allowed").
+					WithArgs("api:user:1", float64(9), true, now, 10, 0.5, 10, 0.5, 10, 0.5, 10, 0.5).
+					WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(4.5, true))
+			},
+			expectedTokens:  4.5,
+			expectedAllowed: true,
+		},
+		{
+			name: "Empty bucket",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO rate_limit_buckets").
+					WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.25, false))
+			},
+			expectedTokens: 0.25,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO rate_limit_buckets").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+
+			tokens, allowed, err := repo.Take(context.Background(), "api:user:1", 10, 0.5, now)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if tokens != testCase.expectedTokens || allowed != testCase.expectedAllowed {
+				t.Errorf("Invalid bucket, expected: %v %v, got: %v %v\n",
+					testCase.expectedTokens, testCase.expectedAllowed, tokens, allowed)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	before := time.Now()
+
+	mock.ExpectExec("DELETE FROM rate_limit_buckets WHERE updated_at < \\$1").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	total, err := NewRepository(db).Purge(context.Background(), before)
+	if err != nil {
+		t.Errorf("Unexpected error: %s\n", err)
+	}
+
+	if total != 3 {
+		t.Errorf("Invalid total, expected: 3, got: %d\n", total)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
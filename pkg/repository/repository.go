@@ -3,8 +3,18 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository/apikey"
+	"github.com/Hargeon/videocmprs/pkg/repository/attempt"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/idempotency"
+	"github.com/Hargeon/videocmprs/pkg/repository/share"
+	"github.com/Hargeon/videocmprs/pkg/repository/stats"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
 
 	"github.com/google/jsonapi"
 )
@@ -21,14 +31,139 @@ type Paginator interface {
 	List(ctx context.Context, params *query.Params) ([]interface{}, error)
 }
 
+// RelationExistable returns id of relation which user owns or can access
+// as member of its organization
 type RelationExistable interface {
 	RelationExists(ctx context.Context, userID, relationID int64) (int64, error)
 }
 
+// RelationManageable returns id of relation which user owns or can change
+// as owner or admin of its organization
+type RelationManageable interface {
+	RelationManageable(ctx context.Context, userID, relationID int64) (int64, error)
+}
+
 type Updater interface {
 	Update(ctx context.Context, id int64, fields map[string]interface{}) (jsonapi.Linkable, error)
 }
 
+type Deleter interface {
+	Delete(ctx context.Context, id int64) error
+}
+
+type Purger interface {
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type RetentionRetriever interface {
+	Retention(ctx context.Context, id int64) (*user.Retention, error)
+}
+
+type QuotaRetriever interface {
+	Quota(ctx context.Context, id int64) (*user.Quota, error)
+	Usage(ctx context.Context, id int64, since time.Time) (*user.Usage, error)
+}
+
+type TokenRepository interface {
+	Purger
+
+	CreateRefresh(ctx context.Context, userID int64, hash string, expiresAt time.Time) error
+	RetrieveRefresh(ctx context.Context, hash string) (*token.Refresh, error)
+	RevokeRefresh(ctx context.Context, id int64) (bool, error)
+	RevokeUserRefresh(ctx context.Context, userID int64) error
+	Deny(ctx context.Context, jti string, expiresAt time.Time) error
+	Denied(ctx context.Context, jti string, userID int64) (bool, error)
+	CreateEmail(ctx context.Context, userID int64, purpose, hash string, expiresAt time.Time) error
+	UseEmail(ctx context.Context, purpose, hash string) (int64, error)
+	EmailOwner(ctx context.Context, purpose, hash string) (int64, error)
+	ReplaceRecovery(ctx context.Context, userID int64, hashes []string) error
+	UseRecovery(ctx context.Context, userID int64, hash string) (bool, error)
+	CreateOIDCState(ctx context.Context, state *token.OIDCState) error
+	UseOIDCState(ctx context.Context, hash string) (*token.OIDCState, error)
+}
+
+type APIKeyRepository interface {
+	Creator
+	Paginator
+
+	RetrieveByHash(ctx context.Context, hash string) (*apikey.Resource, error)
+	Revoke(ctx context.Context, userID, id int64) (bool, error)
+	Touch(ctx context.Context, id int64, usedAt, notAfter time.Time) error
+}
+
+// ShareLinkRepository stores public share links of videos
+type ShareLinkRepository interface {
+	Creator
+	Paginator
+
+	RetrieveByHash(ctx context.Context, hash string) (*share.Resource, error)
+	UseDownload(ctx context.Context, id int64, now time.Time) (bool, error)
+	Revoke(ctx context.Context, userID, id int64) (bool, error)
+}
+
+type AttemptRepository interface {
+	Purger
+
+	Retrieve(ctx context.Context, keys ...string) ([]*attempt.Attempt, error)
+	Fail(ctx context.Context, key string, now, windowStart time.Time) (*attempt.Attempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// RateLimitStore keeps token buckets of rate limits
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, burst int, rate float64, now time.Time) (float64, bool, error)
+}
+
+// RateLimitRepository keeps token buckets in db, so they are shared by replicas
+type RateLimitRepository interface {
+	RateLimitStore
+	Purger
+}
+
+// IdempotencyRepository stores idempotency keys and responses of requests sent with them
+type IdempotencyRepository interface {
+	Purger
+
+	Reserve(ctx context.Context, k *idempotency.Key, staleBefore time.Time) (*idempotency.Key, bool, error)
+	Complete(ctx context.Context, id int64, status int, contentType string, response []byte) error
+	Release(ctx context.Context, id int64) error
+}
+
+type AuditCreator interface {
+	Create(ctx context.Context, e *audit.Event) error
+}
+
+type AuditRepository interface {
+	AuditCreator
+
+	List(ctx context.Context, params *query.Params, filter *query.AuditFilter) ([]interface{}, error)
+	Export(ctx context.Context, filter *query.AuditFilter, fn func(e *audit.Event) error) error
+}
+
+// MembershipRetriever returns role of user in organization, sql.ErrNoRows
+// if user is not a member
+type MembershipRetriever interface {
+	Membership(ctx context.Context, organizationID, userID int64) (string, error)
+}
+
+type OrganizationRepository interface {
+	Creator
+	Retriever
+	Paginator
+	Deleter
+	MembershipRetriever
+
+	Members(ctx context.Context, organizationID int64) ([]interface{}, error)
+	AddMember(ctx context.Context, organizationID, userID int64, role string) (*time.Time, error)
+	UpdateMember(ctx context.Context, organizationID, userID int64, role string) (bool, error)
+	RemoveMember(ctx context.Context, organizationID, userID int64) (bool, error)
+}
+
+type StatsRetriever interface {
+	Retrieve(ctx context.Context) (*stats.Resource, error)
+}
+
 type CreatorRetriever interface {
 	Creator
 	Retriever
@@ -37,17 +172,44 @@ type CreatorRetriever interface {
 type UserRepository interface {
 	Creator
 	Retriever
+	RetentionRetriever
+	Deleter
 
-	Exists(ctx context.Context, email, password string) (int64, error)
+	Credentials(ctx context.Context, email string) (*user.Credentials, error)
+	UpdatePasswordHash(ctx context.Context, id int64, hash string) error
+	VerifyEmail(ctx context.Context, id int64) error
+	SetDisabled(ctx context.Context, id int64, disabled bool) (bool, error)
 	Unique(ctx context.Context, email string) (bool, error)
+	TOTP(ctx context.Context, id int64) (*user.TOTPState, error)
+	SetTOTPSecret(ctx context.Context, id int64, secret string) (bool, error)
+	EnableTOTP(ctx context.Context, id int64) (bool, error)
+	DisableTOTP(ctx context.Context, id int64) error
+	UseTOTPStep(ctx context.Context, id, step int64) (bool, error)
+	IdentityEmail(ctx context.Context, issuer, subject string) (string, error)
+	LinkIdentity(ctx context.Context, id int64, issuer, subject string) error
+}
+
+// DataKeyRepository keeps data keys of users wrapped by master key
+type DataKeyRepository interface {
+	FindOrCreate(ctx context.Context, userID int64, wrapped []byte) ([]byte, error)
 }
 
 type VideoRepository interface {
 	Retriever
 	Updater
 	RelationExistable
+	RelationManageable
+	Deleter
 
 	Create(ctx context.Context, fields map[string]interface{}) (jsonapi.Linkable, error)
+	ListDeleted(ctx context.Context, before time.Time) ([]*video.Resource, error)
+	Destroy(ctx context.Context, id int64) error
+	ListExpired(ctx context.Context, before time.Time) ([]*video.Resource, error)
+	MarkExpired(ctx context.Context, id int64) error
+	ServiceIDs(ctx context.Context) ([]string, error)
+	ListStored(ctx context.Context, before time.Time) ([]*video.Resource, error)
+	ServiceIDBySHA256(ctx context.Context, userID, organizationID int64, sha256 string) (string, error)
+	ServiceIDShared(ctx context.Context, id int64, serviceID string) (bool, error)
 }
 
 type RequestRepository interface {
@@ -55,4 +217,13 @@ type RequestRepository interface {
 	Updater
 	Paginator
 	RelationExistable
+	RelationManageable
+	Deleter
+	Purger
+
+	CreateChecked(ctx context.Context, resource jsonapi.Linkable,
+		check func(ctx context.Context) error) (jsonapi.Linkable, error)
+	ListAll(ctx context.Context, params *query.Params, filter *query.RequestFilter) ([]interface{}, error)
+	RetrieveNotDeleted(ctx context.Context, id int64) (jsonapi.Linkable, error)
+	Transition(ctx context.Context, id int64, from []string, fields map[string]interface{}) (bool, error)
 }
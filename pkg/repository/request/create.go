@@ -2,6 +2,7 @@ package request
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
 	sq "github.com/Masterminds/squirrel"
@@ -18,20 +19,82 @@ func (repo *Repository) Create(ctx context.Context, resource jsonapi.Linkable) (
 	c, cancel := context.WithTimeout(ctx, queryTimeOut)
 	defer cancel()
 
-	var id int64
-	err := sq.Insert(TableName).
-		Columns("bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "user_id", "video_name").
-		Values(request.Bitrate, request.ResolutionX, request.ResolutionY, request.RatioX,
-			request.RatioY, request.UserID, request.VideoName).
-		Suffix("RETURNING id").
+	id, err := insert(c, repo.db, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.Retrieve(ctx, id)
+}
+
+// CreateChecked creates request in transaction holding lock of row of its user,
+// so concurrent requests of the same user are checked and created one by one.
+// check is called after the lock is taken and request isn't created if it fails
+func (repo *Repository) CreateChecked(ctx context.Context, resource jsonapi.Linkable,
+	check func(ctx context.Context) error) (jsonapi.Linkable, error) {
+	request, ok := resource.(*Resource)
+	if !ok {
+		return nil, errors.New("invalid type assertion *request.Resource in request repository")
+	}
+
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	tx, err := repo.db.BeginTx(c, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	var userID int64
+	err = sq.
+		Select("id").
+		From(usersTableName).
+		Where(sq.Eq{"id": request.UserID}).
+		Suffix("FOR UPDATE").
 		PlaceholderFormat(sq.Dollar).
-		RunWith(repo.db).
+		RunWith(tx).
 		QueryRowContext(c).
-		Scan(&id)
+		Scan(&userID)
+
+	if err != nil {
+		return nil, err
+	}
 
+	if err = check(c); err != nil {
+		return nil, err
+	}
+
+	id, err := insert(c, tx, request)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return repo.Retrieve(ctx, id)
 }
+
+func insert(ctx context.Context, runner sq.BaseRunner, request *Resource) (int64, error) {
+	var organizationID sql.NullInt64
+	if request.OrganizationID != 0 {
+		organizationID.Int64, organizationID.Valid = request.OrganizationID, true
+	}
+
+	var id int64
+	err := sq.Insert(TableName).
+		Columns("bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "user_id", "video_name",
+			"organization_id").
+		Values(request.Bitrate, request.ResolutionX, request.ResolutionY, request.RatioX,
+			request.RatioY, request.UserID, request.VideoName, organizationID).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(runner).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	return id, err
+}
@@ -2,6 +2,8 @@ package request
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -34,11 +36,11 @@ func TestCreate(t *testing.T) {
 			name: "Should add request to db",
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", TableName)).
-					WithArgs(64000, 800, 600, 4, 3, 1, "new_video").
+					WithArgs(64000, 800, 600, 4, 3, 1, "new_video", nil).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).
 						AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -46,13 +48,13 @@ func TestCreate(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "original_in_review", "", 64000, 800, 600, 4, 3, "new_video", nil, nil, nil,
-						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 			},
 			req: &Resource{
 				UserID:      1,
@@ -78,7 +80,7 @@ func TestCreate(t *testing.T) {
 			name: "Should not add request to db",
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", TableName)).
-					WithArgs(64000, 800, 600, 4, 3, 1, "new_video").
+					WithArgs(64000, 800, 600, 4, 3, 1, "new_video", nil).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 			},
 			req: &Resource{
@@ -173,3 +175,101 @@ func TestCreate(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateChecked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	errQuota := errors.New("quota exceeded")
+	req := &Resource{UserID: 1, Bitrate: 64000, VideoName: "new_video"}
+
+	cases := []struct {
+		name        string
+		mock        func()
+		checkErr    error
+		expectedErr error
+		expectedID  int64
+	}{
+		{
+			name: "Should create request under lock of user",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", TableName)).
+					WithArgs(64000, 0, 0, 0, 0, 1, "new_video", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectCommit()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
+						"requests.details", "requests.bitrate", "requests.resolution_x",
+						"requests.resolution_y", "requests.ratio_x", "requests.ratio_y",
+						"requests.video_name", "origin_video.id", "origin_video.name",
+						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
+						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
+						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
+						"converted_video.resolution_y", "converted_video.ratio_x",
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
+						2, 1, "original_in_review", "", 64000, 0, 0, 0, 0, "new_video", nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
+			},
+			expectedID: 2,
+		},
+		{
+			name: "Failed check",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			checkErr:    errQuota,
+			expectedErr: errQuota,
+		},
+		{
+			name: "User doesn't exists",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+
+			linkable, err := repo.CreateChecked(context.Background(), req, func(ctx context.Context) error {
+				// check runs before insert and commit of transaction
+				if err := mock.ExpectationsWereMet(); err == nil {
+					t.Errorf("Check should be called inside of transaction\n")
+				}
+
+				return testCase.checkErr
+			})
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && linkable.(*Resource).ID != testCase.expectedID {
+				t.Errorf("Invalid id, expected: %d, got: %d\n", testCase.expectedID, linkable.(*Resource).ID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
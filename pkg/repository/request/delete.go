@@ -0,0 +1,68 @@
+package request
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Delete marks request and its videos as deleted. Records stay in db
+// until Purge removes them after the grace period
+func (repo *Repository) Delete(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	tx, err := repo.db.BeginTx(c, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	now := time.Now()
+
+	var originalID, convertedID sql.NullInt64
+	err = sq.
+		Update(TableName).
+		Set("deleted_at", now).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		Suffix("RETURNING original_file_id, converted_file_id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(c).
+		Scan(&originalID, &convertedID)
+
+	if err != nil {
+		return err
+	}
+
+	videoIDs := make([]int64, 0, 2)
+
+	if originalID.Valid {
+		videoIDs = append(videoIDs, originalID.Int64)
+	}
+
+	if convertedID.Valid {
+		videoIDs = append(videoIDs, convertedID.Int64)
+	}
+
+	if len(videoIDs) > 0 {
+		_, err = sq.
+			Update(video.TableName).
+			Set("deleted_at", now).
+			Where(sq.Eq{"id": videoIDs, "deleted_at": nil}).
+			PlaceholderFormat(sq.Dollar).
+			RunWith(tx).
+			ExecContext(c)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
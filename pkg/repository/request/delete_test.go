@@ -0,0 +1,101 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		id           int64
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Request does not exist",
+			id:   1,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_file_id", "converted_file_id"}))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+		{
+			name: "Request without videos",
+			id:   1,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_file_id", "converted_file_id"}).
+						AddRow(nil, nil))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Request with videos",
+			id:   1,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_file_id", "converted_file_id"}).
+						AddRow(2, 3))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET deleted_at", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), 2, 3).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Videos update failed",
+			id:   1,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_file_id", "converted_file_id"}).
+						AddRow(2, nil))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET deleted_at", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), 2).
+					WillReturnError(errors.New("mock error"))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.Delete(context.Background(), testCase.id)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -3,9 +3,13 @@ package request
 import (
 	"context"
 
+	"github.com/Hargeon/videocmprs/pkg/repository/organization"
+
 	sq "github.com/Masterminds/squirrel"
 )
 
+// RelationExists returns id of request if it is not deleted and belongs to user
+// or to organization in which user is a member
 func (repo *Repository) RelationExists(ctx context.Context, userID, relationID int64) (int64, error) {
 	c, cancel := context.WithTimeout(ctx, queryTimeOut)
 	defer cancel()
@@ -14,7 +18,35 @@ func (repo *Repository) RelationExists(ctx context.Context, userID, relationID i
 
 	err := sq.Select("id").
 		From(TableName).
-		Where(sq.And{sq.Eq{"id": relationID}, sq.Eq{"user_id": userID}}).
+		Where(sq.And{
+			sq.Eq{"id": relationID},
+			sq.Eq{"deleted_at": nil},
+			sq.Or{sq.Eq{"user_id": userID}, organization.MemberOf("organization_id", userID)},
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&id)
+
+	return id, err
+}
+
+// RelationManageable returns id of request if it is not deleted and belongs to user
+// or to organization in which user is owner or admin. Members of organization
+// can only read requests of others
+func (repo *Repository) RelationManageable(ctx context.Context, userID, relationID int64) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var id int64
+
+	err := sq.Select("id").
+		From(TableName).
+		Where(sq.And{
+			sq.Eq{"id": relationID},
+			sq.Eq{"deleted_at": nil},
+			sq.Or{sq.Eq{"user_id": userID}, organization.ManagerOf("organization_id", userID)},
+		}).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(repo.db).
 		QueryRowContext(c).
@@ -28,7 +28,7 @@ func TestRelationExists(t *testing.T) {
 			requestID: 1,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}))
 			},
 			errorPresent: true,
@@ -38,8 +38,8 @@ func TestRelationExists(t *testing.T) {
 			userId:    1,
 			requestID: 1,
 			mock: func() {
-				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", TableName)).
-					WithArgs(1, 1).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE \\(id = \\$1 AND deleted_at IS NULL AND \\(user_id = \\$2 OR organization_id IN \\(SELECT organization_id FROM organization_members WHERE user_id = \\$3\\)\\)\\)", TableName)).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
 			},
 			expectedID: 1,
@@ -70,3 +70,66 @@ func TestRelationExists(t *testing.T) {
 		})
 	}
 }
+
+func TestRelationManageable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		userId       int64
+		requestID    int64
+		mock         func()
+		expectedID   int64
+		errorPresent bool
+	}{
+		{
+			name:      "Invalid db connection",
+			userId:    1,
+			requestID: 1,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", TableName)).
+					WithArgs(1, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			errorPresent: true,
+		},
+		{
+			name:      "Should return id",
+			userId:    1,
+			requestID: 1,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE \\(id = \\$1 AND deleted_at IS NULL AND \\(user_id = \\$2 OR organization_id IN \\(SELECT organization_id FROM organization_members WHERE user_id = \\$3 AND role IN \\(\\$4, \\$5\\)\\)\\)\\)", TableName)).
+					WithArgs(1, 1, 1, "owner", "admin").
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedID: 1,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			id, err := repo.RelationManageable(context.Background(), testCase.userId, testCase.requestID)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if id != testCase.expectedID {
+				t.Errorf("Invalid ID, expected: %d, got: %d\n",
+					testCase.expectedID, id)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package request
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/Hargeon/videocmprs/api/query"
@@ -10,13 +11,21 @@ import (
 	sq "github.com/Masterminds/squirrel"
 )
 
-// List returns requests
+// List returns requests of user or, if OrganizationID of params is set, requests shared with organization
 func (repo *Repository) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
 	c, cancel := context.WithTimeout(ctx, queryTimeOut)
 	defer cancel()
 
 	requests := make([]interface{}, 0, params.PageSize)
 
+	where := sq.Eq{fmt.Sprintf("%s.deleted_at", TableName): nil}
+
+	if params.OrganizationID != 0 {
+		where[fmt.Sprintf("%s.organization_id", TableName)] = params.OrganizationID
+	} else {
+		where[fmt.Sprintf("%s.user_id", TableName)] = params.RelationID
+	}
+
 	rows, err := sq.
 		Select(fmt.Sprintf("%s.id", TableName),
 			fmt.Sprintf("%s.status", TableName),
@@ -36,6 +45,7 @@ func (repo *Repository) List(ctx context.Context, params *query.Params) ([]inter
 			"origin_video.ratio_x",
 			"origin_video.ratio_y",
 			"origin_video.service_id",
+			"origin_video.expires_at",
 			"converted_video.id",
 			"converted_video.name",
 			"converted_video.size",
@@ -44,13 +54,15 @@ func (repo *Repository) List(ctx context.Context, params *query.Params) ([]inter
 			"converted_video.resolution_y",
 			"converted_video.ratio_x",
 			"converted_video.ratio_y",
-			"converted_video.service_id").
+			"converted_video.service_id",
+			"converted_video.expires_at",
+			fmt.Sprintf("%s.organization_id", TableName)).
 		From(TableName).
-		LeftJoin(fmt.Sprintf("%s AS origin_video ON %s.original_file_id = origin_video.id",
+		LeftJoin(fmt.Sprintf("%s AS origin_video ON %s.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL",
 			video.TableName, TableName)).
-		LeftJoin(fmt.Sprintf("%s AS converted_video ON %s.converted_file_id = converted_video.id",
+		LeftJoin(fmt.Sprintf("%s AS converted_video ON %s.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL",
 			video.TableName, TableName)).
-		Where(sq.Eq{fmt.Sprintf("%s.user_id", TableName): params.RelationID}).
+		Where(where).
 		OrderBy(fmt.Sprintf("%s.created_at DESC", TableName)).
 		Limit(params.PageSize).
 		Offset(params.PageNumber).
@@ -69,19 +81,23 @@ func (repo *Repository) List(ctx context.Context, params *query.Params) ([]inter
 		origin := new(video.DTO)
 		converted := new(video.DTO)
 
+		var organizationID sql.NullInt64
+
 		err = rows.Scan(&request.ID, &request.Status, &request.DetailsDB, &request.Bitrate,
 			&request.ResolutionX, &request.ResolutionY, &request.RatioX, &request.RatioY,
 			&request.VideoName, &origin.ID, &origin.Name, &origin.Size, &origin.Bitrate,
 			&origin.ResolutionX, &origin.ResolutionY, &origin.RatioX, &origin.RatioY,
-			&origin.ServiceID, &converted.ID, &converted.Name, &converted.Size,
+			&origin.ServiceID, &origin.ExpiresAt, &converted.ID, &converted.Name, &converted.Size,
 			&converted.Bitrate, &converted.ResolutionX, &converted.ResolutionY,
-			&converted.RatioX, &converted.RatioY, &converted.ServiceID)
+			&converted.RatioX, &converted.RatioY, &converted.ServiceID, &converted.ExpiresAt,
+			&organizationID)
 
 		if err != nil {
 			return nil, err
 		}
 
 		request.Details = request.DetailsDB.String
+		request.OrganizationID = organizationID.Int64
 		// check if videos exists in db
 		if origin.ID.Valid {
 			request.OriginalVideo = origin.BuildResource()
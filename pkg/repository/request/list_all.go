@@ -0,0 +1,112 @@
+package request
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ListAll returns not deleted requests of all users matching filter
+func (repo *Repository) ListAll(ctx context.Context, params *query.Params, filter *query.RequestFilter) ([]interface{}, error) { //nolint:lll
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	requests := make([]interface{}, 0, params.PageSize)
+
+	where := sq.And{sq.Eq{fmt.Sprintf("%s.deleted_at", TableName): nil}}
+
+	if filter.UserID != 0 {
+		where = append(where, sq.Eq{fmt.Sprintf("%s.user_id", TableName): filter.UserID})
+	}
+
+	if filter.OrganizationID != 0 {
+		where = append(where, sq.Eq{fmt.Sprintf("%s.organization_id", TableName): filter.OrganizationID})
+	}
+
+	if filter.Status != "" {
+		where = append(where, sq.Eq{fmt.Sprintf("%s.status", TableName): filter.Status})
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, sq.GtOrEq{fmt.Sprintf("%s.created_at", TableName): filter.CreatedAfter})
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		where = append(where, sq.Lt{fmt.Sprintf("%s.created_at", TableName): filter.CreatedBefore})
+	}
+
+	rows, err := sq.
+		Select(fmt.Sprintf("%s.id", TableName),
+			fmt.Sprintf("%s.user_id", TableName),
+			fmt.Sprintf("%s.status", TableName),
+			fmt.Sprintf("%s.details", TableName),
+			fmt.Sprintf("%s.bitrate", TableName),
+			fmt.Sprintf("%s.resolution_x", TableName),
+			fmt.Sprintf("%s.resolution_y", TableName),
+			fmt.Sprintf("%s.ratio_x", TableName),
+			fmt.Sprintf("%s.ratio_y", TableName),
+			fmt.Sprintf("%s.video_name", TableName),
+			"origin_video.id",
+			"origin_video.name",
+			"origin_video.size",
+			"origin_video.service_id",
+			"converted_video.id",
+			"converted_video.name",
+			"converted_video.size",
+			"converted_video.service_id").
+		From(TableName).
+		LeftJoin(fmt.Sprintf("%s AS origin_video ON %s.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL",
+			video.TableName, TableName)).
+		LeftJoin(fmt.Sprintf("%s AS converted_video ON %s.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL",
+			video.TableName, TableName)).
+		Where(where).
+		OrderBy(fmt.Sprintf("%s.created_at DESC", TableName)).
+		Limit(params.PageSize).
+		Offset(params.PageNumber).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		request := new(Resource)
+		origin := new(video.DTO)
+		converted := new(video.DTO)
+
+		err = rows.Scan(&request.ID, &request.UserID, &request.Status, &request.DetailsDB, &request.Bitrate,
+			&request.ResolutionX, &request.ResolutionY, &request.RatioX, &request.RatioY,
+			&request.VideoName, &origin.ID, &origin.Name, &origin.Size, &origin.ServiceID,
+			&converted.ID, &converted.Name, &converted.Size, &converted.ServiceID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		request.Details = request.DetailsDB.String
+
+		if origin.ID.Valid {
+			request.OriginalVideo = origin.BuildResource()
+		}
+
+		if converted.ID.Valid {
+			request.ConvertedVideo = converted.BuildResource()
+		}
+
+		requests = append(requests, request)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
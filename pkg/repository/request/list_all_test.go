@@ -0,0 +1,89 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	columns := []string{"requests.id", "requests.user_id", "requests.status", "requests.details",
+		"requests.bitrate", "requests.resolution_x", "requests.resolution_y", "requests.ratio_x",
+		"requests.ratio_y", "requests.video_name", "origin_video.id", "origin_video.name",
+		"origin_video.size", "origin_video.service_id", "converted_video.id", "converted_video.name",
+		"converted_video.size", "converted_video.service_id"}
+	after := time.Date(2021, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name          string
+		filter        *query.RequestFilter
+		mock          func()
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name:   "Without filter",
+			filter: &query.RequestFilter{},
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests (.+) WHERE \\(requests.deleted_at IS NULL\\) ORDER BY requests.created_at DESC LIMIT 10 OFFSET 0").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, 5, "failed", "Failed connection to worker", 64000, 0, 0, 0, 0, "video",
+							1, "video", 1000, "service_id", nil, nil, nil, nil).
+						AddRow(2, 6, "original_in_review", nil, 64000, 0, 0, 0, 0, "video",
+							nil, nil, nil, nil, nil, nil, nil, nil))
+			},
+			expectedCount: 2,
+		},
+		{
+			name:   "With filter",
+			filter: &query.RequestFilter{UserID: 5, Status: "failed", CreatedAfter: after},
+			mock: func() {
+				mock.ExpectQuery("WHERE \\(requests.deleted_at IS NULL AND requests.user_id = \\$1 AND requests.status = \\$2 AND requests.created_at >= \\$3\\)").
+					WithArgs(5, "failed", after).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+		{
+			name:   "With bad db connection",
+			filter: &query.RequestFilter{},
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			requests, err := repo.ListAll(context.Background(), &query.Params{PageSize: 10}, testCase.filter)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(requests) != testCase.expectedCount {
+				t.Errorf("Invalid count of requests, expected: %d, got: %d\n", testCase.expectedCount, len(requests))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -32,7 +32,7 @@ func TestList(t *testing.T) {
 			},
 			expectedRequests: []*Resource{},
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -40,10 +40,10 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}))
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}))
 			},
 			errorPresent: false,
 		},
@@ -69,7 +69,7 @@ func TestList(t *testing.T) {
 				},
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -77,12 +77,12 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, "", "", 64000, 800, 600, 4, 3, "new_video", nil, nil, nil,
-						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 			},
 			errorPresent: false,
 		},
@@ -119,7 +119,7 @@ func TestList(t *testing.T) {
 				},
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -127,12 +127,12 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, "", "", 64000, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", nil, nil, nil, nil, nil, nil, nil, nil, nil))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
 			},
 			errorPresent: false,
 		},
@@ -180,7 +180,7 @@ func TestList(t *testing.T) {
 				},
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -188,13 +188,13 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, "", "", 64000, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", 2, "converted_video", 12000, 64000,
-						800, 600, 4, 3, "converted_service_id"))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, 2, "converted_video", 12000, 64000,
+						800, 600, 4, 3, "converted_service_id", nil, nil))
 			},
 			errorPresent: false,
 		},
@@ -0,0 +1,28 @@
+package request
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Purge permanently removes requests deleted before the time and returns
+// count of removed records
+func (repo *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Delete(TableName).
+		Where(sq.Lt{"deleted_at": before}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
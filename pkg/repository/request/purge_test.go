@@ -0,0 +1,69 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	before := time.Now()
+
+	cases := []struct {
+		name          string
+		mock          func()
+		expectedTotal int64
+		errorPresent  bool
+	}{
+		{
+			name: "Should purge requests",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE deleted_at <", TableName)).
+					WithArgs(before).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			expectedTotal: 3,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE deleted_at <", TableName)).
+					WithArgs(before).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			total, err := repo.Purge(context.Background(), before)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if total != testCase.expectedTotal {
+				t.Errorf("Invalid total, expected: %d, got: %d\n", testCase.expectedTotal, total)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -14,16 +14,20 @@ import (
 // TableName is table name in db
 const TableName = "requests"
 
+const usersTableName = "users"
+
 var _ jsonapi.Linkable = (*Resource)(nil)
 
 // Resource represent requests in db
 type Resource struct {
-	ID        int64 `jsonapi:"primary,requests"`
-	UserID    int64
-	VideoName string `jsonapi:"attr,video_name"`
-	Status    string `jsonapi:"attr,status,omitempty"`
-	Details   string `jsonapi:"attr,details,omitempty"`
-	DetailsDB sql.NullString
+	ID     int64 `jsonapi:"primary,requests"`
+	UserID int64 `jsonapi:"attr,user_id,omitempty"`
+	// OrganizationID shares request and its videos with members of organization
+	OrganizationID int64  `jsonapi:"attr,organization_id,omitempty"`
+	VideoName      string `jsonapi:"attr,video_name"`
+	Status         string `jsonapi:"attr,status,omitempty"`
+	Details        string `jsonapi:"attr,details,omitempty"`
+	DetailsDB      sql.NullString
 
 	Bitrate     int64 `jsonapi:"attr,bitrate" validate:"required_if=ResolutionX 0 ResolutionY 0 RatioX 0 RatioY 0"`
 	ResolutionX int   `jsonapi:"attr,resolution_x" validate:"required_if=Bitrate 0 RatioX 0 RatioY 0,required_with=ResolutionY"` //nolint:lll
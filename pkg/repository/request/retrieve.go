@@ -2,6 +2,7 @@ package request
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
@@ -12,6 +13,19 @@ import (
 
 // Retrieve request from db
 func (repo *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkable, error) {
+	return repo.retrieve(ctx, sq.Eq{fmt.Sprintf("%s.id", TableName): id})
+}
+
+// RetrieveNotDeleted returns request from db if it isn't marked as deleted
+func (repo *Repository) RetrieveNotDeleted(ctx context.Context, id int64) (jsonapi.Linkable, error) {
+	return repo.retrieve(ctx, sq.Eq{
+		fmt.Sprintf("%s.id", TableName):         id,
+		fmt.Sprintf("%s.deleted_at", TableName): nil,
+	})
+}
+
+// retrieve returns the first request matching condition with its not deleted videos
+func (repo *Repository) retrieve(ctx context.Context, where sq.Sqlizer) (jsonapi.Linkable, error) {
 	c, cancel := context.WithTimeout(ctx, queryTimeOut)
 
 	defer cancel()
@@ -20,6 +34,8 @@ func (repo *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkabl
 	origin := new(video.DTO)
 	converted := new(video.DTO)
 
+	var organizationID sql.NullInt64
+
 	err := sq.
 		Select(fmt.Sprintf("%s.id", TableName),
 			fmt.Sprintf("%s.user_id", TableName),
@@ -40,6 +56,7 @@ func (repo *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkabl
 			"origin_video.ratio_x",
 			"origin_video.ratio_y",
 			"origin_video.service_id",
+			"origin_video.expires_at",
 			"converted_video.id",
 			"converted_video.name",
 			"converted_video.size",
@@ -48,13 +65,15 @@ func (repo *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkabl
 			"converted_video.resolution_y",
 			"converted_video.ratio_x",
 			"converted_video.ratio_y",
-			"converted_video.service_id").
+			"converted_video.service_id",
+			"converted_video.expires_at",
+			fmt.Sprintf("%s.organization_id", TableName)).
 		From(TableName).
-		LeftJoin(fmt.Sprintf("%s AS origin_video ON %s.original_file_id = origin_video.id",
+		LeftJoin(fmt.Sprintf("%s AS origin_video ON %s.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL",
 			video.TableName, TableName)).
-		LeftJoin(fmt.Sprintf("%s AS converted_video ON %s.converted_file_id = converted_video.id",
+		LeftJoin(fmt.Sprintf("%s AS converted_video ON %s.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL",
 			video.TableName, TableName)).
-		Where(sq.Eq{fmt.Sprintf("%s.id", TableName): id}).
+		Where(where).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(repo.db).
 		QueryRowContext(c).
@@ -62,15 +81,17 @@ func (repo *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkabl
 			&request.ResolutionX, &request.ResolutionY, &request.RatioX, &request.RatioY,
 			&request.VideoName, &origin.ID, &origin.Name, &origin.Size, &origin.Bitrate,
 			&origin.ResolutionX, &origin.ResolutionY, &origin.RatioX, &origin.RatioY,
-			&origin.ServiceID, &converted.ID, &converted.Name, &converted.Size,
+			&origin.ServiceID, &origin.ExpiresAt, &converted.ID, &converted.Name, &converted.Size,
 			&converted.Bitrate, &converted.ResolutionX, &converted.ResolutionY,
-			&converted.RatioX, &converted.RatioY, &converted.ServiceID)
+			&converted.RatioX, &converted.RatioY, &converted.ServiceID, &converted.ExpiresAt,
+			&organizationID)
 
 	if err != nil {
 		return nil, err
 	}
 
 	request.Details = request.DetailsDB.String
+	request.OrganizationID = organizationID.Int64
 	// check if videos exists in db
 	if origin.ID.Valid {
 		request.OriginalVideo = origin.BuildResource()
@@ -2,6 +2,8 @@ package request
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -32,7 +34,7 @@ func TestRetrieve(t *testing.T) {
 			name: "Should return request",
 			id:   1,
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -40,13 +42,13 @@ func TestRetrieve(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "original_in_review", "", 1589875, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", 2, "converted_video", 12000, 64000,
-						800, 600, 4, 3, "converted_service_id"))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, 2, "converted_video", 12000, 64000,
+						800, 600, 4, 3, "converted_service_id", nil, nil))
 			},
 			expectedID:          1,
 			expectedStatus:      "original_in_review",
@@ -63,7 +65,7 @@ func TestRetrieve(t *testing.T) {
 			name: "Should not return request",
 			id:   1,
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -71,10 +73,10 @@ func TestRetrieve(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}))
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}))
 			},
 			errorPresent: true,
 		},
@@ -152,3 +154,22 @@ func TestRetrieve(t *testing.T) {
 		})
 	}
 }
+
+func TestRetrieveNotDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM requests (.+) WHERE requests.deleted_at IS NULL AND requests.id = \\$1").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err = NewRepository(db).RetrieveNotDeleted(context.Background(), 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Invalid error, expected: %v, got: %v\n", sql.ErrNoRows, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,33 @@
+package request
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Transition updates not deleted request only if its current status is one of from.
+// Returns false if request doesn't exist or has another status
+func (repo *Repository) Transition(ctx context.Context, id int64, from []string, fields map[string]interface{}) (bool, error) { //nolint:lll
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(TableName).
+		SetMap(fields).
+		Where(sq.Eq{"id": id, "status": from, "deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,75 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTransition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedUpdated bool
+		errorPresent    bool
+	}{
+		{
+			name: "Request has expected status",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status = (.+) WHERE deleted_at IS NULL AND id = (.+) AND status IN \\((.+),(.+)\\)", TableName)).
+					WithArgs("cancelled", 1, "original_in_review", "processing").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUpdated: true,
+		},
+		{
+			name: "Request has another status",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status", TableName)).
+					WithArgs("cancelled", 1, "original_in_review", "processing").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status", TableName)).
+					WithArgs("cancelled", 1, "original_in_review", "processing").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			updated, err := repo.Transition(context.Background(), 1, []string{"original_in_review", "processing"},
+				map[string]interface{}{"status": "cancelled"})
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if updated != testCase.expectedUpdated {
+				t.Errorf("Invalid updated, expected: %v, got: %v\n", testCase.expectedUpdated, updated)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
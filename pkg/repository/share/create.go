@@ -0,0 +1,54 @@
+package share
+
+import (
+	"context"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/jsonapi"
+)
+
+// Create stores share link with hashes of token and password. Returned
+// resource keeps plain Token of given resource, so it can be shown to user once
+func (repo *Repository) Create(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
+	link, ok := resource.(*Resource)
+	if !ok {
+		return nil, errors.New("invalid type assertion *share.Resource in share repository")
+	}
+
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	dto := &DTO{UserID: link.UserID, VideoID: link.VideoID}
+
+	if link.PasswordHash != "" {
+		dto.PasswordHash.String, dto.PasswordHash.Valid = link.PasswordHash, true
+	}
+
+	if link.MaxDownloads > 0 {
+		dto.MaxDownloads.Int64, dto.MaxDownloads.Valid = link.MaxDownloads, true
+	}
+
+	if link.ExpiresAt != nil {
+		dto.ExpiresAt.Time, dto.ExpiresAt.Valid = *link.ExpiresAt, true
+	}
+
+	err := sq.
+		Insert(TableName).
+		Columns("user_id", "video_id", "token_hash", "password_hash", "max_downloads", "expires_at").
+		Values(dto.UserID, dto.VideoID, link.Hash, dto.PasswordHash, dto.MaxDownloads, dto.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&dto.ID, &dto.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	created := dto.BuildResource()
+	created.Token = link.Token
+
+	return created, nil
+}
@@ -0,0 +1,88 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	expiresAt := time.Date(2021, time.October, 2, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		resource     *Resource
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Link with all limits",
+			resource: &Resource{UserID: 5, VideoID: 3, Token: "token", Hash: "hash", PasswordHash: "password_hash",
+				MaxDownloads: 2, ExpiresAt: &expiresAt},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+) RETURNING id, created_at", TableName)).
+					WithArgs(5, 3, "hash", "password_hash", 2, expiresAt).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+			},
+		},
+		{
+			name:     "Link without limits",
+			resource: &Resource{UserID: 5, VideoID: 3, Token: "token", Hash: "hash"},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", TableName)).
+					WithArgs(5, 3, "hash", nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+			},
+		},
+		{
+			name:     "With bad db connection",
+			resource: &Resource{UserID: 5, VideoID: 3, Token: "token", Hash: "hash"},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", TableName)).
+					WithArgs(5, 3, "hash", nil, nil, nil).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			created, err := repo.Create(context.Background(), testCase.resource)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil {
+				link := created.(*Resource)
+				if link.ID != 1 || link.Token != testCase.resource.Token {
+					t.Errorf("Invalid link, expected id 1 with token %s, got: %d %s\n",
+						testCase.resource.Token, link.ID, link.Token)
+				}
+
+				if link.Protected != (testCase.resource.PasswordHash != "") {
+					t.Errorf("Invalid password_protected, got: %v\n", link.Protected)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
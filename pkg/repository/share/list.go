@@ -0,0 +1,70 @@
+package share
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// List returns active share links of user, limited to video if
+// params.VideoID is set
+func (repo *Repository) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	links := make([]interface{}, 0, params.PageSize)
+
+	owner := sq.Eq{"user_id": params.RelationID}
+	if params.VideoID != 0 {
+		owner["video_id"] = params.VideoID
+	}
+
+	rows, err := sq.
+		Select("id", "user_id", "video_id", "password_hash", "max_downloads", "downloads",
+			"expires_at", "created_at").
+		From(TableName).
+		Where(sq.And{owner, active(time.Now())}).
+		OrderBy("created_at DESC").
+		Limit(params.PageSize).
+		Offset(params.PageNumber).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		dto := new(DTO)
+
+		err = rows.Scan(&dto.ID, &dto.UserID, &dto.VideoID, &dto.PasswordHash, &dto.MaxDownloads,
+			&dto.Downloads, &dto.ExpiresAt, &dto.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, dto.BuildResource())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// active limits query to links which are not revoked, not expired at now
+// and have downloads left
+func active(now time.Time) sq.Sqlizer {
+	return sq.And{
+		sq.Eq{"revoked_at": nil},
+		sq.Or{sq.Eq{"expires_at": nil}, sq.Gt{"expires_at": now}},
+		sq.Or{sq.Eq{"max_downloads": nil}, sq.Expr("downloads < max_downloads")},
+	}
+}
@@ -0,0 +1,89 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "video_id", "password_hash", "max_downloads", "downloads",
+		"expires_at", "created_at"}
+
+	cases := []struct {
+		name          string
+		params        *query.Params
+		mock          func()
+		expectedCount int
+		errorPresent  bool
+	}{
+		{
+			name:   "Should return active links of user",
+			params: &query.Params{RelationID: 5, PageSize: 10},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE \\(user_id = \\$1 AND \\(revoked_at IS NULL AND \\(expires_at IS NULL OR expires_at > \\$2\\) AND \\(max_downloads IS NULL OR downloads < max_downloads\\)\\)\\) ORDER BY created_at DESC LIMIT 10 OFFSET 0", TableName)).
+					WithArgs(5, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(2, 5, 3, "hash", 5, 1, createdAt, createdAt).
+						AddRow(1, 5, 4, nil, nil, 0, nil, createdAt))
+			},
+			expectedCount: 2,
+		},
+		{
+			name:   "Should return active links of video",
+			params: &query.Params{RelationID: 5, VideoID: 3, PageSize: 10},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE \\(user_id = \\$1 AND video_id = \\$2 AND", TableName)).
+					WithArgs(5, 3, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(2, 5, 3, "hash", 5, 1, createdAt, createdAt))
+			},
+			expectedCount: 1,
+		},
+		{
+			name:   "With bad db connection",
+			params: &query.Params{RelationID: 5, PageSize: 10},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs(5, sqlmock.AnyArg()).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			links, err := repo.List(context.Background(), testCase.params)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(links) != testCase.expectedCount {
+				t.Errorf("Invalid count of links, expected: %d, got: %d\n", testCase.expectedCount, len(links))
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
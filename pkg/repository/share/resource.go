@@ -0,0 +1,93 @@
+package share
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/jsonapi"
+)
+
+// TableName is name of table in db
+const TableName = "share_links"
+
+var _ jsonapi.Linkable = (*Resource)(nil)
+
+// Resource represent share_links table in db. Only hashes of token and
+// password are stored, Token is filled once after creation
+type Resource struct {
+	ID           int64 `jsonapi:"primary,share-links"`
+	UserID       int64
+	VideoID      int64  `jsonapi:"attr,video_id" validate:"required,min=1"`
+	Token        string `jsonapi:"attr,token,omitempty"`
+	Password     string `jsonapi:"attr,password,omitempty" validate:"omitempty,min=4,max=64"`
+	Protected    bool   `jsonapi:"attr,password_protected"`
+	MaxDownloads int64  `jsonapi:"attr,max_downloads,omitempty" validate:"omitempty,min=1"`
+	Downloads    int64  `jsonapi:"attr,downloads"`
+	Hash         string
+	PasswordHash string
+	// ServiceID and VideoExpiresAt describe file of shared video,
+	// they are filled only by RetrieveByHash
+	ServiceID      string
+	VideoExpiresAt *time.Time
+
+	ExpiresAt *time.Time `jsonapi:"attr,expires_at,iso8601,omitempty"`
+	CreatedAt *time.Time `jsonapi:"attr,created_at,iso8601,omitempty"`
+}
+
+// JSONAPILinks ...
+func (r *Resource) JSONAPILinks() *jsonapi.Links {
+	links := jsonapi.Links{
+		"self":  fmt.Sprintf("%s/api/v1/share-links/%d", os.Getenv("BASE_URL"), r.ID),
+		"video": fmt.Sprintf("%s/api/v1/videos/%d", os.Getenv("BASE_URL"), r.VideoID),
+	}
+
+	if r.Token != "" {
+		links["share"] = fmt.Sprintf("%s/share/%s", os.Getenv("BASE_URL"), r.Token)
+	}
+
+	return &links
+}
+
+// Expired returns true if link has expiration time in the past
+func (r *Resource) Expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !r.ExpiresAt.After(now)
+}
+
+// Exhausted returns true if all allowed downloads of link are used
+func (r *Resource) Exhausted() bool {
+	return r.MaxDownloads > 0 && r.Downloads >= r.MaxDownloads
+}
+
+// DTO is used for scanning nullable columns of share_links table
+type DTO struct {
+	ID           int64
+	UserID       int64
+	VideoID      int64
+	PasswordHash sql.NullString
+	MaxDownloads sql.NullInt64
+	Downloads    int64
+	ExpiresAt    sql.NullTime
+	CreatedAt    time.Time
+}
+
+// BuildResource converts DTO to Resource
+func (dto *DTO) BuildResource() *Resource {
+	res := &Resource{
+		ID:           dto.ID,
+		UserID:       dto.UserID,
+		VideoID:      dto.VideoID,
+		Protected:    dto.PasswordHash.Valid,
+		PasswordHash: dto.PasswordHash.String,
+		MaxDownloads: dto.MaxDownloads.Int64,
+		Downloads:    dto.Downloads,
+		CreatedAt:    &dto.CreatedAt,
+	}
+
+	if dto.ExpiresAt.Valid {
+		res.ExpiresAt = &dto.ExpiresAt.Time
+	}
+
+	return res
+}
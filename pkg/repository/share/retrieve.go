@@ -0,0 +1,57 @@
+package share
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// RetrieveByHash returns not revoked share link of not deleted video by hash
+// of token with file of video
+func (repo *Repository) RetrieveByHash(ctx context.Context, hash string) (*Resource, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	dto := new(DTO)
+	vid := new(video.DTO)
+
+	err := sq.
+		Select(fmt.Sprintf("%s.id", TableName),
+			fmt.Sprintf("%s.user_id", TableName),
+			fmt.Sprintf("%s.video_id", TableName),
+			fmt.Sprintf("%s.password_hash", TableName),
+			fmt.Sprintf("%s.max_downloads", TableName),
+			fmt.Sprintf("%s.downloads", TableName),
+			fmt.Sprintf("%s.expires_at", TableName),
+			fmt.Sprintf("%s.created_at", TableName),
+			fmt.Sprintf("%s.service_id", video.TableName),
+			fmt.Sprintf("%s.expires_at", video.TableName)).
+		From(TableName).
+		Join(fmt.Sprintf("%s ON %s.video_id = %s.id", video.TableName, TableName, video.TableName)).
+		Where(sq.Eq{
+			fmt.Sprintf("%s.token_hash", TableName):       hash,
+			fmt.Sprintf("%s.revoked_at", TableName):       nil,
+			fmt.Sprintf("%s.deleted_at", video.TableName): nil,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&dto.ID, &dto.UserID, &dto.VideoID, &dto.PasswordHash, &dto.MaxDownloads,
+			&dto.Downloads, &dto.ExpiresAt, &dto.CreatedAt, &vid.ServiceID, &vid.ExpiresAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := dto.BuildResource()
+	res.ServiceID = vid.ServiceID.String
+
+	if vid.ExpiresAt.Valid {
+		res.VideoExpiresAt = &vid.ExpiresAt.Time
+	}
+
+	return res, nil
+}
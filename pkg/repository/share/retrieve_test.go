@@ -0,0 +1,100 @@
+package share
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRetrieveByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "video_id", "password_hash", "max_downloads", "downloads",
+		"expires_at", "created_at", "service_id", "expires_at"}
+
+	cases := []struct {
+		name              string
+		mock              func()
+		expectedServiceID string
+		expectedProtected bool
+		expectedErr       error
+		errorPresent      bool
+	}{
+		{
+			name: "Should return link with file of video",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s JOIN videos ON %s.video_id = videos.id WHERE %s.revoked_at IS NULL AND %s.token_hash = (.+) AND videos.deleted_at IS NULL", TableName, TableName, TableName, TableName)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, 5, 3, "password_hash", 2, 1, nil, createdAt, "video.mp4", createdAt))
+			},
+			expectedServiceID: "video.mp4",
+			expectedProtected: true,
+		},
+		{
+			name: "Unknown hash",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr:  sql.ErrNoRows,
+			errorPresent: true,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs("hash").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			link, err := repo.RetrieveByHash(context.Background(), "hash")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if testCase.expectedErr != nil && !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil {
+				if link.ServiceID != testCase.expectedServiceID {
+					t.Errorf("Invalid service_id, expected: %s, got: %s\n", testCase.expectedServiceID, link.ServiceID)
+				}
+
+				if link.Protected != testCase.expectedProtected {
+					t.Errorf("Invalid password_protected, expected: %v, got: %v\n", testCase.expectedProtected, link.Protected)
+				}
+
+				if link.VideoExpiresAt == nil {
+					t.Errorf("Expiration time of video should be present\n")
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRevoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedRevoked bool
+		errorPresent    bool
+	}{
+		{
+			name: "Should revoke link",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at = (.+) WHERE id = (.+) AND revoked_at IS NULL AND user_id = (.+)", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1, 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedRevoked: true,
+		},
+		{
+			name: "Link of other user",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1, 5).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1, 5).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			revoked, err := repo.Revoke(context.Background(), 5, 1)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if revoked != testCase.expectedRevoked {
+				t.Errorf("Invalid revoked, expected: %v, got: %v\n", testCase.expectedRevoked, revoked)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
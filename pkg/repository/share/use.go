@@ -0,0 +1,35 @@
+package share
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// UseDownload counts download of link. Returns false if link was revoked,
+// expired at now or has no downloads left, so concurrent downloads can't
+// exceed max_downloads
+func (repo *Repository) UseDownload(ctx context.Context, id int64, now time.Time) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(TableName).
+		Set("downloads", sq.Expr("downloads + 1")).
+		Where(sq.And{sq.Eq{"id": id}, active(now)}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,77 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUseDownload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedUsed bool
+		errorPresent bool
+	}{
+		{
+			name: "Should count download",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads = downloads \\+ 1 WHERE \\(id = \\$1 AND \\(revoked_at IS NULL AND \\(expires_at IS NULL OR expires_at > \\$2\\) AND \\(max_downloads IS NULL OR downloads < max_downloads\\)\\)\\)", TableName)).
+					WithArgs(1, now).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUsed: true,
+		},
+		{
+			name: "Link without downloads left",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads", TableName)).
+					WithArgs(1, now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads", TableName)).
+					WithArgs(1, now).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			used, err := repo.UseDownload(context.Background(), 1, now)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if used != testCase.expectedUsed {
+				t.Errorf("Invalid used, expected: %v, got: %v\n", testCase.expectedUsed, used)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
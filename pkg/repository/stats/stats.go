@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/jsonapi"
+)
+
+// systemID is id of the only stats resource
+const systemID = "system"
+
+var _ jsonapi.Linkable = (*Resource)(nil)
+
+// Resource represent system wide statistics. Deleted records are not counted
+type Resource struct {
+	ID               string           `jsonapi:"primary,stats"`
+	RequestsByStatus map[string]int64 `jsonapi:"attr,requests_by_status"`
+	Users            int64            `jsonapi:"attr,users"`
+	DisabledUsers    int64            `jsonapi:"attr,disabled_users"`
+	StoredVideos     int64            `jsonapi:"attr,stored_videos"`
+	StoredBytes      int64            `jsonapi:"attr,stored_bytes"`
+}
+
+// JSONAPILinks ...
+func (r *Resource) JSONAPILinks() *jsonapi.Links {
+	return &jsonapi.Links{
+		"self": fmt.Sprintf("%s/api/v1/admin/stats", os.Getenv("BASE_URL")),
+	}
+}
+
+// Retrieve returns count of users, stored videos and requests by status
+func (repo *Repository) Retrieve(ctx context.Context) (*Resource, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res := &Resource{ID: systemID, RequestsByStatus: make(map[string]int64)}
+
+	err := sq.
+		Select().
+		Column(sq.Expr(fmt.Sprintf("(SELECT COUNT(*) FROM %s WHERE deleted_at IS NULL)", user.TableName))).
+		Column(sq.Expr(fmt.Sprintf("(SELECT COUNT(*) FROM %s WHERE deleted_at IS NULL AND disabled_at IS NOT NULL)",
+			user.TableName))).
+		Column(sq.Expr(fmt.Sprintf("(SELECT COUNT(*) FROM %s WHERE deleted_at IS NULL AND expired = FALSE)",
+			video.TableName))).
+		Column(sq.Expr(fmt.Sprintf("(SELECT COALESCE(SUM(size), 0) FROM %s WHERE deleted_at IS NULL AND expired = FALSE)",
+			video.TableName))).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&res.Users, &res.DisabledUsers, &res.StoredVideos, &res.StoredBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sq.
+		Select("status", "COUNT(*)").
+		From(request.TableName).
+		Where(sq.Eq{"deleted_at": nil}).
+		GroupBy("status").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			status string
+			count  int64
+		)
+
+		if err = rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+
+		res.RequestsByStatus[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
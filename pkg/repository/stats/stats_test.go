@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRetrieve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name           string
+		mock           func()
+		expectedFailed int64
+		expectedBytes  int64
+		errorPresent   bool
+	}{
+		{
+			name: "Should return stats",
+			mock: func() {
+				mock.ExpectQuery("SELECT \\(SELECT COUNT\\(\\*\\) FROM users (.+)\\) FROM videos (.+)\\)").
+					WillReturnRows(sqlmock.NewRows([]string{"users", "disabled_users", "stored_videos", "stored_bytes"}).
+						AddRow(10, 1, 20, 3000))
+				mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) FROM requests WHERE deleted_at IS NULL GROUP BY status").
+					WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+						AddRow("failed", 2).
+						AddRow("success", 7))
+			},
+			expectedFailed: 2,
+			expectedBytes:  3000,
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			res, err := repo.Retrieve(context.Background())
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil {
+				if res.RequestsByStatus["failed"] != testCase.expectedFailed {
+					t.Errorf("Invalid failed requests, expected: %d, got: %d\n", testCase.expectedFailed,
+						res.RequestsByStatus["failed"])
+				}
+
+				if res.StoredBytes != testCase.expectedBytes {
+					t.Errorf("Invalid stored bytes, expected: %d, got: %d\n", testCase.expectedBytes, res.StoredBytes)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// CreateRefresh stores hash of refresh token of user
+func (repo *Repository) CreateRefresh(ctx context.Context, userID int64, hash string, expiresAt time.Time) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Insert(RefreshTableName).
+		Columns("user_id", "token_hash", "expires_at").
+		Values(userID, hash, expiresAt).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
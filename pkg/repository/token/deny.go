@@ -0,0 +1,46 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Deny adds access token with jti to denylist until it expires
+func (repo *Repository) Deny(ctx context.Context, jti string, expiresAt time.Time) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Insert(RevokedTableName).
+		Columns("jti", "expires_at").
+		Values(jti, expiresAt).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// Denied returns true if access token with jti is in denylist or its user is
+// disabled or deleted, so access tokens stop working together with API keys
+func (repo *Repository) Denied(ctx context.Context, jti string, userID int64) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var revoked, inactive bool
+	err := sq.
+		Select().
+		Column(sq.Expr(fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE jti = ?)", RevokedTableName), jti)).
+		Column(sq.Expr(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s "+
+			"WHERE id = ? AND disabled_at IS NULL AND deleted_at IS NULL)", usersTableName), userID)).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&revoked, &inactive)
+
+	return revoked || inactive, err
+}
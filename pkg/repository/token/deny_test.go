@@ -0,0 +1,87 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name           string
+		mock           func()
+		expectedDenied bool
+		errorPresent   bool
+	}{
+		{
+			name: "Token is denied",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT EXISTS (.+) FROM %s WHERE jti = \\$1(.+) "+
+					"NOT EXISTS (.+) FROM users WHERE id = \\$2 AND disabled_at IS NULL AND deleted_at IS NULL", RevokedTableName)).
+					WithArgs("jti", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"revoked", "inactive"}).AddRow(true, false))
+			},
+			expectedDenied: true,
+		},
+		{
+			name: "User is disabled or deleted",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT EXISTS (.+) FROM %s WHERE jti = \\$1(.+) "+
+					"NOT EXISTS (.+) FROM users WHERE id = \\$2 AND disabled_at IS NULL AND deleted_at IS NULL", RevokedTableName)).
+					WithArgs("jti", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"revoked", "inactive"}).AddRow(false, true))
+			},
+			expectedDenied: true,
+		},
+		{
+			name: "Token is not denied",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT EXISTS (.+) FROM %s WHERE jti = \\$1(.+) "+
+					"NOT EXISTS (.+) FROM users WHERE id = \\$2 AND disabled_at IS NULL AND deleted_at IS NULL", RevokedTableName)).
+					WithArgs("jti", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"revoked", "inactive"}).AddRow(false, false))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT EXISTS (.+) FROM %s WHERE jti = \\$1(.+) "+
+					"NOT EXISTS (.+) FROM users WHERE id = \\$2 AND disabled_at IS NULL AND deleted_at IS NULL", RevokedTableName)).
+					WithArgs("jti", 1).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			denied, err := repo.Denied(context.Background(), "jti", 1)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if denied != testCase.expectedDenied {
+				t.Errorf("Invalid denied, expected: %v, got: %v\n", testCase.expectedDenied, denied)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
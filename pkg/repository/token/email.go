@@ -0,0 +1,91 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// CreateEmail stores hash of token sent to user by email. Unused tokens of
+// user with the same purpose are invalidated, so only the last sent token works
+func (repo *Repository) CreateEmail(ctx context.Context, userID int64, purpose, hash string, expiresAt time.Time) error { //nolint:lll
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	tx, err := repo.db.BeginTx(c, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = sq.
+		Update(EmailTableName).
+		Set("used_at", time.Now()).
+		Where(sq.Eq{"user_id": userID, "purpose": purpose, "used_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(c)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = sq.
+		Insert(EmailTableName).
+		Columns("user_id", "purpose", "token_hash", "expires_at").
+		Values(userID, purpose, hash, expiresAt).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(c)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseEmail marks not expired and unused token with purpose as used and returns
+// id of its user. Returns sql.ErrNoRows if token is unknown, expired or already used
+func (repo *Repository) UseEmail(ctx context.Context, purpose, hash string) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	now := time.Now()
+
+	var userID int64
+	err := sq.
+		Update(EmailTableName).
+		Set("used_at", now).
+		Where(sq.Eq{"token_hash": hash, "purpose": purpose, "used_at": nil}).
+		Where(sq.Gt{"expires_at": now}).
+		Suffix("RETURNING user_id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&userID)
+
+	return userID, err
+}
+
+// EmailOwner returns id of user of not expired and unused token with purpose
+// without using it. Returns sql.ErrNoRows if token is unknown, expired or already used
+func (repo *Repository) EmailOwner(ctx context.Context, purpose, hash string) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var userID int64
+	err := sq.
+		Select("user_id").
+		From(EmailTableName).
+		Where(sq.Eq{"token_hash": hash, "purpose": purpose, "used_at": nil}).
+		Where(sq.Gt{"expires_at": time.Now()}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&userID)
+
+	return userID, err
+}
@@ -0,0 +1,176 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	cases := []struct {
+		name         string
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Should invalidate previous tokens and store new one",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at = (.+) WHERE purpose = (.+) AND used_at IS NULL AND user_id = (.+)", EmailTableName)).
+					WithArgs(sqlmock.AnyArg(), PurposeResetPassword, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", EmailTableName)).
+					WithArgs(1, PurposeResetPassword, "hash", expiresAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at", EmailTableName)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", EmailTableName)).
+					WillReturnError(errors.New("mock error"))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.CreateEmail(context.Background(), 1, PurposeResetPassword, "hash", expiresAt)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestUseEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name           string
+		mock           func()
+		expectedUserID int64
+		expectedErr    error
+	}{
+		{
+			name: "Should use token",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET used_at = (.+) WHERE purpose = (.+) AND token_hash = (.+) AND used_at IS NULL AND expires_at > (.+) RETURNING user_id", EmailTableName)).
+					WithArgs(sqlmock.AnyArg(), PurposeVerifyEmail, "hash", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(5))
+			},
+			expectedUserID: 5,
+		},
+		{
+			name: "Used or expired token",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET used_at", EmailTableName)).
+					WithArgs(sqlmock.AnyArg(), PurposeVerifyEmail, "hash", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			userID, err := repo.UseEmail(context.Background(), PurposeVerifyEmail, "hash")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if userID != testCase.expectedUserID {
+				t.Errorf("Invalid user id, expected: %d, got: %d\n", testCase.expectedUserID, userID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestEmailOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name           string
+		mock           func()
+		expectedUserID int64
+		expectedErr    error
+	}{
+		{
+			name: "Should return user of token",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT user_id FROM %s WHERE purpose = (.+) AND token_hash = (.+) AND used_at IS NULL AND expires_at > (.+)", EmailTableName)).
+					WithArgs(PurposeResetPassword, "hash", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(5))
+			},
+			expectedUserID: 5,
+		},
+		{
+			name: "Used or expired token",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT user_id FROM %s", EmailTableName)).
+					WithArgs(PurposeResetPassword, "hash", sqlmock.AnyArg()).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			userID, err := repo.EmailOwner(context.Background(), PurposeResetPassword, "hash")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if userID != testCase.expectedUserID {
+				t.Errorf("Invalid user id, expected: %d, got: %d\n", testCase.expectedUserID, userID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
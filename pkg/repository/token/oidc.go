@@ -0,0 +1,48 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// CreateOIDCState stores state of OpenID Connect login
+func (repo *Repository) CreateOIDCState(ctx context.Context, state *OIDCState) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Insert(OIDCStateTableName).
+		Columns("state_hash", "nonce", "code_verifier", "expires_at").
+		Values(state.Hash, state.Nonce, state.Verifier, state.ExpiresAt).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// UseOIDCState removes not expired state with hash and returns it, so every
+// state is used only once. Returns sql.ErrNoRows if state is unknown or expired
+func (repo *Repository) UseOIDCState(ctx context.Context, hash string) (*OIDCState, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	state := &OIDCState{Hash: hash}
+	err := sq.
+		Delete(OIDCStateTableName).
+		Where(sq.Eq{"state_hash": hash}).
+		Where(sq.Gt{"expires_at": time.Now()}).
+		Suffix("RETURNING nonce, code_verifier, expires_at").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&state.Nonce, &state.Verifier, &state.ExpiresAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
@@ -0,0 +1,92 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateOIDCState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	expiresAt := time.Now().Add(10 * time.Minute)
+
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s \\(state_hash,nonce,code_verifier,expires_at\\)", OIDCStateTableName)).
+		WithArgs("hash", "nonce", "verifier", expiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := NewRepository(db)
+	err = repo.CreateOIDCState(context.Background(), &OIDCState{Hash: "hash", Nonce: "nonce", Verifier: "verifier",
+		ExpiresAt: expiresAt})
+
+	if err != nil {
+		t.Errorf("Unexpected error: %s\n", err.Error())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
+
+func TestUseOIDCState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name          string
+		mock          func()
+		expectedNonce string
+		errorPresent  bool
+	}{
+		{
+			name: "Valid state",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("DELETE FROM %s WHERE state_hash = \\$1 AND expires_at > \\$2 RETURNING nonce, code_verifier, expires_at", OIDCStateTableName)).
+					WithArgs("hash", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}).
+						AddRow("nonce", "verifier", time.Now().Add(time.Minute)))
+			},
+			expectedNonce: "nonce",
+		},
+		{
+			name: "Used or expired state",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("DELETE FROM %s", OIDCStateTableName)).
+					WithArgs("hash", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			state, err := repo.UseOIDCState(context.Background(), "hash")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil && state.Nonce != testCase.expectedNonce {
+				t.Errorf("Invalid nonce, expected: %s, got: %s\n", testCase.expectedNonce, state.Nonce)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
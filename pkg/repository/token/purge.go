@@ -0,0 +1,38 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Purge removes refresh tokens, denylist entries, email tokens and OpenID Connect states which expired before
+func (repo *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var total int64
+
+	for _, table := range []string{RefreshTableName, RevokedTableName, EmailTableName, OIDCStateTableName} {
+		res, err := sq.
+			Delete(table).
+			Where(sq.Lt{"expires_at": before}).
+			PlaceholderFormat(sq.Dollar).
+			RunWith(repo.db).
+			ExecContext(c)
+
+		if err != nil {
+			return total, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+
+		total += affected
+	}
+
+	return total, nil
+}
@@ -0,0 +1,80 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ReplaceRecovery removes recovery codes of user and stores hashes of new ones.
+// Empty hashes only remove codes
+func (repo *Repository) ReplaceRecovery(ctx context.Context, userID int64, hashes []string) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	tx, err := repo.db.BeginTx(c, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = sq.
+		Delete(RecoveryTableName).
+		Where(sq.Eq{"user_id": userID}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(c)
+
+	if err != nil {
+		return err
+	}
+
+	if len(hashes) > 0 {
+		insert := sq.
+			Insert(RecoveryTableName).
+			Columns("user_id", "code_hash")
+
+		for _, hash := range hashes {
+			insert = insert.Values(userID, hash)
+		}
+
+		_, err = insert.
+			PlaceholderFormat(sq.Dollar).
+			RunWith(tx).
+			ExecContext(c)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseRecovery marks unused recovery code of user as used. Returns false if
+// code is unknown or was already used
+func (repo *Repository) UseRecovery(ctx context.Context, userID int64, hash string) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(RecoveryTableName).
+		Set("used_at", time.Now()).
+		Where(sq.Eq{"user_id": userID, "code_hash": hash, "used_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
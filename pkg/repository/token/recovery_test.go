@@ -0,0 +1,131 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReplaceRecovery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		hashes       []string
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name:   "Should replace codes",
+			hashes: []string{"hash1", "hash2"},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE user_id = \\$1", RecoveryTableName)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 10))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s \\(user_id,code_hash\\) VALUES \\(\\$1,\\$2\\),\\(\\$3,\\$4\\)", RecoveryTableName)).
+					WithArgs(1, "hash1", 1, "hash2").
+					WillReturnResult(sqlmock.NewResult(2, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Should only remove codes",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", RecoveryTableName)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 10))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "With bad db connection",
+			hashes: []string{"hash1"},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", RecoveryTableName)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", RecoveryTableName)).
+					WillReturnError(errors.New("mock error"))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.ReplaceRecovery(context.Background(), 1, testCase.hashes)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestUseRecovery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedUsed bool
+	}{
+		{
+			name: "Unused code",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at = \\$1 WHERE code_hash = \\$2 AND used_at IS NULL AND user_id = \\$3", RecoveryTableName)).
+					WithArgs(sqlmock.AnyArg(), "hash", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUsed: true,
+		},
+		{
+			name: "Used or unknown code",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at", RecoveryTableName)).
+					WithArgs(sqlmock.AnyArg(), "hash", 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			used, err := repo.UseRecovery(context.Background(), 1, "hash")
+			if err != nil {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if used != testCase.expectedUsed {
+				t.Errorf("Invalid used, expected: %v, got: %v\n", testCase.expectedUsed, used)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// Package token represent db connection to storing refresh tokens, revoked access tokens
+// and tokens sent by email
+package token
+
+import (
+	"database/sql"
+	"time"
+)
+
+const queryTimeOut = 5 * time.Second
+
+// Repository represent db connection for refresh_tokens, revoked_tokens and email_tokens tables
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository initialize Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
@@ -0,0 +1,52 @@
+package token
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// RefreshTableName is name of refresh_tokens table in db
+	RefreshTableName = "refresh_tokens"
+	// RevokedTableName is name of revoked_tokens table in db
+	RevokedTableName = "revoked_tokens"
+	// EmailTableName is name of email_tokens table in db
+	EmailTableName = "email_tokens"
+	// RecoveryTableName is name of recovery_codes table in db
+	RecoveryTableName = "recovery_codes"
+	// OIDCStateTableName is name of oidc_states table in db
+	OIDCStateTableName = "oidc_states"
+
+	usersTableName = "users"
+)
+
+// Purposes of single-use tokens stored in email_tokens. MFA challenge isn't
+// sent by email, it is returned by sign in and exchanged for access token
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+	PurposeMFAChallenge  = "mfa_challenge"
+)
+
+// Refresh represent refresh_tokens table in db. Only hash of token is stored
+type Refresh struct {
+	ID        int64
+	UserID    int64
+	Hash      string
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+}
+
+// Revoked returns true if token was already used or revoked
+func (r *Refresh) Revoked() bool {
+	return r.RevokedAt.Valid
+}
+
+// OIDCState represent oidc_states table in db. Only hash of state is stored,
+// nonce is checked in ID token and verifier is sent with authorization code (PKCE)
+type OIDCState struct {
+	Hash      string
+	Nonce     string
+	Verifier  string
+	ExpiresAt time.Time
+}
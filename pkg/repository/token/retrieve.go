@@ -0,0 +1,29 @@
+package token
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// RetrieveRefresh returns refresh token by its hash
+func (repo *Repository) RetrieveRefresh(ctx context.Context, hash string) (*Refresh, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	r := new(Refresh)
+	err := sq.
+		Select("id", "user_id", "token_hash", "expires_at", "revoked_at").
+		From(RefreshTableName).
+		Where(sq.Eq{"token_hash": hash}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&r.ID, &r.UserID, &r.Hash, &r.ExpiresAt, &r.RevokedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,88 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRetrieveRefresh(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	expiresAt := time.Date(2021, time.November, 1, 12, 0, 0, 0, time.UTC)
+	revokedAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedUserID  int64
+		expectedRevoked bool
+		errorPresent    bool
+	}{
+		{
+			name: "Active token",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, token_hash, expires_at, revoked_at FROM %s", RefreshTableName)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "revoked_at"}).
+						AddRow(1, 5, "hash", expiresAt, nil))
+			},
+			expectedUserID: 5,
+		},
+		{
+			name: "Revoked token",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, token_hash, expires_at, revoked_at FROM %s", RefreshTableName)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "revoked_at"}).
+						AddRow(1, 5, "hash", expiresAt, revokedAt))
+			},
+			expectedUserID:  5,
+			expectedRevoked: true,
+		},
+		{
+			name: "Token doesn't exist",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, token_hash, expires_at, revoked_at FROM %s", RefreshTableName)).
+					WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "revoked_at"}))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			r, err := repo.RetrieveRefresh(context.Background(), "hash")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil {
+				if r.UserID != testCase.expectedUserID {
+					t.Errorf("Invalid user id, expected: %d, got: %d\n", testCase.expectedUserID, r.UserID)
+				}
+
+				if r.Revoked() != testCase.expectedRevoked {
+					t.Errorf("Invalid revoked, expected: %v, got: %v\n", testCase.expectedRevoked, r.Revoked())
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// RevokeRefresh marks refresh token as revoked. Returns false if token
+// was already revoked, e.g. by concurrent refresh with the same token
+func (repo *Repository) RevokeRefresh(ctx context.Context, id int64) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(RefreshTableName).
+		Set("revoked_at", time.Now()).
+		Where(sq.Eq{"id": id, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// RevokeUserRefresh marks all active refresh tokens of user as revoked
+func (repo *Repository) RevokeUserRefresh(ctx context.Context, userID int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(RefreshTableName).
+		Set("revoked_at", time.Now()).
+		Where(sq.Eq{"user_id": userID, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
@@ -34,9 +34,9 @@ func TestCreate(t *testing.T) {
 					WithArgs("check@check.com", "qweqweqweqwe").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s WHERE", TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s WHERE", TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("1", "check@check.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow("1", "check@check.com", "user"))
 			},
 			expectedID:    1,
 			expectedEmail: "check@check.com",
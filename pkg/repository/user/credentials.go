@@ -0,0 +1,63 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Credentials function return id, password hash, role, disabled, email verification and two-factor
+// enrollment time of user with email
+func (repo *Repository) Credentials(ctx context.Context, email string) (*Credentials, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	cred := new(Credentials)
+	err := sq.
+		Select("id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at").
+		From(TableName).
+		Where(sq.Eq{"email": email}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&cred.ID, &cred.PasswordHash, &cred.Role, &cred.DisabledAt, &cred.VerifiedAt, &cred.MFAEnabledAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// UpdatePasswordHash replaces password hash of user
+func (repo *Repository) UpdatePasswordHash(ctx context.Context, id int64, hash string) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(TableName).
+		Set("password_hash", hash).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// VerifyEmail marks email of user as verified
+func (repo *Repository) VerifyEmail(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(TableName).
+		Set("email_verified_at", time.Now()).
+		Where(sq.Eq{"id": id, "email_verified_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
@@ -0,0 +1,184 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCredentials(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		email        string
+		mock         func()
+		expectedId   int64
+		expectedHash string
+		expectedRole string
+		errorPresent bool
+	}{
+		{
+			name:  "User exists",
+			email: "check@check.com",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).AddRow(1, "qweqweqweqwe", "admin", nil, time.Now(), nil))
+			},
+			expectedId:   1,
+			expectedHash: "qweqweqweqwe",
+			expectedRole: "admin",
+			errorPresent: false,
+		},
+		{
+			name:  "User doesn't exists",
+			email: "check@check.com",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}))
+			},
+			expectedId:   0,
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			cred, err := repo.Credentials(context.Background(), testCase.email)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error, error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if cred == nil {
+				cred = new(Credentials)
+			}
+
+			if cred.ID != testCase.expectedId {
+				t.Errorf("Invalid id, expected: %d, got: %d\n", testCase.expectedId, cred.ID)
+			}
+
+			if cred.PasswordHash != testCase.expectedHash {
+				t.Errorf("Invalid hash, expected: %s, got: %s\n", testCase.expectedHash, cred.PasswordHash)
+			}
+
+			if cred.Role != testCase.expectedRole {
+				t.Errorf("Invalid role, expected: %s, got: %s\n", testCase.expectedRole, cred.Role)
+			}
+		})
+	}
+}
+
+func TestUpdatePasswordHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Should update hash",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password_hash", TableName)).
+					WithArgs("new_hash", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Invalid db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password_hash", TableName)).
+					WithArgs("new_hash", 1).
+					WillReturnError(errors.New("some error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.UpdatePasswordHash(context.Background(), 1, "new_hash")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error, error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Should mark email as verified",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET email_verified_at = (.+) WHERE email_verified_at IS NULL AND id = (.+)", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Invalid db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET email_verified_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnError(errors.New("some error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.VerifyEmail(context.Background(), 1)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error, error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Delete marks user, its requests and videos as deleted. User can't sign in
+// anymore, videos are removed from cloud after the grace period
+func (repo *Repository) Delete(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	tx, err := repo.db.BeginTx(c, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	now := time.Now()
+
+	var userID int64
+	err = sq.
+		Update(TableName).
+		Set("deleted_at", now).
+		Set("disabled_at", sq.Expr("COALESCE(disabled_at, ?)", now)).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(c).
+		Scan(&userID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{request.TableName, video.TableName} {
+		_, err = sq.
+			Update(table).
+			Set("deleted_at", now).
+			Where(sq.Eq{"user_id": id, "deleted_at": nil}).
+			PlaceholderFormat(sq.Dollar).
+			RunWith(tx).
+			ExecContext(c)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "User does not exist",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at = (.+), disabled_at = COALESCE\\(disabled_at, (.+)\\)", TableName)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+		{
+			name: "Should delete user with requests and videos",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("UPDATE requests SET deleted_at = (.+) WHERE deleted_at IS NULL AND user_id = (.+)").
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("UPDATE videos SET deleted_at = (.+) WHERE deleted_at IS NULL AND user_id = (.+)").
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("UPDATE requests SET deleted_at").
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnError(errors.New("mock error"))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.Delete(context.Background(), 1)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
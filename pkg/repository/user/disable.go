@@ -0,0 +1,38 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// SetDisabled disables or enables not deleted user. Returns false if user doesn't exist
+func (repo *Repository) SetDisabled(ctx context.Context, id int64, disabled bool) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var disabledAt interface{}
+	if disabled {
+		disabledAt = time.Now()
+	}
+
+	res, err := sq.
+		Update(TableName).
+		Set("disabled_at", disabledAt).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
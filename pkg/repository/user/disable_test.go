@@ -0,0 +1,71 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSetDisabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name            string
+		disabled        bool
+		mock            func()
+		expectedUpdated bool
+	}{
+		{
+			name:     "Disable user",
+			disabled: true,
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET disabled_at = (.+) WHERE deleted_at IS NULL AND id = (.+)", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUpdated: true,
+		},
+		{
+			name: "Enable user",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET disabled_at = (.+) WHERE deleted_at IS NULL AND id = (.+)", TableName)).
+					WithArgs(nil, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUpdated: true,
+		},
+		{
+			name:     "User doesn't exist",
+			disabled: true,
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET disabled_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			updated, err := repo.SetDisabled(context.Background(), 1, testCase.disabled)
+			if err != nil {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if updated != testCase.expectedUpdated {
+				t.Errorf("Invalid updated, expected: %v, got: %v\n", testCase.expectedUpdated, updated)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
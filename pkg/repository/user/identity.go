@@ -0,0 +1,44 @@
+package user
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// IdentityEmail returns email of user linked with subject of external identity
+// provider. Returns sql.ErrNoRows if subject isn't linked
+func (repo *Repository) IdentityEmail(ctx context.Context, issuer, subject string) (string, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var email string
+	err := sq.
+		Select(TableName + ".email").
+		From(IdentityTableName).
+		Join(TableName + " ON " + TableName + ".id = " + IdentityTableName + ".user_id").
+		Where(sq.Eq{IdentityTableName + ".issuer": issuer, IdentityTableName + ".subject": subject}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&email)
+
+	return email, err
+}
+
+// LinkIdentity links subject of external identity provider with user
+func (repo *Repository) LinkIdentity(ctx context.Context, id int64, issuer, subject string) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Insert(IdentityTableName).
+		Columns("user_id", "issuer", "subject").
+		Values(id, issuer, subject).
+		Suffix("ON CONFLICT (issuer, subject) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
@@ -0,0 +1,86 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestIdentityEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name          string
+		mock          func()
+		expectedEmail string
+		errorPresent  bool
+	}{
+		{
+			name: "Linked subject",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s JOIN users ON users.id = %s.user_id WHERE %s.issuer = \\$1 AND %s.subject = \\$2",
+					IdentityTableName, IdentityTableName, IdentityTableName, IdentityTableName)).
+					WithArgs("https://idp", "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("check@check.com"))
+			},
+			expectedEmail: "check@check.com",
+		},
+		{
+			name: "Unknown subject",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", IdentityTableName)).
+					WithArgs("https://idp", "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			email, err := repo.IdentityEmail(context.Background(), "https://idp", "sub")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if email != testCase.expectedEmail {
+				t.Errorf("Invalid email, expected: %s, got: %s\n", testCase.expectedEmail, email)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s \\(user_id,issuer,subject\\) VALUES \\(\\$1,\\$2,\\$3\\) ON CONFLICT \\(issuer, subject\\) DO NOTHING", IdentityTableName)).
+		WithArgs(1, "https://idp", "sub").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := NewRepository(db)
+	if err := repo.LinkIdentity(context.Background(), 1, "https://idp", "sub"); err != nil {
+		t.Errorf("Unexpected error: %s\n", err.Error())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Quota represent user specific quota settings. Invalid value
+// means global settings are used
+type Quota struct {
+	StorageBytes   sql.NullInt64
+	MaxFileSize    sql.NullInt64
+	ActiveRequests sql.NullInt64
+	MonthlyMinutes sql.NullInt64
+}
+
+// Usage represent resources consumed by user
+type Usage struct {
+	StoredBytes    int64
+	ActiveRequests int64
+	MonthlyMinutes float64
+}
+
+// Quota returns quota settings of user
+func (repo *Repository) Quota(ctx context.Context, id int64) (*Quota, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	q := new(Quota)
+	err := sq.
+		Select("quota_storage_bytes", "quota_max_file_size", "quota_active_requests", "quota_monthly_minutes").
+		From(TableName).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&q.StorageBytes, &q.MaxFileSize, &q.ActiveRequests, &q.MonthlyMinutes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Usage returns stored bytes, count of requests in progress and minutes of
+// video processed since given time. Deleted requests are counted in processed
+// minutes because their videos were already processed. Duration of converted
+// video is used when duration of original video isn't known
+func (repo *Repository) Usage(ctx context.Context, id int64, since time.Time) (*Usage, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	u := new(Usage)
+	err := sq.
+		Select().
+		Column(sq.Expr("(SELECT COALESCE(SUM(size), 0) FROM videos "+
+			"WHERE user_id = ? AND deleted_at IS NULL AND expired = FALSE)", id)).
+		Column(sq.Expr("(SELECT COUNT(*) FROM requests "+
+			"WHERE user_id = ? AND deleted_at IS NULL AND status NOT IN ('failed', 'success', 'cancelled', 'quarantined'))", id)).
+		Column(sq.Expr("(SELECT COALESCE(SUM(COALESCE(originals.duration, converted.duration)), 0) / 60 FROM requests "+
+			"INNER JOIN videos originals ON requests.original_file_id = originals.id "+
+			"LEFT JOIN videos converted ON requests.converted_file_id = converted.id "+
+			"WHERE requests.user_id = ? AND requests.created_at >= ? AND requests.status != 'failed')", id, since)).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&u.StoredBytes, &u.ActiveRequests, &u.MonthlyMinutes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
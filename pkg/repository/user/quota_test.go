@@ -0,0 +1,142 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestQuota(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		id           int64
+		mock         func()
+		expected     *Quota
+		errorPresent bool
+	}{
+		{
+			name: "Should return user quota",
+			id:   1,
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"quota_storage_bytes", "quota_max_file_size",
+					"quota_active_requests", "quota_monthly_minutes"}).
+					AddRow(1024, nil, 2, nil)
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE", TableName)).
+					WithArgs(1).
+					WillReturnRows(rows)
+			},
+			expected: &Quota{
+				StorageBytes:   sql.NullInt64{Int64: 1024, Valid: true},
+				ActiveRequests: sql.NullInt64{Int64: 2, Valid: true},
+			},
+		},
+		{
+			name: "Should return error",
+			id:   2,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE", TableName)).
+					WithArgs(2).
+					WillReturnError(errors.New("some error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			q, err := repo.Quota(context.Background(), testCase.id)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if !testCase.errorPresent && *q != *testCase.expected {
+				t.Errorf("Invalid quota, expected: %v, got: %v\n", *testCase.expected, *q)
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	since := time.Date(2021, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		id           int64
+		mock         func()
+		expected     *Usage
+		errorPresent bool
+	}{
+		{
+			name: "Should return user usage",
+			id:   1,
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"stored_bytes", "active_requests", "monthly_minutes"}).
+					AddRow(2048, 1, 12.5)
+				mock.ExpectQuery("SELECT (.+) FROM videos (.+) FROM requests (.+)COALESCE\\(originals.duration, converted.duration\\)(.+) LEFT JOIN videos converted").
+					WithArgs(1, 1, 1, since).
+					WillReturnRows(rows)
+			},
+			expected: &Usage{StoredBytes: 2048, ActiveRequests: 1, MonthlyMinutes: 12.5},
+		},
+		{
+			name: "Should return error",
+			id:   2,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM videos (.+) FROM requests (.+)COALESCE\\(originals.duration, converted.duration\\)(.+) LEFT JOIN videos converted").
+					WithArgs(2, 2, 2, since).
+					WillReturnError(errors.New("some error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			u, err := repo.Usage(context.Background(), testCase.id, since)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if !testCase.errorPresent && *u != *testCase.expected {
+				t.Errorf("Invalid usage, expected: %v, got: %v\n", *testCase.expected, *u)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"time"
@@ -8,24 +9,91 @@ import (
 	"github.com/google/jsonapi"
 )
 
-// TableName is name of users table in db
-const TableName = "users"
+const (
+	// TableName is name of users table in db
+	TableName = "users"
+	// IdentityTableName is name of user_identities table in db
+	IdentityTableName = "user_identities"
+)
 
-var _ jsonapi.Linkable = (*Resource)(nil)
+var (
+	_ jsonapi.Linkable = (*Resource)(nil)
+	_ jsonapi.Linkable = (*TOTP)(nil)
+)
 
 // Resource represent users table in db
 type Resource struct {
 	ID                   int64  `jsonapi:"primary,users" db:"id"`
-	Email                string `jsonapi:"attr,email" db:"email" validate:"required,email,min=6,max=32"`
-	Password             string `jsonapi:"attr,password,omitempty" validate:"required,min=6,max=250"`
-	PasswordConfirmation string `jsonapi:"attr,password_confirmation,omitempty" validate:"required,min=6,max=250,eqfield=Password"` //nolint:lll
+	Email                string `jsonapi:"attr,email" db:"email" validate:"required,email,max=254"`
+	Password             string `jsonapi:"attr,password,omitempty" validate:"required,max=250"`
+	PasswordConfirmation string `jsonapi:"attr,password_confirmation,omitempty" validate:"required,max=250,eqfield=Password"`
 	Token                string `jsonapi:"attr,token,omitempty"`
+	RefreshToken         string `jsonapi:"attr,refresh_token,omitempty"`
+	Role                 string `jsonapi:"attr,role,omitempty"`
+	MFAToken             string `jsonapi:"attr,mfa_token,omitempty"`
+	Code                 string `jsonapi:"attr,code,omitempty"`
 	CreatedAt            time.Time
 }
 
+// Credentials are used for signing in user
+type Credentials struct {
+	ID           int64
+	PasswordHash string
+	Role         string
+	DisabledAt   sql.NullTime
+	VerifiedAt   sql.NullTime
+	MFAEnabledAt sql.NullTime
+}
+
+// Disabled returns true if user was disabled or deleted by admin
+func (c *Credentials) Disabled() bool {
+	return c.DisabledAt.Valid
+}
+
+// Verified returns true if user confirmed ownership of email
+func (c *Credentials) Verified() bool {
+	return c.VerifiedAt.Valid
+}
+
+// MFAEnabled returns true if user confirmed TOTP enrollment, sign in requires a code then
+func (c *Credentials) MFAEnabled() bool {
+	return c.MFAEnabledAt.Valid
+}
+
+// TOTP represent two-factor enrollment of user. Secret and URI are returned
+// only on enrollment, recovery codes only when they are generated
+type TOTP struct {
+	ID            int64    `jsonapi:"primary,totp"`
+	Secret        string   `jsonapi:"attr,secret,omitempty"`
+	URI           string   `jsonapi:"attr,uri,omitempty"`
+	Code          string   `jsonapi:"attr,code,omitempty"`
+	RecoveryCodes []string `jsonapi:"attr,recovery_codes,omitempty"`
+}
+
+// TOTPState is two-factor state of user stored in users table
+type TOTPState struct {
+	Email     string
+	Role      string
+	Secret    sql.NullString
+	EnabledAt sql.NullTime
+	LastStep  sql.NullInt64
+}
+
+// Enabled returns true if enrollment was confirmed
+func (s *TOTPState) Enabled() bool {
+	return s.EnabledAt.Valid
+}
+
 // JSONAPILinks ...
 func (r *Resource) JSONAPILinks() *jsonapi.Links {
 	return &jsonapi.Links{
 		"self": fmt.Sprintf("%s/api/v1/auth/me", os.Getenv("BASE_URL")),
 	}
 }
+
+// JSONAPILinks ...
+func (t *TOTP) JSONAPILinks() *jsonapi.Links {
+	return &jsonapi.Links{
+		"self": fmt.Sprintf("%s/api/v1/auth/mfa/totp", os.Getenv("BASE_URL")),
+	}
+}
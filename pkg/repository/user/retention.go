@@ -0,0 +1,37 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Retention represent user specific retention settings. Invalid value
+// means global settings are used
+type Retention struct {
+	OriginalDays  sql.NullInt32
+	ConvertedDays sql.NullInt32
+}
+
+// Retention returns retention settings of user
+func (repo *Repository) Retention(ctx context.Context, id int64) (*Retention, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	ret := new(Retention)
+	err := sq.
+		Select("original_retention_days", "converted_retention_days").
+		From(TableName).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&ret.OriginalDays, &ret.ConvertedDays)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -14,14 +14,14 @@ func (repo *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkabl
 
 	user := new(Resource)
 	err := sq.
-		Select("id", "email").
+		Select("id", "email", "role").
 		From(TableName).
 		Where(sq.Eq{"id": id}).
 		Limit(1).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(repo.db).
 		QueryRowContext(c).
-		Scan(&user.ID, &user.Email)
+		Scan(&user.ID, &user.Email, &user.Role)
 
 	if err != nil {
 		return nil, err
@@ -26,9 +26,9 @@ func TestRetrieve(t *testing.T) {
 			name: "Should find user",
 			id:   1,
 			mock: func() {
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s WHERE", TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s WHERE", TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("1", "check@check.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow("1", "check@check.com", "user"))
 			},
 			expectedID:    1,
 			expectedEmail: "check@check.com",
@@ -38,9 +38,9 @@ func TestRetrieve(t *testing.T) {
 			name: "Should not find user",
 			id:   58,
 			mock: func() {
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s WHERE", TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s WHERE", TableName)).
 					WithArgs(58).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}))
 			},
 			expectedID:    0,
 			expectedEmail: "",
@@ -0,0 +1,116 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// TOTP returns two-factor state of not deleted user
+func (repo *Repository) TOTP(ctx context.Context, id int64) (*TOTPState, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	state := new(TOTPState)
+	err := sq.
+		Select("email", "role", "totp_secret", "totp_enabled_at", "totp_last_step").
+		From(TableName).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		QueryRowContext(c).
+		Scan(&state.Email, &state.Role, &state.Secret, &state.EnabledAt, &state.LastStep)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// SetTOTPSecret stores a new not confirmed secret of user. Returns false if
+// two-factor authentication is already enabled
+func (repo *Repository) SetTOTPSecret(ctx context.Context, id int64, secret string) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(TableName).
+		Set("totp_secret", secret).
+		Set("totp_last_step", nil).
+		Where(sq.Eq{"id": id, "totp_enabled_at": nil, "deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return affected(res, err)
+}
+
+// EnableTOTP confirms enrollment of stored secret. Returns false if it was already confirmed
+func (repo *Repository) EnableTOTP(ctx context.Context, id int64) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(TableName).
+		Set("totp_enabled_at", time.Now()).
+		Where(sq.Eq{"id": id, "totp_enabled_at": nil}).
+		Where(sq.NotEq{"totp_secret": nil}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return affected(res, err)
+}
+
+// DisableTOTP removes secret of user and turns two-factor authentication off
+func (repo *Repository) DisableTOTP(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(TableName).
+		Set("totp_secret", nil).
+		Set("totp_enabled_at", nil).
+		Set("totp_last_step", nil).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return err
+}
+
+// UseTOTPStep remembers time step of accepted code. Returns false if code of
+// the same or a later step was already used, so every code works only once
+func (repo *Repository) UseTOTPStep(ctx context.Context, id, step int64) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	res, err := sq.
+		Update(TableName).
+		Set("totp_last_step", step).
+		Where(sq.Eq{"id": id}).
+		Where(sq.Or{sq.Eq{"totp_last_step": nil}, sq.Lt{"totp_last_step": step}}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(repo.db).
+		ExecContext(c)
+
+	return affected(res, err)
+}
+
+// affected returns true if exec updated at least one row
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
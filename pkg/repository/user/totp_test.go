@@ -0,0 +1,235 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedEnabled bool
+		errorPresent    bool
+	}{
+		{
+			name: "Enabled",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT email, role, totp_secret, totp_enabled_at, totp_last_step FROM %s WHERE deleted_at IS NULL AND id = (.+)", TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"email", "role", "totp_secret", "totp_enabled_at", "totp_last_step"}).
+						AddRow("check@check.com", "user", "SECRET", time.Now(), 100))
+			},
+			expectedEnabled: true,
+		},
+		{
+			name: "Not enrolled",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"email", "role", "totp_secret", "totp_enabled_at", "totp_last_step"}).
+						AddRow("check@check.com", "user", nil, nil, nil))
+			},
+		},
+		{
+			name: "User doesn't exist",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"email", "role", "totp_secret", "totp_enabled_at", "totp_last_step"}))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			state, err := repo.TOTP(context.Background(), 1)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil && state.Enabled() != testCase.expectedEnabled {
+				t.Errorf("Invalid enabled, expected: %v, got: %v\n", testCase.expectedEnabled, state.Enabled())
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestSetTOTPSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedUpdated bool
+		errorPresent    bool
+	}{
+		{
+			name: "Not enabled",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_secret = \\$1, totp_last_step = \\$2 WHERE deleted_at IS NULL AND id = \\$3 AND totp_enabled_at IS NULL", TableName)).
+					WithArgs("SECRET", nil, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUpdated: true,
+		},
+		{
+			name: "Already enabled",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_secret", TableName)).
+					WithArgs("SECRET", nil, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_secret", TableName)).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			updated, err := repo.SetTOTPSecret(context.Background(), 1, "SECRET")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if updated != testCase.expectedUpdated {
+				t.Errorf("Invalid updated, expected: %v, got: %v\n", testCase.expectedUpdated, updated)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestEnableTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_enabled_at = \\$1 WHERE id = \\$2 AND totp_enabled_at IS NULL AND totp_secret IS NOT NULL", TableName)).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewRepository(db)
+	enabled, err := repo.EnableTOTP(context.Background(), 1)
+	if err != nil {
+		t.Errorf("Unexpected error: %s\n", err.Error())
+	}
+
+	if !enabled {
+		t.Errorf("Should be enabled\n")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_secret = \\$1, totp_enabled_at = \\$2, totp_last_step = \\$3 WHERE id = \\$4", TableName)).
+		WithArgs(nil, nil, nil, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewRepository(db)
+	if err := repo.DisableTOTP(context.Background(), 1); err != nil {
+		t.Errorf("Unexpected error: %s\n", err.Error())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedUsed bool
+	}{
+		{
+			name: "Code of a new step",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_last_step = \\$1 WHERE id = \\$2 AND \\(totp_last_step IS NULL OR totp_last_step < \\$3\\)", TableName)).
+					WithArgs(100, 1, 100).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUsed: true,
+		},
+		{
+			name: "Replayed code",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_last_step", TableName)).
+					WithArgs(100, 1, 100).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			used, err := repo.UseTOTPStep(context.Background(), 1, 100)
+			if err != nil {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if used != testCase.expectedUsed {
+				t.Errorf("Invalid used, expected: %v, got: %v\n", testCase.expectedUsed, used)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package video
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ServiceIDBySHA256 returns cloud file name of stored video with the content hash
+// which belongs to organization or, if organizationID is zero, to user.
+// Returns empty string if there is no such video
+func (r *Repository) ServiceIDBySHA256(ctx context.Context, userID, organizationID int64, sha256 string) (string, error) { //nolint:lll
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	owner := sq.Eq{"user_id": userID}
+	if organizationID != 0 {
+		owner = sq.Eq{"organization_id": organizationID}
+	}
+
+	var serviceID string
+
+	err := sq.Select("service_id").
+		From(TableName).
+		Where(sq.And{
+			sq.Eq{"sha256": sha256},
+			owner,
+			sq.NotEq{"service_id": nil},
+			sq.Eq{"expired": false},
+			sq.Eq{"deleted_at": nil},
+		}).
+		OrderBy("id DESC").
+		Limit(1).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		QueryRowContext(c).
+		Scan(&serviceID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return serviceID, err
+}
+
+// ServiceIDShared reports whether cloud file of video is referenced by other
+// video which is not expired, deleted videos waiting for purge are included
+func (r *Repository) ServiceIDShared(ctx context.Context, id int64, serviceID string) (bool, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var shared bool
+
+	err := sq.Select().
+		Column(sq.Expr("EXISTS (SELECT 1 FROM "+TableName+
+			" WHERE service_id = ? AND id <> ? AND expired = FALSE)", serviceID, id)).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		QueryRowContext(c).
+		Scan(&shared)
+
+	return shared, err
+}
@@ -0,0 +1,114 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServiceIDBySHA256(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name              string
+		organizationID    int64
+		mock              func()
+		expectedServiceID string
+		errorPresent      bool
+	}{
+		{
+			name: "Video of user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s WHERE \\(sha256 = \\$1 AND user_id = \\$2 "+
+					"AND service_id IS NOT NULL AND expired = \\$3 AND deleted_at IS NULL\\) ORDER BY id DESC LIMIT 1",
+					TableName)).
+					WithArgs("hash", 1, false).
+					WillReturnRows(mock.NewRows([]string{"service_id"}).AddRow("service_id"))
+			},
+			expectedServiceID: "service_id",
+		},
+		{
+			name:           "Video of organization",
+			organizationID: 2,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s WHERE \\(sha256 = \\$1 AND organization_id = \\$2 ",
+					TableName)).
+					WithArgs("hash", 2, false).
+					WillReturnRows(mock.NewRows([]string{"service_id"}).AddRow("org_service_id"))
+			},
+			expectedServiceID: "org_service_id",
+		},
+		{
+			name: "Without video",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", TableName)).
+					WithArgs("hash", 1, false).
+					WillReturnRows(mock.NewRows([]string{"service_id"}))
+			},
+		},
+		{
+			name: "Invalid db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", TableName)).
+					WithArgs("hash", 1, false).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+
+			serviceID, err := repo.ServiceIDBySHA256(context.Background(), 1, testCase.organizationID, "hash")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if serviceID != testCase.expectedServiceID {
+				t.Errorf("Invalid service id, expected: %s, got: %s\n", testCase.expectedServiceID, serviceID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestServiceIDShared(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectQuery(fmt.Sprintf("SELECT EXISTS \\(SELECT 1 FROM %s WHERE service_id = \\$1 AND id <> \\$2 "+
+		"AND expired = FALSE\\)", TableName)).
+		WithArgs("service_id", 1).
+		WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+
+	shared, err := NewRepository(db).ServiceIDShared(context.Background(), 1, "service_id")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err.Error())
+	}
+
+	if !shared {
+		t.Errorf("Service id should be shared\n")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,28 @@
+package video
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Delete marks video as deleted. Record stays in db until Destroy
+// removes it after the grace period
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var videoID int64
+	err := sq.
+		Update(TableName).
+		Set("deleted_at", time.Now()).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		QueryRowContext(c).
+		Scan(&videoID)
+
+	return err
+}
@@ -0,0 +1,62 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		id           int64
+		mock         func()
+		errorPresent bool
+	}{
+		{
+			name: "Video does not exist",
+			id:   1,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			errorPresent: true,
+		},
+		{
+			name: "Should mark video as deleted",
+			id:   1,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			err := repo.Delete(context.Background(), testCase.id)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
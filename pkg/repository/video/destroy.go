@@ -0,0 +1,22 @@
+package video
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Destroy permanently removes video from db
+func (r *Repository) Destroy(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Delete(TableName).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		ExecContext(c)
+
+	return err
+}
@@ -3,9 +3,13 @@ package video
 import (
 	"context"
 
+	"github.com/Hargeon/videocmprs/pkg/repository/organization"
+
 	sq "github.com/Masterminds/squirrel"
 )
 
+// RelationExists returns id of video if it is not deleted and belongs to user
+// or to organization in which user is a member
 func (r *Repository) RelationExists(ctx context.Context, userID, relationID int64) (int64, error) {
 	c, cancel := context.WithTimeout(ctx, queryTimeOut)
 	defer cancel()
@@ -14,7 +18,35 @@ func (r *Repository) RelationExists(ctx context.Context, userID, relationID int6
 
 	err := sq.Select("id").
 		From(TableName).
-		Where(sq.And{sq.Eq{"id": relationID}, sq.Eq{"user_id": userID}}).
+		Where(sq.And{
+			sq.Eq{"id": relationID},
+			sq.Eq{"deleted_at": nil},
+			sq.Or{sq.Eq{"user_id": userID}, organization.MemberOf("organization_id", userID)},
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		QueryRowContext(c).
+		Scan(&id)
+
+	return id, err
+}
+
+// RelationManageable returns id of video if it is not deleted and belongs to user
+// or to organization in which user is owner or admin. Members of organization
+// can only read videos of others
+func (r *Repository) RelationManageable(ctx context.Context, userID, relationID int64) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	var id int64
+
+	err := sq.Select("id").
+		From(TableName).
+		Where(sq.And{
+			sq.Eq{"id": relationID},
+			sq.Eq{"deleted_at": nil},
+			sq.Or{sq.Eq{"user_id": userID}, organization.ManagerOf("organization_id", userID)},
+		}).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.db).
 		QueryRowContext(c).
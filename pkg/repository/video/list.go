@@ -0,0 +1,46 @@
+package video
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// list returns short representation of videos which match the condition
+func (r *Repository) list(ctx context.Context, where sq.Sqlizer) ([]*Resource, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	rows, err := sq.
+		Select("id", "user_id", "name", "service_id").
+		From(TableName).
+		Where(where).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	videos := make([]*Resource, 0)
+
+	for rows.Next() {
+		video := new(DTO)
+
+		if err = rows.Scan(&video.ID, &video.UserID, &video.Name, &video.ServiceID); err != nil {
+			return nil, err
+		}
+
+		videos = append(videos, video.BuildResource())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return videos, nil
+}
@@ -0,0 +1,13 @@
+package video
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ListDeleted returns videos which were deleted before the time
+func (r *Repository) ListDeleted(ctx context.Context, before time.Time) ([]*Resource, error) {
+	return r.list(ctx, sq.Lt{"deleted_at": before})
+}
@@ -0,0 +1,79 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	before := time.Now()
+
+	cases := []struct {
+		name               string
+		mock               func()
+		expectedServiceIDs []string
+		errorPresent       bool
+	}{
+		{
+			name: "Should return videos",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s WHERE deleted_at <", TableName)).
+					WithArgs(before).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "service_id"}).
+						AddRow(1, 1, "first.mkv", "first_service_id").
+						AddRow(2, 1, "second.mkv", "second_service_id"))
+			},
+			expectedServiceIDs: []string{"first_service_id", "second_service_id"},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s WHERE deleted_at <", TableName)).
+					WithArgs(before).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := NewRepository(db)
+			videos, err := repo.ListDeleted(context.Background(), before)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(videos) != len(testCase.expectedServiceIDs) {
+				t.Fatalf("Invalid count of videos, expected: %d, got: %d\n",
+					len(testCase.expectedServiceIDs), len(videos))
+			}
+
+			for i, v := range videos {
+				if v.ServiceID != testCase.expectedServiceIDs[i] {
+					t.Errorf("Invalid service id, expected: %s, got: %s\n",
+						testCase.expectedServiceIDs[i], v.ServiceID)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
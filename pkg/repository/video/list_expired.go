@@ -0,0 +1,18 @@
+package video
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ListExpired returns videos which retention period ended before the time
+// and which are not removed from cloud yet
+func (r *Repository) ListExpired(ctx context.Context, before time.Time) ([]*Resource, error) {
+	return r.list(ctx, sq.And{
+		sq.Lt{"expires_at": before},
+		sq.Eq{"expired": false},
+		sq.Eq{"deleted_at": nil},
+	})
+}
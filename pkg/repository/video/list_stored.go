@@ -0,0 +1,17 @@
+package video
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ListStored returns videos created before the time which files should be present in cloud
+func (r *Repository) ListStored(ctx context.Context, before time.Time) ([]*Resource, error) {
+	return r.list(ctx, sq.And{
+		sq.Lt{"created_at": before},
+		sq.Eq{"expired": false},
+		sq.Eq{"deleted_at": nil},
+	})
+}
@@ -0,0 +1,23 @@
+package video
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// MarkExpired marks video as expired after its file was removed from cloud
+func (r *Repository) MarkExpired(ctx context.Context, id int64) error {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	_, err := sq.
+		Update(TableName).
+		Set("expired", true).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		ExecContext(c)
+
+	return err
+}
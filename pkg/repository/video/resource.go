@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/google/jsonapi"
 )
@@ -15,16 +16,25 @@ var _ jsonapi.Linkable = (*Resource)(nil)
 
 // Resource represent video in db
 type Resource struct {
-	ID          int64  `jsonapi:"primary,videos" json:"id,omitempty"`
-	UserID      int64  `json:"user_id"`
-	Name        string `jsonapi:"attr,name" json:"name"`
-	Size        int64  `jsonapi:"attr,size" json:"size"`
-	Bitrate     int64  `jsonapi:"attr,bitrate,omitempty" json:"bitrate"`
-	ResolutionX int    `jsonapi:"attr,resolution_x,omitempty" json:"resolution_x"`
-	ResolutionY int    `jsonapi:"attr,resolution_y,omitempty" json:"resolution_y"`
-	RatioX      int    `jsonapi:"attr,ratio_x,omitempty" json:"ratio_x"`
-	RatioY      int    `jsonapi:"attr,ratio_y,omitempty" json:"ratio_y"`
-	ServiceID   string `json:"service_id,omitempty"`
+	ID          int64   `jsonapi:"primary,videos" json:"id,omitempty"`
+	UserID      int64   `json:"user_id"`
+	Name        string  `jsonapi:"attr,name" json:"name"`
+	Size        int64   `jsonapi:"attr,size" json:"size"`
+	Bitrate     int64   `jsonapi:"attr,bitrate,omitempty" json:"bitrate"`
+	ResolutionX int     `jsonapi:"attr,resolution_x,omitempty" json:"resolution_x"`
+	ResolutionY int     `jsonapi:"attr,resolution_y,omitempty" json:"resolution_y"`
+	RatioX      int     `jsonapi:"attr,ratio_x,omitempty" json:"ratio_x"`
+	RatioY      int     `jsonapi:"attr,ratio_y,omitempty" json:"ratio_y"`
+	ServiceID   string  `json:"service_id,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	// Codec of video stream detected on upload
+	Codec string `json:"codec,omitempty"`
+	// SHA256 is hex encoded hash of content of original video
+	SHA256 string `json:"sha256,omitempty"`
+	// OrganizationID shares video with members of organization
+	OrganizationID int64 `json:"organization_id,omitempty"`
+
+	ExpiresAt *time.Time `jsonapi:"attr,expires_at,iso8601,omitempty" json:"expires_at,omitempty"`
 }
 
 type DTO struct {
@@ -38,10 +48,11 @@ type DTO struct {
 	RatioX      sql.NullInt32
 	RatioY      sql.NullInt32
 	ServiceID   sql.NullString
+	ExpiresAt   sql.NullTime
 }
 
 func (dto *DTO) BuildResource() *Resource {
-	return &Resource{
+	res := &Resource{
 		ID:          dto.ID.Int64,
 		Name:        dto.Name.String,
 		UserID:      dto.UserID.Int64,
@@ -53,6 +64,13 @@ func (dto *DTO) BuildResource() *Resource {
 		RatioY:      int(dto.RatioY.Int32),
 		ServiceID:   dto.ServiceID.String,
 	}
+
+	if dto.ExpiresAt.Valid {
+		expiresAt := dto.ExpiresAt.Time
+		res.ExpiresAt = &expiresAt
+	}
+
+	return res
 }
 
 // BuildFields function create map with fields and values for INSERT DB query
@@ -95,16 +113,41 @@ func (r *Resource) BuildFields() map[string]interface{} {
 		fields["service_id"] = r.ServiceID
 	}
 
+	if r.Duration != 0 {
+		fields["duration"] = r.Duration
+	}
+
+	if r.Codec != "" {
+		fields["codec"] = r.Codec
+	}
+
+	if r.SHA256 != "" {
+		fields["sha256"] = r.SHA256
+	}
+
+	if r.OrganizationID != 0 {
+		fields["organization_id"] = r.OrganizationID
+	}
+
+	if r.ExpiresAt != nil {
+		fields["expires_at"] = *r.ExpiresAt
+	}
+
 	return fields
 }
 
+// Expired reports whether retention period of the video is over
+func (r *Resource) Expired() bool {
+	return r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now())
+}
+
 // JSONAPILinks ...
 func (r *Resource) JSONAPILinks() *jsonapi.Links {
 	links := jsonapi.Links{
 		"self": fmt.Sprintf("%s/api/v1/videos/%d", os.Getenv("BASE_URL"), r.ID),
 	}
 
-	if r.ServiceID != "" {
+	if r.ServiceID != "" && !r.Expired() {
 		links["download"] = fmt.Sprintf("%s/api/v1/videos/download_url/%d", os.Getenv("BASE_URL"), r.ID)
 	}
 
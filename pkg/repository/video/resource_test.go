@@ -2,6 +2,7 @@ package video
 
 import (
 	"testing"
+	"time"
 )
 
 func TestBuildFields(t *testing.T) {
@@ -127,3 +128,48 @@ func TestBuildFields(t *testing.T) {
 		})
 	}
 }
+
+func TestExpired(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	cases := []struct {
+		name            string
+		video           *Resource
+		expected        bool
+		downloadPresent bool
+	}{
+		{
+			name:            "Without expiration",
+			video:           &Resource{ID: 1, ServiceID: "service_id"},
+			expected:        false,
+			downloadPresent: true,
+		},
+		{
+			name:            "Expires in future",
+			video:           &Resource{ID: 1, ServiceID: "service_id", ExpiresAt: &future},
+			expected:        false,
+			downloadPresent: true,
+		},
+		{
+			name:            "Expired",
+			video:           &Resource{ID: 1, ServiceID: "service_id", ExpiresAt: &past},
+			expected:        true,
+			downloadPresent: false,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if expired := testCase.video.Expired(); expired != testCase.expected {
+				t.Errorf("Invalid expired, expected: %v, got: %v\n", testCase.expected, expired)
+			}
+
+			links := testCase.video.JSONAPILinks()
+			if _, ok := (*links)["download"]; ok != testCase.downloadPresent {
+				t.Errorf("Invalid download link presence, expected: %v, got: %v\n",
+					testCase.downloadPresent, ok)
+			}
+		})
+	}
+}
@@ -16,14 +16,14 @@ func (r *Repository) Retrieve(ctx context.Context, id int64) (jsonapi.Linkable,
 
 	err := sq.
 		Select("id", "name", "size", "bitrate", "resolution_x",
-			"resolution_y", "ratio_x", "ratio_y", "service_id").
+			"resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at").
 		From(TableName).
 		Where(sq.Eq{"id": id}).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.db).
 		QueryRowContext(c).
 		Scan(&video.ID, &video.Name, &video.Size, &video.Bitrate, &video.ResolutionX,
-			&video.ResolutionY, &video.RatioX, &video.RatioY, &video.ServiceID)
+			&video.ResolutionY, &video.RatioX, &video.RatioY, &video.ServiceID, &video.ExpiresAt)
 
 	if err != nil {
 		return nil, err
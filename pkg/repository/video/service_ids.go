@@ -0,0 +1,46 @@
+package video
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ServiceIDs returns cloud file names referenced by videos which are not expired.
+// Deleted videos are included because their files are removed after the grace period
+func (r *Repository) ServiceIDs(ctx context.Context) ([]string, error) {
+	c, cancel := context.WithTimeout(ctx, queryTimeOut)
+	defer cancel()
+
+	rows, err := sq.
+		Select("service_id").
+		From(TableName).
+		Where(sq.Eq{"expired": false}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.db).
+		QueryContext(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]string, 0)
+
+	for rows.Next() {
+		var id string
+
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
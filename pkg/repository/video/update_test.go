@@ -65,10 +65,10 @@ func TestUpdate(t *testing.T) {
 					WithArgs(64000, 4, 3, 800, 600, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 789569, 64000, 800, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 789569, 64000, 800, 600, 4, 3, "mock_service_id", nil))
 			},
 			expectedID:          1,
 			expectedName:        "my_name.mkv",
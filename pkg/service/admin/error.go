@@ -0,0 +1,16 @@
+package admin
+
+import "errors"
+
+var (
+	// ErrRequestNotPresent returns if request doesn't exists or is deleted
+	ErrRequestNotPresent = errors.New("request does not exists")
+	// ErrUserNotPresent returns if user doesn't exists or is deleted
+	ErrUserNotPresent = errors.New("user does not exists")
+	// ErrInvalidTransition returns if action isn't allowed in current status of request
+	ErrInvalidTransition = errors.New("action is not allowed in current status of request")
+	// ErrStatusChanged returns if status of request was changed concurrently
+	ErrStatusChanged = errors.New("status of request was changed")
+	// ErrOriginalVideoMissing returns if request is retried without uploaded original video
+	ErrOriginalVideoMissing = errors.New("original video is missing")
+)
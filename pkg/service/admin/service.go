@@ -0,0 +1,238 @@
+// Package admin uses for managing requests and users of all users by admins
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/compress"
+
+	"github.com/google/jsonapi"
+)
+
+// Statuses of request
+const (
+	StatusQueued      = "original_in_review"
+	StatusFailed      = "failed"
+	StatusSuccess     = "success"
+	StatusCancelled   = "cancelled"
+	StatusQuarantined = "quarantined"
+)
+
+// defaultFailReason is details of request failed by admin without reason
+const defaultFailReason = "Failed by admin"
+
+// Service for admin actions
+type Service struct {
+	requests  repository.RequestRepository
+	users     repository.UserRepository
+	tokens    repository.TokenRepository
+	stats     repository.StatsRetriever
+	publisher service.Publisher
+	audit     service.AuditRecorder
+}
+
+// NewService initialize Service. Every admin action is recorded in audit log
+func NewService(rRepo repository.RequestRepository, uRepo repository.UserRepository, tRepo repository.TokenRepository, sRepo repository.StatsRetriever, pb service.Publisher, audit service.AuditRecorder) *Service { //nolint:lll
+	return &Service{requests: rRepo, users: uRepo, tokens: tRepo, stats: sRepo, publisher: pb, audit: audit}
+}
+
+// ListRequests returns requests of all users
+func (srv *Service) ListRequests(ctx context.Context, params *query.Params, filter *query.RequestFilter) ([]interface{}, error) { //nolint:lll
+	return srv.requests.ListAll(ctx, params, filter)
+}
+
+// RetrieveRequest returns request of any user with its videos
+func (srv *Service) RetrieveRequest(ctx context.Context, id int64) (jsonapi.Linkable, error) {
+	return srv.request(ctx, id)
+}
+
+// FailRequest marks request in progress as failed
+func (srv *Service) FailRequest(ctx context.Context, id int64, reason string) (jsonapi.Linkable, error) {
+	if reason == "" {
+		reason = defaultFailReason
+	}
+
+	res, err := srv.finish(ctx, id, map[string]interface{}{"status": StatusFailed, "details": reason})
+	if err != nil {
+		return nil, err
+	}
+
+	srv.record(ctx, audit.ActionAdminRequestFail, request.TableName, id, map[string]string{"reason": reason})
+
+	return res, nil
+}
+
+// CancelRequest marks request in progress as cancelled. Conversion already
+// started by worker isn't interrupted
+func (srv *Service) CancelRequest(ctx context.Context, id int64) (jsonapi.Linkable, error) {
+	res, err := srv.finish(ctx, id, map[string]interface{}{"status": StatusCancelled, "details": "Cancelled by admin"})
+	if err != nil {
+		return nil, err
+	}
+
+	srv.record(ctx, audit.ActionAdminRequestCancel, request.TableName, id, nil)
+
+	return res, nil
+}
+
+// RetryRequest sends failed or cancelled request to worker again
+func (srv *Service) RetryRequest(ctx context.Context, id int64) (jsonapi.Linkable, error) {
+	req, err := srv.request(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != StatusFailed && req.Status != StatusCancelled {
+		return nil, ErrInvalidTransition
+	}
+
+	if req.OriginalVideo == nil || req.OriginalVideo.ServiceID == "" {
+		return nil, ErrOriginalVideoMissing
+	}
+
+	fields := map[string]interface{}{"status": StatusQueued, "details": nil}
+	if err = srv.transition(ctx, req, fields); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(compress.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = srv.publisher.Publish(body); err != nil {
+		fields = map[string]interface{}{"status": StatusFailed, "details": "Failed connection to worker"}
+		if _, updateErr := srv.requests.Transition(ctx, id, []string{StatusQueued}, fields); updateErr != nil {
+			return nil, updateErr
+		}
+
+		return nil, err
+	}
+
+	srv.record(ctx, audit.ActionAdminRequestRetry, request.TableName, id, nil)
+
+	return srv.requests.Retrieve(ctx, id)
+}
+
+// DisableUser disables or enables user. Disabled user can't sign in, its
+// refresh tokens are revoked and API keys are rejected
+func (srv *Service) DisableUser(ctx context.Context, id int64, disabled bool) error {
+	ok, err := srv.users.SetDisabled(ctx, id, disabled)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrUserNotPresent
+	}
+
+	if !disabled {
+		srv.record(ctx, audit.ActionAdminUserEnable, user.TableName, id, nil)
+
+		return nil
+	}
+
+	srv.record(ctx, audit.ActionAdminUserDisable, user.TableName, id, nil)
+
+	return srv.tokens.RevokeUserRefresh(ctx, id)
+}
+
+// DeleteUser marks user with its requests and videos as deleted
+func (srv *Service) DeleteUser(ctx context.Context, id int64) error {
+	err := srv.users.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotPresent
+	}
+
+	if err != nil {
+		return err
+	}
+
+	srv.record(ctx, audit.ActionAdminUserDelete, user.TableName, id, nil)
+
+	return srv.tokens.RevokeUserRefresh(ctx, id)
+}
+
+// Stats returns system wide statistics
+func (srv *Service) Stats(ctx context.Context) (jsonapi.Linkable, error) {
+	return srv.stats.Retrieve(ctx)
+}
+
+// finish moves request in progress to terminal status
+func (srv *Service) finish(ctx context.Context, id int64, fields map[string]interface{}) (jsonapi.Linkable, error) {
+	req, err := srv.request(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if terminal(req.Status) {
+		return nil, ErrInvalidTransition
+	}
+
+	if err = srv.transition(ctx, req, fields); err != nil {
+		return nil, err
+	}
+
+	return srv.requests.Retrieve(ctx, id)
+}
+
+// transition updates request only if its status wasn't changed after it was read
+func (srv *Service) transition(ctx context.Context, req *request.Resource, fields map[string]interface{}) error {
+	ok, err := srv.requests.Transition(ctx, req.ID, []string{req.Status}, fields)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrStatusChanged
+	}
+
+	return nil
+}
+
+// request returns not deleted request by id
+func (srv *Service) request(ctx context.Context, id int64) (*request.Resource, error) {
+	linkable, err := srv.requests.RetrieveNotDeleted(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestNotPresent
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, ok := linkable.(*request.Resource)
+	if !ok {
+		return nil, service.ErrInvalidTypeAssertion
+	}
+
+	return req, nil
+}
+
+// record adds admin action to audit log, admin is taken from ctx
+func (srv *Service) record(ctx context.Context, action, resourceType string, id int64, details map[string]string) {
+	e := &audit.Event{Action: action, ResourceType: resourceType, ResourceID: id}
+
+	if details != nil {
+		body, err := json.Marshal(details)
+		if err == nil {
+			e.Details = string(body)
+		}
+	}
+
+	srv.audit.Record(ctx, e)
+}
+
+func terminal(status string) bool {
+	return status == StatusFailed || status == StatusSuccess || status == StatusCancelled ||
+		status == StatusQuarantined
+}
@@ -0,0 +1,376 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/stats"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var requestColumns = []string{"requests.id", "requests.user_id", "requests.status",
+	"requests.details", "requests.bitrate", "requests.resolution_x",
+	"requests.resolution_y", "requests.ratio_x", "requests.ratio_y",
+	"requests.video_name", "origin_video.id", "origin_video.name",
+	"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
+	"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
+	"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
+	"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
+	"converted_video.resolution_y", "converted_video.ratio_x",
+	"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}
+
+type rabbitSuccess struct{}
+
+type rabbitError struct{}
+
+func (r *rabbitSuccess) Publish(body []byte) error {
+	return nil
+}
+
+func (r *rabbitSuccess) Ping() error {
+	return nil
+}
+
+func (r *rabbitError) Publish(body []byte) error {
+	return errors.New("mock error")
+}
+
+func (r *rabbitError) Ping() error {
+	return errors.New("mock error")
+}
+
+func requestRows(status string) *sqlmock.Rows {
+	return sqlmock.NewRows(requestColumns).AddRow(
+		1, 2, status, "", 1589875, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
+		78000, 1200, 800, 6, 5, "new_service_id", nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil)
+}
+
+// auditMock keeps recorded audit events
+type auditMock struct {
+	events []*audit.Event
+}
+
+func (a *auditMock) Record(ctx context.Context, e *audit.Event) {
+	a.events = append(a.events, e)
+}
+
+func newService(db *sql.DB) *Service {
+	return NewService(request.NewRepository(db), user.NewRepository(db), token.NewRepository(db),
+		stats.NewRepository(db), &rabbitSuccess{}, new(auditMock))
+}
+
+func TestFailRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "Request in progress",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("original_in_review"))
+
+				mock.ExpectExec("UPDATE requests SET details = (.+), status = (.+) WHERE deleted_at IS NULL AND id = (.+) AND status IN").
+					WithArgs("Broken file", "failed", 1, "original_in_review").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("failed"))
+			},
+		},
+		{
+			name: "Finished request",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("success"))
+			},
+			expectedErr: ErrInvalidTransition,
+		},
+		{
+			name: "Status changed concurrently",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("original_in_review"))
+
+				mock.ExpectExec("UPDATE requests").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: ErrStatusChanged,
+		},
+		{
+			name: "Missing request",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr: ErrRequestNotPresent,
+		},
+		{
+			name: "Deleted request",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests (.+) WHERE requests.deleted_at IS NULL AND requests.id = \\$1").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr: ErrRequestNotPresent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := newService(db)
+			recorder := new(auditMock)
+			srv.audit = recorder
+
+			_, err := srv.FailRequest(context.Background(), 1, "Broken file")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if recorded := len(recorder.events) == 1; recorded != (err == nil) {
+				t.Errorf("Invalid audit events, got: %d\n", len(recorder.events))
+			}
+
+			if err == nil && recorder.events[0].Details != `{"reason":"Broken file"}` {
+				t.Errorf("Invalid audit details, got: %s\n", recorder.events[0].Details)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestRetryRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		publisher    *rabbitError
+		errorPresent bool
+		expectedErr  error
+	}{
+		{
+			name: "Failed request",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("failed"))
+
+				mock.ExpectExec("UPDATE requests SET details = (.+), status = (.+) WHERE").
+					WithArgs(nil, "original_in_review", 1, "failed").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("original_in_review"))
+			},
+		},
+		{
+			name: "Request in progress",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("original_in_review"))
+			},
+			errorPresent: true,
+			expectedErr:  ErrInvalidTransition,
+		},
+		{
+			name: "Worker is unavailable",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests").
+					WithArgs(1).
+					WillReturnRows(requestRows("cancelled"))
+
+				mock.ExpectExec("UPDATE requests SET details = (.+), status = (.+) WHERE").
+					WithArgs(nil, "original_in_review", 1, "cancelled").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("UPDATE requests SET").
+					WithArgs("Failed connection to worker", "failed", 1, "original_in_review").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			publisher:    &rabbitError{},
+			errorPresent: true,
+		},
+		{
+			name: "Deleted request isn't published again",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM requests (.+) WHERE requests.deleted_at IS NULL AND requests.id = \\$1").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			publisher:    &rabbitError{},
+			errorPresent: true,
+			expectedErr:  ErrRequestNotPresent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := newService(db)
+			if testCase.publisher != nil {
+				srv.publisher = testCase.publisher
+			}
+
+			_, err := srv.RetryRequest(context.Background(), 1)
+			if !testCase.errorPresent && err != nil {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if testCase.errorPresent && err == nil {
+				t.Errorf("Should be error\n")
+			}
+
+			if testCase.expectedErr != nil && !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		disabled    bool
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:     "Disable user revokes refresh tokens",
+			disabled: true,
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET disabled_at").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked_at").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			name: "Enable user",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET disabled_at").
+					WithArgs(nil, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Missing user",
+			disabled: true,
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET disabled_at").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: ErrUserNotPresent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := newService(db)
+
+			err := srv.DisableUser(context.Background(), 1, testCase.disabled)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "Delete user",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET deleted_at").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("UPDATE requests SET deleted_at").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("UPDATE videos SET deleted_at").
+					WillReturnResult(sqlmock.NewResult(0, 5))
+				mock.ExpectCommit()
+
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked_at").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Missing user",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users SET deleted_at").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErr: ErrUserNotPresent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := newService(db)
+
+			err := srv.DeleteUser(context.Background(), 1)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package apikey
+
+import "errors"
+
+var (
+	// ErrInvalidScope returns if API key is created with unknown scope
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrExpiresInPast returns if API key is created with expiration time in the past
+	ErrExpiresInPast = errors.New("expiration time is in the past")
+	// ErrAPIKeyNotPresent returns if API key doesn't exists or is already revoked
+	ErrAPIKeyNotPresent = errors.New("api key does not exists")
+	// ErrInvalidAPIKey returns if API key is unknown, revoked or expired
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
@@ -0,0 +1,142 @@
+// Package apikey manages personal API keys used for machine-to-machine access
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/apikey"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/rbac"
+
+	"github.com/google/jsonapi"
+)
+
+const (
+	// KeyPrefix starts every API key, it distinguishes keys from jwt tokens
+	KeyPrefix = "vcp_"
+
+	keyLen    = 32
+	prefixLen = 8
+
+	// touchInterval is how often last_used_at of key is updated
+	touchInterval = time.Minute
+)
+
+// IsKey returns true if s looks like API key
+func IsKey(s string) bool {
+	return strings.HasPrefix(s, KeyPrefix)
+}
+
+// Service creates, lists, revokes and identifies API keys
+type Service struct {
+	repo repository.APIKeyRepository
+}
+
+// NewService initialize Service
+func NewService(repo repository.APIKeyRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// Create generates new API key for user. Plain key is returned only here.
+// Key without scopes has the same access as its owner
+func (srv *Service) Create(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
+	res, ok := resource.(*apikey.Resource)
+	if !ok {
+		return nil, service.ErrInvalidTypeAssertion
+	}
+
+	for _, scope := range res.Scopes {
+		if !rbac.ValidScope(scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	if res.Expired(time.Now()) {
+		return nil, ErrExpiresInPast
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Key = key
+	res.Prefix = key[len(KeyPrefix) : len(KeyPrefix)+prefixLen]
+	res.Hash = hashKey(key)
+
+	return srv.repo.Create(ctx, res)
+}
+
+// List returns active API keys of user
+func (srv *Service) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
+	return srv.repo.List(ctx, params)
+}
+
+// Delete revokes API key of user
+func (srv *Service) Delete(ctx context.Context, userID, relationID int64) error {
+	revoked, err := srv.repo.Revoke(ctx, userID, relationID)
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return ErrAPIKeyNotPresent
+	}
+
+	return nil
+}
+
+// Identify returns active API key by plain key and records its usage
+func (srv *Service) Identify(ctx context.Context, key string) (*apikey.Resource, error) {
+	if !IsKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	res, err := srv.repo.RetrieveByHash(ctx, hashKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if res.Expired(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err = srv.repo.Touch(ctx, res.ID, now, now.Add(-touchInterval)); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// generateKey returns random url safe key with KeyPrefix
+func generateKey() (string, error) {
+	b := make([]byte, keyLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return KeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashKey returns hash of key stored in db. Key has enough entropy,
+// so a fast hash without salt is sufficient
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,173 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/apikey"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var keyColumns = []string{"id", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at", "role"}
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	cases := []struct {
+		name        string
+		resource    *apikey.Resource
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:     "Valid key",
+			resource: &apikey.Resource{UserID: 5, Name: "ci", Scopes: []string{"requests:write"}, ExpiresAt: &future},
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WithArgs(5, "ci", sqlmock.AnyArg(), sqlmock.AnyArg(), "requests:write", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+			},
+		},
+		{
+			name:        "Unknown scope",
+			resource:    &apikey.Resource{UserID: 5, Name: "ci", Scopes: []string{"admin"}},
+			mock:        func() {},
+			expectedErr: ErrInvalidScope,
+		},
+		{
+			name:        "Expiration in the past",
+			resource:    &apikey.Resource{UserID: 5, Name: "ci", ExpiresAt: &past},
+			mock:        func() {},
+			expectedErr: ErrExpiresInPast,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(apikey.NewRepository(db))
+
+			res, err := srv.Create(context.Background(), testCase.resource)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil {
+				key := res.(*apikey.Resource)
+				if !IsKey(key.Key) || !strings.HasPrefix(key.Key, KeyPrefix+key.Prefix) {
+					t.Errorf("Invalid key %s with prefix %s\n", key.Key, key.Prefix)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	key := KeyPrefix + "secret"
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		key         string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "Active key",
+			key:  key,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM api_keys").
+					WithArgs(hashKey(key)).
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(1, 5, "ci", "secret", "", nil, nil, createdAt, "user"))
+				mock.ExpectExec("UPDATE api_keys SET last_used_at = (.+) WHERE id = (.+) AND \\(last_used_at IS NULL OR last_used_at < (.+)\\)").
+					WithArgs(sqlmock.AnyArg(), 1, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Expired key",
+			key:  key,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM api_keys").
+					WithArgs(hashKey(key)).
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(1, 5, "ci", "secret", "", createdAt, nil, createdAt, "user"))
+			},
+			expectedErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "Unknown or revoked key",
+			key:  key,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM api_keys").
+					WithArgs(hashKey(key)).
+					WillReturnRows(sqlmock.NewRows(keyColumns))
+			},
+			expectedErr: ErrInvalidAPIKey,
+		},
+		{
+			name:        "Without prefix",
+			key:         "secret",
+			mock:        func() {},
+			expectedErr: ErrInvalidAPIKey,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(apikey.NewRepository(db))
+
+			res, err := srv.Identify(context.Background(), testCase.key)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && res.UserID != 5 {
+				t.Errorf("Invalid user id, expected: %d, got: %d\n", 5, res.UserID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectExec("UPDATE api_keys SET revoked_at").
+		WithArgs(sqlmock.AnyArg(), 1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	srv := NewService(apikey.NewRepository(db))
+	if err = srv.Delete(context.Background(), 5, 1); !errors.Is(err, ErrAPIKeyNotPresent) {
+		t.Errorf("Invalid error, expected: %v, got: %v\n", ErrAPIKeyNotPresent, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package audit records security and data events and exports them for admins
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+
+	"go.uber.org/zap"
+)
+
+// Locals of request, fiber exposes them as values of c.Context()
+const (
+	userIDKey    = "user_id"
+	ipKey        = "ip"
+	userAgentKey = "user_agent"
+)
+
+// maxUserAgentLen is size of user_agent column
+const maxUserAgentLen = 512
+
+// csvHeader is first row of exported events
+var csvHeader = []string{"id", "created_at", "user_id", "action", "resource_type", "resource_id",
+	"ip", "user_agent", "details"}
+
+// Service records and exports audit events
+type Service struct {
+	repo   repository.AuditRepository
+	logger *zap.Logger
+}
+
+// NewService initialize Service
+func NewService(repo repository.AuditRepository, logger *zap.Logger) *Service {
+	return &Service{repo: repo, logger: logger}
+}
+
+// Record appends event to audit log. Actor, ip and user agent are taken
+// from ctx if event doesn't have them. Failure is only logged, so it
+// doesn't break audited action
+func (srv *Service) Record(ctx context.Context, e *audit.Event) {
+	if e.UserID == 0 {
+		e.UserID, _ = ctx.Value(userIDKey).(int64)
+	}
+
+	if e.IP == "" {
+		e.IP, _ = ctx.Value(ipKey).(string)
+	}
+
+	if e.UserAgent == "" {
+		e.UserAgent, _ = ctx.Value(userAgentKey).(string)
+	}
+
+	if len(e.UserAgent) > maxUserAgentLen {
+		e.UserAgent = e.UserAgent[:maxUserAgentLen]
+	}
+
+	if err := srv.repo.Create(ctx, e); err != nil {
+		srv.logger.Error("Record audit event", zap.Error(err), zap.String("Action", e.Action),
+			zap.Int64("User ID", e.UserID), zap.Int64("Resource ID", e.ResourceID))
+	}
+}
+
+// detached is context without deadline and cancellation which keeps audit
+// values of request
+type detached struct {
+	context.Context
+	values map[string]interface{}
+}
+
+func (d *detached) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if v, ok := d.values[k]; ok {
+			return v
+		}
+	}
+
+	return d.Context.Value(key)
+}
+
+// Detach returns background context with actor, ip and user agent of ctx. Fiber
+// reuses context of request after handler returns, so work started in goroutine
+// records audit events with detached context
+func Detach(ctx context.Context) context.Context {
+	values := make(map[string]interface{}, 3)
+
+	for _, key := range []string{userIDKey, ipKey, userAgentKey} {
+		if v := ctx.Value(key); v != nil {
+			values[key] = v
+		}
+	}
+
+	return &detached{Context: context.Background(), values: values}
+}
+
+// List returns page of audit events matching filter
+func (srv *Service) List(ctx context.Context, params *query.Params, filter *query.AuditFilter) ([]interface{}, error) { //nolint:lll
+	return srv.repo.List(ctx, params, filter)
+}
+
+// Export writes audit events matching filter to w in CSV
+func (srv *Service) Export(ctx context.Context, filter *query.AuditFilter, w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	err := srv.repo.Export(ctx, filter, func(e *audit.Event) error {
+		return cw.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			formatID(e.UserID),
+			safe(e.Action),
+			safe(e.ResourceType),
+			formatID(e.ResourceID),
+			safe(e.IP),
+			safe(e.UserAgent),
+			safe(e.Details),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// formatID returns empty string for zero id which is stored as NULL
+func formatID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(id, 10)
+}
+
+// safe prevents value controlled by client, e.g. user agent, from being
+// evaluated as formula when export is opened in spreadsheet
+func safe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+)
+
+func TestRecord(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	// locals of fiber are user values of fasthttp request
+	requestCtx := new(fasthttp.RequestCtx)
+	requestCtx.Init(new(fasthttp.Request), nil, nil)
+	requestCtx.SetUserValue("user_id", int64(1))
+	requestCtx.SetUserValue("ip", "127.0.0.1")
+	requestCtx.SetUserValue("user_agent", "curl/7.79.1")
+
+	cases := []struct {
+		name  string
+		ctx   context.Context
+		event *audit.Event
+		mock  func()
+	}{
+		{
+			name:  "Actor from context",
+			ctx:   requestCtx,
+			event: &audit.Event{Action: audit.ActionVideoDownloadURL, ResourceType: "videos", ResourceID: 3},
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(sql.NullInt64{Int64: 1, Valid: true}, audit.ActionVideoDownloadURL, "videos",
+						sql.NullInt64{Int64: 3, Valid: true}, "127.0.0.1", "curl/7.79.1", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:  "Actor of event isn't replaced",
+			ctx:   requestCtx,
+			event: &audit.Event{UserID: 2, Action: audit.ActionLoginSucceeded, ResourceType: "users", ResourceID: 2},
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(sql.NullInt64{Int64: 2, Valid: true}, audit.ActionLoginSucceeded, "users",
+						sql.NullInt64{Int64: 2, Valid: true}, "127.0.0.1", "curl/7.79.1", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:  "Without request",
+			ctx:   context.Background(),
+			event: &audit.Event{Action: audit.ActionLoginFailed, UserAgent: strings.Repeat("a", 600)},
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(sql.NullInt64{}, audit.ActionLoginFailed, "", sql.NullInt64{}, "",
+						strings.Repeat("a", maxUserAgentLen), "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:  "With bad db connection",
+			ctx:   context.Background(),
+			event: &audit.Event{Action: audit.ActionLoginFailed},
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_events").
+					WillReturnError(errors.New("mock error"))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(audit.NewRepository(db), zap.NewNop())
+			srv.Record(testCase.ctx, testCase.event)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestDetach(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	requestCtx := new(fasthttp.RequestCtx)
+	requestCtx.Init(new(fasthttp.Request), nil, nil)
+	requestCtx.SetUserValue("user_id", int64(1))
+	requestCtx.SetUserValue("ip", "127.0.0.1")
+	requestCtx.SetUserValue("user_agent", "curl/7.79.1")
+
+	ctx := Detach(requestCtx)
+
+	// context of request is reused by the next request
+	requestCtx.ResetUserValues()
+	requestCtx.SetUserValue("user_id", int64(2))
+
+	if ctx.Done() != nil {
+		t.Error("Detached context should not be cancelled\n")
+	}
+
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sql.NullInt64{Int64: 1, Valid: true}, audit.ActionRequestQuarantined, "requests",
+			sql.NullInt64{Int64: 3, Valid: true}, "127.0.0.1", "curl/7.79.1", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	NewService(audit.NewRepository(db), zap.NewNop()).
+		Record(ctx, &audit.Event{Action: audit.ActionRequestQuarantined, ResourceType: "requests", ResourceID: 3})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	createdAt := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "action", "resource_type", "resource_id", "ip", "user_agent",
+		"details", "created_at"}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedCSV  string
+		errorPresent bool
+	}{
+		{
+			name: "Should write events",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM audit_events").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(2, 1, audit.ActionVideoDownloadURL, "videos", 3, "127.0.0.1", "=HYPERLINK()", "", createdAt).
+						AddRow(1, nil, audit.ActionLoginFailed, "", nil, "127.0.0.1", "curl", `{"email":"check@check.com"}`, createdAt))
+			},
+			expectedCSV: "id,created_at,user_id,action,resource_type,resource_id,ip,user_agent,details\n" +
+				"2,2021-10-01T12:00:00Z,1,video.download_url,videos,3,127.0.0.1,'=HYPERLINK(),\n" +
+				`1,2021-10-01T12:00:00Z,,login.failed,,,127.0.0.1,curl,"{""email"":""check@check.com""}"` + "\n",
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM audit_events").
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(audit.NewRepository(db), zap.NewNop())
+
+			buf := new(bytes.Buffer)
+			err := srv.Export(context.Background(), &query.AuditFilter{}, buf)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil && buf.String() != testCase.expectedCSV {
+				t.Errorf("Invalid CSV, expected: %s, got: %s\n", testCase.expectedCSV, buf.String())
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -3,64 +3,282 @@ package auth
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/Hargeon/videocmprs/pkg/repository"
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
 	"github.com/Hargeon/videocmprs/pkg/service"
-	"github.com/Hargeon/videocmprs/pkg/service/encryption"
 	"github.com/Hargeon/videocmprs/pkg/service/jwt"
+	"github.com/Hargeon/videocmprs/pkg/service/mail"
 
 	"github.com/google/jsonapi"
 )
 
+// refreshTokenTD is lifetime of refresh token
+const refreshTokenTD = 30 * 24 * time.Hour
+
 // Service ...
 type Service struct {
-	repo repository.UserRepository
+	repo   repository.UserRepository
+	tokens repository.TokenRepository
+	hasher service.PasswordHasher
+	mailer service.Mailer
+
+	// appURL is base url of links sent by email
+	appURL string
+	// requireVerified rejects sign in of users with not verified email
+	requireVerified bool
+	// mfaIssuer is name of service shown in authenticator apps
+	mfaIssuer string
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// NewService initialize Service without mailer
+func NewService(repo repository.UserRepository, tokens repository.TokenRepository, hasher service.PasswordHasher) *Service { //nolint:lll
+	srv := &Service{repo: repo, tokens: tokens, hasher: hasher, mailer: mail.Disabled{}, appURL: os.Getenv("BASE_URL")}
+
+	srv.mfaIssuer = os.Getenv("MFA_ISSUER")
+	if srv.mfaIssuer == "" {
+		srv.mfaIssuer = defaultMFAIssuer
+	}
+
+	return srv
 }
 
-// NewService initialize Service
-func NewService(repo repository.UserRepository) *Service {
-	return &Service{repo: repo}
+// NewEnvService initialize Service with mailer configured by SMTP_* variables,
+// APP_URL used in links sent by email and EMAIL_VERIFICATION_REQUIRED
+func NewEnvService(repo repository.UserRepository, tokens repository.TokenRepository, hasher service.PasswordHasher) *Service { //nolint:lll
+	srv := NewService(repo, tokens, hasher)
+	srv.mailer = mail.NewEnvMailer()
+	srv.requireVerified = os.Getenv("EMAIL_VERIFICATION_REQUIRED") == "true"
+
+	if appURL := os.Getenv("APP_URL"); appURL != "" {
+		srv.appURL = appURL
+	}
+
+	return srv
 }
 
-// GenerateToken jwt for user
+// GenerateToken jwt for user. If user enabled two-factor authentication only
+// MFA challenge is returned, it is exchanged for tokens with VerifyMFA
 func (srv *Service) GenerateToken(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
 	usr, ok := resource.(*user.Resource)
 	if !ok {
 		return nil, service.ErrInvalidTypeAssertion
 	}
 
-	notExist, err := srv.repo.Unique(ctx, usr.Email)
+	cred, err := srv.repo.Credentials(ctx, usr.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// password is verified anyway, so response time doesn't disclose unknown email
+		_, _, _ = srv.hasher.Verify(usr.Password, srv.dummyHash())
+
+		return nil, service.ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	match, rehash, err := srv.hasher.Verify(usr.Password, cred.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if !match {
+		return nil, service.ErrInvalidCredentials
+	}
+
+	if cred.Disabled() {
+		return nil, service.ErrUserDisabled
+	}
+
+	if srv.requireVerified && !cred.Verified() {
+		return nil, service.ErrEmailNotVerified
+	}
+
+	if rehash {
+		// failed upgrade doesn't prevent sign in, it will be retried next time
+		_ = srv.upgradeHash(ctx, cred.ID, usr.Password)
+	}
+
+	if cred.MFAEnabled() {
+		return srv.mfaChallenge(ctx, cred.ID, usr.Email)
+	}
+
+	return srv.issue(ctx, cred.ID, usr.Email, cred.Role)
+}
+
+// Refresh exchanges refresh token for a new pair of access and refresh tokens.
+// Refresh token can be used only once, reuse of it revokes all refresh tokens of user.
+// Tokens of disabled or deleted users are rejected and revoked
+func (srv *Service) Refresh(ctx context.Context, refreshToken string) (jsonapi.Linkable, error) {
+	r, err := srv.tokens.RetrieveRefresh(ctx, hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Revoked() {
+		// token was already rotated, somebody else may have it
+		if err = srv.tokens.RevokeUserRefresh(ctx, r.UserID); err != nil {
+			return nil, err
+		}
+
+		return nil, service.ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(r.ExpiresAt) {
+		return nil, service.ErrInvalidRefreshToken
+	}
+
+	usr, cred, err := srv.refreshUser(ctx, r.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// deleted users are disabled too
+	if cred.Disabled() {
+		if err = srv.tokens.RevokeUserRefresh(ctx, r.UserID); err != nil {
+			return nil, err
+		}
+
+		return nil, service.ErrUserDisabled
+	}
+
+	revoked, err := srv.tokens.RevokeRefresh(ctx, r.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if notExist {
-		return nil, service.ErrUserNotExists
+	if !revoked {
+		return nil, service.ErrInvalidRefreshToken
+	}
+
+	return srv.issue(ctx, usr.ID, usr.Email, cred.Role)
+}
+
+// refreshUser returns user of refresh token with its credentials. Token of
+// unknown user is invalid
+func (srv *Service) refreshUser(ctx context.Context, userID int64) (*user.Resource, *user.Credentials, error) {
+	linkable, err := srv.repo.Retrieve(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, service.ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usr, ok := linkable.(*user.Resource)
+	if !ok {
+		return nil, nil, service.ErrInvalidTypeAssertion
+	}
+
+	cred, err := srv.repo.Credentials(ctx, usr.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, service.ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return usr, cred, nil
+}
+
+// Logout revokes access token with jti and refresh token of user
+func (srv *Service) Logout(ctx context.Context, userID int64, refreshToken, jti string, expiresAt time.Time) error {
+	if refreshToken != "" {
+		r, err := srv.tokens.RetrieveRefresh(ctx, hashToken(refreshToken))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err == nil && r.UserID == userID {
+			if _, err = srv.tokens.RevokeRefresh(ctx, r.ID); err != nil {
+				return err
+			}
+		}
 	}
 
-	hashPass := encryption.GenerateHash([]byte(usr.Password))
-	id, err := srv.repo.Exists(ctx, usr.Email, fmt.Sprintf("%x", hashPass))
+	if jti == "" {
+		return nil
+	}
+
+	return srv.tokens.Deny(ctx, jti, expiresAt)
+}
+
+// Issue creates access and refresh tokens for user authenticated by single sign-on
+func (srv *Service) Issue(ctx context.Context, id int64, email, role string) (jsonapi.Linkable, error) {
+	return srv.issue(ctx, id, email, role)
+}
+
+// Challenge creates MFA challenge for user authenticated by single sign-on
+func (srv *Service) Challenge(ctx context.Context, id int64, email string) (jsonapi.Linkable, error) {
+	return srv.mfaChallenge(ctx, id, email)
+}
 
+// issue creates access and refresh tokens for user
+func (srv *Service) issue(ctx context.Context, id int64, email, role string) (*user.Resource, error) {
+	token, err := jwt.SignedString(id, role)
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := jwt.SignedString(id)
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = srv.tokens.CreateRefresh(ctx, id, hashToken(refreshToken), time.Now().Add(refreshTokenTD))
 	if err != nil {
 		return nil, err
 	}
 
 	res := &user.Resource{
-		ID:    id,
-		Email: usr.Email,
-		Token: token,
+		ID:           id,
+		Email:        email,
+		Role:         role,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}
 
 	return res, nil
 }
 
+// dummyHash returns hash of random password used for unknown emails
+func (srv *Service) dummyHash() string {
+	srv.dummyOnce.Do(func() {
+		password, err := generateRefreshToken()
+		if err != nil {
+			return
+		}
+
+		srv.dummy, _ = srv.hasher.Hash(password)
+	})
+
+	return srv.dummy
+}
+
+// upgradeHash replaces legacy or outdated password hash of user
+func (srv *Service) upgradeHash(ctx context.Context, id int64, password string) error {
+	hash, err := srv.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return srv.repo.UpdatePasswordHash(ctx, id, hash)
+}
+
 // Retrieve return user params
 func (srv *Service) Retrieve(ctx context.Context, id int64) (jsonapi.Linkable, error) {
 	res, err := srv.repo.Retrieve(ctx, id)
@@ -2,16 +2,33 @@ package auth
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
 	"github.com/Hargeon/videocmprs/pkg/service/encryption"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
 
+// argon2Hash matches any argon2id password hash
+type argon2Hash struct{}
+
+func (a argon2Hash) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+
+	return ok && strings.HasPrefix(hash, "$argon2id$")
+}
+
 func TestGenerateToken(t *testing.T) {
+	hasher := encryption.NewPasswordHasher(encryption.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
@@ -23,6 +40,7 @@ func TestGenerateToken(t *testing.T) {
 		mock         func()
 		errorPresent bool
 		tokenPresent bool
+		expectedErr  error
 	}{
 		{
 			name: "Should find user",
@@ -31,18 +49,84 @@ func TestGenerateToken(t *testing.T) {
 				Password: "qweqweqwe",
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT count").
+				hash, err := hasher.Hash("qweqweqwe")
+				if err != nil {
+					t.Fatalf("Unexpected error when hashing password, error: %s\n", err)
+				}
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
 					WithArgs("check@check.com").
-					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).AddRow(1, hash, "user", nil, time.Now(), nil))
 
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.RefreshTableName)).
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			errorPresent: false,
+			tokenPresent: true,
+		},
+		{
+			name: "Should upgrade legacy hash",
+			user: &user.Resource{
+				Email:    "check@check.com",
+				Password: "qweqweqwe",
+			},
+			mock: func() {
 				hashPass := encryption.GenerateHash([]byte("qweqweqwe"))
-				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", user.TableName)).
-					WithArgs("check@check.com", fmt.Sprintf("%x", hashPass)).
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).AddRow(1, fmt.Sprintf("%x", hashPass), "user", nil, time.Now(), nil))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password_hash", user.TableName)).
+					WithArgs(argon2Hash{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.RefreshTableName)).
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			errorPresent: false,
 			tokenPresent: true,
 		},
+		{
+			name: "Invalid password",
+			user: &user.Resource{
+				Email:    "check@check.com",
+				Password: "qweqweqwe",
+			},
+			mock: func() {
+				hash, err := hasher.Hash("other_password")
+				if err != nil {
+					t.Fatalf("Unexpected error when hashing password, error: %s\n", err)
+				}
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).AddRow(1, hash, "user", nil, time.Now(), nil))
+			},
+			errorPresent: true,
+			tokenPresent: false,
+			expectedErr:  service.ErrInvalidCredentials,
+		},
+		{
+			name: "Disabled user",
+			user: &user.Resource{
+				Email:    "check@check.com",
+				Password: "qweqweqwe",
+			},
+			mock: func() {
+				hash, err := hasher.Hash("qweqweqwe")
+				if err != nil {
+					t.Fatalf("Unexpected error when hashing password, error: %s\n", err)
+				}
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}).AddRow(1, hash, "user", time.Now(), time.Now(), nil))
+			},
+			errorPresent: true,
+			tokenPresent: false,
+		},
 		{
 			name: "Should not find user",
 			user: &user.Resource{
@@ -50,12 +134,13 @@ func TestGenerateToken(t *testing.T) {
 				Password: "qweqweqwe",
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT count").
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)).
 					WithArgs("check2@check.com").
-					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}))
 			},
 			errorPresent: true,
 			tokenPresent: false,
+			expectedErr:  service.ErrInvalidCredentials,
 		},
 	}
 
@@ -63,7 +148,7 @@ func TestGenerateToken(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mock()
 			repo := user.NewRepository(db)
-			srv := NewService(repo)
+			srv := NewService(repo, token.NewRepository(db), hasher)
 			linkable, err := srv.GenerateToken(context.Background(), testCase.user)
 			if err != nil && !testCase.errorPresent {
 				t.Errorf("Unexpected error: %s\n", err.Error())
@@ -73,6 +158,10 @@ func TestGenerateToken(t *testing.T) {
 				t.Errorf("Should be error\n")
 			}
 
+			if testCase.expectedErr != nil && !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
 			if err == nil {
 				usr, ok := linkable.(*user.Resource)
 				if !ok {
@@ -111,9 +200,9 @@ func TestRetrieve(t *testing.T) {
 			name: "Should find user",
 			id:   1,
 			mock: func() {
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s", user.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("1", "check@check.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow("1", "check@check.com", "user"))
 			},
 			expectedID:    1,
 			expectedEmail: "check@check.com",
@@ -124,9 +213,9 @@ func TestRetrieve(t *testing.T) {
 			name: "Should not find user",
 			id:   1,
 			mock: func() {
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s", user.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}))
 			},
 			errorPresent: true,
 		},
@@ -136,7 +225,7 @@ func TestRetrieve(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mock()
 			repo := user.NewRepository(db)
-			srv := NewService(repo)
+			srv := NewService(repo, token.NewRepository(db), encryption.NewPasswordHasher(encryption.DefaultArgon2Params))
 			usrLinkable, err := srv.Retrieve(context.Background(), testCase.id)
 
 			if err != nil && !testCase.errorPresent {
@@ -170,3 +259,188 @@ func TestRetrieve(t *testing.T) {
 		})
 	}
 }
+
+func TestRefresh(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	refreshColumns := []string{"id", "user_id", "token_hash", "expires_at", "revoked_at"}
+	refreshQuery := fmt.Sprintf("SELECT id, user_id, token_hash, expires_at, revoked_at FROM %s", token.RefreshTableName)
+	future := time.Now().Add(time.Hour)
+
+	userQuery := fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)
+	credentialsQuery := fmt.Sprintf("SELECT id, password_hash, role, disabled_at, email_verified_at, totp_enabled_at FROM %s", user.TableName)
+	credentialsColumns := []string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedErr  error
+		errorPresent bool
+	}{
+		{
+			name: "Should rotate token",
+			mock: func() {
+				mock.ExpectQuery(refreshQuery).
+					WithArgs(hashToken("refresh")).
+					WillReturnRows(sqlmock.NewRows(refreshColumns).AddRow(3, 1, hashToken("refresh"), future, nil))
+
+				mock.ExpectQuery(userQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(1, "check@check.com", "user"))
+
+				mock.ExpectQuery(credentialsQuery).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, "hash", "user", nil, nil, nil))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", token.RefreshTableName)).
+					WithArgs(sqlmock.AnyArg(), 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.RefreshTableName)).
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name: "Disabled or deleted user",
+			mock: func() {
+				mock.ExpectQuery(refreshQuery).
+					WithArgs(hashToken("refresh")).
+					WillReturnRows(sqlmock.NewRows(refreshColumns).AddRow(3, 1, hashToken("refresh"), future, nil))
+
+				mock.ExpectQuery(userQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(1, "check@check.com", "user"))
+
+				mock.ExpectQuery(credentialsQuery).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, "hash", "user", time.Now(), nil, nil))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at = (.+) WHERE revoked_at IS NULL AND user_id", token.RefreshTableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedErr:  service.ErrUserDisabled,
+			errorPresent: true,
+		},
+		{
+			name: "Unknown user",
+			mock: func() {
+				mock.ExpectQuery(refreshQuery).
+					WithArgs(hashToken("refresh")).
+					WillReturnRows(sqlmock.NewRows(refreshColumns).AddRow(3, 1, hashToken("refresh"), future, nil))
+
+				mock.ExpectQuery(userQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}))
+			},
+			expectedErr:  service.ErrInvalidRefreshToken,
+			errorPresent: true,
+		},
+		{
+			name: "Unknown token",
+			mock: func() {
+				mock.ExpectQuery(refreshQuery).
+					WithArgs(hashToken("refresh")).
+					WillReturnRows(sqlmock.NewRows(refreshColumns))
+			},
+			expectedErr:  service.ErrInvalidRefreshToken,
+			errorPresent: true,
+		},
+		{
+			name: "Expired token",
+			mock: func() {
+				mock.ExpectQuery(refreshQuery).
+					WithArgs(hashToken("refresh")).
+					WillReturnRows(sqlmock.NewRows(refreshColumns).
+						AddRow(3, 1, hashToken("refresh"), time.Now().Add(-time.Hour), nil))
+			},
+			expectedErr:  service.ErrInvalidRefreshToken,
+			errorPresent: true,
+		},
+		{
+			name: "Reused token revokes all tokens of user",
+			mock: func() {
+				mock.ExpectQuery(refreshQuery).
+					WithArgs(hashToken("refresh")).
+					WillReturnRows(sqlmock.NewRows(refreshColumns).
+						AddRow(3, 1, hashToken("refresh"), future, time.Now()))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at = (.+) WHERE revoked_at IS NULL AND user_id", token.RefreshTableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedErr:  service.ErrInvalidRefreshToken,
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(user.NewRepository(db), token.NewRepository(db), nil)
+
+			linkable, err := srv.Refresh(context.Background(), "refresh")
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if testCase.expectedErr != nil && !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil {
+				usr, ok := linkable.(*user.Resource)
+				if !ok {
+					t.Fatalf("Can't type assertion for user.Resource\n")
+				}
+
+				if usr.Token == "" || usr.RefreshToken == "" || usr.RefreshToken == "refresh" {
+					t.Errorf("Should return new tokens\n")
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	expiresAt := time.Now().Add(time.Minute)
+
+	mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", token.RefreshTableName)).
+		WithArgs(hashToken("refresh")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "revoked_at"}).
+			AddRow(3, 1, hashToken("refresh"), expiresAt, nil))
+
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", token.RefreshTableName)).
+		WithArgs(sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.RevokedTableName)).
+		WithArgs("jti", expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	srv := NewService(user.NewRepository(db), token.NewRepository(db), nil)
+	if err = srv.Logout(context.Background(), 1, "refresh", "jti", expiresAt); err != nil {
+		t.Errorf("Unexpected error: %s\n", err.Error())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/mail"
+)
+
+const (
+	// verifyEmailTD is lifetime of email verification token
+	verifyEmailTD = 24 * time.Hour
+	// resetPasswordTD is lifetime of password reset token
+	resetPasswordTD = time.Hour
+)
+
+// SendVerification sends email verification link to user, mail.ErrNotConfigured
+// returns if mailer isn't configured. Unknown, disabled
+// and already verified users are skipped without error, so the result doesn't
+// disclose registered emails
+func (srv *Service) SendVerification(ctx context.Context, email string) error {
+	if _, ok := srv.mailer.(mail.Disabled); ok {
+		return mail.ErrNotConfigured
+	}
+
+	cred, err := srv.repo.Credentials(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if cred.Disabled() || cred.Verified() {
+		return nil
+	}
+
+	link, err := srv.emailToken(ctx, cred.ID, token.PurposeVerifyEmail, "/verify-email", verifyEmailTD)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm your email by opening the link below, it is valid for %s:\n\n%s\n",
+		verifyEmailTD, link)
+
+	return srv.mailer.Send(ctx, email, "Confirm your email", body)
+}
+
+// VerifyEmail marks email of user as verified. Token can be used only once
+func (srv *Service) VerifyEmail(ctx context.Context, verifyToken string) error {
+	userID, err := srv.useEmailToken(ctx, token.PurposeVerifyEmail, verifyToken)
+	if err != nil {
+		return err
+	}
+
+	return srv.repo.VerifyEmail(ctx, userID)
+}
+
+// RequestPasswordReset sends password reset link to user, mail.ErrNotConfigured
+// returns if mailer isn't configured. Unknown and disabled
+// users are skipped without error, so the result doesn't disclose registered emails
+func (srv *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	if _, ok := srv.mailer.(mail.Disabled); ok {
+		return mail.ErrNotConfigured
+	}
+
+	cred, err := srv.repo.Credentials(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if cred.Disabled() {
+		return nil
+	}
+
+	link, err := srv.emailToken(ctx, cred.ID, token.PurposeResetPassword, "/reset-password", resetPasswordTD)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Set a new password by opening the link below, it is valid for %s:\n\n%s\n\n"+
+		"If you didn't request password reset, ignore this email.\n", resetPasswordTD, link)
+
+	return srv.mailer.Send(ctx, email, "Reset your password", body)
+}
+
+// PasswordResetEmail returns email of user of password reset token without using
+// the token, so new password can be checked against it before reset
+func (srv *Service) PasswordResetEmail(ctx context.Context, resetToken string) (string, error) {
+	if resetToken == "" {
+		return "", service.ErrInvalidEmailToken
+	}
+
+	userID, err := srv.tokens.EmailOwner(ctx, token.PurposeResetPassword, hashToken(resetToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", service.ErrInvalidEmailToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	linkable, err := srv.repo.Retrieve(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", service.ErrInvalidEmailToken
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	usr, ok := linkable.(*user.Resource)
+	if !ok {
+		return "", service.ErrInvalidTypeAssertion
+	}
+
+	return usr.Email, nil
+}
+
+// ResetPassword replaces password of user and revokes its refresh tokens.
+// Token can be used only once, it also confirms ownership of email
+func (srv *Service) ResetPassword(ctx context.Context, resetToken, password string) error {
+	userID, err := srv.useEmailToken(ctx, token.PurposeResetPassword, resetToken)
+	if err != nil {
+		return err
+	}
+
+	hash, err := srv.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err = srv.repo.UpdatePasswordHash(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	if err = srv.repo.VerifyEmail(ctx, userID); err != nil {
+		return err
+	}
+
+	return srv.tokens.RevokeUserRefresh(ctx, userID)
+}
+
+// emailToken creates token with purpose for user and returns link with it
+func (srv *Service) emailToken(ctx context.Context, userID int64, purpose, path string, ttl time.Duration) (string, error) { //nolint:lll
+	t, err := generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err = srv.tokens.CreateEmail(ctx, userID, purpose, hashToken(t), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%s?token=%s", srv.appURL, path, url.QueryEscape(t)), nil
+}
+
+// useEmailToken marks token as used and returns id of its user
+func (srv *Service) useEmailToken(ctx context.Context, purpose, t string) (int64, error) {
+	if t == "" {
+		return 0, service.ErrInvalidEmailToken
+	}
+
+	userID, err := srv.tokens.UseEmail(ctx, purpose, hashToken(t))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, service.ErrInvalidEmailToken
+	}
+
+	return userID, err
+}
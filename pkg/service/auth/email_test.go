@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var credentialsColumns = []string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}
+
+// mailerMock stores sent emails
+type mailerMock struct {
+	to   []string
+	body []string
+}
+
+func (m *mailerMock) Send(ctx context.Context, to, subject, body string) error {
+	m.to = append(m.to, to)
+	m.body = append(m.body, body)
+
+	return nil
+}
+
+func TestSendVerification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedSent bool
+	}{
+		{
+			name: "Not verified user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, "hash", "user", nil, nil, nil))
+
+				mock.ExpectBegin()
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at", token.EmailTableName)).
+					WithArgs(sqlmock.AnyArg(), token.PurposeVerifyEmail, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.EmailTableName)).
+					WithArgs(1, token.PurposeVerifyEmail, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedSent: true,
+		},
+		{
+			name: "Verified user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, "hash", "user", nil, time.Now(), nil))
+			},
+		},
+		{
+			name: "Unknown user",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			mailer := new(mailerMock)
+			srv := NewService(user.NewRepository(db), token.NewRepository(db), nil)
+			srv.mailer = mailer
+			srv.appURL = "http://localhost"
+
+			if err := srv.SendVerification(context.Background(), "check@check.com"); err != nil {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if sent := len(mailer.to) > 0; sent != testCase.expectedSent {
+				t.Errorf("Invalid sent, expected: %v, got: %v\n", testCase.expectedSent, sent)
+			}
+
+			link := regexp.MustCompile(`http://localhost/verify-email\?token=[A-Za-z0-9_-]{43}`)
+			if testCase.expectedSent && !link.MatchString(mailer.body[0]) {
+				t.Errorf("Email doesn't contain verification link, got: %s\n", mailer.body[0])
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	hasher := encryption.NewPasswordHasher(encryption.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		token       string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:  "Valid token",
+			token: "reset",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET used_at", token.EmailTableName)).
+					WithArgs(sqlmock.AnyArg(), token.PurposeResetPassword, hashToken("reset"), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password_hash", user.TableName)).
+					WithArgs(argon2Hash{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET email_verified_at", user.TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET revoked_at", token.RefreshTableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			name:  "Used or expired token",
+			token: "reset",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET used_at", token.EmailTableName)).
+					WithArgs(sqlmock.AnyArg(), token.PurposeResetPassword, hashToken("reset"), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			expectedErr: service.ErrInvalidEmailToken,
+		},
+		{
+			name:        "Empty token",
+			mock:        func() {},
+			expectedErr: service.ErrInvalidEmailToken,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(user.NewRepository(db), token.NewRepository(db), hasher)
+
+			err := srv.ResetPassword(context.Background(), testCase.token, "new_password")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestPasswordResetEmail(t *testing.T) {
+	hasher := encryption.NewPasswordHasher(encryption.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name          string
+		token         string
+		mock          func()
+		expectedEmail string
+		expectedErr   error
+	}{
+		{
+			name:  "Valid token isn't used",
+			token: "reset",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT user_id FROM %s", token.EmailTableName)).
+					WithArgs(token.PurposeResetPassword, hashToken("reset"), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(1, "check@check.com", "user"))
+			},
+			expectedEmail: "check@check.com",
+		},
+		{
+			name:  "Used or expired token",
+			token: "reset",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT user_id FROM %s", token.EmailTableName)).
+					WithArgs(token.PurposeResetPassword, hashToken("reset"), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			expectedErr: service.ErrInvalidEmailToken,
+		},
+		{
+			name:        "Empty token",
+			mock:        func() {},
+			expectedErr: service.ErrInvalidEmailToken,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(user.NewRepository(db), token.NewRepository(db), hasher)
+
+			email, err := srv.PasswordResetEmail(context.Background(), testCase.token)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if email != testCase.expectedEmail {
+				t.Errorf("Invalid email, expected: %s, got: %s\n", testCase.expectedEmail, email)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestGenerateTokenNotVerified(t *testing.T) {
+	hasher := encryption.NewPasswordHasher(encryption.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	hash, err := hasher.Hash("qweqweqwe")
+	if err != nil {
+		t.Fatalf("Unexpected error when hashing password, error: %s\n", err)
+	}
+
+	mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+		WithArgs("check@check.com").
+		WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, hash, "user", nil, nil, nil))
+
+	srv := NewService(user.NewRepository(db), token.NewRepository(db), hasher)
+	srv.requireVerified = true
+
+	_, err = srv.GenerateToken(context.Background(), &user.Resource{Email: "check@check.com", Password: "qweqweqwe"})
+	if !errors.Is(err, service.ErrEmailNotVerified) {
+		t.Errorf("Invalid error, expected: %v, got: %v\n", service.ErrEmailNotVerified, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/totp"
+
+	"github.com/google/jsonapi"
+)
+
+const (
+	// mfaChallengeTD is lifetime of MFA challenge returned by sign in
+	mfaChallengeTD = 5 * time.Minute
+	// recoveryCodesCount is number of recovery codes generated for user
+	recoveryCodesCount = 10
+	// recoveryCodeLen is length of recovery code without separator
+	recoveryCodeLen = 10
+
+	defaultMFAIssuer = "videocmprs"
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTOTP generates a new secret for user. It isn't required for sign in
+// until it is confirmed with ConfirmTOTP
+func (srv *Service) EnrollTOTP(ctx context.Context, userID int64) (jsonapi.Linkable, error) {
+	state, err := srv.repo.TOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Enabled() {
+		return nil, service.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := srv.repo.SetTOTPSecret(ctx, userID, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stored {
+		return nil, service.ErrMFAAlreadyEnabled
+	}
+
+	res := &user.TOTP{
+		ID:     userID,
+		Secret: secret,
+		URI:    totp.URI(srv.mfaIssuer, state.Email, secret),
+	}
+
+	return res, nil
+}
+
+// ConfirmTOTP enables two-factor authentication if code matches enrolled
+// secret and returns recovery codes. They are shown only once
+func (srv *Service) ConfirmTOTP(ctx context.Context, userID int64, code string) (jsonapi.Linkable, error) {
+	state, err := srv.repo.TOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Enabled() {
+		return nil, service.ErrMFAAlreadyEnabled
+	}
+
+	if !state.Secret.Valid {
+		return nil, service.ErrMFANotEnrolled
+	}
+
+	valid, err := srv.checkTOTP(ctx, userID, state, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		return nil, service.ErrInvalidMFACode
+	}
+
+	enabled, err := srv.repo.EnableTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		return nil, service.ErrMFAAlreadyEnabled
+	}
+
+	return srv.recoveryCodes(ctx, userID)
+}
+
+// DisableTOTP turns two-factor authentication off, code or recovery code is required
+func (srv *Service) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	state, err := srv.repo.TOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !state.Enabled() {
+		return service.ErrMFANotEnabled
+	}
+
+	valid, err := srv.checkSecondFactor(ctx, userID, state, code)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return service.ErrInvalidMFACode
+	}
+
+	if err = srv.repo.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+
+	return srv.tokens.ReplaceRecovery(ctx, userID, nil)
+}
+
+// RegenerateRecoveryCodes replaces recovery codes of user, previous codes stop working
+func (srv *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (jsonapi.Linkable, error) { //nolint:lll
+	state, err := srv.repo.TOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !state.Enabled() {
+		return nil, service.ErrMFANotEnabled
+	}
+
+	valid, err := srv.checkTOTP(ctx, userID, state, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		return nil, service.ErrInvalidMFACode
+	}
+
+	return srv.recoveryCodes(ctx, userID)
+}
+
+// VerifyMFA exchanges challenge and code or recovery code for access and
+// refresh tokens. Challenge can be used only once, wrong code requires signing
+// in again. On wrong code user is returned along with service.ErrInvalidMFACode,
+// so failed attempt can be counted
+func (srv *Service) VerifyMFA(ctx context.Context, challenge, code string) (jsonapi.Linkable, error) {
+	userID, err := srv.useEmailToken(ctx, token.PurposeMFAChallenge, challenge)
+	if errors.Is(err, service.ErrInvalidEmailToken) {
+		return nil, service.ErrInvalidMFAChallenge
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := srv.repo.TOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrInvalidMFAChallenge
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !state.Enabled() {
+		return nil, service.ErrInvalidMFAChallenge
+	}
+
+	valid, err := srv.checkSecondFactor(ctx, userID, state, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		return &user.Resource{ID: userID, Email: state.Email}, service.ErrInvalidMFACode
+	}
+
+	return srv.issue(ctx, userID, state.Email, state.Role)
+}
+
+// mfaChallenge creates single-use challenge for user who passed password check
+func (srv *Service) mfaChallenge(ctx context.Context, id int64, email string) (*user.Resource, error) {
+	challenge, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = srv.tokens.CreateEmail(ctx, id, token.PurposeMFAChallenge, hashToken(challenge), time.Now().Add(mfaChallengeTD))
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.Resource{ID: id, Email: email, MFAToken: challenge}, nil
+}
+
+// checkSecondFactor accepts TOTP code or unused recovery code
+func (srv *Service) checkSecondFactor(ctx context.Context, userID int64, state *user.TOTPState, code string) (bool, error) { //nolint:lll
+	if isTOTPCode(code) {
+		return srv.checkTOTP(ctx, userID, state, code)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLen {
+		return false, nil
+	}
+
+	return srv.tokens.UseRecovery(ctx, userID, hashToken(normalized))
+}
+
+// checkTOTP validates code against secret of user, every code is accepted only once
+func (srv *Service) checkTOTP(ctx context.Context, userID int64, state *user.TOTPState, code string) (bool, error) {
+	if !state.Secret.Valid || !isTOTPCode(code) {
+		return false, nil
+	}
+
+	step, valid, err := totp.Validate(state.Secret.String, code, time.Now())
+	if err != nil || !valid {
+		return false, err
+	}
+
+	return srv.repo.UseTOTPStep(ctx, userID, step)
+}
+
+// recoveryCodes generates and stores new recovery codes of user
+func (srv *Service) recoveryCodes(ctx context.Context, userID int64) (*user.TOTP, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b := make([]byte, recoveryCodeLen*5/8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes[i] = code[:recoveryCodeLen/2] + "-" + code[recoveryCodeLen/2:]
+		hashes[i] = hashToken(code)
+	}
+
+	if err := srv.tokens.ReplaceRecovery(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &user.TOTP{ID: userID, RecoveryCodes: codes}, nil
+}
+
+// isTOTPCode returns true if code consists of totp.Digits digits
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeRecoveryCode removes separators and whitespaces typed by user
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+	"github.com/Hargeon/videocmprs/pkg/service/totp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const mfaSecret = "JBSWY3DPEHPK3PXP"
+
+var totpColumns = []string{"email", "role", "totp_secret", "totp_enabled_at", "totp_last_step"}
+
+func currentCode(t *testing.T) string {
+	code, err := totp.Code(mfaSecret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return code
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "Not enabled",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", nil, nil, nil))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_secret", user.TableName)).
+					WithArgs(sqlmock.AnyArg(), nil, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Already enabled",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", mfaSecret, time.Now(), nil))
+			},
+			expectedErr: service.ErrMFAAlreadyEnabled,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(user.NewRepository(db), token.NewRepository(db), nil)
+
+			res, err := srv.EnrollTOTP(context.Background(), 1)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil {
+				enrollment, ok := res.(*user.TOTP)
+				if !ok {
+					t.Fatalf("Invalid type assertion\n")
+				}
+
+				if enrollment.Secret == "" || enrollment.URI == "" {
+					t.Errorf("Secret and uri should be present\n")
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		code        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "Valid code",
+			code: currentCode(t),
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", mfaSecret, nil, nil))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_last_step", user.TableName)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_enabled_at", user.TableName)).
+					WithArgs(sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectBegin()
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", token.RecoveryTableName)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.RecoveryTableName)).
+					WillReturnResult(sqlmock.NewResult(10, 10))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Invalid code",
+			code: "000000",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", mfaSecret, nil, nil))
+			},
+			expectedErr: service.ErrInvalidMFACode,
+		},
+		{
+			name: "Replayed code",
+			code: currentCode(t),
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", mfaSecret, nil, nil))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_last_step", user.TableName)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: service.ErrInvalidMFACode,
+		},
+		{
+			name: "Not enrolled",
+			code: "000000",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", nil, nil, nil))
+			},
+			expectedErr: service.ErrMFANotEnrolled,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(user.NewRepository(db), token.NewRepository(db), nil)
+
+			res, err := srv.ConfirmTOTP(context.Background(), 1, testCase.code)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil {
+				codes, ok := res.(*user.TOTP)
+				if !ok {
+					t.Fatalf("Invalid type assertion\n")
+				}
+
+				if len(codes.RecoveryCodes) != recoveryCodesCount {
+					t.Errorf("Invalid recovery codes count, expected: %d, got: %d\n", recoveryCodesCount,
+						len(codes.RecoveryCodes))
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestVerifyMFA(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	useChallenge := func() {
+		mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET used_at", token.EmailTableName)).
+			WithArgs(sqlmock.AnyArg(), token.PurposeMFAChallenge, hashToken("challenge"), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+
+		mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(totpColumns).AddRow("check@check.com", "user", mfaSecret, time.Now(), nil))
+	}
+
+	cases := []struct {
+		name          string
+		code          string
+		mock          func()
+		expectedErr   error
+		expectedToken bool
+	}{
+		{
+			name: "Valid code",
+			code: currentCode(t),
+			mock: func() {
+				useChallenge()
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_last_step", user.TableName)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.RefreshTableName)).
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedToken: true,
+		},
+		{
+			name: "Valid recovery code",
+			code: "ABCDE-fghij",
+			mock: func() {
+				useChallenge()
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at", token.RecoveryTableName)).
+					WithArgs(sqlmock.AnyArg(), hashToken("abcdefghij"), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.RefreshTableName)).
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedToken: true,
+		},
+		{
+			name: "Used recovery code",
+			code: "abcde-fghij",
+			mock: func() {
+				useChallenge()
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at", token.RecoveryTableName)).
+					WithArgs(sqlmock.AnyArg(), hashToken("abcdefghij"), 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: service.ErrInvalidMFACode,
+		},
+		{
+			name: "Invalid code",
+			code: "000000",
+			mock: func() {
+				useChallenge()
+			},
+			expectedErr: service.ErrInvalidMFACode,
+		},
+		{
+			name: "Invalid challenge",
+			code: "000000",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET used_at", token.EmailTableName)).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+			},
+			expectedErr: service.ErrInvalidMFAChallenge,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(user.NewRepository(db), token.NewRepository(db), nil)
+
+			res, err := srv.VerifyMFA(context.Background(), "challenge", testCase.code)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			usr, _ := res.(*user.Resource)
+			if errors.Is(err, service.ErrInvalidMFACode) && (usr == nil || usr.Email != "check@check.com") {
+				t.Errorf("User should be returned with invalid code\n")
+			}
+
+			if hasToken := usr != nil && usr.Token != ""; hasToken != testCase.expectedToken {
+				t.Errorf("Invalid token present, expected: %v, got: %v\n", testCase.expectedToken, hasToken)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestGenerateTokenMFA(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	hasher := encryption.NewPasswordHasher(encryption.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+
+	hash, err := hasher.Hash("qweqweqwe")
+	if err != nil {
+		t.Fatalf("Unexpected error when hashing password, error: %s\n", err)
+	}
+
+	mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+		WithArgs("check@check.com").
+		WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, hash, "user", nil, time.Now(), time.Now()))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_at", token.EmailTableName)).
+		WithArgs(sqlmock.AnyArg(), token.PurposeMFAChallenge, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.EmailTableName)).
+		WithArgs(1, token.PurposeMFAChallenge, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	srv := NewService(user.NewRepository(db), token.NewRepository(db), hasher)
+
+	res, err := srv.GenerateToken(context.Background(), &user.Resource{Email: "check@check.com", Password: "qweqweqwe"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	usr, ok := res.(*user.Resource)
+	if !ok {
+		t.Fatalf("Invalid type assertion\n")
+	}
+
+	if usr.MFAToken == "" || usr.Token != "" || usr.RefreshToken != "" {
+		t.Errorf("Only MFA token should be returned, got: %#v\n", usr)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
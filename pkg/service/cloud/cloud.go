@@ -1,13 +1,19 @@
-// Package cloud uses for uploading video to cloud
+// Package cloud uses for uploading video to cloud or to local directory
 package cloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"time"
 
+	"github.com/Hargeon/videocmprs/pkg/service"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -17,26 +23,35 @@ import (
 
 const presignTime = time.Minute
 
+// ErrNotFound returns by storages if file is not present
+var ErrNotFound = errors.New("file not found")
+
 // AWSS3 represent aws s3 storage
 type AWSS3 struct {
 	bucketName string
 	accessKey  string
 	secretKey  string
 	region     string
+	encryption S3Encryption
+	// signer generates download urls served by application, objects encrypted
+	// with SSE-C can't be downloaded by presigned urls
+	signer *URLSigner
 }
 
 // NewS3Storage initialize *AWS3
-func NewS3Storage(bucketName, region, accessKey, secretKey string) *AWSS3 {
+func NewS3Storage(bucketName, region, accessKey, secretKey string, enc S3Encryption, signer *URLSigner) *AWSS3 {
 	return &AWSS3{
 		bucketName: bucketName,
 		accessKey:  accessKey,
 		secretKey:  secretKey,
 		region:     region,
+		encryption: enc,
+		signer:     signer,
 	}
 }
 
-// Upload file to aws s3
-func (cloud *AWSS3) Upload(ctx context.Context, header *multipart.FileHeader) (string, error) {
+// Upload file to aws s3, it is encrypted by S3 if server-side encryption is set
+func (cloud *AWSS3) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
 	file, err := header.Open()
 	if err != nil {
 		return "", err
@@ -51,11 +66,14 @@ func (cloud *AWSS3) Upload(ctx context.Context, header *multipart.FileHeader) (s
 	uploader := s3manager.NewUploader(sess)
 
 	newFileName := fmt.Sprintf("%s_%s", uuid.New().String(), header.Filename)
-	_, err = uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+	input := &s3manager.UploadInput{
 		Body:   file,
 		Bucket: aws.String(cloud.bucketName),
 		Key:    aws.String(newFileName),
-	})
+	}
+	cloud.encryption.applyUpload(input)
+
+	_, err = uploader.UploadWithContext(ctx, input)
 
 	if err != nil {
 		return "", err
@@ -64,7 +82,22 @@ func (cloud *AWSS3) Upload(ctx context.Context, header *multipart.FileHeader) (s
 	return newFileName, nil
 }
 
+// WorkerReadable returns false if objects are encrypted with SSE-C, workers
+// don't get the customer key
+func (cloud *AWSS3) WorkerReadable() bool {
+	return cloud.encryption.Mode != SSEC
+}
+
+// URL returns presigned url of file, or url served by application if SSE-C is used
 func (cloud *AWSS3) URL(filename string) (string, error) {
+	if cloud.encryption.Mode == SSEC {
+		if cloud.signer == nil {
+			return "", errors.New("download urls of SSE-C objects require STORAGE_URL_SECRET")
+		}
+
+		return cloud.signer.URL(filename), nil
+	}
+
 	sess, err := cloud.session()
 
 	if err != nil {
@@ -80,6 +113,126 @@ func (cloud *AWSS3) URL(filename string) (string, error) {
 	return req.Presign(presignTime)
 }
 
+// Delete file from aws s3
+func (cloud *AWSS3) Delete(ctx context.Context, filename string) error {
+	sess, err := cloud.session()
+
+	if err != nil {
+		return err
+	}
+
+	s3svc := s3.New(sess)
+	_, err = s3svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(cloud.bucketName),
+		Key:    aws.String(filename),
+	})
+
+	return err
+}
+
+// List returns all files stored in aws s3 bucket
+func (cloud *AWSS3) List(ctx context.Context) ([]service.CloudObject, error) {
+	sess, err := cloud.session()
+
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]service.CloudObject, 0)
+	s3svc := s3.New(sess)
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(cloud.bucketName),
+	}
+
+	err = s3svc.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			objects = append(objects, service.CloudObject{
+				Name:         aws.StringValue(obj.Key),
+				Size:         aws.Int64Value(obj.Size),
+				LastModified: aws.TimeValue(obj.LastModified),
+			})
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// Stat returns information about file stored in aws s3
+func (cloud *AWSS3) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	sess, err := cloud.session()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s3svc := s3.New(sess)
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(cloud.bucketName),
+		Key:    aws.String(filename),
+	}
+	cloud.encryption.applyHead(input)
+
+	out, err := s3svc.HeadObjectWithContext(ctx, input)
+
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &service.CloudObject{
+		Name:         filename,
+		Size:         aws.Int64Value(out.ContentLength),
+		LastModified: aws.TimeValue(out.LastModified),
+		ETag:         aws.StringValue(out.ETag),
+	}, nil
+}
+
+// Open returns reader of length bytes of file stored in aws s3 starting from offset
+func (cloud *AWSS3) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	sess, err := cloud.session()
+
+	if err != nil {
+		return nil, err
+	}
+
+	s3svc := s3.New(sess)
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(cloud.bucketName),
+		Key:    aws.String(filename),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	}
+	cloud.encryption.applyGet(input)
+
+	out, err := s3svc.GetObjectWithContext(ctx, input)
+
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return out.Body, nil
+}
+
+// notFound replaces errors of missing objects with ErrNotFound. HEAD responses
+// have no body, so S3 reports them only by status code
+func notFound(err error) error {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return ErrNotFound
+	}
+
+	return err
+}
+
 func (cloud *AWSS3) session() (*session.Session, error) {
 	return session.NewSession(
 		&aws.Config{
@@ -0,0 +1,50 @@
+package cloud
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestNotFound(t *testing.T) {
+	other := errors.New("connection refused")
+	denied := awserr.NewRequestFailure(awserr.New("Forbidden", "Forbidden", nil), http.StatusForbidden, "id")
+
+	cases := []struct {
+		name        string
+		err         error
+		expectedErr error
+	}{
+		{
+			name:        "Missing object of GET",
+			err:         awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil),
+			expectedErr: ErrNotFound,
+		},
+		{
+			name:        "Missing object of HEAD",
+			err:         awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), http.StatusNotFound, "id"),
+			expectedErr: ErrNotFound,
+		},
+		{
+			name:        "Access denied",
+			err:         denied,
+			expectedErr: denied,
+		},
+		{
+			name:        "Other error",
+			err:         other,
+			expectedErr: other,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := notFound(testCase.err); err != testCase.expectedErr {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+		})
+	}
+}
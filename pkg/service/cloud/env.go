@@ -0,0 +1,119 @@
+package cloud
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/service"
+)
+
+// BackendLocal is value of STORAGE_BACKEND which keeps files in LOCAL_STORAGE_DIR
+const BackendLocal = "local"
+
+// ErrStorageAPIOnly returns if storage can't be read by compression workers and
+// it isn't allowed by STORAGE_API_ONLY
+var ErrStorageAPIOnly = errors.New("storage can't be read by compression workers, " +
+	"set STORAGE_API_ONLY=true to use it without compression")
+
+// NewEnvURLSigner initialize URLSigner with STORAGE_URL_SECRET and BASE_URL env
+// variables, urls are valid for STORAGE_URL_TTL (default 1m). Returns nil if
+// secret is empty
+func NewEnvURLSigner() *URLSigner {
+	secret := os.Getenv("STORAGE_URL_SECRET")
+	if secret == "" {
+		return nil
+	}
+
+	ttl, err := time.ParseDuration(os.Getenv("STORAGE_URL_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = presignTime
+	}
+
+	return NewURLSigner([]byte(secret), strings.TrimSuffix(os.Getenv("BASE_URL"), "/"), ttl)
+}
+
+// NewEnvStorage initialize storage selected by STORAGE_BACKEND env variable.
+// S3 is used by default, objects are encrypted according to AWS_SSE (SSE-S3,
+// SSE-KMS with AWS_SSE_KMS_KEY_ID or SSE-C with base64 AWS_SSE_CUSTOMER_KEY).
+// Local storage encrypts files with data keys of users from keys if base64
+// LOCAL_STORAGE_MASTER_KEY is set. Local storage and SSE-C can't be read by
+// compression workers, they are used only with STORAGE_API_ONLY=true
+func NewEnvStorage(keys repository.DataKeyRepository) (service.CloudStorage, error) {
+	storage, err := newEnvStorage(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	apiOnly, _ := strconv.ParseBool(os.Getenv("STORAGE_API_ONLY"))
+
+	if ws, ok := storage.(service.WorkerStorage); ok && !ws.WorkerReadable() && !apiOnly {
+		return nil, ErrStorageAPIOnly
+	}
+
+	return storage, nil
+}
+
+func newEnvStorage(keys repository.DataKeyRepository) (service.CloudStorage, error) {
+	signer := NewEnvURLSigner()
+
+	if os.Getenv("STORAGE_BACKEND") == BackendLocal {
+		return newEnvLocalStorage(keys, signer)
+	}
+
+	enc := S3Encryption{Mode: os.Getenv("AWS_SSE"), KMSKeyID: os.Getenv("AWS_SSE_KMS_KEY_ID")}
+
+	if enc.Mode == SSEC {
+		key, err := base64.StdEncoding.DecodeString(os.Getenv("AWS_SSE_CUSTOMER_KEY"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid AWS_SSE_CUSTOMER_KEY: %w", err)
+		}
+
+		enc.CustomerKey = key
+	}
+
+	if err := enc.Validate(); err != nil {
+		return nil, err
+	}
+
+	return NewS3Storage(
+		os.Getenv("AWS_BUCKET_NAME"),
+		os.Getenv("AWS_REGION"),
+		os.Getenv("AWS_ACCESS_KEY"),
+		os.Getenv("AWS_SECRET_KEY"),
+		enc,
+		signer), nil
+}
+
+func newEnvLocalStorage(keys repository.DataKeyRepository, signer *URLSigner) (*Local, error) {
+	dir := os.Getenv("LOCAL_STORAGE_DIR")
+	if dir == "" {
+		return nil, errors.New("LOCAL_STORAGE_DIR is required for local storage")
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	master := os.Getenv("LOCAL_STORAGE_MASTER_KEY")
+	if master == "" {
+		return NewLocalStorage(dir, nil, signer), nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(master)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOCAL_STORAGE_MASTER_KEY: %w", err)
+	}
+
+	ring, err := NewKeyRing(key, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLocalStorage(dir, ring, signer), nil
+}
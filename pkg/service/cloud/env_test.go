@@ -0,0 +1,46 @@
+package cloud
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestNewEnvStorageAPIOnly(t *testing.T) {
+	os.Setenv("STORAGE_BACKEND", BackendLocal)
+	defer os.Unsetenv("STORAGE_BACKEND")
+
+	os.Setenv("LOCAL_STORAGE_DIR", t.TempDir())
+	defer os.Unsetenv("LOCAL_STORAGE_DIR")
+
+	cases := []struct {
+		name        string
+		apiOnly     string
+		expectedErr error
+	}{
+		{
+			name:        "Local storage isn't allowed for compression",
+			expectedErr: ErrStorageAPIOnly,
+		},
+		{
+			name:    "Local storage is allowed without compression",
+			apiOnly: "true",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			os.Setenv("STORAGE_API_ONLY", testCase.apiOnly)
+			defer os.Unsetenv("STORAGE_API_ONLY")
+
+			storage, err := NewEnvStorage(nil)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && storage == nil {
+				t.Errorf("Should return storage\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package cloud
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Encrypted file starts with header of magic, id of user which data key
+// encrypts the file and nonce prefix. Content is split in chunks sealed
+// separately with AES-GCM, so any range of file can be decrypted
+const (
+	chunkSize   = 64 * 1024
+	tagSize     = 16
+	headerSize  = 4 + 8 + nonceSize
+	nonceSize   = 12
+	sealedChunk = chunkSize + tagSize
+)
+
+var encryptedMagic = []byte("VCE1")
+
+// ErrInvalidEncryptedFile returns if encrypted file is truncated or broken
+var ErrInvalidEncryptedFile = errors.New("invalid encrypted file")
+
+// fileHeader is header of encrypted file
+type fileHeader struct {
+	userID int64
+	nonce  [nonceSize]byte
+}
+
+func (h *fileHeader) bytes() []byte {
+	b := make([]byte, 0, headerSize)
+	b = append(b, encryptedMagic...)
+	b = append(b, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(b[4:12], uint64(h.userID))
+
+	return append(b, h.nonce[:]...)
+}
+
+// readFileHeader returns header of encrypted file, nil if file isn't encrypted
+func readFileHeader(r io.Reader) (*fileHeader, error) {
+	b := make([]byte, headerSize)
+
+	if _, err := io.ReadFull(r, b); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if !bytes.Equal(b[:4], encryptedMagic) {
+		return nil, nil
+	}
+
+	h := &fileHeader{userID: int64(binary.BigEndian.Uint64(b[4:12]))}
+	copy(h.nonce[:], b[12:])
+
+	return h, nil
+}
+
+// chunkNonce returns nonce of chunk, its index is XORed into nonce prefix
+func (h *fileHeader) chunkNonce(index uint64) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, h.nonce[:])
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], index)
+
+	for i := range counter {
+		nonce[nonceSize-8+i] ^= counter[i]
+	}
+
+	return nonce
+}
+
+// chunkAAD binds chunk to header, its position and to end of file,
+// so chunks can't be reordered or file truncated
+func (h *fileHeader) chunkAAD(index uint64, last bool) []byte {
+	aad := h.bytes()
+	aad = append(aad, make([]byte, 9)...)
+	binary.BigEndian.PutUint64(aad[headerSize:], index)
+
+	if last {
+		aad[len(aad)-1] = 1
+	}
+
+	return aad
+}
+
+// plainSize returns size of content of encrypted file of size bytes
+func plainSize(size int64) (int64, error) {
+	body := size - headerSize
+	if body < tagSize {
+		return 0, ErrInvalidEncryptedFile
+	}
+
+	chunks := (body + sealedChunk - 1) / sealedChunk
+	if body-(chunks-1)*sealedChunk < tagSize {
+		return 0, ErrInvalidEncryptedFile
+	}
+
+	return body - chunks*tagSize, nil
+}
+
+// encryptWriter seals content written to it in chunks, Close seals the last one
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header *fileHeader
+	buf    []byte
+	index  uint64
+}
+
+func newEncryptWriter(w io.Writer, aead cipher.AEAD, header *fileHeader) (*encryptWriter, error) {
+	if _, err := w.Write(header.bytes()); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{w: w, aead: aead, header: header, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	var written int
+
+	for len(p) > 0 {
+		// full chunk is sealed only when more content comes, the last chunk is sealed by Close
+		if len(e.buf) == chunkSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(e.buf[len(e.buf):chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close seals the last chunk, it doesn't close underlying writer
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	sealed := e.aead.Seal(nil, e.header.chunkNonce(e.index), e.buf, e.header.chunkAAD(e.index, last))
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+
+	e.index++
+	e.buf = e.buf[:0]
+
+	return nil
+}
+
+// decryptReader returns length bytes of content of encrypted file starting from offset
+type decryptReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	header *fileHeader
+	index  uint64
+	last   uint64
+	skip   int
+	left   int64
+	plain  []byte
+}
+
+// newDecryptReader seeks f to chunk with offset. size is size of encrypted file
+func newDecryptReader(f io.ReadSeeker, aead cipher.AEAD, header *fileHeader, size, offset, length int64) (*decryptReader, error) { //nolint:lll
+	plain, err := plainSize(size)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset < 0 || length < 0 || offset+length > plain {
+		return nil, errors.New("range is out of file")
+	}
+
+	chunks := (size - headerSize + sealedChunk - 1) / sealedChunk
+	index := offset / chunkSize
+
+	if _, err = f.Seek(headerSize+index*sealedChunk, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:      f,
+		aead:   aead,
+		header: header,
+		index:  uint64(index),
+		last:   uint64(chunks - 1),
+		skip:   int(offset % chunkSize),
+		left:   length,
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	if d.left == 0 {
+		return 0, io.EOF
+	}
+
+	if len(d.plain) == 0 {
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if int64(len(p)) > d.left {
+		p = p[:d.left]
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	d.left -= int64(n)
+
+	return n, nil
+}
+
+// open reads and decrypts next chunk
+func (d *decryptReader) open() error {
+	if d.index > d.last {
+		return io.ErrUnexpectedEOF
+	}
+
+	sealed := make([]byte, sealedChunk)
+
+	n, err := io.ReadFull(d.r, sealed)
+	if err != nil && !(errors.Is(err, io.ErrUnexpectedEOF) && d.index == d.last) {
+		return err
+	}
+
+	last := d.index == d.last
+
+	plain, err := d.aead.Open(sealed[:0], d.header.chunkNonce(d.index), sealed[:n], d.header.chunkAAD(d.index, last))
+	if err != nil {
+		return ErrInvalidEncryptedFile
+	}
+
+	d.index++
+	d.plain = plain[d.skip:]
+	d.skip = 0
+
+	return nil
+}
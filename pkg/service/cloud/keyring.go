@@ -0,0 +1,120 @@
+package cloud
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+)
+
+// KeySize is size of master and data keys, AES-256 is used
+const KeySize = 32
+
+// KeyRing keeps data keys of users. Data key is generated on the first upload of
+// user and stored wrapped by master key, unwrapped keys are cached in memory
+type KeyRing struct {
+	master cipher.AEAD
+	repo   repository.DataKeyRepository
+
+	mu   sync.Mutex
+	keys map[int64]cipher.AEAD
+}
+
+// NewKeyRing initialize KeyRing with 32 bytes master key
+func NewKeyRing(master []byte, repo repository.DataKeyRepository) (*KeyRing, error) {
+	aead, err := newAEAD(master)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %w", err)
+	}
+
+	return &KeyRing{master: aead, repo: repo, keys: make(map[int64]cipher.AEAD)}, nil
+}
+
+// DataKey returns cipher with data key of user
+func (k *KeyRing) DataKey(ctx context.Context, userID int64) (cipher.AEAD, error) {
+	k.mu.Lock()
+	aead, ok := k.keys[userID]
+	k.mu.Unlock()
+
+	if ok {
+		return aead, nil
+	}
+
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := k.wrap(userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if wrapped, err = k.repo.FindOrCreate(ctx, userID, wrapped); err != nil {
+		return nil, err
+	}
+
+	if key, err = k.unwrap(userID, wrapped); err != nil {
+		return nil, err
+	}
+
+	if aead, err = newAEAD(key); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.keys[userID] = aead
+	k.mu.Unlock()
+
+	return aead, nil
+}
+
+// wrap seals data key with master key, id of user is authenticated with it
+func (k *KeyRing) wrap(userID int64, key []byte) ([]byte, error) {
+	nonce := make([]byte, k.master.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return k.master.Seal(nonce, nonce, key, userAAD(userID)), nil
+}
+
+func (k *KeyRing) unwrap(userID int64, wrapped []byte) ([]byte, error) {
+	size := k.master.NonceSize()
+	if len(wrapped) < size {
+		return nil, errors.New("invalid wrapped data key")
+	}
+
+	key, err := k.master.Open(nil, wrapped[:size], wrapped[size:], userAAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("can't unwrap data key of user %d: %w", userID, err)
+	}
+
+	return key, nil
+}
+
+func userAAD(userID int64) []byte {
+	aad := make([]byte, 8)
+	binary.BigEndian.PutUint64(aad, uint64(userID))
+
+	return aad
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes", KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
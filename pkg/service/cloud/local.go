@@ -0,0 +1,262 @@
+package cloud
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidFileName returns if file name points outside of storage directory
+var ErrInvalidFileName = errors.New("invalid file name")
+
+// Local represent storage of files in local directory. If keys are set, files
+// are encrypted with data keys of their owners, files stored before encryption
+// was enabled are still read as plain
+type Local struct {
+	dir    string
+	keys   *KeyRing
+	signer *URLSigner
+}
+
+// NewLocalStorage initialize *Local. Nil keys stores files without encryption
+func NewLocalStorage(dir string, keys *KeyRing, signer *URLSigner) *Local {
+	return &Local{dir: dir, keys: keys, signer: signer}
+}
+
+// WorkerReadable returns false, local storage is served only by API
+func (l *Local) WorkerReadable() bool {
+	return false
+}
+
+// Upload file to storage directory
+func (l *Local) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
+	src, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	newFileName := fmt.Sprintf("%s_%s", uuid.New().String(), filepath.Base(header.Filename))
+
+	// file is written under temporary name, so it isn't listed until it's complete
+	tmp, err := os.CreateTemp(l.dir, ".upload-")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err = l.write(ctx, tmp, src, userID); err != nil {
+		return "", err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err = os.Rename(tmp.Name(), filepath.Join(l.dir, newFileName)); err != nil {
+		return "", err
+	}
+
+	return newFileName, nil
+}
+
+// write content of src to dst, encrypted with data key of user if keys are set
+func (l *Local) write(ctx context.Context, dst io.Writer, src io.Reader, userID int64) error {
+	if l.keys == nil {
+		_, err := io.Copy(dst, src)
+
+		return err
+	}
+
+	aead, err := l.keys.DataKey(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	h := &fileHeader{userID: userID}
+	if _, err = rand.Read(h.nonce[:]); err != nil {
+		return err
+	}
+
+	w, err := newEncryptWriter(dst, aead, h)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(w, src); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// URL returns signed url of file served by application
+func (l *Local) URL(filename string) (string, error) {
+	if l.signer == nil {
+		return "", errors.New("download urls of local storage require STORAGE_URL_SECRET")
+	}
+
+	return l.signer.URL(filename), nil
+}
+
+// Delete file from storage directory
+func (l *Local) Delete(ctx context.Context, filename string) error {
+	path, err := l.path(filename)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// List returns all files stored in storage directory
+func (l *Local) List(ctx context.Context) ([]service.CloudObject, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]service.CloudObject, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		obj, err := l.Stat(ctx, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, *obj)
+	}
+
+	return objects, nil
+}
+
+// Stat returns information about file, size of encrypted file is size of its content
+func (l *Local) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	f, info, header, err := l.open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size := info.Size()
+	if header != nil {
+		if size, err = plainSize(size); err != nil {
+			return nil, err
+		}
+	}
+
+	return &service.CloudObject{
+		Name:         filename,
+		Size:         size,
+		LastModified: info.ModTime(),
+		ETag:         fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), size),
+	}, nil
+}
+
+// Open returns reader of length bytes of file starting from offset, encrypted
+// file is decrypted with data key of its owner
+func (l *Local) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	f, info, header, err := l.open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if header == nil {
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+
+			return nil, err
+		}
+
+		return readCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
+	}
+
+	if l.keys == nil {
+		f.Close()
+
+		return nil, errors.New("file is encrypted, LOCAL_STORAGE_MASTER_KEY is required")
+	}
+
+	aead, err := l.keys.DataKey(ctx, header.userID)
+	if err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	r, err := newDecryptReader(f, aead, header, info.Size(), offset, length)
+	if err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return readCloser{Reader: r, Closer: f}, nil
+}
+
+// open file and read header of encrypted file, header is nil if file isn't encrypted
+func (l *Local) open(filename string) (*os.File, os.FileInfo, *fileHeader, error) {
+	path, err := l.path(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+
+		return nil, nil, nil, err
+	}
+
+	header, err := readFileHeader(f)
+	if err != nil {
+		f.Close()
+
+		return nil, nil, nil, err
+	}
+
+	return f, info, header, nil
+}
+
+// path returns path of file in storage directory
+func (l *Local) path(filename string) (string, error) {
+	if filename == "" || filename != filepath.Base(filename) || strings.HasPrefix(filename, ".") {
+		return "", ErrInvalidFileName
+	}
+
+	return filepath.Join(l.dir, filename), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,278 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// keyRepoMock keeps wrapped data keys in memory
+type keyRepoMock struct {
+	mu   sync.Mutex
+	keys map[int64][]byte
+}
+
+func (r *keyRepoMock) FindOrCreate(ctx context.Context, userID int64, wrapped []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys == nil {
+		r.keys = make(map[int64][]byte)
+	}
+
+	if key, ok := r.keys[userID]; ok {
+		return key, nil
+	}
+
+	r.keys[userID] = wrapped
+
+	return wrapped, nil
+}
+
+// multipartFile returns header of multipart file with content
+func multipartFile(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	part, err := writer.CreateFormFile("video", name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = part.Write(content); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	req := httptest.NewRequest("POST", "/", buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, header, err := req.FormFile("video")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return header
+}
+
+func newKeyRing(t *testing.T, repo *keyRepoMock) *KeyRing {
+	master := make([]byte, KeySize)
+	if _, err := rand.Read(master); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	ring, err := NewKeyRing(master, repo)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return ring
+}
+
+func TestLocal(t *testing.T) {
+	content := make([]byte, 2*chunkSize+100)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	cases := []struct {
+		name    string
+		keys    *KeyRing
+		content []byte
+	}{
+		{
+			name:    "Plain",
+			content: content,
+		},
+		{
+			name:    "Encrypted",
+			keys:    newKeyRing(t, new(keyRepoMock)),
+			content: content,
+		},
+		{
+			name:    "Encrypted full chunk",
+			keys:    newKeyRing(t, new(keyRepoMock)),
+			content: content[:chunkSize],
+		},
+		{
+			name:    "Encrypted empty file",
+			keys:    newKeyRing(t, new(keyRepoMock)),
+			content: []byte{},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			storage := NewLocalStorage(dir, testCase.keys, nil)
+
+			name, err := storage.Upload(ctx, 1, multipartFile(t, "video.mkv", testCase.content))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			stored, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			encrypted := !bytes.Equal(stored, testCase.content)
+			if encrypted != (testCase.keys != nil) {
+				t.Errorf("Invalid stored file, encrypted: %v\n", encrypted)
+			}
+
+			obj, err := storage.Stat(ctx, name)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if obj.Size != int64(len(testCase.content)) {
+				t.Errorf("Invalid size, expected: %d, got: %d\n", len(testCase.content), obj.Size)
+			}
+
+			size := int64(len(testCase.content))
+			ranges := [][2]int64{{0, size}, {size / 2, size - size/2}}
+
+			if size > chunkSize {
+				ranges = append(ranges, [2]int64{chunkSize - 10, 20}, [2]int64{chunkSize, 1})
+			}
+
+			for _, r := range ranges {
+				body, err := storage.Open(ctx, name, r[0], r[1])
+				if err != nil {
+					t.Fatalf("Unexpected error: %s\n", err)
+				}
+
+				got, err := io.ReadAll(body)
+				body.Close()
+
+				if err != nil {
+					t.Fatalf("Unexpected error: %s\n", err)
+				}
+
+				if !bytes.Equal(got, testCase.content[r[0]:r[0]+r[1]]) {
+					t.Errorf("Invalid content of range %d-%d\n", r[0], r[0]+r[1])
+				}
+			}
+
+			objects, err := storage.List(ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if len(objects) != 1 || objects[0].Name != name {
+				t.Errorf("Invalid list of files: %v\n", objects)
+			}
+
+			if err = storage.Delete(ctx, name); err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if _, err = storage.Stat(ctx, name); !errors.Is(err, ErrNotFound) {
+				t.Errorf("File should be deleted, error: %v\n", err)
+			}
+		})
+	}
+}
+
+func TestLocal_TamperedFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	storage := NewLocalStorage(dir, newKeyRing(t, new(keyRepoMock)), nil)
+
+	name, err := storage.Upload(ctx, 1, multipartFile(t, "video.mkv", bytes.Repeat([]byte("a"), 100)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	path := filepath.Join(dir, name)
+
+	stored, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	stored[headerSize] ^= 1
+	if err = os.WriteFile(path, stored, 0o600); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	body, err := storage.Open(ctx, name, 0, 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+	defer body.Close()
+
+	if _, err = io.ReadAll(body); !errors.Is(err, ErrInvalidEncryptedFile) {
+		t.Errorf("Invalid error, expected: %s, got: %v\n", ErrInvalidEncryptedFile, err)
+	}
+}
+
+func TestLocal_DataKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := new(keyRepoMock)
+	ring := newKeyRing(t, repo)
+
+	first, err := ring.DataKey(ctx, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	// data key is unwrapped from repository by other instance with the same master key
+	other := &KeyRing{master: ring.master, repo: repo, keys: make(map[int64]cipher.AEAD)}
+
+	second, err := other.DataKey(ctx, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	nonce := make([]byte, nonceSize)
+	sealed := first.Seal(nil, nonce, []byte("content"), nil)
+
+	if _, err = second.Open(nil, nonce, sealed, nil); err != nil {
+		t.Errorf("Data keys of user should be equal, error: %s\n", err)
+	}
+
+	// wrapped key of one user can't be used for other user
+	repo.keys[2] = repo.keys[1]
+	if _, err = other.DataKey(ctx, 2); err == nil {
+		t.Errorf("Should be error\n")
+	}
+}
+
+func TestLocal_InvalidFileName(t *testing.T) {
+	storage := NewLocalStorage(t.TempDir(), nil, nil)
+
+	for _, name := range []string{"../secret", ".upload-1", "", "dir/file"} {
+		if _, err := storage.Stat(context.Background(), name); !errors.Is(err, ErrInvalidFileName) {
+			t.Errorf("Invalid error for %q, expected: %s, got: %v\n", name, ErrInvalidFileName, err)
+		}
+	}
+}
+
+func TestWorkerReadable(t *testing.T) {
+	if NewLocalStorage(t.TempDir(), nil, nil).WorkerReadable() {
+		t.Error("Local storage should not be readable by workers\n")
+	}
+
+	if !NewS3Storage("bucket", "region", "key", "secret", S3Encryption{Mode: SSEKMS}, nil).WorkerReadable() {
+		t.Error("SSE-KMS objects should be readable by workers\n")
+	}
+
+	if NewS3Storage("bucket", "region", "key", "secret", S3Encryption{Mode: SSEC}, nil).WorkerReadable() {
+		t.Error("SSE-C objects should not be readable by workers\n")
+	}
+}
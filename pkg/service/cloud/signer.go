@@ -0,0 +1,56 @@
+package cloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// FilesPath is path of route which serves files by signed urls
+const FilesPath = "/files"
+
+// URLSigner generates and verifies download urls of files served by application.
+// They are used instead of presigned urls of S3 when files can't be downloaded
+// from storage directly, e.g. if they are encrypted with keys of application
+type URLSigner struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewURLSigner initialize URLSigner, urls are valid for ttl
+func NewURLSigner(secret []byte, baseURL string, ttl time.Duration) *URLSigner {
+	return &URLSigner{secret: secret, baseURL: baseURL, ttl: ttl, now: time.Now}
+}
+
+// URL returns signed download url of file
+func (s *URLSigner) URL(filename string) string {
+	expires := strconv.FormatInt(s.now().Add(s.ttl).Unix(), 10)
+
+	return fmt.Sprintf("%s%s/%s?expires=%s&signature=%s", s.baseURL, FilesPath,
+		url.PathEscape(filename), expires, s.signature(filename, expires))
+}
+
+// Verify checks that signature of file is valid and not expired
+func (s *URLSigner) Verify(filename, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > exp {
+		return false
+	}
+
+	expected := s.signature(filename, expires)
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (s *URLSigner) signature(filename, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(filename + "\n" + expires))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,73 @@
+package cloud
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	signer := NewURLSigner([]byte("secret"), "https://api.example.com", time.Minute)
+	signer.now = func() time.Time { return now }
+
+	raw := signer.URL("uuid_my video.mkv")
+	if !strings.HasPrefix(raw, "https://api.example.com/files/uuid_my%20video.mkv?") {
+		t.Fatalf("Invalid url: %s\n", raw)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+
+	cases := []struct {
+		name      string
+		filename  string
+		expires   string
+		signature string
+		after     time.Duration
+		expected  bool
+	}{
+		{
+			name:      "Valid url",
+			filename:  "uuid_my video.mkv",
+			expires:   expires,
+			signature: signature,
+			expected:  true,
+		},
+		{
+			name:      "Other file",
+			filename:  "uuid_other.mkv",
+			expires:   expires,
+			signature: signature,
+		},
+		{
+			name:      "Extended expiration",
+			filename:  "uuid_my video.mkv",
+			expires:   "9999999999",
+			signature: signature,
+		},
+		{
+			name:      "Expired url",
+			filename:  "uuid_my video.mkv",
+			expires:   expires,
+			signature: signature,
+			after:     2 * time.Minute,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			signer.now = func() time.Time { return now.Add(testCase.after) }
+
+			if ok := signer.Verify(testCase.filename, testCase.expires, testCase.signature); ok != testCase.expected {
+				t.Errorf("Invalid result, expected: %v, got: %v\n", testCase.expected, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Server-side encryption modes of S3
+const (
+	// SSES3 encrypts objects with keys managed by S3
+	SSES3 = "SSE-S3"
+	// SSEKMS encrypts objects with AWS KMS key, default aws/s3 key if key id is empty
+	SSEKMS = "SSE-KMS"
+	// SSEC encrypts objects with key provided by application, S3 doesn't store it
+	SSEC = "SSE-C"
+)
+
+// S3Encryption is server-side encryption of objects uploaded to S3. Zero value
+// uses default encryption of bucket
+type S3Encryption struct {
+	Mode     string
+	KMSKeyID string
+	// CustomerKey is 32 bytes key of SSE-C
+	CustomerKey []byte
+}
+
+// Validate checks that options of mode are set
+func (e S3Encryption) Validate() error {
+	switch e.Mode {
+	case "", SSES3:
+		return nil
+	case SSEKMS:
+		return nil
+	case SSEC:
+		if len(e.CustomerKey) != KeySize {
+			return fmt.Errorf("SSE-C key must be %d bytes", KeySize)
+		}
+
+		return nil
+	default:
+		return errors.New("unknown S3 encryption mode " + e.Mode)
+	}
+}
+
+// customerKey returns algorithm and key of SSE-C, nil if SSE-C isn't used
+func (e S3Encryption) customerKey() (*string, *string) {
+	if e.Mode != SSEC {
+		return nil, nil
+	}
+
+	return aws.String(s3.ServerSideEncryptionAes256), aws.String(string(e.CustomerKey))
+}
+
+func (e S3Encryption) applyUpload(in *s3manager.UploadInput) {
+	switch e.Mode {
+	case SSES3:
+		in.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAes256)
+	case SSEKMS:
+		in.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		if e.KMSKeyID != "" {
+			in.SSEKMSKeyId = aws.String(e.KMSKeyID)
+		}
+	case SSEC:
+		in.SSECustomerAlgorithm, in.SSECustomerKey = e.customerKey()
+	}
+}
+
+func (e S3Encryption) applyGet(in *s3.GetObjectInput) {
+	in.SSECustomerAlgorithm, in.SSECustomerKey = e.customerKey()
+}
+
+func (e S3Encryption) applyHead(in *s3.HeadObjectInput) {
+	in.SSECustomerAlgorithm, in.SSECustomerKey = e.customerKey()
+}
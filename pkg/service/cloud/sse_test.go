@@ -0,0 +1,92 @@
+package cloud
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+func TestS3Encryption(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), KeySize)
+
+	cases := []struct {
+		name              string
+		encryption        S3Encryption
+		expectedSSE       string
+		expectedKMSKeyID  string
+		expectedCustomer  bool
+		validationFailure bool
+	}{
+		{
+			name: "Default encryption of bucket",
+		},
+		{
+			name:        "SSE-S3",
+			encryption:  S3Encryption{Mode: SSES3},
+			expectedSSE: s3.ServerSideEncryptionAes256,
+		},
+		{
+			name:             "SSE-KMS",
+			encryption:       S3Encryption{Mode: SSEKMS, KMSKeyID: "alias/videos"},
+			expectedSSE:      s3.ServerSideEncryptionAwsKms,
+			expectedKMSKeyID: "alias/videos",
+		},
+		{
+			name:             "SSE-C",
+			encryption:       S3Encryption{Mode: SSEC, CustomerKey: key},
+			expectedCustomer: true,
+		},
+		{
+			name:              "SSE-C with short key",
+			encryption:        S3Encryption{Mode: SSEC, CustomerKey: key[:16]},
+			validationFailure: true,
+		},
+		{
+			name:              "Unknown mode",
+			encryption:        S3Encryption{Mode: "AES128"},
+			validationFailure: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.encryption.Validate()
+			if (err != nil) != testCase.validationFailure {
+				t.Fatalf("Unexpected validation result: %v\n", err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			upload := new(s3manager.UploadInput)
+			get := new(s3.GetObjectInput)
+			head := new(s3.HeadObjectInput)
+
+			testCase.encryption.applyUpload(upload)
+			testCase.encryption.applyGet(get)
+			testCase.encryption.applyHead(head)
+
+			if sse := aws.StringValue(upload.ServerSideEncryption); sse != testCase.expectedSSE {
+				t.Errorf("Invalid server-side encryption, expected: %q, got: %q\n", testCase.expectedSSE, sse)
+			}
+
+			if id := aws.StringValue(upload.SSEKMSKeyId); id != testCase.expectedKMSKeyID {
+				t.Errorf("Invalid KMS key id, expected: %q, got: %q\n", testCase.expectedKMSKeyID, id)
+			}
+
+			for _, customerKey := range []*string{upload.SSECustomerKey, get.SSECustomerKey, head.SSECustomerKey} {
+				if (customerKey != nil) != testCase.expectedCustomer {
+					t.Errorf("Invalid SSE-C key: %v\n", customerKey)
+				}
+
+				if customerKey != nil && aws.StringValue(customerKey) != string(key) {
+					t.Errorf("Invalid SSE-C key\n")
+				}
+			}
+		})
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/request"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
 
 	"go.uber.org/zap"
 )
@@ -19,17 +22,20 @@ const (
 // Service for updating request and original video in db.
 // And adding converted video to db.
 type Service struct {
-	reqRepo repository.Updater
-	vRepo   repository.VideoRepository
-	logger  *zap.Logger
+	reqRepo   repository.Updater
+	vRepo     repository.VideoRepository
+	retention service.RetentionPolicy
+	logger    *zap.Logger
 }
 
 // NewService initialize Service
-func NewService(reqRepo repository.Updater, vRepo repository.VideoRepository, logger *zap.Logger) *Service {
+func NewService(reqRepo repository.Updater, vRepo repository.VideoRepository, rp service.RetentionPolicy,
+	logger *zap.Logger) *Service {
 	return &Service{
-		reqRepo: reqRepo,
-		vRepo:   vRepo,
-		logger:  logger,
+		reqRepo:   reqRepo,
+		vRepo:     vRepo,
+		retention: rp,
+		logger:    logger,
 	}
 }
 
@@ -61,10 +67,16 @@ func (srv *Service) UpdateRequest(ctx context.Context, data []byte) error {
 
 		if err == nil {
 			fields := map[string]interface{}{"status": completedStatus, "converted_file_id": id}
-			_, reqErr := srv.reqRepo.Update(context.Background(), res.RequestID, fields)
+			updated, reqErr := srv.reqRepo.Update(context.Background(), res.RequestID, fields)
 
 			if reqErr != nil {
 				srv.logger.Error("updating request status", zap.Error(reqErr))
+			} else if req, ok := updated.(*request.Resource); ok && req.OrganizationID != 0 {
+				// converted video is shared with the same organization as request
+				fields := map[string]interface{}{"organization_id": req.OrganizationID}
+				if _, err = srv.vRepo.Update(ctx, id, fields); err != nil {
+					srv.logger.Error("can't share converted video with organization", zap.Error(err))
+				}
 			}
 		} else {
 			msg := fmt.Sprintf("Сan't add converted video to db, id: %s",
@@ -106,6 +118,13 @@ func (srv *Service) UpdateRequestStatus(ctx context.Context, id int64, status, d
 
 // AddConvertedVideo function add converted video to db
 func (srv *Service) AddConvertedVideo(ctx context.Context, v *video.Resource) (int64, error) {
+	expiresAt, err := srv.retention.ConvertedExpiresAt(ctx, v.UserID, time.Now())
+	if err != nil {
+		srv.logger.Error("can't calculate expiration time for converted video", zap.Error(err))
+	}
+
+	v.ExpiresAt = expiresAt
+
 	convertedVideo, err := srv.vRepo.Create(ctx, v.BuildFields())
 	if err != nil {
 		return 0, err
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Hargeon/videocmprs/pkg/repository/request"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
@@ -12,6 +13,16 @@ import (
 	"go.uber.org/zap"
 )
 
+type retentionMock struct{}
+
+func (r *retentionMock) OriginalExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error) {
+	return nil, nil
+}
+
+func (r *retentionMock) ConvertedExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error) {
+	return nil, nil
+}
+
 func TestService_AddConvertedVideo(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -44,10 +55,10 @@ func TestService_AddConvertedVideo(t *testing.T) {
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).
 						AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id", nil))
 			},
 			expectedID:   1,
 			errorPresent: false,
@@ -83,7 +94,7 @@ func TestService_AddConvertedVideo(t *testing.T) {
 			logger := zap.NewExample()
 			defer logger.Sync()
 
-			srv := NewService(reqRepo, vRepo, logger)
+			srv := NewService(reqRepo, vRepo, new(retentionMock), logger)
 
 			id, err := srv.AddConvertedVideo(context.Background(), testCase.video)
 			if err != nil && !testCase.errorPresent {
@@ -142,7 +153,7 @@ func TestService_Update(t *testing.T) {
 					WithArgs("Invalid ffmpeg path", "failed", 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -150,13 +161,13 @@ func TestService_Update(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "failed", "Invalid ffmpeg path", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
-						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 			},
 			errorPresent: true,
 		},
@@ -178,7 +189,7 @@ func TestService_Update(t *testing.T) {
 					WithArgs("Converted video does not present", "failed", 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -186,13 +197,13 @@ func TestService_Update(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "failed", "Converted video does not present", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
-						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 			},
 			errorPresent: false,
 		},
@@ -218,10 +229,10 @@ func TestService_Update(t *testing.T) {
 					WithArgs(64000, "converted_video.mkv", 4, 3, 800, 600, "mock_service", 12500, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id", nil))
 
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs(1, completedStatus, 1).
@@ -237,16 +248,16 @@ func TestService_Update(t *testing.T) {
 					WithArgs(64000, "converted_video.mkv", 4, 3, 800, 600, "mock_service", 12500, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(2).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(2, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(2, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id", nil))
 
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs(2, completedStatus, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -254,13 +265,57 @@ func TestService_Update(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, completedStatus, "", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
-						1258000, 0, 0, 0, 0, 0, "mock_service_id", 2, "converted_video.mkv", 12500, 64000,
-						800, 600, 4, 3, "mock_service_id"))
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, 2, "converted_video.mkv", 12500, 64000,
+						800, 600, 4, 3, "mock_service_id", nil, nil))
+			},
+			errorPresent: false,
+		},
+		{
+			name: "With ConvertedVideo in response, shared with organization",
+			data: []byte(`{"request_id":1,"converted_video":{"name":"converted_video.mkv","user_id":1,"size":12500,"bitrate":64000,"resolution_x":800,"resolution_y":600,"ratio_x":4,"ratio_y":3,"service_id":"mock_service"}}`),
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
+					WithArgs(64000, "converted_video.mkv", 4, 3, 800, 600, "mock_service", 12500, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(2, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id", nil))
+
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
+					WithArgs(2, completedStatus, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
+						"requests.details", "requests.bitrate", "requests.resolution_x",
+						"requests.resolution_y", "requests.ratio_x", "requests.ratio_y",
+						"requests.video_name", "origin_video.id", "origin_video.name",
+						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
+						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
+						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
+						"converted_video.resolution_y", "converted_video.ratio_x",
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
+						1, 1, completedStatus, "", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, 2, "converted_video.mkv", 12500, 64000,
+						800, 600, 4, 3, "mock_service_id", nil, 5))
+
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET organization_id = \\$1 WHERE id = \\$2", video.TableName)).
+					WithArgs(5, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(2, "converted_video.mkv", 12500, 64000, 800, 600, 4, 3, "mock_service_id", nil))
 			},
 			errorPresent: false,
 		},
@@ -282,10 +337,10 @@ func TestService_Update(t *testing.T) {
 					WithArgs(64000, 4, 3, 800, 600, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 789569, 64000, 800, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 789569, 64000, 800, 600, 4, 3, "mock_service_id", nil))
 			},
 			errorPresent: false,
 		},
@@ -299,7 +354,7 @@ func TestService_Update(t *testing.T) {
 			logger := zap.NewExample()
 			defer logger.Sync()
 
-			srv := NewService(reqRepo, vRepo, logger)
+			srv := NewService(reqRepo, vRepo, new(retentionMock), logger)
 
 			err := srv.UpdateRequest(context.Background(), testCase.data)
 
@@ -377,10 +432,10 @@ func TestService_UpdateOriginalVideo(t *testing.T) {
 					WithArgs(64000, 4, 3, 800, 600, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 789569, 64000, 800, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 789569, 64000, 800, 600, 4, 3, "mock_service_id", nil))
 			},
 			errorPresent: false,
 		},
@@ -394,7 +449,7 @@ func TestService_UpdateOriginalVideo(t *testing.T) {
 			logger := zap.NewExample()
 			defer logger.Sync()
 
-			srv := NewService(reqRepo, vRepo, logger)
+			srv := NewService(reqRepo, vRepo, new(retentionMock), logger)
 
 			err := srv.UpdateOriginalVideo(context.Background(), testCase.video)
 			if err != nil && !testCase.errorPresent {
@@ -456,7 +511,7 @@ func TestService_UpdateRequestStatus(t *testing.T) {
 					WithArgs("Can't add video to database", "failed", 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -464,13 +519,13 @@ func TestService_UpdateRequestStatus(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "failed", "Can't add video to database", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
-						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 			},
 			errorPresent: false,
 		},
@@ -485,7 +540,7 @@ func TestService_UpdateRequestStatus(t *testing.T) {
 			logger := zap.NewExample()
 			defer logger.Sync()
 
-			srv := NewService(reqRepo, vRepo, logger)
+			srv := NewService(reqRepo, vRepo, new(retentionMock), logger)
 
 			err := srv.UpdateRequestStatus(context.Background(), testCase.id, testCase.status, testCase.details)
 
@@ -1,3 +1,4 @@
+// Package encryption uses for hashing passwords
 package encryption
 
 import (
@@ -5,6 +6,10 @@ import (
 	"os"
 )
 
+// GenerateHash returns legacy SHA-1 password hash. It is used only for verifying
+// passwords of users who haven't signed in since argon2id was introduced.
+//
+// Deprecated: use PasswordHasher
 func GenerateHash(text []byte) []byte {
 	secret := os.Getenv("SECRET")
 	hash := sha1.New()
@@ -0,0 +1,123 @@
+package encryption
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testParams = Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+
+func TestPasswordHasher(t *testing.T) {
+	hasher := NewPasswordHasher(testParams)
+
+	hash, err := hasher.Hash("qweqweqwe")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("Invalid hash format: %s\n", hash)
+	}
+
+	other, err := hasher.Hash("qweqweqwe")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if hash == other {
+		t.Errorf("Hashes of the same password should have different salts\n")
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("qweqweqwe"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	strongHasher := NewPasswordHasher(Argon2Params{Time: 2, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+
+	cases := []struct {
+		name           string
+		hasher         *PasswordHasher
+		password       string
+		hash           string
+		expectedMatch  bool
+		expectedRehash bool
+		errorPresent   bool
+	}{
+		{
+			name:          "Argon2id hash",
+			hasher:        hasher,
+			password:      "qweqweqwe",
+			hash:          hash,
+			expectedMatch: true,
+		},
+		{
+			name:     "Argon2id hash with invalid password",
+			hasher:   hasher,
+			password: "qweqweqwa",
+			hash:     hash,
+		},
+		{
+			name:           "Argon2id hash with outdated params",
+			hasher:         strongHasher,
+			password:       "qweqweqwe",
+			hash:           hash,
+			expectedMatch:  true,
+			expectedRehash: true,
+		},
+		{
+			name:           "Bcrypt hash",
+			hasher:         hasher,
+			password:       "qweqweqwe",
+			hash:           string(bcryptHash),
+			expectedMatch:  true,
+			expectedRehash: true,
+		},
+		{
+			name:           "Legacy hash",
+			hasher:         hasher,
+			password:       "qweqweqwe",
+			hash:           fmt.Sprintf("%x", GenerateHash([]byte("qweqweqwe"))),
+			expectedMatch:  true,
+			expectedRehash: true,
+		},
+		{
+			name:     "Legacy hash with invalid password",
+			hasher:   hasher,
+			password: "qweqweqwa",
+			hash:     fmt.Sprintf("%x", GenerateHash([]byte("qweqweqwe"))),
+		},
+		{
+			name:         "Broken hash",
+			hasher:       hasher,
+			password:     "qweqweqwe",
+			hash:         "$argon2id$v=19$broken",
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			match, rehash, err := testCase.hasher.Verify(testCase.password, testCase.hash)
+
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if match != testCase.expectedMatch {
+				t.Errorf("Invalid match, expected: %v, got: %v\n", testCase.expectedMatch, match)
+			}
+
+			if rehash != testCase.expectedRehash {
+				t.Errorf("Invalid rehash, expected: %v, got: %v\n", testCase.expectedRehash, rehash)
+			}
+		})
+	}
+}
@@ -3,8 +3,23 @@ package service
 import "errors"
 
 var (
-	ErrUserNotExists = errors.New("user is not exists")
-	ErrAlreadyExists = errors.New("the user is already exists")
+	ErrAlreadyExists      = errors.New("the user is already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserDisabled       = errors.New("user is disabled")
+
+	ErrEmailNotVerified  = errors.New("email is not verified")
+	ErrInvalidEmailToken = errors.New("invalid or expired email token")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled      = errors.New("two-factor enrollment is not started")
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa challenge")
+
+	ErrInvalidSSOState = errors.New("invalid or expired sso state")
+	ErrSSOUserNotFound = errors.New("no user for sso identity")
 
 	ErrInvalidTypeAssertion = errors.New("invalid type assertion in service")
 )
@@ -0,0 +1,10 @@
+package idempotency
+
+import "errors"
+
+var (
+	// ErrKeyReused returns if idempotency key is sent again with different payload
+	ErrKeyReused = errors.New("idempotency key is reused with different payload")
+	// ErrKeyInProgress returns if request with the same idempotency key isn't finished yet
+	ErrKeyInProgress = errors.New("request with idempotency key is in progress")
+)
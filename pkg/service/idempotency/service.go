@@ -0,0 +1,63 @@
+// Package idempotency uses for replaying responses of requests repeated with
+// the same Idempotency-Key header, e.g. retries of clients on flaky networks
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/idempotency"
+)
+
+// KeyTTL is how long response is replayed for idempotency key
+const KeyTTL = 24 * time.Hour
+
+// MaxKeyLength is max length of idempotency key
+const MaxKeyLength = 255
+
+// Service stores idempotency keys of users
+type Service struct {
+	repo repository.IdempotencyRepository
+	now  func() time.Time
+}
+
+// NewService initialize Service
+func NewService(repo repository.IdempotencyRepository) *Service {
+	return &Service{repo: repo, now: time.Now}
+}
+
+// Begin reserves key of user for request with fingerprint of payload. Completed key
+// is returned if the same request was already sent, its response has to be replayed
+func (srv *Service) Begin(ctx context.Context, userID int64, key, fingerprint string) (*idempotency.Key, error) {
+	k := &idempotency.Key{UserID: userID, Key: key, Fingerprint: fingerprint}
+
+	stored, reserved, err := srv.repo.Reserve(ctx, k, srv.now().Add(-KeyTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	if reserved {
+		return stored, nil
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return nil, ErrKeyReused
+	}
+
+	if !stored.Completed() {
+		return nil, ErrKeyInProgress
+	}
+
+	return stored, nil
+}
+
+// Complete stores response of request with reserved key
+func (srv *Service) Complete(ctx context.Context, id int64, status int, contentType string, response []byte) error {
+	return srv.repo.Complete(ctx, id, status, contentType, response)
+}
+
+// Release removes reserved key of failed request, so it can be retried
+func (srv *Service) Release(ctx context.Context, id int64) error {
+	return srv.repo.Release(ctx, id)
+}
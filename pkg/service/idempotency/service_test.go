@@ -0,0 +1,87 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/idempotency"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBegin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	keyColumns := []string{"id", "fingerprint", "status", "content_type", "response"}
+
+	cases := []struct {
+		name           string
+		mock           func()
+		expectedStatus int
+		expectedErr    error
+	}{
+		{
+			name: "New key",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WithArgs(1, "key", "hash", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			},
+		},
+		{
+			name: "Repeated request",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, "hash", 201, "application/vnd.api+json", []byte(`{"data":{}}`)))
+			},
+			expectedStatus: 201,
+		},
+		{
+			name: "Key with other payload",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, "other", 201, "application/vnd.api+json", []byte(`{"data":{}}`)))
+			},
+			expectedErr: ErrKeyReused,
+		},
+		{
+			name: "Request in progress",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+					WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(3, "hash", nil, nil, nil))
+			},
+			expectedErr: ErrKeyInProgress,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(idempotency.NewRepository(db))
+
+			k, err := srv.Begin(context.Background(), 1, "key", "hash")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && k.Status != testCase.expectedStatus {
+				t.Errorf("Invalid status, expected: %d, got: %d\n", testCase.expectedStatus, k.Status)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
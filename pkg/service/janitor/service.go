@@ -0,0 +1,198 @@
+// Package janitor uses for removing deleted and expired videos from cloud and db,
+// expired auth tokens, outdated failed sign in attempts, unused rate limit
+// buckets and expired idempotency keys from db
+package janitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/idempotency"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// attemptsTTL is how long failed sign in attempts are kept after the last failure
+	attemptsTTL = 24 * time.Hour
+	// bucketsTTL is how long rate limit buckets are kept after the last request
+	bucketsTTL = 24 * time.Hour
+	// keysTTL is how long responses of idempotency keys are replayed
+	keysTTL = idempotency.KeyTTL
+)
+
+// Service removes records which were deleted more than gracePeriod ago
+// and files which retention period is over
+type Service struct {
+	reqRepo     repository.Purger
+	vRepo       repository.VideoRepository
+	tokenRepo   repository.Purger
+	attemptRepo repository.Purger
+	bucketRepo  repository.Purger
+	keyRepo     repository.Purger
+	cloud       service.CloudStorage
+	gracePeriod time.Duration
+	logger      *zap.Logger
+}
+
+// NewService initialize Service
+func NewService(reqRepo repository.Purger, vRepo repository.VideoRepository,
+	tokenRepo, attemptRepo, bucketRepo, keyRepo repository.Purger,
+	cloud service.CloudStorage, gracePeriod time.Duration, logger *zap.Logger) *Service {
+	return &Service{
+		reqRepo:     reqRepo,
+		vRepo:       vRepo,
+		tokenRepo:   tokenRepo,
+		attemptRepo: attemptRepo,
+		bucketRepo:  bucketRepo,
+		keyRepo:     keyRepo,
+		cloud:       cloud,
+		gracePeriod: gracePeriod,
+		logger:      logger,
+	}
+}
+
+// Run calls PurgeDeleted, ExpireVideos and PurgeTokens every interval until ctx is done
+func (srv *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := srv.PurgeDeleted(ctx); err != nil {
+			srv.logger.Error("Purge deleted records", zap.Error(err))
+		}
+
+		if err := srv.ExpireVideos(ctx); err != nil {
+			srv.logger.Error("Expire videos", zap.Error(err))
+		}
+
+		if err := srv.PurgeTokens(ctx); err != nil {
+			srv.logger.Error("Purge expired tokens", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDeleted removes videos from cloud and db, and requests from db
+// if they were deleted before the grace period
+func (srv *Service) PurgeDeleted(ctx context.Context) error {
+	before := time.Now().Add(-srv.gracePeriod)
+
+	videos, err := srv.vRepo.ListDeleted(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range videos {
+		if v.ServiceID != "" {
+			if err = srv.deleteFile(ctx, v); err != nil {
+				srv.logger.Error("can't delete video from cloud", zap.Error(err),
+					zap.Int64("Video ID", v.ID), zap.String("Service ID", v.ServiceID))
+
+				continue
+			}
+		}
+
+		if err = srv.vRepo.Destroy(ctx, v.ID); err != nil {
+			srv.logger.Error("can't destroy video", zap.Error(err), zap.Int64("Video ID", v.ID))
+		}
+	}
+
+	total, err := srv.reqRepo.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	srv.logger.Info("Purged deleted records", zap.Int("Videos", len(videos)),
+		zap.Int64("Requests", total))
+
+	return nil
+}
+
+// ExpireVideos removes files from cloud if retention period of video is over
+// and marks videos as expired
+func (srv *Service) ExpireVideos(ctx context.Context) error {
+	videos, err := srv.vRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var expired int
+
+	for _, v := range videos {
+		if err = srv.deleteFile(ctx, v); err != nil {
+			srv.logger.Error("can't delete expired video from cloud", zap.Error(err),
+				zap.Int64("Video ID", v.ID), zap.String("Service ID", v.ServiceID))
+
+			continue
+		}
+
+		if err = srv.vRepo.MarkExpired(ctx, v.ID); err != nil {
+			srv.logger.Error("can't mark video as expired", zap.Error(err), zap.Int64("Video ID", v.ID))
+
+			continue
+		}
+
+		expired++
+	}
+
+	srv.logger.Info("Expired videos", zap.Int("Videos", expired))
+
+	return nil
+}
+
+// deleteFile removes file of video from cloud unless other video with the same
+// content still references it
+func (srv *Service) deleteFile(ctx context.Context, v *video.Resource) error {
+	shared, err := srv.vRepo.ServiceIDShared(ctx, v.ID, v.ServiceID)
+	if err != nil {
+		return err
+	}
+
+	if shared {
+		srv.logger.Info("Cloud file is shared with other video, it is kept", zap.Int64("Video ID", v.ID),
+			zap.String("Service ID", v.ServiceID))
+
+		return nil
+	}
+
+	return srv.cloud.Delete(ctx, v.ServiceID)
+}
+
+// PurgeTokens removes expired refresh tokens, denylist entries, email tokens,
+// failed sign in attempts which are no longer counted, unused rate limit buckets
+// and idempotency keys which responses aren't replayed anymore
+func (srv *Service) PurgeTokens(ctx context.Context) error {
+	total, err := srv.tokenRepo.Purge(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	attempts, err := srv.attemptRepo.Purge(ctx, time.Now().Add(-attemptsTTL))
+	if err != nil {
+		return err
+	}
+
+	buckets, err := srv.bucketRepo.Purge(ctx, time.Now().Add(-bucketsTTL))
+	if err != nil {
+		return err
+	}
+
+	keys, err := srv.keyRepo.Purge(ctx, time.Now().Add(-keysTTL))
+	if err != nil {
+		return err
+	}
+
+	srv.logger.Info("Purged expired tokens", zap.Int64("Tokens", total), zap.Int64("Attempts", attempts),
+		zap.Int64("Rate limit buckets", buckets), zap.Int64("Idempotency keys", keys))
+
+	return nil
+}
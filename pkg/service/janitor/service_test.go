@@ -0,0 +1,246 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/attempt"
+	"github.com/Hargeon/videocmprs/pkg/repository/idempotency"
+	"github.com/Hargeon/videocmprs/pkg/repository/ratelimit"
+	"github.com/Hargeon/videocmprs/pkg/repository/request"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+)
+
+type cloudMock struct {
+	deleted []string
+}
+
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
+	return "mock_service_id", nil
+}
+
+func (c *cloudMock) URL(filename string) (string, error) {
+	return filename, nil
+}
+
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	if filename == "error" {
+		return errors.New("mock error")
+	}
+
+	c.deleted = append(c.deleted, filename)
+
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	return &service.CloudObject{Name: filename}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedDeleted []string
+		errorPresent    bool
+	}{
+		{
+			name: "Should purge videos and requests",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s", video.TableName)).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "service_id"}).
+						AddRow(1, 1, "first.mkv", "first_service_id").
+						AddRow(2, 1, "second.mkv", "error").
+						AddRow(3, 1, "third.mkv", "third_service_id"))
+
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs("first_service_id", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", video.TableName)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs("error", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs("third_service_id", 3).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", video.TableName)).
+					WithArgs(3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s", request.TableName)).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			// file of the third video is kept because other video references it
+			expectedDeleted: []string{"first_service_id"},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s", video.TableName)).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			cloud := new(cloudMock)
+			srv := NewService(request.NewRepository(db), video.NewRepository(db), token.NewRepository(db), attempt.NewRepository(db), ratelimit.NewRepository(db), idempotency.NewRepository(db), cloud, time.Hour, logger)
+
+			err := srv.PurgeDeleted(context.Background())
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(cloud.deleted) != len(testCase.expectedDeleted) {
+				t.Fatalf("Invalid deleted files, expected: %v, got: %v\n",
+					testCase.expectedDeleted, cloud.deleted)
+			}
+
+			for i, name := range cloud.deleted {
+				if name != testCase.expectedDeleted[i] {
+					t.Errorf("Invalid deleted file, expected: %s, got: %s\n",
+						testCase.expectedDeleted[i], name)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestExpireVideos(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	cases := []struct {
+		name            string
+		mock            func()
+		expectedDeleted []string
+		errorPresent    bool
+	}{
+		{
+			name: "Should expire videos",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), false).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "service_id"}).
+						AddRow(1, 1, "first.mkv", "first_service_id").
+						AddRow(2, 1, "second.mkv", "error").
+						AddRow(3, 1, "third.mkv", "third_service_id"))
+
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs("first_service_id", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET expired", video.TableName)).
+					WithArgs(true, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs("error", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs("third_service_id", 3).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET expired", video.TableName)).
+					WithArgs(true, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedDeleted: []string{"first_service_id"},
+		},
+		{
+			name: "With bad db connection",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), false).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			cloud := new(cloudMock)
+			srv := NewService(request.NewRepository(db), video.NewRepository(db), token.NewRepository(db), attempt.NewRepository(db), ratelimit.NewRepository(db), idempotency.NewRepository(db), cloud, time.Hour, logger)
+
+			err := srv.ExpireVideos(context.Background())
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if len(cloud.deleted) != len(testCase.expectedDeleted) {
+				t.Fatalf("Invalid deleted files, expected: %v, got: %v\n",
+					testCase.expectedDeleted, cloud.deleted)
+			}
+
+			for i, name := range cloud.deleted {
+				if name != testCase.expectedDeleted[i] {
+					t.Errorf("Invalid deleted file, expected: %s, got: %s\n",
+						testCase.expectedDeleted[i], name)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
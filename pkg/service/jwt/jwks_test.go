@@ -0,0 +1,70 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestJWKPublicKey(t *testing.T) {
+	rsaPriv, _ := rsaPEM(t)
+	edPriv, _ := ed25519PEM(t)
+
+	for _, data := range [][]byte{rsaPriv, edPriv} {
+		key := mustParseKey(t, data)
+
+		m, err := NewManager(key, nil, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+
+		tokenStr, err := m.SignedString(65, "user")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+
+		pub, err := m.JWKS().Keys[0].PublicKey()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+
+		_, err = jwt.Parse(tokenStr, func(*jwt.Token) (interface{}, error) { return pub, nil })
+		if err != nil {
+			t.Errorf("Token should be verified with key from jwk %s, error: %s\n", key.Method.Alg(), err)
+		}
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	tokenStr, err := jwt.New(jwt.SigningMethodES256).SignedString(ecKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	jwk := JWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+	}
+
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = jwt.Parse(tokenStr, func(*jwt.Token) (interface{}, error) { return pub, nil }); err != nil {
+		t.Errorf("Token should be verified with key from jwk ES256, error: %s\n", err)
+	}
+
+	if _, err = (JWK{Kty: "oct"}).PublicKey(); err == nil {
+		t.Errorf("Should be error\n")
+	}
+}
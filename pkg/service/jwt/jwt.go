@@ -2,49 +2,60 @@
 package jwt
 
 import (
-	"errors"
-	"os"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
-const tokenTD = 12 * time.Hour
+// tokenTD is lifetime of access token. Refresh token is used for getting a new one
+const tokenTD = 15 * time.Minute
 
-type authClaims struct {
-	ID int64 `json:"id"`
+var (
+	defaultOnce    sync.Once
+	defaultManager *Manager
+	defaultErr     error
+)
+
+// Claims represent payload of access token. StandardClaims.Id is unique
+// token id (jti) used for revocation. Role of user is carried in token,
+// so permissions are checked without db round trip
+type Claims struct {
+	ID   int64  `json:"id"`
+	Role string `json:"role,omitempty"`
 	jwt.StandardClaims
 }
 
-// SignedString function creates jwt token
-func SignedString(id int64) (string, error) {
-	claims := authClaims{
-		ID: id,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenTD).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	return token.SignedString([]byte(os.Getenv("TOKEN_SECRET")))
+// ExpiresAtTime returns expiration time of token
+func (c *Claims) ExpiresAtTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
 }
 
-func ParseToken(tokenStr string) (int64, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &authClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-
-		return []byte(os.Getenv("TOKEN_SECRET")), nil
+// Default returns Manager configured from env. Keys are loaded only once
+func Default() (*Manager, error) {
+	defaultOnce.Do(func() {
+		defaultManager, defaultErr = NewEnvManager()
 	})
+
+	return defaultManager, defaultErr
+}
+
+// SignedString function creates jwt token with default Manager
+func SignedString(id int64, role string) (string, error) {
+	m, err := Default()
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
-	if claims, ok := token.Claims.(*authClaims); ok && token.Valid {
-		return claims.ID, nil
+	return m.SignedString(id, role)
+}
+
+// ParseToken validates token with default Manager and returns its claims
+func ParseToken(tokenStr string) (*Claims, error) {
+	m, err := Default()
+	if err != nil {
+		return nil, err
 	}
 
-	return 0, errors.New("invalid token")
+	return m.ParseToken(tokenStr)
 }
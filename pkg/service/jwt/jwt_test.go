@@ -12,12 +12,12 @@ import (
 func TestSignedString(t *testing.T) {
 	var expectedId int64 = 65
 
-	tokenString, err := SignedString(expectedId)
+	tokenString, err := SignedString(expectedId, "admin")
 	if err != nil {
 		t.Fatalf("Unexpected error when signing string, error: %s\n", err.Error())
 	}
 
-	parsedToken, err := jwt.ParseWithClaims(tokenString, &authClaims{}, func(token *jwt.Token) (interface{}, error) {
+	parsedToken, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
@@ -28,15 +28,23 @@ func TestSignedString(t *testing.T) {
 		t.Fatalf("Unexpected error: %s\n", err.Error())
 	}
 
-	claims, ok := parsedToken.Claims.(*authClaims)
+	claims, ok := parsedToken.Claims.(*Claims)
 	if !ok {
-		t.Fatalf("Invalid type assertion for authClaims\n")
+		t.Fatalf("Invalid type assertion for Claims\n")
 	}
 
 	id := claims.ID
 	if id != expectedId {
 		t.Errorf("Invalid id, expected: %d, got: %d\n", expectedId, id)
 	}
+
+	if claims.Role != "admin" {
+		t.Errorf("Invalid role, expected: %s, got: %s\n", "admin", claims.Role)
+	}
+
+	if claims.Id == "" {
+		t.Errorf("Token should have jti\n")
+	}
 }
 
 func TestParseToken(t *testing.T) {
@@ -80,7 +88,7 @@ func TestParseToken(t *testing.T) {
 
 	for _, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
-			claims := authClaims{
+			claims := Claims{
 				ID: testCase.id,
 				StandardClaims: jwt.StandardClaims{
 					IssuedAt:  testCase.timeFrom.Unix(),
@@ -94,7 +102,12 @@ func TestParseToken(t *testing.T) {
 				t.Fatalf("Unexpected error: %s\n", err.Error())
 			}
 
-			id, err := ParseToken(tokenStr)
+			var id int64
+
+			parsed, err := ParseToken(tokenStr)
+			if err == nil {
+				id = parsed.ID
+			}
 
 			if err == nil && testCase.errorPresent {
 				t.Errorf("Should be error\n")
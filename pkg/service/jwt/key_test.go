@@ -0,0 +1,122 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func rsaPEM(t *testing.T) (private, public []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	private = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	public = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	return private, public
+}
+
+func ed25519PEM(t *testing.T) (private, public []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	private = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	public = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	return private, public
+}
+
+func writeKey(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return path
+}
+
+func TestParseKey(t *testing.T) {
+	rsaPriv, rsaPub := rsaPEM(t)
+	edPriv, edPub := ed25519PEM(t)
+
+	cases := []struct {
+		name    string
+		private []byte
+		public  []byte
+		alg     string
+	}{
+		{
+			name:    "RSA",
+			private: rsaPriv,
+			public:  rsaPub,
+			alg:     "RS256",
+		},
+		{
+			name:    "Ed25519",
+			private: edPriv,
+			public:  edPub,
+			alg:     "EdDSA",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			private, err := LoadKey(writeKey(t, "private.pem", testCase.private))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			public, err := ParseKey(testCase.public)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if private.Method.Alg() != testCase.alg || public.Method.Alg() != testCase.alg {
+				t.Errorf("Invalid alg, expected: %s, got: %s and %s\n",
+					testCase.alg, private.Method.Alg(), public.Method.Alg())
+			}
+
+			if !private.CanSign() || public.CanSign() {
+				t.Errorf("Only private key should be able to sign\n")
+			}
+
+			if private.ID == "" || private.ID != public.ID {
+				t.Errorf("Key id should be derived from public key, got: %s and %s\n", private.ID, public.ID)
+			}
+		})
+	}
+
+	if _, err := ParseKey([]byte("not a key")); err == nil {
+		t.Errorf("Should be error\n")
+	}
+}
@@ -0,0 +1,146 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidSigningMethod = errors.New("invalid signing method")
+	errUnknownKey           = errors.New("unknown key id")
+	errInvalidToken         = errors.New("invalid token")
+)
+
+// Manager signs and verifies access tokens. Tokens are signed with RS256 or
+// EdDSA key and carry its id in kid header. Verification keys are looked up
+// by kid, so tokens signed with previous key stay valid while it is listed.
+// Without signing key tokens are signed with HS256 shared secret
+type Manager struct {
+	signing *Key
+	keys    map[string]*Key
+	secret  []byte
+}
+
+// NewManager returns Manager. signing may be nil, then secret is used for HS256.
+// With signing key HS256 tokens are accepted only when secret is not empty,
+// it allows to switch from shared secret without signing out users
+func NewManager(signing *Key, verification []*Key, secret []byte) (*Manager, error) {
+	m := &Manager{signing: signing, keys: make(map[string]*Key), secret: secret}
+
+	if signing != nil {
+		if !signing.CanSign() {
+			return nil, errors.New("signing key has no private part")
+		}
+
+		m.keys[signing.ID] = signing
+	}
+
+	for _, key := range verification {
+		if _, ok := m.keys[key.ID]; !ok {
+			m.keys[key.ID] = key
+		}
+	}
+
+	return m, nil
+}
+
+// NewEnvManager loads keys from files specified in env variables
+// JWT_SIGNING_KEY_FILE and JWT_VERIFICATION_KEY_FILES (comma separated).
+// TOKEN_SECRET is shared secret for HS256
+func NewEnvManager() (*Manager, error) {
+	var (
+		signing      *Key
+		verification []*Key
+		err          error
+	)
+
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		signing, err = LoadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE: %w", err)
+		}
+	}
+
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		key, err := LoadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_VERIFICATION_KEY_FILES: %w", err)
+		}
+
+		verification = append(verification, key)
+	}
+
+	return NewManager(signing, verification, []byte(os.Getenv("TOKEN_SECRET")))
+}
+
+// SignedString creates access token for user id with role
+func (m *Manager) SignedString(id int64, role string) (string, error) {
+	claims := Claims{
+		ID:   id,
+		Role: role,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			ExpiresAt: time.Now().Add(tokenTD).Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+	}
+
+	if m.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	}
+
+	token := jwt.NewWithClaims(m.signing.Method, claims)
+	token.Header["kid"] = m.signing.ID
+
+	return token.SignedString(m.signing.private)
+}
+
+// ParseToken validates token and returns its claims
+func (m *Manager) ParseToken(tokenStr string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, m.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, errInvalidToken
+}
+
+// key returns verification key for token. Key type always matches
+// signing method of token, so public key can't be used as HMAC secret
+func (m *Manager) key(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if m.signing != nil && len(m.secret) == 0 {
+			return nil, errInvalidSigningMethod
+		}
+
+		return m.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, errUnknownKey
+	}
+
+	if key.Method.Alg() != token.Method.Alg() {
+		return nil, errInvalidSigningMethod
+	}
+
+	return key.public, nil
+}
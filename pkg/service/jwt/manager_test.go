@@ -0,0 +1,173 @@
+package jwt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func mustParseKey(t *testing.T, data []byte) *Key {
+	t.Helper()
+
+	key, err := ParseKey(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return key
+}
+
+func TestManagerSignAndParse(t *testing.T) {
+	rsaPriv, _ := rsaPEM(t)
+	edPriv, _ := ed25519PEM(t)
+
+	cases := []struct {
+		name string
+		key  []byte
+		alg  string
+	}{
+		{
+			name: "RS256",
+			key:  rsaPriv,
+			alg:  "RS256",
+		},
+		{
+			name: "EdDSA",
+			key:  edPriv,
+			alg:  "EdDSA",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			key := mustParseKey(t, testCase.key)
+
+			m, err := NewManager(key, nil, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			tokenStr, err := m.SignedString(65, "user")
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			token, _, err := new(jwt.Parser).ParseUnverified(tokenStr, &Claims{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if token.Header["alg"] != testCase.alg || token.Header["kid"] != key.ID {
+				t.Errorf("Invalid header: %v\n", token.Header)
+			}
+
+			claims, err := m.ParseToken(tokenStr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if claims.ID != 65 {
+				t.Errorf("Invalid id, expected: %d, got: %d\n", 65, claims.ID)
+			}
+		})
+	}
+}
+
+func TestManagerRotation(t *testing.T) {
+	oldPriv, oldPub := rsaPEM(t)
+	newPriv, _ := ed25519PEM(t)
+	otherPriv, _ := ed25519PEM(t)
+
+	old, err := NewManager(mustParseKey(t, oldPriv), nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	other, err := NewManager(mustParseKey(t, otherPriv), nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	rotated, err := NewManager(mustParseKey(t, newPriv), []*Key{mustParseKey(t, oldPub)}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	oldToken, err := old.SignedString(1, "user")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = rotated.ParseToken(oldToken); err != nil {
+		t.Errorf("Token signed with previous key should be valid, error: %s\n", err)
+	}
+
+	otherToken, err := other.SignedString(1, "user")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = rotated.ParseToken(otherToken); err == nil {
+		t.Errorf("Token signed with unknown key should be invalid\n")
+	}
+
+	hsToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{ID: 1}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = rotated.ParseToken(hsToken); err == nil {
+		t.Errorf("HS256 token should be invalid without shared secret\n")
+	}
+
+	set := rotated.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("Invalid number of keys, expected: %d, got: %d\n", 2, len(set.Keys))
+	}
+
+	for _, jwk := range set.Keys {
+		switch jwk.Kty {
+		case "RSA":
+			if jwk.Alg != "RS256" || jwk.N == "" || jwk.E != "AQAB" {
+				t.Errorf("Invalid RSA key: %+v\n", jwk)
+			}
+		case "OKP":
+			if jwk.Alg != "EdDSA" || jwk.Crv != "Ed25519" || jwk.X == "" {
+				t.Errorf("Invalid OKP key: %+v\n", jwk)
+			}
+		default:
+			t.Errorf("Unexpected key type %s\n", jwk.Kty)
+		}
+
+		if jwk.Use != "sig" || strings.TrimSpace(jwk.Kid) == "" {
+			t.Errorf("Invalid key: %+v\n", jwk)
+		}
+	}
+}
+
+func TestManagerPublicKeyAsSecret(t *testing.T) {
+	priv, pub := rsaPEM(t)
+
+	m, err := NewManager(mustParseKey(t, priv), nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	// classic algorithm confusion: HS256 token signed with public key as secret
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{ID: 1})
+	token.Header["kid"] = mustParseKey(t, pub).ID
+
+	tokenStr, err := token.SignedString(pub)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = m.ParseToken(tokenStr); err == nil {
+		t.Errorf("Should be error\n")
+	}
+
+	if _, err = NewManager(mustParseKey(t, pub), nil, nil); err == nil {
+		t.Errorf("Public key can't be used for signing\n")
+	}
+}
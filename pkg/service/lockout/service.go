@@ -0,0 +1,219 @@
+// Package lockout uses for protection of sign in against password guessing.
+// Failed attempts are counted per email and per ip, repeated failures of email
+// are delayed progressively and too many failures lock email or ip temporarily
+package lockout
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+)
+
+const (
+	emailKeyPrefix = "email:"
+	ipKeyPrefix    = "ip:"
+)
+
+// Policy of sign in protection. Zero MaxFailures or MaxIPFailures disables lockout of email or ip
+type Policy struct {
+	// MaxFailures of email within Window before it is locked
+	MaxFailures int
+	// MaxIPFailures of ip within Window before it is locked
+	MaxIPFailures int
+	// Window in which failures are counted
+	Window time.Duration
+	// Lockout is how long email or ip is locked
+	Lockout time.Duration
+	// BaseDelay is delay after the second failure of email, it doubles with each next failure
+	BaseDelay time.Duration
+	// MaxDelay limits delay between failures of email
+	MaxDelay time.Duration
+}
+
+// DefaultPolicy is used for settings which aren't set in env
+var DefaultPolicy = Policy{
+	MaxFailures:   5,
+	MaxIPFailures: 50,
+	Window:        15 * time.Minute,
+	Lockout:       15 * time.Minute,
+	BaseDelay:     time.Second,
+	MaxDelay:      30 * time.Second,
+}
+
+// Service counts failed sign in attempts
+type Service struct {
+	repo   repository.AttemptRepository
+	audit  repository.AuditCreator
+	policy Policy
+	now    func() time.Time
+}
+
+// NewService initialize Service
+func NewService(repo repository.AttemptRepository, audit repository.AuditCreator, policy Policy) *Service {
+	return &Service{repo: repo, audit: audit, policy: policy, now: time.Now}
+}
+
+// NewEnvService initialize Service with policy from LOGIN_MAX_FAILURES, LOGIN_MAX_IP_FAILURES,
+// LOGIN_FAILURE_WINDOW and LOGIN_LOCKOUT_DURATION env variables
+func NewEnvService(repo repository.AttemptRepository, audit repository.AuditCreator) *Service {
+	policy := DefaultPolicy
+	policy.MaxFailures = envInt("LOGIN_MAX_FAILURES", policy.MaxFailures)
+	policy.MaxIPFailures = envInt("LOGIN_MAX_IP_FAILURES", policy.MaxIPFailures)
+	policy.Window = envDuration("LOGIN_FAILURE_WINDOW", policy.Window)
+	policy.Lockout = envDuration("LOGIN_LOCKOUT_DURATION", policy.Lockout)
+
+	return NewService(repo, audit, policy)
+}
+
+// Check returns how long sign in with email from ip has to wait, zero allows it
+func (srv *Service) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := srv.now()
+
+	attempts, err := srv.repo.Retrieve(ctx, keys(email, ip)...)
+	if err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+
+	for _, a := range attempts {
+		if a.LockedUntil.Valid && a.LockedUntil.Time.After(now) {
+			wait = maxDuration(wait, a.LockedUntil.Time.Sub(now))
+		}
+
+		if strings.HasPrefix(a.Key, emailKeyPrefix) && a.LastFailedAt.After(now.Add(-srv.policy.Window)) {
+			retryAt := a.LastFailedAt.Add(srv.delay(a.Failures))
+			if retryAt.After(now) {
+				wait = maxDuration(wait, retryAt.Sub(now))
+			}
+		}
+	}
+
+	return wait, nil
+}
+
+// Fail counts failed sign in with email from ip, locks email or ip and adds
+// audit event if they reached the limit of failures
+func (srv *Service) Fail(ctx context.Context, email, ip string) error {
+	now := srv.now()
+
+	for _, key := range keys(email, ip) {
+		a, err := srv.repo.Fail(ctx, key, now, now.Add(-srv.policy.Window))
+		if err != nil {
+			return err
+		}
+
+		limit := srv.policy.MaxFailures
+		if strings.HasPrefix(key, ipKeyPrefix) {
+			limit = srv.policy.MaxIPFailures
+		}
+
+		if limit <= 0 || a.Failures < limit || (a.LockedUntil.Valid && a.LockedUntil.Time.After(now)) {
+			continue
+		}
+
+		until := now.Add(srv.policy.Lockout)
+		if err = srv.repo.Lock(ctx, key, until); err != nil {
+			return err
+		}
+
+		if err = srv.auditLock(ctx, key, email, ip, a.Failures, until); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Succeed resets failures of email after successful sign in. Failures of ip
+// are kept, so a valid account can't be used to reset them
+func (srv *Service) Succeed(ctx context.Context, email string) error {
+	return srv.repo.Reset(ctx, emailKey(email))
+}
+
+// delay returns how long to wait after failures of email
+func (srv *Service) delay(failures int) time.Duration {
+	if failures < 2 || srv.policy.BaseDelay <= 0 {
+		return 0
+	}
+
+	d := srv.policy.BaseDelay
+	for i := 2; i < failures && d < srv.policy.MaxDelay; i++ {
+		d *= 2
+	}
+
+	if srv.policy.MaxDelay > 0 && d > srv.policy.MaxDelay {
+		return srv.policy.MaxDelay
+	}
+
+	return d
+}
+
+func (srv *Service) auditLock(ctx context.Context, key, email, ip string, failures int, until time.Time) error {
+	details := map[string]interface{}{
+		"failures":     failures,
+		"locked_until": until.UTC().Format(time.RFC3339),
+	}
+
+	if strings.HasPrefix(key, emailKeyPrefix) {
+		details["email"] = email
+	} else {
+		details["locked_ip"] = ip
+	}
+
+	body, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	return srv.audit.Create(ctx, &audit.Event{
+		Action:  audit.ActionLoginLocked,
+		IP:      ip,
+		Details: string(body),
+	})
+}
+
+func keys(email, ip string) []string {
+	k := []string{emailKey(email)}
+	if ip != "" {
+		k = append(k, ipKeyPrefix+ip)
+	}
+
+	return k
+}
+
+func emailKey(email string) string {
+	return emailKeyPrefix + strings.ToLower(strings.TrimSpace(email))
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return def
+	}
+
+	return value
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || d <= 0 {
+		return def
+	}
+
+	return d
+}
@@ -0,0 +1,161 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/attempt"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var attemptColumns = []string{"attempt_key", "failures", "last_failed_at", "locked_until"}
+
+func TestCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedWait time.Duration
+	}{
+		{
+			name: "Without failures",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", "ip:127.0.0.1").
+					WillReturnRows(sqlmock.NewRows(attemptColumns))
+			},
+		},
+		{
+			name: "Progressive delay after failures of email",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", "ip:127.0.0.1").
+					WillReturnRows(sqlmock.NewRows(attemptColumns).
+						AddRow("email:check@check.com", 4, now.Add(-time.Second), nil).
+						AddRow("ip:127.0.0.1", 4, now.Add(-time.Second), nil))
+			},
+			expectedWait: 3 * time.Second,
+		},
+		{
+			name: "Locked ip",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", "ip:127.0.0.1").
+					WillReturnRows(sqlmock.NewRows(attemptColumns).
+						AddRow("ip:127.0.0.1", 50, now.Add(-time.Minute), now.Add(10*time.Minute)))
+			},
+			expectedWait: 10 * time.Minute,
+		},
+		{
+			name: "Failures outside of window",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM login_attempts").
+					WithArgs("email:check@check.com", "ip:127.0.0.1").
+					WillReturnRows(sqlmock.NewRows(attemptColumns).
+						AddRow("email:check@check.com", 4, now.Add(-time.Hour), nil))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(attempt.NewRepository(db), audit.NewRepository(db), DefaultPolicy)
+			srv.now = func() time.Time { return now }
+
+			wait, err := srv.Check(context.Background(), " Check@check.com", "127.0.0.1")
+			if err != nil {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if wait != testCase.expectedWait {
+				t.Errorf("Invalid wait, expected: %s, got: %s\n", testCase.expectedWait, wait)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		mock func()
+	}{
+		{
+			name: "Failure below limit",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO login_attempts").
+					WithArgs("email:check@check.com", 1, now, now.Add(-15*time.Minute), now, now.Add(-15*time.Minute), now).
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).AddRow(2, now, nil))
+				mock.ExpectQuery("INSERT INTO login_attempts").
+					WithArgs("ip:127.0.0.1", 1, now, now.Add(-15*time.Minute), now, now.Add(-15*time.Minute), now).
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).AddRow(2, now, nil))
+			},
+		},
+		{
+			name: "Email reached limit",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO login_attempts").
+					WithArgs("email:check@check.com", 1, now, sqlmock.AnyArg(), now, sqlmock.AnyArg(), now).
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).AddRow(5, now, nil))
+				mock.ExpectExec("UPDATE login_attempts SET locked_until").
+					WithArgs(now.Add(15*time.Minute), "email:check@check.com").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(nil, audit.ActionLoginLocked, "", nil, "127.0.0.1", "",
+						`{"email":"check@check.com","failures":5,"locked_until":"2021-10-01T12:15:00Z"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("INSERT INTO login_attempts").
+					WithArgs("ip:127.0.0.1", 1, now, sqlmock.AnyArg(), now, sqlmock.AnyArg(), now).
+					WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failed_at", "locked_until"}).AddRow(5, now, nil))
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(attempt.NewRepository(db), audit.NewRepository(db), DefaultPolicy)
+			srv.now = func() time.Time { return now }
+
+			if err := srv.Fail(context.Background(), "check@check.com", "127.0.0.1"); err != nil {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	srv := NewService(nil, nil, DefaultPolicy)
+
+	for failures, expected := range map[int]time.Duration{
+		1: 0, 2: time.Second, 3: 2 * time.Second, 4: 4 * time.Second, 10: 30 * time.Second,
+	} {
+		if d := srv.delay(failures); d != expected {
+			t.Errorf("Invalid delay after %d failures, expected: %s, got: %s\n", failures, expected, d)
+		}
+	}
+}
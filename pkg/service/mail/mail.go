@@ -0,0 +1,40 @@
+// Package mail uses for sending emails to users
+package mail
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/Hargeon/videocmprs/pkg/service"
+)
+
+const defaultSMTPPort = 587
+
+// ErrNotConfigured returns if email is sent without configured SMTP server
+var ErrNotConfigured = errors.New("mailer is not configured")
+
+// Disabled is used when SMTP server isn't configured, every email fails
+type Disabled struct{}
+
+// Send returns ErrNotConfigured
+func (Disabled) Send(ctx context.Context, to, subject, body string) error {
+	return ErrNotConfigured
+}
+
+// NewEnvMailer returns SMTP mailer configured by SMTP_HOST, SMTP_PORT,
+// SMTP_USERNAME, SMTP_PASSWORD and MAIL_FROM or Disabled if SMTP_HOST is empty
+func NewEnvMailer() service.Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return Disabled{}
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		port = defaultSMTPPort
+	}
+
+	return NewSMTP(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+}
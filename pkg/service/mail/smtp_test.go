@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// smtpStandIn is a minimal SMTP server which accepts one email
+type smtpStandIn struct {
+	listener net.Listener
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error when starting smtp stand-in, error: %s\n", err)
+	}
+
+	s := &smtpStandIn{listener: l, done: make(chan struct{})}
+	go s.serve()
+
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n")) //nolint:errcheck
+	}
+
+	reply("220 localhost ESMTP stand-in")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		s.commands = append(s.commands, line)
+
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if l == ".\r\n" {
+					break
+				}
+
+				data.WriteString(l)
+			}
+
+			s.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	server := newSMTPStandIn(t)
+	defer server.listener.Close()
+
+	m := NewSMTP("127.0.0.1", server.port(), "user", "secret", "noreply@videocmprs.com")
+
+	err := m.Send(context.Background(), "check@check.com", "Verify your email", "Open link\nhttp://localhost/verify-email?token=abc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	<-server.done
+
+	commands := strings.Join(server.commands, "\n")
+	for _, expected := range []string{"AUTH PLAIN", "MAIL FROM:<noreply@videocmprs.com>", "RCPT TO:<check@check.com>", "QUIT"} {
+		if !strings.Contains(commands, expected) {
+			t.Errorf("Command %s wasn't sent, got:\n%s\n", expected, commands)
+		}
+	}
+
+	for _, expected := range []string{"To: check@check.com\r\n", "Subject: Verify your email\r\n",
+		"Content-Type: text/plain; charset=UTF-8\r\n", "\r\nhttp://localhost/verify-email?token=abc"} {
+		if !strings.Contains(server.data, expected) {
+			t.Errorf("Message doesn't contain %q, got:\n%s\n", expected, server.data)
+		}
+	}
+}
+
+func TestSMTPSendInvalidHeader(t *testing.T) {
+	m := NewSMTP("127.0.0.1", 25, "", "", "noreply@videocmprs.com")
+
+	err := m.Send(context.Background(), "check@check.com\r\nBcc: other@check.com", "Subject", "Body")
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Invalid error, expected: %v, got: %v\n", ErrInvalidHeader, err)
+	}
+}
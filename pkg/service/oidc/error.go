@@ -0,0 +1,14 @@
+package oidc
+
+import "errors"
+
+var (
+	// ErrNotConfigured returns if OIDC_ISSUER isn't set
+	ErrNotConfigured = errors.New("single sign-on is not configured")
+	// ErrDiscovery returns if provider configuration can't be fetched
+	ErrDiscovery = errors.New("invalid openid provider configuration")
+	// ErrExchange returns if provider rejected authorization code
+	ErrExchange = errors.New("authorization code exchange failed")
+	// ErrInvalidIDToken returns if ID token isn't issued for this client or is expired
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
@@ -0,0 +1,349 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/service/jwt"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	requestTimeOut = 10 * time.Second
+	// keysRefreshInterval limits how often keys are fetched for unknown kid
+	keysRefreshInterval = time.Minute
+	// maxResponseSize limits size of provider responses
+	maxResponseSize = 1 << 20
+)
+
+// signingMethods are accepted algorithms of ID token, HS* is never accepted
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Discovery is part of provider configuration published at
+// /.well-known/openid-configuration
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDToken is verified identity of user
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// Provider is OpenID Connect provider. Configuration and keys are fetched on
+// first use and cached, keys are fetched again when token has unknown kid
+type Provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	client       *http.Client
+
+	mu          sync.Mutex
+	discovery   *Discovery
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// NewProvider initialize Provider. clientSecret may be empty for public clients
+func NewProvider(issuer, clientID, clientSecret, redirectURL string, scopes []string) *Provider {
+	return &Provider{
+		issuer:       strings.TrimRight(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		scopes:       scopes,
+		client:       &http.Client{Timeout: requestTimeOut},
+	}
+}
+
+// Issuer returns issuer identifier of provider
+func (p *Provider) Issuer() string {
+	return p.issuer
+}
+
+// AuthCodeURL returns url of provider login page. challenge is S256 PKCE code challenge
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	d, err := p.configuration(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.clientID)
+	params.Set("redirect_uri", p.redirectURL)
+	params.Set("scope", strings.Join(p.scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", challenge)
+	params.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return d.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange exchanges authorization code and PKCE verifier for ID token and
+// verifies it
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	d, err := p.configuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("client_id", p.clientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+
+	if err = p.do(req, &body); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExchange, err)
+	}
+
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("%w: response has no id_token", ErrExchange)
+	}
+
+	return p.Verify(ctx, body.IDToken, nonce)
+}
+
+// Verify checks signature, issuer, audience, expiration and nonce of ID token
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	parser := &jwtgo.Parser{ValidMethods: signingMethods}
+
+	token, err := parser.Parse(rawIDToken, func(token *jwtgo.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		return p.key(ctx, kid)
+	})
+
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims, ok := token.Claims.(jwtgo.MapClaims)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	if err = p.validate(claims, nonce); err != nil {
+		return nil, err
+	}
+
+	id := &IDToken{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = verified
+	case string:
+		id.EmailVerified = verified == "true"
+	}
+
+	return id, nil
+}
+
+// validate checks claims which aren't checked by jwt parser
+func (p *Provider) validate(claims jwtgo.MapClaims, nonce string) error {
+	if iss, _ := claims["iss"].(string); strings.TrimRight(iss, "/") != p.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, iss)
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return fmt.Errorf("%w: no expiration", ErrInvalidIDToken)
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	var audience []string
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		audience = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+	}
+
+	found := false
+	for _, aud := range audience {
+		found = found || aud == p.clientID
+	}
+
+	if !found {
+		return fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	}
+
+	if azp, ok := claims["azp"].(string); ok && azp != p.clientID {
+		return fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return fmt.Errorf("%w: invalid nonce", ErrInvalidIDToken)
+	}
+
+	return nil
+}
+
+// configuration returns discovery document of provider
+func (p *Provider) configuration(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(Discovery)
+	if err = p.do(req, d); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDiscovery, err)
+	}
+
+	if strings.TrimRight(d.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("%w: issuer %q doesn't match %q", ErrDiscovery, d.Issuer, p.issuer)
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: endpoints are missing", ErrDiscovery)
+	}
+
+	p.discovery = d
+
+	return d, nil
+}
+
+// key returns verification key with kid. Keys are fetched again if kid is
+// unknown, but not more often than keysRefreshInterval
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	d, err := p.configuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetched) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	set := new(jwt.JWKS)
+	if err = p.do(req, set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.Kid] = pub
+	}
+
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// lookup returns key with kid. Token without kid is accepted only if
+// provider has a single key
+func (p *Provider) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+
+	return key, ok
+}
+
+// do sends request and decodes JSON response
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, v)
+}
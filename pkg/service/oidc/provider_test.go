@@ -0,0 +1,97 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/Hargeon/videocmprs/pkg/service/oidc/oidctest"
+)
+
+func TestProviderExchange(t *testing.T) {
+	server, err := oidctest.NewServer("videocmprs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	defer server.Close()
+
+	server.Subject = "sub"
+	server.Email = "check@check.com"
+
+	cases := []struct {
+		name        string
+		issuer      string
+		audience    string
+		verifier    string
+		expectedErr error
+	}{
+		{
+			name:     "Valid code",
+			issuer:   server.URL,
+			audience: "videocmprs",
+			verifier: "verifier",
+		},
+		{
+			name:        "Invalid verifier",
+			issuer:      server.URL,
+			audience:    "videocmprs",
+			verifier:    "other",
+			expectedErr: ErrExchange,
+		},
+		{
+			name:        "Token for other client",
+			issuer:      server.URL,
+			audience:    "other",
+			verifier:    "verifier",
+			expectedErr: ErrInvalidIDToken,
+		},
+		{
+			name:        "Issuer mismatch",
+			issuer:      server.URL + "/other",
+			audience:    "videocmprs",
+			verifier:    "verifier",
+			expectedErr: ErrDiscovery,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			p := NewProvider(testCase.issuer, "videocmprs", "", "http://localhost/callback", defaultScopes)
+
+			// challenge of "verifier"
+			authURL := server.URL + "/authorize?" + url.Values{
+				"client_id":             {"videocmprs"},
+				"response_type":         {"code"},
+				"redirect_uri":          {"http://localhost/callback"},
+				"nonce":                 {"nonce"},
+				"state":                 {"state"},
+				"code_challenge":        {"iMnq5o6zALKXGivsnlom_0F5_WYda32GHkxlV7mq7hQ"},
+				"code_challenge_method": {"S256"},
+			}.Encode()
+
+			redirect, err := server.Login(authURL)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			callback, err := url.Parse(redirect)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			server.ClientID = testCase.audience
+			defer func() { server.ClientID = "videocmprs" }()
+
+			id, err := p.Exchange(context.Background(), callback.Query().Get("code"), testCase.verifier, "nonce")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && (id.Subject != "sub" || id.Email != "check@check.com" || !id.EmailVerified) {
+				t.Errorf("Invalid id token: %#v\n", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Package oidc uses for signing in users with OpenID Connect provider
+// (authorization code flow with PKCE)
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/google/jsonapi"
+)
+
+const (
+	// StateTD is how long user can stay on provider login page
+	StateTD = 10 * time.Minute
+
+	randomLen = 32
+)
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Disabled is used when provider isn't configured, every login fails
+type Disabled struct{}
+
+// AuthURL returns ErrNotConfigured
+func (Disabled) AuthURL(ctx context.Context) (string, string, error) {
+	return "", "", ErrNotConfigured
+}
+
+// Callback returns ErrNotConfigured
+func (Disabled) Callback(ctx context.Context, state, browserState, code string) (jsonapi.Linkable, error) {
+	return nil, ErrNotConfigured
+}
+
+// Service signs in users with Provider and issues usual access and refresh tokens.
+// Subject of provider is linked with user on first login by verified email
+type Service struct {
+	provider *Provider
+	users    repository.UserRepository
+	tokens   repository.TokenRepository
+	issuer   service.TokenIssuer
+	hasher   service.PasswordHasher
+
+	// autoProvision creates users for unknown verified emails
+	autoProvision bool
+}
+
+// NewService initialize Service
+func NewService(provider *Provider, users repository.UserRepository, tokens repository.TokenRepository,
+	issuer service.TokenIssuer, hasher service.PasswordHasher, autoProvision bool) *Service {
+	return &Service{
+		provider:      provider,
+		users:         users,
+		tokens:        tokens,
+		issuer:        issuer,
+		hasher:        hasher,
+		autoProvision: autoProvision,
+	}
+}
+
+// NewEnvService returns Service configured by OIDC_ISSUER, OIDC_CLIENT_ID,
+// OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL, OIDC_SCOPES and OIDC_AUTO_PROVISION
+// or Disabled if OIDC_ISSUER is empty
+func NewEnvService(users repository.UserRepository, tokens repository.TokenRepository, issuer service.TokenIssuer,
+	hasher service.PasswordHasher) service.SSO {
+	issuerURL := os.Getenv("OIDC_ISSUER")
+	if issuerURL == "" {
+		return Disabled{}
+	}
+
+	scopes := strings.Fields(strings.ReplaceAll(os.Getenv("OIDC_SCOPES"), ",", " "))
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	provider := NewProvider(issuerURL, os.Getenv("OIDC_CLIENT_ID"), os.Getenv("OIDC_CLIENT_SECRET"),
+		os.Getenv("OIDC_REDIRECT_URL"), scopes)
+
+	return NewService(provider, users, tokens, issuer, hasher, os.Getenv("OIDC_AUTO_PROVISION") == "true")
+}
+
+// AuthURL stores state, nonce and PKCE verifier of a new login and returns
+// url of provider login page and state, which has to be kept by browser
+func (srv *Service) AuthURL(ctx context.Context) (string, string, error) {
+	state, err := random()
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := random()
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, err := random()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = srv.tokens.CreateOIDCState(ctx, &token.OIDCState{
+		Hash:      hash(state),
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().Add(StateTD),
+	})
+
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	url, err := srv.provider.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", "", err
+	}
+
+	return url, state, nil
+}
+
+// Callback exchanges authorization code for ID token, finds user of it and
+// returns access and refresh tokens, or MFA challenge if user enabled two-factor
+// authentication. State can be used only once and only by browser which started
+// the login, browserState is state kept by it
+func (srv *Service) Callback(ctx context.Context, state, browserState, code string) (jsonapi.Linkable, error) {
+	if state == "" || code == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, service.ErrInvalidSSOState
+	}
+
+	s, err := srv.tokens.UseOIDCState(ctx, hash(state))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrInvalidSSOState
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := srv.provider.Exchange(ctx, code, s.Verifier, s.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, email, err := srv.user(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cred.Disabled() {
+		return nil, service.ErrUserDisabled
+	}
+
+	// provider doesn't replace the second factor of user
+	if cred.MFAEnabled() {
+		return srv.issuer.Challenge(ctx, cred.ID, email)
+	}
+
+	return srv.issuer.Issue(ctx, cred.ID, email, cred.Role)
+}
+
+// user returns credentials of user linked with subject of ID token. Not linked
+// subject is linked with user by verified email, user is created if
+// auto-provisioning is enabled
+func (srv *Service) user(ctx context.Context, id *IDToken) (*user.Credentials, string, error) {
+	email, err := srv.users.IdentityEmail(ctx, srv.provider.Issuer(), id.Subject)
+	if err == nil {
+		cred, err := srv.users.Credentials(ctx, email)
+
+		return cred, email, err
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, "", err
+	}
+
+	// email of unverified account could be set to email of somebody else
+	if id.Email == "" || !id.EmailVerified {
+		return nil, "", service.ErrSSOUserNotFound
+	}
+
+	cred, err := srv.users.Credentials(ctx, id.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !srv.autoProvision {
+			return nil, "", service.ErrSSOUserNotFound
+		}
+
+		cred, err = srv.provision(ctx, id.Email)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = srv.users.LinkIdentity(ctx, cred.ID, srv.provider.Issuer(), id.Subject); err != nil {
+		return nil, "", err
+	}
+
+	return cred, id.Email, nil
+}
+
+// provision creates verified user with random password, it signs in only
+// with provider until the password is reset
+func (srv *Service) provision(ctx context.Context, email string) (*user.Credentials, error) {
+	password, err := random()
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := srv.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := srv.users.Create(ctx, &user.Resource{Email: email, Password: passwordHash})
+	if err != nil {
+		return nil, err
+	}
+
+	created, ok := res.(*user.Resource)
+	if !ok {
+		return nil, service.ErrInvalidTypeAssertion
+	}
+
+	if err = srv.users.VerifyEmail(ctx, created.ID); err != nil {
+		return nil, err
+	}
+
+	return &user.Credentials{ID: created.ID, Role: created.Role}, nil
+}
+
+// random returns random url safe string
+func random() (string, error) {
+	b := make([]byte, randomLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash returns hash of state stored in db
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,298 @@
+package oidc
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/token"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/oidc/oidctest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/jsonapi"
+)
+
+var credentialsColumns = []string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}
+
+// capture matches any argument and remembers it
+type capture struct {
+	value driver.Value
+}
+
+func (c *capture) Match(v driver.Value) bool {
+	c.value = v
+
+	return true
+}
+
+// issuerMock returns user with fake token
+type issuerMock struct{}
+
+func (issuerMock) Issue(ctx context.Context, id int64, email, role string) (jsonapi.Linkable, error) {
+	return &user.Resource{ID: id, Email: email, Role: role, Token: "token"}, nil
+}
+
+func (issuerMock) Challenge(ctx context.Context, id int64, email string) (jsonapi.Linkable, error) {
+	return &user.Resource{ID: id, Email: email, MFAToken: "challenge"}, nil
+}
+
+// hasherMock returns constant hash
+type hasherMock struct{}
+
+func (hasherMock) Hash(password string) (string, error) {
+	return "hash", nil
+}
+
+func (hasherMock) Verify(password, encoded string) (bool, bool, error) {
+	return false, false, nil
+}
+
+func TestCallback(t *testing.T) {
+	provider, err := oidctest.NewServer("videocmprs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	defer provider.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name          string
+		email         string
+		emailVerified bool
+		autoProvision bool
+		nonce         string
+		mock          func()
+		expectedErr   error
+		expectedID    int64
+		expectedMFA   bool
+	}{
+		{
+			name:          "Linked identity",
+			email:         "other@check.com",
+			emailVerified: true,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", user.IdentityTableName)).
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("check@check.com"))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, "hash", "user", nil, time.Now(), nil))
+			},
+			expectedID: 1,
+		},
+		{
+			name:          "Existing user with verified email",
+			email:         "check@check.com",
+			emailVerified: true,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", user.IdentityTableName)).
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(2, "hash", "user", nil, time.Now(), nil))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", user.IdentityTableName)).
+					WithArgs(2, provider.URL, "sub").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedID: 2,
+		},
+		{
+			name:  "Existing user with not verified email",
+			email: "check@check.com",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", user.IdentityTableName)).
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}))
+			},
+			expectedErr: service.ErrSSOUserNotFound,
+		},
+		{
+			name:          "Unknown user",
+			email:         "check@check.com",
+			emailVerified: true,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", user.IdentityTableName)).
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns))
+			},
+			expectedErr: service.ErrSSOUserNotFound,
+		},
+		{
+			name:          "Unknown user with auto-provisioning",
+			email:         "check@check.com",
+			emailVerified: true,
+			autoProvision: true,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", user.IdentityTableName)).
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", user.TableName)).
+					WithArgs("check@check.com", "hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow(3, "check@check.com", "user"))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET email_verified_at", user.TableName)).
+					WithArgs(sqlmock.AnyArg(), 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", user.IdentityTableName)).
+					WithArgs(3, provider.URL, "sub").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedID: 3,
+		},
+		{
+			name:          "User with two-factor authentication",
+			email:         "check@check.com",
+			emailVerified: true,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", user.IdentityTableName)).
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("check@check.com"))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, "hash", "user", nil, time.Now(), time.Now()))
+			},
+			expectedID:  1,
+			expectedMFA: true,
+		},
+		{
+			name:          "Disabled user",
+			email:         "check@check.com",
+			emailVerified: true,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT users.email FROM %s", user.IdentityTableName)).
+					WithArgs(provider.URL, "sub").
+					WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("check@check.com"))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", user.TableName)).
+					WithArgs("check@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(1, "hash", "user", time.Now(), time.Now(), nil))
+			},
+			expectedErr: service.ErrUserDisabled,
+		},
+		{
+			name:        "Replayed ID token",
+			nonce:       "other",
+			mock:        func() {},
+			expectedErr: ErrInvalidIDToken,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider.Subject = "sub"
+			provider.Email = testCase.email
+			provider.EmailVerified = testCase.emailVerified
+
+			p := NewProvider(provider.URL, "videocmprs", "secret", "http://localhost/callback", defaultScopes)
+			srv := NewService(p, user.NewRepository(db), token.NewRepository(db), issuerMock{}, hasherMock{},
+				testCase.autoProvision)
+
+			nonce, verifier := new(capture), new(capture)
+			mock.ExpectExec(fmt.Sprintf("INSERT INTO %s", token.OIDCStateTableName)).
+				WithArgs(sqlmock.AnyArg(), nonce, verifier, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			authURL, state, err := srv.AuthURL(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			redirect, err := provider.Login(authURL)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			callback, err := url.Parse(redirect)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if callback.Query().Get("state") != state {
+				t.Fatalf("Invalid state, expected: %s, got: %s\n", state, callback.Query().Get("state"))
+			}
+
+			storedNonce := nonce.value
+			if testCase.nonce != "" {
+				storedNonce = testCase.nonce
+			}
+
+			mock.ExpectQuery(fmt.Sprintf("DELETE FROM %s", token.OIDCStateTableName)).
+				WithArgs(hash(callback.Query().Get("state")), sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}).
+					AddRow(storedNonce, verifier.value, time.Now().Add(time.Minute)))
+
+			testCase.mock()
+
+			res, err := srv.Callback(context.Background(), state, state, callback.Query().Get("code"))
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil {
+				usr, ok := res.(*user.Resource)
+				if !ok {
+					t.Fatalf("Invalid type assertion\n")
+				}
+
+				if usr.ID != testCase.expectedID || (usr.Token == "") != testCase.expectedMFA ||
+					(usr.MFAToken != "") != testCase.expectedMFA {
+					t.Errorf("Invalid user, expected id: %d, mfa: %t, got: %#v\n", testCase.expectedID,
+						testCase.expectedMFA, usr)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestCallbackInvalidState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	mock.ExpectQuery(fmt.Sprintf("DELETE FROM %s", token.OIDCStateTableName)).
+		WithArgs(hash("state"), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}))
+
+	p := NewProvider("http://localhost", "videocmprs", "", "http://localhost/callback", defaultScopes)
+	srv := NewService(p, user.NewRepository(db), token.NewRepository(db), issuerMock{}, hasherMock{}, false)
+
+	if _, err = srv.Callback(context.Background(), "state", "state", "code"); !errors.Is(err, service.ErrInvalidSSOState) {
+		t.Errorf("Invalid error, expected: %v, got: %v\n", service.ErrInvalidSSOState, err)
+	}
+
+	// state of another browser is rejected before it is used
+	for _, browserState := range []string{"", "other"} {
+		_, err = srv.Callback(context.Background(), "state", browserState, "code")
+		if !errors.Is(err, service.ErrInvalidSSOState) {
+			t.Errorf("Invalid error, expected: %v, got: %v\n", service.ErrInvalidSSOState, err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
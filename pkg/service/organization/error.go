@@ -0,0 +1,18 @@
+package organization
+
+import "errors"
+
+var (
+	// ErrOrganizationNotPresent returns if organization doesn't exists or user is not its member
+	ErrOrganizationNotPresent = errors.New("organization does not exists")
+	// ErrForbidden returns if role of user in organization doesn't allow action
+	ErrForbidden = errors.New("not enough permissions in organization")
+	// ErrUserNotFound returns if user added to organization doesn't exists
+	ErrUserNotFound = errors.New("user does not exists")
+	// ErrAlreadyMember returns if user is already a member of organization
+	ErrAlreadyMember = errors.New("user is already a member of organization")
+	// ErrMemberNotPresent returns if member doesn't exists or is owner of organization
+	ErrMemberNotPresent = errors.New("member does not exists")
+	// ErrInvalidRole returns if role can't be granted to member
+	ErrInvalidRole = errors.New("invalid role")
+)
@@ -0,0 +1,208 @@
+// Package organization manages organizations and their members. Requests and
+// videos shared with organization are accessible to all its members
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/organization"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/google/jsonapi"
+)
+
+// Service creates organizations and manages their members
+type Service struct {
+	repo  repository.OrganizationRepository
+	users repository.UserRepository
+}
+
+// NewService initialize Service
+func NewService(repo repository.OrganizationRepository, users repository.UserRepository) *Service {
+	return &Service{repo: repo, users: users}
+}
+
+// Create stores organization, user of resource becomes its owner
+func (srv *Service) Create(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
+	if _, ok := resource.(*organization.Resource); !ok {
+		return nil, service.ErrInvalidTypeAssertion
+	}
+
+	return srv.repo.Create(ctx, resource)
+}
+
+// List returns organizations in which user is a member
+func (srv *Service) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
+	return srv.repo.List(ctx, params)
+}
+
+// Retrieve returns organization with role of user in it
+func (srv *Service) Retrieve(ctx context.Context, userID, relationID int64) (jsonapi.Linkable, error) {
+	role, err := srv.role(ctx, relationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	linkable, err := srv.repo.Retrieve(ctx, relationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrganizationNotPresent
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	org, ok := linkable.(*organization.Resource)
+	if !ok {
+		return nil, service.ErrInvalidTypeAssertion
+	}
+
+	org.Role = role
+	org.UserID = userID
+
+	return org, nil
+}
+
+// Delete removes organization. Only owner deletes organization
+func (srv *Service) Delete(ctx context.Context, userID, relationID int64) error {
+	role, err := srv.role(ctx, relationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if role != organization.RoleOwner {
+		return ErrForbidden
+	}
+
+	return srv.repo.Delete(ctx, relationID)
+}
+
+// Members returns members of organization in which user is a member
+func (srv *Service) Members(ctx context.Context, userID, organizationID int64) ([]interface{}, error) {
+	if _, err := srv.role(ctx, organizationID, userID); err != nil {
+		return nil, err
+	}
+
+	return srv.repo.Members(ctx, organizationID)
+}
+
+// AddMember adds user with email of member to organization. Only owner and admins add members
+func (srv *Service) AddMember(ctx context.Context, userID, organizationID int64, member *organization.Member) (jsonapi.Linkable, error) { //nolint:lll
+	if err := srv.manager(ctx, organizationID, userID); err != nil {
+		return nil, err
+	}
+
+	if !grantable(member.Role) {
+		return nil, ErrInvalidRole
+	}
+
+	cred, err := srv.users.Credentials(ctx, member.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = srv.repo.Membership(ctx, organizationID, cred.ID)
+	if err == nil {
+		return nil, ErrAlreadyMember
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	createdAt, err := srv.repo.AddMember(ctx, organizationID, cred.ID, member.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &organization.Member{
+		ID:             cred.ID,
+		OrganizationID: organizationID,
+		Email:          member.Email,
+		Role:           member.Role,
+		CreatedAt:      createdAt,
+	}, nil
+}
+
+// UpdateMember changes role of member. Only owner and admins change roles,
+// role of owner can't be changed
+func (srv *Service) UpdateMember(ctx context.Context, userID, organizationID, memberID int64, role string) error {
+	if err := srv.manager(ctx, organizationID, userID); err != nil {
+		return err
+	}
+
+	if !grantable(role) {
+		return ErrInvalidRole
+	}
+
+	updated, err := srv.repo.UpdateMember(ctx, organizationID, memberID, role)
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		return ErrMemberNotPresent
+	}
+
+	return nil
+}
+
+// RemoveMember removes member from organization. Owner and admins remove
+// any member except owner, other members only leave organization
+func (srv *Service) RemoveMember(ctx context.Context, userID, organizationID, memberID int64) error {
+	if userID == memberID {
+		if _, err := srv.role(ctx, organizationID, userID); err != nil {
+			return err
+		}
+	} else if err := srv.manager(ctx, organizationID, userID); err != nil {
+		return err
+	}
+
+	removed, err := srv.repo.RemoveMember(ctx, organizationID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return ErrMemberNotPresent
+	}
+
+	return nil
+}
+
+// role returns role of user in organization, ErrOrganizationNotPresent if user is not a member
+func (srv *Service) role(ctx context.Context, organizationID, userID int64) (string, error) {
+	role, err := srv.repo.Membership(ctx, organizationID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrOrganizationNotPresent
+	}
+
+	return role, err
+}
+
+// manager returns ErrForbidden if user can't manage members of organization
+func (srv *Service) manager(ctx context.Context, organizationID, userID int64) error {
+	role, err := srv.role(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if role != organization.RoleOwner && role != organization.RoleAdmin {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// grantable returns true if role can be granted to member. There is only one owner
+func grantable(role string) bool {
+	return role == organization.RoleAdmin || role == organization.RoleMember
+}
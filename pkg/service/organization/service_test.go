@@ -0,0 +1,272 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/organization"
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var credentialsColumns = []string{"id", "password_hash", "role", "disabled_at", "email_verified_at", "totp_enabled_at"}
+
+func expectRole(mock sqlmock.Sqlmock, userID int64, role string) {
+	rows := sqlmock.NewRows([]string{"role"})
+	if role != "" {
+		rows.AddRow(role)
+	}
+
+	mock.ExpectQuery("SELECT role FROM organization_members").
+		WithArgs(1, userID).
+		WillReturnRows(rows)
+}
+
+func TestRetrieve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedRole string
+		expectedErr  error
+	}{
+		{
+			name: "Member retrieves organization",
+			mock: func() {
+				expectRole(mock, 2, organization.RoleMember)
+				mock.ExpectQuery("SELECT id, name, created_at FROM organizations").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).AddRow(1, "team", time.Now()))
+			},
+			expectedRole: organization.RoleMember,
+		},
+		{
+			name: "Not a member",
+			mock: func() {
+				expectRole(mock, 2, "")
+			},
+			expectedErr: ErrOrganizationNotPresent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(organization.NewRepository(db), user.NewRepository(db))
+
+			res, err := srv.Retrieve(context.Background(), 2, 1)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && res.(*organization.Resource).Role != testCase.expectedRole {
+				t.Errorf("Invalid role, expected: %s, got: %s\n", testCase.expectedRole, res.(*organization.Resource).Role)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "Owner deletes organization",
+			mock: func() {
+				expectRole(mock, 2, organization.RoleOwner)
+				mock.ExpectExec("DELETE FROM organizations").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Admin can't delete organization",
+			mock: func() {
+				expectRole(mock, 2, organization.RoleAdmin)
+			},
+			expectedErr: ErrForbidden,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(organization.NewRepository(db), user.NewRepository(db))
+
+			err := srv.Delete(context.Background(), 2, 1)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestAddMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		member      *organization.Member
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:   "Admin adds member",
+			member: &organization.Member{Email: "new@check.com", Role: organization.RoleMember},
+			mock: func() {
+				expectRole(mock, 2, organization.RoleAdmin)
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("new@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(3, "hash", "user", nil, nil, nil))
+				expectRole(mock, 3, "")
+				mock.ExpectQuery("INSERT INTO organization_members").
+					WithArgs(1, 3, organization.RoleMember).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+			},
+		},
+		{
+			name:   "Member can't add members",
+			member: &organization.Member{Email: "new@check.com", Role: organization.RoleMember},
+			mock: func() {
+				expectRole(mock, 2, organization.RoleMember)
+			},
+			expectedErr: ErrForbidden,
+		},
+		{
+			name:   "Owner role can't be granted",
+			member: &organization.Member{Email: "new@check.com", Role: organization.RoleOwner},
+			mock: func() {
+				expectRole(mock, 2, organization.RoleOwner)
+			},
+			expectedErr: ErrInvalidRole,
+		},
+		{
+			name:   "Unknown user",
+			member: &organization.Member{Email: "new@check.com", Role: organization.RoleMember},
+			mock: func() {
+				expectRole(mock, 2, organization.RoleOwner)
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("new@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns))
+			},
+			expectedErr: ErrUserNotFound,
+		},
+		{
+			name:   "Already a member",
+			member: &organization.Member{Email: "new@check.com", Role: organization.RoleAdmin},
+			mock: func() {
+				expectRole(mock, 2, organization.RoleOwner)
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WithArgs("new@check.com").
+					WillReturnRows(sqlmock.NewRows(credentialsColumns).AddRow(3, "hash", "user", nil, nil, nil))
+				expectRole(mock, 3, organization.RoleMember)
+			},
+			expectedErr: ErrAlreadyMember,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(organization.NewRepository(db), user.NewRepository(db))
+
+			res, err := srv.AddMember(context.Background(), 2, 1, testCase.member)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && res.(*organization.Member).ID != 3 {
+				t.Errorf("Invalid member ID, expected: 3, got: %d\n", res.(*organization.Member).ID)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name        string
+		memberID    int64
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:     "Member leaves organization",
+			memberID: 2,
+			mock: func() {
+				expectRole(mock, 2, organization.RoleMember)
+				mock.ExpectExec("DELETE FROM organization_members").
+					WithArgs(1, 2, organization.RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Member can't remove others",
+			memberID: 3,
+			mock: func() {
+				expectRole(mock, 2, organization.RoleMember)
+			},
+			expectedErr: ErrForbidden,
+		},
+		{
+			name:     "Owner can't be removed",
+			memberID: 3,
+			mock: func() {
+				expectRole(mock, 2, organization.RoleAdmin)
+				mock.ExpectExec("DELETE FROM organization_members").
+					WithArgs(1, 3, organization.RoleOwner).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: ErrMemberNotPresent,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(organization.NewRepository(db), user.NewRepository(db))
+
+			err := srv.RemoveMember(context.Background(), 2, 1, testCase.memberID)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
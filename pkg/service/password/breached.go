@@ -0,0 +1,97 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec
+	_ "embed"     // bundled breached list
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PrefixLen is length of hash prefix used for range lookups
+const PrefixLen = 5
+
+//go:embed breached.txt
+var bundled string
+
+// BreachedList is a set of SHA-1 hashes of breached passwords grouped by
+// prefix. It's queried with k-anonymity: only the first PrefixLen chars of
+// hash select a range, the rest is compared within it, so the list can be
+// replaced with a remote range API without sending passwords or whole hashes
+type BreachedList struct {
+	ranges map[string][]string
+}
+
+// NewBreachedList reads list of upper or lower case hex SHA-1 hashes, one per
+// line. Empty lines, lines starting with # and ":COUNT" suffixes are skipped
+func NewBreachedList(r io.Reader) (*BreachedList, error) {
+	list := &BreachedList{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		hash := strings.TrimSpace(scanner.Text())
+		if hash == "" || strings.HasPrefix(hash, "#") {
+			continue
+		}
+
+		if i := strings.IndexByte(hash, ':'); i >= 0 {
+			hash = hash[:i]
+		}
+
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("invalid hash at line %d of breached list", line)
+		}
+
+		list.ranges[hash[:PrefixLen]] = append(list.ranges[hash[:PrefixLen]], hash[PrefixLen:])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// BundledBreachedList returns list of the most common passwords shipped with binary
+func BundledBreachedList() *BreachedList {
+	list, err := NewBreachedList(strings.NewReader(bundled))
+	if err != nil {
+		panic(err)
+	}
+
+	return list
+}
+
+// LoadBreachedList reads list from file
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewBreachedList(f)
+}
+
+// Range returns suffixes of hashes starting with prefix
+func (l *BreachedList) Range(prefix string) []string {
+	return l.ranges[strings.ToUpper(prefix)]
+}
+
+// Breached returns true if password is in list
+func (l *BreachedList) Breached(password string) bool {
+	sum := sha1.Sum([]byte(password)) //nolint:gosec
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	for _, suffix := range l.Range(hash[:PrefixLen]) {
+		if suffix == hash[PrefixLen:] {
+			return true
+		}
+	}
+
+	return false
+}
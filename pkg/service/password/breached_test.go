@@ -0,0 +1,63 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sha1 of "password"
+const passwordHash = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+func TestBreachedList(t *testing.T) {
+	list, err := NewBreachedList(strings.NewReader("# comment\n\n" + strings.ToLower(passwordHash) + ":3861493\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if suffixes := list.Range("5baa6"); len(suffixes) != 1 || suffixes[0] != passwordHash[PrefixLen:] {
+		t.Errorf("Invalid range, got: %v\n", suffixes)
+	}
+
+	if !list.Breached("password") {
+		t.Error("Password should be breached\n")
+	}
+
+	if list.Breached("Password") {
+		t.Error("Password should not be breached\n")
+	}
+}
+
+func TestNewBreachedListInvalid(t *testing.T) {
+	for _, content := range []string{"5BAA61E4\n", passwordHash + "ZZ\n", strings.Repeat("X", 40)} {
+		if _, err := NewBreachedList(strings.NewReader(content)); err == nil {
+			t.Errorf("Should be error for %q\n", content)
+		}
+	}
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(passwordHash+"\n"), 0o600); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	os.Setenv("PASSWORD_BREACHED_LIST", path)
+	defer os.Unsetenv("PASSWORD_BREACHED_LIST")
+
+	srv := NewEnvService(nil)
+	if !srv.breached.Breached("password") || srv.breached.Breached("123456") {
+		t.Error("Breached list should be loaded from file\n")
+	}
+}
+
+func TestBundledBreachedList(t *testing.T) {
+	list := BundledBreachedList()
+
+	for _, password := range []string{"123456", "password", "qwerty123", "P@ssw0rd"} {
+		if !list.Breached(password) {
+			t.Errorf("%s should be breached\n", password)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package password uses for checking passwords of users against configured
+// policy and list of breached passwords
+package password
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// DefaultMinLength of password
+const DefaultMinLength = 8
+
+// Policy is set of rules for passwords. Zero value accepts every password
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// RejectEmail rejects passwords containing email or its local part
+	RejectEmail bool
+}
+
+// DefaultPolicy requires DefaultMinLength characters and rejects passwords containing email
+var DefaultPolicy = Policy{MinLength: DefaultMinLength, RejectEmail: true}
+
+// Service checks passwords against policy and breached list
+type Service struct {
+	policy Policy
+	// breached is nil when check of breached passwords is disabled
+	breached *BreachedList
+}
+
+// NewService initialize Service. Nil breached list disables check of breached passwords
+func NewService(policy Policy, breached *BreachedList) *Service {
+	return &Service{policy: policy, breached: breached}
+}
+
+// NewEnvService initialize Service with PASSWORD_MIN_LENGTH, PASSWORD_REQUIRE_UPPER,
+// PASSWORD_REQUIRE_LOWER, PASSWORD_REQUIRE_DIGIT, PASSWORD_REQUIRE_SYMBOL and
+// PASSWORD_REJECT_EMAIL env variables. Bundled breached list is replaced with
+// file at PASSWORD_BREACHED_LIST, PASSWORD_BREACHED_CHECK=false disables the check
+func NewEnvService(logger *zap.Logger) *Service {
+	policy := DefaultPolicy
+
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && n >= 0 {
+		policy.MinLength = n
+	}
+
+	policy.RequireUpper = envBool("PASSWORD_REQUIRE_UPPER", policy.RequireUpper)
+	policy.RequireLower = envBool("PASSWORD_REQUIRE_LOWER", policy.RequireLower)
+	policy.RequireDigit = envBool("PASSWORD_REQUIRE_DIGIT", policy.RequireDigit)
+	policy.RequireSymbol = envBool("PASSWORD_REQUIRE_SYMBOL", policy.RequireSymbol)
+	policy.RejectEmail = envBool("PASSWORD_REJECT_EMAIL", policy.RejectEmail)
+
+	if !envBool("PASSWORD_BREACHED_CHECK", true) {
+		return NewService(policy, nil)
+	}
+
+	path := os.Getenv("PASSWORD_BREACHED_LIST")
+	if path == "" {
+		return NewService(policy, BundledBreachedList())
+	}
+
+	breached, err := LoadBreachedList(path)
+	if err != nil {
+		logger.Error("Can't load breached passwords, bundled list is used", zap.Error(err),
+			zap.String("Path", path))
+
+		breached = BundledBreachedList()
+	}
+
+	return NewService(policy, breached)
+}
+
+// Check returns descriptions of rules which password violates, empty if password
+// is accepted. Empty email skips check of email
+func (srv *Service) Check(email, password string) []string {
+	violations := make([]string, 0)
+	p := srv.policy
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("Password must be at least %d characters", p.MinLength))
+	}
+
+	if p.RequireUpper && !hasRune(password, unicode.IsUpper) {
+		violations = append(violations, "Password must contain an uppercase letter")
+	}
+
+	if p.RequireLower && !hasRune(password, unicode.IsLower) {
+		violations = append(violations, "Password must contain a lowercase letter")
+	}
+
+	if p.RequireDigit && !hasRune(password, unicode.IsDigit) {
+		violations = append(violations, "Password must contain a digit")
+	}
+
+	if p.RequireSymbol && !hasRune(password, isSymbol) {
+		violations = append(violations, "Password must contain a special character")
+	}
+
+	if p.RejectEmail && containsEmail(password, email) {
+		violations = append(violations, "Password must not contain email")
+	}
+
+	if srv.breached != nil && srv.breached.Breached(password) {
+		violations = append(violations, "Password has appeared in a data breach, choose another one")
+	}
+
+	return violations
+}
+
+func hasRune(s string, f func(rune) bool) bool {
+	return strings.IndexFunc(s, f) >= 0
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+// containsEmail checks whole email and its local part, local parts shorter
+// than 3 characters are too common to be checked
+func containsEmail(password, email string) bool {
+	password, email = strings.ToLower(password), strings.ToLower(email)
+	if email == "" {
+		return false
+	}
+
+	if strings.Contains(password, email) {
+		return true
+	}
+
+	local := email
+	if i := strings.LastIndexByte(email, '@'); i >= 0 {
+		local = email[:i]
+	}
+
+	return utf8.RuneCountInString(local) >= 3 && strings.Contains(password, local)
+}
+
+func envBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+
+	return v
+}
@@ -0,0 +1,130 @@
+package password
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	strict := Policy{MinLength: 10, RequireUpper: true, RequireLower: true, RequireDigit: true,
+		RequireSymbol: true, RejectEmail: true}
+
+	cases := []struct {
+		name               string
+		policy             Policy
+		breached           *BreachedList
+		email              string
+		password           string
+		expectedViolations []string
+	}{
+		{
+			name:               "Accepted by default policy",
+			policy:             DefaultPolicy,
+			breached:           BundledBreachedList(),
+			email:              "check@check.com",
+			password:           "video-Compressor",
+			expectedViolations: []string{},
+		},
+		{
+			name:               "Too short",
+			policy:             DefaultPolicy,
+			email:              "check@check.com",
+			password:           "vidёo",
+			expectedViolations: []string{"Password must be at least 8 characters"},
+		},
+		{
+			name:     "Breached",
+			policy:   DefaultPolicy,
+			breached: BundledBreachedList(),
+			email:    "check@check.com",
+			password: "password123",
+			expectedViolations: []string{
+				"Password has appeared in a data breach, choose another one",
+			},
+		},
+		{
+			name:               "Breached when check is disabled",
+			policy:             DefaultPolicy,
+			email:              "check@check.com",
+			password:           "password123",
+			expectedViolations: []string{},
+		},
+		{
+			name:               "Contains local part of email",
+			policy:             DefaultPolicy,
+			email:              "check@check.com",
+			password:           "my-CHECK-1234",
+			expectedViolations: []string{"Password must not contain email"},
+		},
+		{
+			name:               "Contains short local part of email",
+			policy:             DefaultPolicy,
+			email:              "jo@check.com",
+			password:           "jo-video-1234",
+			expectedViolations: []string{},
+		},
+		{
+			name:               "Contains email when email isn't known",
+			policy:             DefaultPolicy,
+			password:           "check@check.com",
+			expectedViolations: []string{},
+		},
+		{
+			name:     "Missing character classes",
+			policy:   strict,
+			email:    "check@check.com",
+			password: "videocompressor",
+			expectedViolations: []string{
+				"Password must contain an uppercase letter",
+				"Password must contain a digit",
+				"Password must contain a special character",
+			},
+		},
+		{
+			name:               "Every character class",
+			policy:             strict,
+			email:              "check@check.com",
+			password:           "Video compressor 1!",
+			expectedViolations: []string{},
+		},
+		{
+			name:               "Zero policy",
+			password:           "1",
+			expectedViolations: []string{},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			srv := NewService(testCase.policy, testCase.breached)
+
+			violations := srv.Check(testCase.email, testCase.password)
+			if !reflect.DeepEqual(violations, testCase.expectedViolations) {
+				t.Errorf("Invalid violations, expected: %v, got: %v\n", testCase.expectedViolations, violations)
+			}
+		})
+	}
+}
+
+func TestNewEnvService(t *testing.T) {
+	os.Setenv("PASSWORD_MIN_LENGTH", "12")
+	defer os.Unsetenv("PASSWORD_MIN_LENGTH")
+	os.Setenv("PASSWORD_REQUIRE_DIGIT", "true")
+	defer os.Unsetenv("PASSWORD_REQUIRE_DIGIT")
+	os.Setenv("PASSWORD_REJECT_EMAIL", "false")
+	defer os.Unsetenv("PASSWORD_REJECT_EMAIL")
+	os.Setenv("PASSWORD_BREACHED_CHECK", "false")
+	defer os.Unsetenv("PASSWORD_BREACHED_CHECK")
+
+	srv := NewEnvService(nil)
+
+	expected := Policy{MinLength: 12, RequireDigit: true}
+	if srv.policy != expected {
+		t.Errorf("Invalid policy, expected: %+v, got: %+v\n", expected, srv.policy)
+	}
+
+	if srv.breached != nil {
+		t.Error("Breached check should be disabled\n")
+	}
+}
@@ -0,0 +1,320 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ids of Matroska elements needed for duration
+const (
+	ebmlSegmentID       = 0x18538067
+	ebmlInfoID          = 0x1549A966
+	ebmlClusterID       = 0x1F43B675
+	ebmlTimecodeScaleID = 0x2AD7B1
+	ebmlDurationID      = 0x4489
+
+	// ebmlDefaultTimecodeScale is nanoseconds in one tick of Duration
+	ebmlDefaultTimecodeScale = 1000000
+)
+
+// Duration reads duration of video in seconds from headers of container, so it
+// doesn't need ffprobe. Zero is returned if container doesn't store duration in
+// headers (transport stream, live Matroska streams, fragmented MP4)
+func Duration(r io.ReaderAt, size int64, container string) (float64, error) {
+	var (
+		duration float64
+		err      error
+	)
+
+	switch container {
+	case ContainerMP4, ContainerMOV:
+		duration, err = mp4Duration(r, size)
+	case ContainerMKV, ContainerWebM:
+		duration, err = ebmlDuration(r, size)
+	case ContainerAVI:
+		duration, err = aviDuration(r, size)
+	}
+
+	// headers point beyond end of file
+	if errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("%w: truncated file", ErrCorrupt)
+	}
+
+	return duration, err
+}
+
+// mp4Duration reads duration from movie header box (moov/mvhd)
+func mp4Duration(r io.ReaderAt, size int64) (float64, error) {
+	moov, moovSize, err := findBox(r, 0, size, "moov")
+	if err != nil || moov < 0 {
+		return 0, err
+	}
+
+	mvhd, mvhdSize, err := findBox(r, moov, moov+moovSize, "mvhd")
+	if err != nil || mvhd < 0 {
+		return 0, err
+	}
+
+	header := make([]byte, 32)
+	if mvhdSize < 20 {
+		return 0, ErrCorrupt
+	}
+
+	if _, err = r.ReadAt(header[:20], mvhd); err != nil {
+		return 0, err
+	}
+
+	var timescale, duration uint64
+
+	// version 1 has 64-bit creation, modification time and duration
+	if header[0] == 1 {
+		if mvhdSize < 32 {
+			return 0, ErrCorrupt
+		}
+
+		if _, err = r.ReadAt(header, mvhd); err != nil {
+			return 0, err
+		}
+
+		timescale = uint64(binary.BigEndian.Uint32(header[20:24]))
+		duration = binary.BigEndian.Uint64(header[24:32])
+		if duration == math.MaxUint64 {
+			return 0, nil
+		}
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(header[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(header[16:20]))
+		if duration == math.MaxUint32 {
+			return 0, nil
+		}
+	}
+
+	if timescale == 0 {
+		return 0, ErrCorrupt
+	}
+
+	return float64(duration) / float64(timescale), nil
+}
+
+// findBox returns offset and size of payload of the first box with type between
+// start and end, offset is -1 if there is no such box
+func findBox(r io.ReaderAt, start, end int64, typ string) (int64, int64, error) {
+	header := make([]byte, 16)
+
+	for off := start; off+8 <= end; {
+		if _, err := r.ReadAt(header[:8], off); err != nil {
+			return 0, 0, err
+		}
+
+		size, headerLen := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+
+		switch size {
+		case 0:
+			// the last box extends to end of file
+			size = end - off
+		case 1:
+			// 64-bit size follows type
+			if _, err := r.ReadAt(header[8:16], off+8); err != nil {
+				return 0, 0, err
+			}
+
+			size, headerLen = int64(binary.BigEndian.Uint64(header[8:16])), 16
+		}
+
+		if size < headerLen || size > end-off {
+			return 0, 0, fmt.Errorf("%w: invalid size of %q box", ErrCorrupt, header[4:8])
+		}
+
+		if string(header[4:8]) == typ {
+			return off + headerLen, size - headerLen, nil
+		}
+
+		off += size
+	}
+
+	return -1, 0, nil
+}
+
+// ebmlDuration reads Duration and TimecodeScale from Segment/Info element
+func ebmlDuration(r io.ReaderAt, size int64) (float64, error) {
+	segment, segmentEnd, err := findElement(r, 0, size, ebmlSegmentID)
+	if err != nil || segment < 0 {
+		return 0, err
+	}
+
+	info, infoEnd, err := findElement(r, segment, segmentEnd, ebmlInfoID)
+	if err != nil || info < 0 {
+		return 0, err
+	}
+
+	scale := uint64(ebmlDefaultTimecodeScale)
+
+	scaleOff, scaleEnd, err := findElement(r, info, infoEnd, ebmlTimecodeScaleID)
+	if err != nil {
+		return 0, err
+	}
+
+	if scaleOff >= 0 {
+		if scale, err = readUint(r, scaleOff, scaleEnd); err != nil {
+			return 0, err
+		}
+	}
+
+	durationOff, durationEnd, err := findElement(r, info, infoEnd, ebmlDurationID)
+	if err != nil || durationOff < 0 {
+		return 0, err
+	}
+
+	ticks, err := readFloat(r, durationOff, durationEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	return ticks * float64(scale) / float64(time.Second), nil
+}
+
+// findElement returns offset of data and end of the first element with id
+// between start and end, offset is -1 if there is no such element. Element of
+// unknown size extends to end. Search stops at first cluster, because elements
+// needed for duration precede media data
+func findElement(r io.ReaderAt, start, end, id int64) (int64, int64, error) {
+	for off := start; off < end; {
+		elementID, n, err := readVint(r, off, end, false)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		size, m, err := readVint(r, off+n, end, true)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		data := off + n + m
+
+		dataEnd := end
+		if size >= 0 {
+			if size > end-data {
+				return 0, 0, fmt.Errorf("%w: invalid size of element %x", ErrCorrupt, elementID)
+			}
+
+			dataEnd = data + size
+		}
+
+		if elementID == id {
+			return data, dataEnd, nil
+		}
+
+		if elementID == ebmlClusterID || size < 0 {
+			return -1, 0, nil
+		}
+
+		off = dataEnd
+	}
+
+	return -1, 0, nil
+}
+
+// readVint reads EBML variable size integer at off and returns it with its
+// length. Marker bit is kept in ids and removed from sizes, size with all bits
+// set is unknown and returned as -1
+func readVint(r io.ReaderAt, off, end int64, isSize bool) (int64, int64, error) {
+	b := make([]byte, 8)
+	if _, err := r.ReadAt(b[:1], off); err != nil {
+		return 0, 0, err
+	}
+
+	length := int64(1)
+	for mask := byte(0x80); length <= 8 && b[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+
+	if length > 8 || off+length > end {
+		return 0, 0, fmt.Errorf("%w: invalid EBML integer", ErrCorrupt)
+	}
+
+	if _, err := r.ReadAt(b[1:length], off+1); err != nil {
+		return 0, 0, err
+	}
+
+	value := uint64(b[0])
+	if isSize {
+		value &^= 0x80 >> (length - 1)
+	}
+
+	for _, c := range b[1:length] {
+		value = value<<8 | uint64(c)
+	}
+
+	if isSize && value == 1<<(7*length)-1 {
+		return -1, length, nil
+	}
+
+	return int64(value), length, nil
+}
+
+func readUint(r io.ReaderAt, off, end int64) (uint64, error) {
+	if end-off > 8 {
+		return 0, fmt.Errorf("%w: invalid EBML unsigned integer", ErrCorrupt)
+	}
+
+	b := make([]byte, end-off)
+	if _, err := r.ReadAt(b, off); err != nil {
+		return 0, err
+	}
+
+	var value uint64
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+
+	return value, nil
+}
+
+func readFloat(r io.ReaderAt, off, end int64) (float64, error) {
+	b := make([]byte, end-off)
+
+	switch len(b) {
+	case 4, 8:
+	default:
+		return 0, fmt.Errorf("%w: invalid EBML float", ErrCorrupt)
+	}
+
+	if _, err := r.ReadAt(b, off); err != nil {
+		return 0, err
+	}
+
+	if len(b) == 4 {
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	}
+
+	return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+}
+
+// aviDuration reads frame duration and count of frames from main AVI header,
+// which is the first chunk of hdrl list
+func aviDuration(r io.ReaderAt, size int64) (float64, error) {
+	const headerLen = 56
+
+	if size < headerLen {
+		return 0, nil
+	}
+
+	header := make([]byte, headerLen)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return 0, err
+	}
+
+	if !bytes.Equal(header[12:16], []byte("LIST")) || !bytes.Equal(header[20:28], []byte("hdrlavih")) {
+		return 0, nil
+	}
+
+	microSecPerFrame := binary.LittleEndian.Uint32(header[32:36])
+	totalFrames := binary.LittleEndian.Uint32(header[48:52])
+
+	return float64(microSecPerFrame) * float64(totalFrames) / float64(time.Second/time.Microsecond), nil
+}
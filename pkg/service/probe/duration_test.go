@@ -0,0 +1,132 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+// box returns MP4 box with payload
+func box(typ string, payload []byte) []byte {
+	b := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(b, uint32(8+len(payload)))
+	copy(b[4:], typ)
+
+	return append(b, payload...)
+}
+
+// mp4Movie returns MP4 file with movie header of version
+func mp4Movie(version byte, timescale uint32, duration uint64) []byte {
+	var mvhd []byte
+
+	if version == 1 {
+		mvhd = make([]byte, 32)
+		binary.BigEndian.PutUint32(mvhd[20:], timescale)
+		binary.BigEndian.PutUint64(mvhd[24:], duration)
+	} else {
+		mvhd = make([]byte, 20)
+		binary.BigEndian.PutUint32(mvhd[12:], timescale)
+		binary.BigEndian.PutUint32(mvhd[16:], uint32(duration))
+	}
+
+	mvhd[0] = version
+
+	file := box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+	file = append(file, box("mdat", make([]byte, 64))...)
+
+	return append(file, box("moov", append(box("mvhd", mvhd), box("trak", nil)...))...)
+}
+
+// aviMovie returns AVI file with main header
+func aviMovie(microSecPerFrame, totalFrames uint32) []byte {
+	avih := make([]byte, 56)
+	binary.LittleEndian.PutUint32(avih, microSecPerFrame)
+	binary.LittleEndian.PutUint32(avih[16:], totalFrames)
+
+	file := []byte("RIFF\x00\x00\x00\x00AVI LIST\x00\x00\x00\x00hdrlavih\x38\x00\x00\x00")
+
+	return append(file, avih...)
+}
+
+func TestDuration(t *testing.T) {
+	mkv, err := os.ReadFile("../../../api/request/test_video.mkv")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	// size of moov box is bigger than rest of file
+	truncated := mp4Movie(0, 1000, 12500)
+	binary.BigEndian.PutUint32(truncated[len(truncated)-44:], 1000)
+
+	cases := []struct {
+		name             string
+		container        string
+		content          []byte
+		expectedErr      error
+		expectedDuration float64
+	}{
+		{
+			name:             "MP4",
+			container:        ContainerMP4,
+			content:          mp4Movie(0, 1000, 12500),
+			expectedDuration: 12.5,
+		},
+		{
+			name:             "MP4 with 64-bit duration",
+			container:        ContainerMOV,
+			content:          mp4Movie(1, 600, 1800),
+			expectedDuration: 3,
+		},
+		{
+			name:      "MP4 without movie header",
+			container: ContainerMP4,
+			content:   box("ftyp", []byte("isom\x00\x00\x02\x00")),
+		},
+		{
+			name:        "MP4 with invalid box size",
+			container:   ContainerMP4,
+			content:     truncated,
+			expectedErr: ErrCorrupt,
+		},
+		{
+			name:             "Matroska",
+			container:        ContainerMKV,
+			content:          mkv,
+			expectedDuration: 42,
+		},
+		{
+			name:        "Truncated Matroska",
+			container:   ContainerMKV,
+			content:     mkv[:200],
+			expectedErr: ErrCorrupt,
+		},
+		{
+			name:             "AVI",
+			container:        ContainerAVI,
+			content:          aviMovie(40000, 250),
+			expectedDuration: 10,
+		},
+		{
+			name:      "Transport stream",
+			container: ContainerTS,
+			content:   make([]byte, 2*tsPacketLen),
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := bytes.NewReader(testCase.content)
+
+			duration, err := Duration(r, r.Size(), testCase.container)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if duration != testCase.expectedDuration {
+				t.Errorf("Invalid duration, expected: %v, got: %v\n", testCase.expectedDuration, duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package probe
+
+import "errors"
+
+var (
+	// ErrNotVideo returns if file isn't a video container or has no video stream
+	ErrNotVideo = errors.New("file is not a video")
+	// ErrCorrupt returns if ffprobe can't read the file
+	ErrCorrupt = errors.New("video is corrupt")
+	// ErrDurationExceeded returns if video is longer than allowed
+	ErrDurationExceeded = errors.New("video duration exceeds limit")
+	// ErrResolutionExceeded returns if video is bigger than allowed resolution
+	ErrResolutionExceeded = errors.New("video resolution exceeds limit")
+)
@@ -0,0 +1,252 @@
+// Package probe uses for checking uploaded files before upload to cloud. Container
+// of file is detected by magic bytes, if ffprobe is configured file is probed for
+// video stream, its codec, duration and resolution
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/service"
+)
+
+// DefaultTimeout of ffprobe run
+const DefaultTimeout = 30 * time.Second
+
+// Limits of probed video. Zero value disables limit
+type Limits struct {
+	MaxDuration time.Duration
+	// MaxWidth and MaxHeight are compared with the longer and shorter side of
+	// video, so portrait videos have the same limit as landscape
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Service detects container of uploaded files and probes them with ffprobe
+type Service struct {
+	// ffprobe is path to ffprobe binary, empty disables probing
+	ffprobe string
+	timeout time.Duration
+	limits  Limits
+}
+
+// NewService initialize Service. Empty ffprobe only sniffs container of files
+// and reads their duration from headers
+func NewService(ffprobe string, timeout time.Duration, limits Limits) *Service {
+	return &Service{ffprobe: ffprobe, timeout: timeout, limits: limits}
+}
+
+// NewEnvService initialize Service with FFPROBE_PATH, VIDEO_PROBE_TIMEOUT,
+// VIDEO_MAX_DURATION and VIDEO_MAX_RESOLUTION (e.g. 1920x1080) env variables
+func NewEnvService() *Service {
+	timeout, err := time.ParseDuration(os.Getenv("VIDEO_PROBE_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	limits := Limits{}
+
+	if d, err := time.ParseDuration(os.Getenv("VIDEO_MAX_DURATION")); err == nil && d > 0 {
+		limits.MaxDuration = d
+	}
+
+	limits.MaxWidth, limits.MaxHeight = envResolution("VIDEO_MAX_RESOLUTION")
+
+	return NewService(os.Getenv("FFPROBE_PATH"), timeout, limits)
+}
+
+// Probe checks that file is a video of supported container within limits
+func (srv *Service) Probe(ctx context.Context, file *multipart.FileHeader) (*service.VideoInfo, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	container, err := SniffReader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if container == "" {
+		return nil, ErrNotVideo
+	}
+
+	info := &service.VideoInfo{Container: container}
+
+	if srv.ffprobe == "" {
+		// duration is needed for quota of processed minutes even without ffprobe
+		if info.Duration, err = Duration(f, file.Size, container); err != nil {
+			return nil, err
+		}
+
+		if err = srv.check(info); err != nil {
+			return nil, err
+		}
+
+		return info, nil
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if err = srv.run(ctx, f, info); err != nil {
+		return nil, err
+	}
+
+	if err = srv.check(info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// output of ffprobe -print_format json
+type output struct {
+	Streams []struct {
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+		Duration  string `json:"duration"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+// run ffprobe on file and fill info with first video stream
+func (srv *Service) run(ctx context.Context, f multipart.File, info *service.VideoInfo) error {
+	path, cleanup, err := localPath(f)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(ctx, srv.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, srv.ffprobe, "-v", "error", "-print_format", "json",
+		"-select_streams", "v:0", "-show_streams", "-show_format", path)
+
+	out, err := cmd.Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return fmt.Errorf("%w: %s", ErrCorrupt, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	res := new(output)
+	if err = json.Unmarshal(out, res); err != nil {
+		return fmt.Errorf("can't parse ffprobe output: %w", err)
+	}
+
+	if len(res.Streams) == 0 {
+		return ErrNotVideo
+	}
+
+	stream := res.Streams[0]
+	if stream.Width <= 0 || stream.Height <= 0 {
+		return ErrCorrupt
+	}
+
+	duration, err := strconv.ParseFloat(res.Format.Duration, 64)
+	if err != nil {
+		duration, err = strconv.ParseFloat(stream.Duration, 64)
+	}
+
+	if err != nil || duration <= 0 {
+		return ErrCorrupt
+	}
+
+	info.Codec = stream.CodecName
+	info.Width = stream.Width
+	info.Height = stream.Height
+	info.Duration = duration
+
+	return nil
+}
+
+// check info against limits
+func (srv *Service) check(info *service.VideoInfo) error {
+	if srv.limits.MaxDuration > 0 && info.Duration > srv.limits.MaxDuration.Seconds() {
+		return ErrDurationExceeded
+	}
+
+	long, short := info.Width, info.Height
+	if short > long {
+		long, short = short, long
+	}
+
+	maxLong, maxShort := srv.limits.MaxWidth, srv.limits.MaxHeight
+	if maxShort > maxLong {
+		maxLong, maxShort = maxShort, maxLong
+	}
+
+	if (maxLong > 0 && long > maxLong) || (maxShort > 0 && short > maxShort) {
+		return ErrResolutionExceeded
+	}
+
+	return nil
+}
+
+// localPath returns path of file on disk, files kept in memory by multipart
+// reader are written to temporary file which is removed by cleanup
+func localPath(f multipart.File) (string, func(), error) {
+	if osFile, ok := f.(*os.File); ok {
+		return osFile.Name(), func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "videocmprs-probe-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	if _, err = io.Copy(tmp, f); err != nil {
+		cleanup()
+
+		return "", nil, err
+	}
+
+	return tmp.Name(), cleanup, nil
+}
+
+// envResolution parses resolution in format WIDTHxHEIGHT
+func envResolution(name string) (int, int) {
+	value := strings.ToLower(os.Getenv(name))
+
+	i := strings.Index(value, "x")
+	if i < 0 {
+		return 0, 0
+	}
+
+	width, err := strconv.Atoi(value[:i])
+	if err != nil || width < 0 {
+		return 0, 0
+	}
+
+	height, err := strconv.Atoi(value[i+1:])
+	if err != nil || height < 0 {
+		return 0, 0
+	}
+
+	return width, height
+}
@@ -0,0 +1,178 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeFFprobe writes script which prints out as ffprobe output or fails with
+// stderr message if out is empty
+func fakeFFprobe(t *testing.T, out string) string {
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	script := "#!/bin/sh\necho '" + out + "'\n"
+
+	if out == "" {
+		script = "#!/bin/sh\necho 'Invalid data found when processing input' >&2\nexit 1\n"
+	}
+
+	if err := os.WriteFile(path, []byte(script), 0o700); err != nil { //nolint:gosec
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return path
+}
+
+// fileHeader returns header of multipart file with content
+func fileHeader(t *testing.T, content []byte) *multipart.FileHeader {
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	part, err := writer.CreateFormFile("video", "video")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = part.Write(content); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	req := httptest.NewRequest("POST", "/", buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, header, err := req.FormFile("video")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return header
+}
+
+func TestProbe(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("sh is required for fake ffprobe")
+	}
+
+	mp4 := append([]byte{0, 0, 0, 0x20}, "ftypisom\x00\x00\x02\x00"...)
+	limits := Limits{MaxDuration: time.Minute, MaxWidth: 1920, MaxHeight: 1080}
+
+	cases := []struct {
+		name             string
+		ffprobe          string
+		content          []byte
+		expectedErr      error
+		expectedCodec    string
+		expectedDuration float64
+	}{
+		{
+			name:        "Not a video",
+			content:     []byte("qwertyuiopasdfghjkl"),
+			expectedErr: ErrNotVideo,
+		},
+		{
+			name:             "Without ffprobe",
+			content:          mp4Movie(0, 1000, 12500),
+			expectedDuration: 12.5,
+		},
+		{
+			name:        "Without ffprobe too long",
+			content:     mp4Movie(0, 1000, 90000),
+			expectedErr: ErrDurationExceeded,
+		},
+		{
+			name:             "Valid video",
+			ffprobe:          `{"streams":[{"codec_name":"h264","width":1280,"height":720}],"format":{"duration":"12.500000"}}`,
+			content:          mp4,
+			expectedCodec:    "h264",
+			expectedDuration: 12.5,
+		},
+		{
+			name:             "Portrait video",
+			ffprobe:          `{"streams":[{"codec_name":"hevc","width":1080,"height":1920,"duration":"3.0"}],"format":{}}`,
+			content:          mp4,
+			expectedCodec:    "hevc",
+			expectedDuration: 3,
+		},
+		{
+			name:        "Without video stream",
+			ffprobe:     `{"streams":[],"format":{"duration":"12.5"}}`,
+			content:     mp4,
+			expectedErr: ErrNotVideo,
+		},
+		{
+			name:        "Corrupt video",
+			content:     mp4,
+			expectedErr: ErrCorrupt,
+		},
+		{
+			name:        "Too long",
+			ffprobe:     `{"streams":[{"codec_name":"h264","width":1280,"height":720}],"format":{"duration":"60.5"}}`,
+			content:     mp4,
+			expectedErr: ErrDurationExceeded,
+		},
+		{
+			name:        "Too big",
+			ffprobe:     `{"streams":[{"codec_name":"h264","width":3840,"height":2160}],"format":{"duration":"12.5"}}`,
+			content:     mp4,
+			expectedErr: ErrResolutionExceeded,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var ffprobe string
+			if testCase.ffprobe != "" || testCase.expectedErr == ErrCorrupt {
+				ffprobe = fakeFFprobe(t, testCase.ffprobe)
+			}
+
+			srv := NewService(ffprobe, DefaultTimeout, limits)
+
+			info, err := srv.Probe(context.Background(), fileHeader(t, testCase.content))
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if info.Container != ContainerMP4 {
+				t.Errorf("Invalid container, expected: %s, got: %s\n", ContainerMP4, info.Container)
+			}
+
+			if info.Codec != testCase.expectedCodec || info.Duration != testCase.expectedDuration {
+				t.Errorf("Invalid info, expected: %s %v, got: %s %v\n",
+					testCase.expectedCodec, testCase.expectedDuration, info.Codec, info.Duration)
+			}
+		})
+	}
+}
+
+func TestNewEnvService(t *testing.T) {
+	os.Setenv("VIDEO_MAX_RESOLUTION", "1280X720")
+	os.Setenv("VIDEO_MAX_DURATION", "10m")
+
+	defer os.Unsetenv("VIDEO_MAX_RESOLUTION")
+	defer os.Unsetenv("VIDEO_MAX_DURATION")
+
+	srv := NewEnvService()
+
+	expected := Limits{MaxDuration: 10 * time.Minute, MaxWidth: 1280, MaxHeight: 720}
+	if srv.limits != expected {
+		t.Errorf("Invalid limits, expected: %+v, got: %+v\n", expected, srv.limits)
+	}
+
+	if srv.ffprobe != "" || srv.timeout != DefaultTimeout {
+		t.Errorf("Invalid ffprobe settings: %s %s\n", srv.ffprobe, srv.timeout)
+	}
+}
@@ -0,0 +1,143 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Containers detected by Sniff
+const (
+	ContainerMP4  = "mp4"
+	ContainerMOV  = "mov"
+	ContainerMKV  = "mkv"
+	ContainerWebM = "webm"
+	ContainerAVI  = "avi"
+	ContainerTS   = "ts"
+)
+
+// SniffLen is number of bytes of file header needed by Sniff
+const SniffLen = 512
+
+// tsPacketLen is size of MPEG transport stream packet, every packet starts with sync byte
+const (
+	tsPacketLen = 188
+	tsSyncByte  = 0x47
+)
+
+var (
+	ebmlMagic     = []byte{0x1A, 0x45, 0xDF, 0xA3}
+	ebmlDocTypeID = []byte{0x42, 0x82}
+)
+
+// videoBrands are brands of ISO base media files which hold video
+var videoBrands = map[string]bool{
+	"isom": true, "iso2": true, "iso3": true, "iso4": true, "iso5": true, "iso6": true,
+	"mp41": true, "mp42": true, "avc1": true, "dash": true, "mmp4": true, "msnv": true, "XAVC": true,
+	"M4V ": true, "M4VH": true, "M4VP": true, "f4v ": true, "qt  ": true,
+	"3gp4": true, "3gp5": true, "3gp6": true, "3g2a": true,
+}
+
+// nonVideoBrands are major brands of audio and image files, they list generic
+// brands like isom or mp42 as compatible
+var nonVideoBrands = map[string]bool{
+	"M4A ": true, "M4B ": true, "M4P ": true, "F4A ": true, "F4B ": true,
+	"heic": true, "heix": true, "hevc": true, "hevx": true, "mif1": true, "msf1": true,
+	"avif": true, "avis": true,
+}
+
+// Sniff detects container of video by magic bytes of its header, returns
+// empty string if header doesn't belong to supported container
+func Sniff(header []byte) string {
+	switch {
+	case len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")):
+		return sniffFtyp(header)
+	case bytes.HasPrefix(header, ebmlMagic):
+		return sniffEBML(header)
+	case len(header) >= 12 && bytes.Equal(header[:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("AVI ")):
+		return ContainerAVI
+	case isTS(header):
+		return ContainerTS
+	default:
+		return ""
+	}
+}
+
+// SniffReader reads header of r and detects its container
+func SniffReader(r io.Reader) (string, error) {
+	header := make([]byte, SniffLen)
+
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	return Sniff(header[:n]), nil
+}
+
+// sniffFtyp checks brands of file type box, file is video if its major brand
+// or, unless major brand is audio or image, one of compatible brands is video
+func sniffFtyp(header []byte) string {
+	major := string(header[8:12])
+
+	switch {
+	case major == "qt  ":
+		return ContainerMOV
+	case videoBrands[major]:
+		return ContainerMP4
+	case nonVideoBrands[major]:
+		return ""
+	}
+
+	end := int64(binary.BigEndian.Uint32(header[:4]))
+	if end > int64(len(header)) {
+		end = int64(len(header))
+	}
+
+	// minor version precedes compatible brands
+	for i := int64(16); i+4 <= end; i += 4 {
+		if videoBrands[string(header[i:i+4])] {
+			return ContainerMP4
+		}
+	}
+
+	return ""
+}
+
+// sniffEBML distinguishes WebM from Matroska by DocType element of EBML header
+func sniffEBML(header []byte) string {
+	i := bytes.Index(header, ebmlDocTypeID)
+	if i < 0 || i+3 > len(header) {
+		return ""
+	}
+
+	// size of DocType is one byte EBML variable size integer in practice
+	size := int(header[i+2] &^ 0x80)
+	if header[i+2]&0x80 == 0 || i+3+size > len(header) {
+		return ""
+	}
+
+	switch string(bytes.TrimRight(header[i+3:i+3+size], "\x00")) {
+	case "webm":
+		return ContainerWebM
+	case "matroska":
+		return ContainerMKV
+	default:
+		return ""
+	}
+}
+
+// isTS checks sync bytes of first packets of transport stream
+func isTS(header []byte) bool {
+	if len(header) <= tsPacketLen {
+		return false
+	}
+
+	for i := 0; i < len(header); i += tsPacketLen {
+		if header[i] != tsSyncByte {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,124 @@
+package probe
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	ts := make([]byte, 2*tsPacketLen)
+	ts[0], ts[tsPacketLen] = tsSyncByte, tsSyncByte
+
+	mkv, err := os.ReadFile("../../../api/request/test_video.mkv")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	jpeg, err := os.ReadFile("../../../api/request/test_image.jpeg")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	cases := []struct {
+		name              string
+		header            []byte
+		expectedContainer string
+	}{
+		{
+			name:              "MP4",
+			header:            append([]byte{0, 0, 0, 0x20}, "ftypisom\x00\x00\x02\x00"...),
+			expectedContainer: ContainerMP4,
+		},
+		{
+			name:              "QuickTime",
+			header:            append([]byte{0, 0, 0, 0x14}, "ftypqt  \x00\x00\x02\x00"...),
+			expectedContainer: ContainerMOV,
+		},
+		{
+			name:              "MP4 with unknown major brand",
+			header:            append([]byte{0, 0, 0, 0x18}, "ftypXYZ1\x00\x00\x00\x00XYZ1mp42"...),
+			expectedContainer: ContainerMP4,
+		},
+		{
+			name:              "HEIC image",
+			header:            append([]byte{0, 0, 0, 0x18}, "ftypheic\x00\x00\x00\x00mif1heic"...),
+			expectedContainer: "",
+		},
+		{
+			name:              "M4A audio",
+			header:            append([]byte{0, 0, 0, 0x1C}, "ftypM4A \x00\x00\x02\x00M4A mp42isom"...),
+			expectedContainer: "",
+		},
+		{
+			name:              "Unknown brands",
+			header:            append([]byte{0, 0, 0, 0x14}, "ftypcrx \x00\x00\x00\x01crx "...),
+			expectedContainer: "",
+		},
+		{
+			name:              "Matroska",
+			header:            mkv[:SniffLen],
+			expectedContainer: ContainerMKV,
+		},
+		{
+			name:              "WebM",
+			header:            append([]byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x82, 0x84}, "webm"...),
+			expectedContainer: ContainerWebM,
+		},
+		{
+			name:              "Unknown EBML document",
+			header:            append([]byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F, 0x42, 0x82, 0x84}, "mka1"...),
+			expectedContainer: "",
+		},
+		{
+			name:              "AVI",
+			header:            []byte("RIFF\x00\x10\x00\x00AVI LIST"),
+			expectedContainer: ContainerAVI,
+		},
+		{
+			name:              "WAV",
+			header:            []byte("RIFF\x00\x10\x00\x00WAVEfmt "),
+			expectedContainer: "",
+		},
+		{
+			name:              "Transport stream",
+			header:            ts,
+			expectedContainer: ContainerTS,
+		},
+		{
+			name:              "Single sync byte",
+			header:            []byte{tsSyncByte, 1, 2, 3},
+			expectedContainer: "",
+		},
+		{
+			name:              "JPEG",
+			header:            jpeg[:SniffLen],
+			expectedContainer: "",
+		},
+		{
+			name:              "Empty",
+			header:            nil,
+			expectedContainer: "",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			container := Sniff(testCase.header)
+			if container != testCase.expectedContainer {
+				t.Errorf("Invalid container, expected: %q, got: %q\n", testCase.expectedContainer, container)
+			}
+		})
+	}
+}
+
+func TestSniffReader(t *testing.T) {
+	container, err := SniffReader(bytes.NewReader([]byte("RIFF\x00\x10\x00\x00AVI ")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if container != ContainerAVI {
+		t.Errorf("Invalid container, expected: %q, got: %q\n", ContainerAVI, container)
+	}
+}
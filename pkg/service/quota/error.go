@@ -0,0 +1,14 @@
+package quota
+
+import "errors"
+
+var (
+	// ErrFileTooLarge returns if uploaded file is bigger than allowed
+	ErrFileTooLarge = errors.New("file is too large")
+	// ErrStorageExceeded returns if user has no storage left for uploaded file
+	ErrStorageExceeded = errors.New("storage quota exceeded")
+	// ErrActiveRequestsExceeded returns if user has too many requests in progress
+	ErrActiveRequestsExceeded = errors.New("too many active requests")
+	// ErrMonthlyMinutesExceeded returns if user processed all minutes of current month
+	ErrMonthlyMinutesExceeded = errors.New("monthly processing minutes exceeded")
+)
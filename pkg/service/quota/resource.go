@@ -0,0 +1,30 @@
+package quota
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/jsonapi"
+)
+
+var _ jsonapi.Linkable = (*Resource)(nil)
+
+// Resource represent user usage together with applied limits. Zero limit
+// means unlimited
+type Resource struct {
+	ID                  int64   `jsonapi:"primary,usages"`
+	StoredBytes         int64   `jsonapi:"attr,stored_bytes"`
+	ActiveRequests      int64   `jsonapi:"attr,active_requests"`
+	MonthlyMinutes      float64 `jsonapi:"attr,monthly_minutes"`
+	StorageBytesLimit   int64   `jsonapi:"attr,storage_bytes_limit"`
+	MaxFileSize         int64   `jsonapi:"attr,max_file_size"`
+	ActiveRequestsLimit int64   `jsonapi:"attr,active_requests_limit"`
+	MonthlyMinutesLimit int64   `jsonapi:"attr,monthly_minutes_limit"`
+}
+
+// JSONAPILinks return links for usage
+func (r *Resource) JSONAPILinks() *jsonapi.Links {
+	return &jsonapi.Links{
+		"self": fmt.Sprintf("%s/api/v1/auth/me/usage", os.Getenv("BASE_URL")),
+	}
+}
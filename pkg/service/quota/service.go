@@ -0,0 +1,141 @@
+// Package quota uses for checking user storage and processing limits
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+
+	"github.com/google/jsonapi"
+)
+
+// Limits represent quota settings. Zero value means unlimited
+type Limits struct {
+	StorageBytes   int64
+	MaxFileSize    int64
+	ActiveRequests int64
+	MonthlyMinutes int64
+}
+
+// Service checks user usage against limits. User specific settings
+// take precedence over global ones
+type Service struct {
+	repo   repository.QuotaRetriever
+	global Limits
+}
+
+// NewService initialize Service with global limits
+func NewService(repo repository.QuotaRetriever, global Limits) *Service {
+	return &Service{repo: repo, global: global}
+}
+
+// NewEnvService initialize Service with global limits from QUOTA_STORAGE_BYTES,
+// QUOTA_MAX_FILE_SIZE, QUOTA_ACTIVE_REQUESTS and QUOTA_MONTHLY_MINUTES env variables
+func NewEnvService(repo repository.QuotaRetriever) *Service {
+	return NewService(repo, Limits{
+		StorageBytes:   envInt("QUOTA_STORAGE_BYTES"),
+		MaxFileSize:    envInt("QUOTA_MAX_FILE_SIZE"),
+		ActiveRequests: envInt("QUOTA_ACTIVE_REQUESTS"),
+		MonthlyMinutes: envInt("QUOTA_MONTHLY_MINUTES"),
+	})
+}
+
+// Check returns error if user can't upload file with fileSize
+func (srv *Service) Check(ctx context.Context, userID, fileSize int64) error {
+	res, err := srv.usage(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if res.MaxFileSize > 0 && fileSize > res.MaxFileSize {
+		return ErrFileTooLarge
+	}
+
+	if res.StorageBytesLimit > 0 && res.StoredBytes+fileSize > res.StorageBytesLimit {
+		return ErrStorageExceeded
+	}
+
+	if res.ActiveRequestsLimit > 0 && res.ActiveRequests >= res.ActiveRequestsLimit {
+		return ErrActiveRequestsExceeded
+	}
+
+	if res.MonthlyMinutesLimit > 0 && res.MonthlyMinutes >= float64(res.MonthlyMinutesLimit) {
+		return ErrMonthlyMinutesExceeded
+	}
+
+	return nil
+}
+
+// Usage returns *Resource with user usage and limits
+func (srv *Service) Usage(ctx context.Context, userID int64) (jsonapi.Linkable, error) {
+	return srv.usage(ctx, userID)
+}
+
+func (srv *Service) usage(ctx context.Context, userID int64) (*Resource, error) {
+	limits, err := srv.limits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := srv.repo.Usage(ctx, userID, monthStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resource{
+		ID:                  userID,
+		StoredBytes:         u.StoredBytes,
+		ActiveRequests:      u.ActiveRequests,
+		MonthlyMinutes:      u.MonthlyMinutes,
+		StorageBytesLimit:   limits.StorageBytes,
+		MaxFileSize:         limits.MaxFileSize,
+		ActiveRequestsLimit: limits.ActiveRequests,
+		MonthlyMinutesLimit: limits.MonthlyMinutes,
+	}, nil
+}
+
+func (srv *Service) limits(ctx context.Context, userID int64) (Limits, error) {
+	q, err := srv.repo.Quota(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return srv.global, nil
+	}
+
+	if err != nil {
+		return Limits{}, err
+	}
+
+	return Limits{
+		StorageBytes:   limit(q.StorageBytes, srv.global.StorageBytes),
+		MaxFileSize:    limit(q.MaxFileSize, srv.global.MaxFileSize),
+		ActiveRequests: limit(q.ActiveRequests, srv.global.ActiveRequests),
+		MonthlyMinutes: limit(q.MonthlyMinutes, srv.global.MonthlyMinutes),
+	}, nil
+}
+
+func limit(value sql.NullInt64, global int64) int64 {
+	if value.Valid {
+		return value.Int64
+	}
+
+	return global
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func envInt(name string) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || value <= 0 {
+		return 0
+	}
+
+	return value
+}
@@ -0,0 +1,128 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	quotaColumns := []string{"quota_storage_bytes", "quota_max_file_size",
+		"quota_active_requests", "quota_monthly_minutes"}
+	usageColumns := []string{"stored_bytes", "active_requests", "monthly_minutes"}
+
+	cases := []struct {
+		name        string
+		global      Limits
+		fileSize    int64
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:     "Without limits",
+			fileSize: 1 << 30,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(nil, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows(usageColumns).AddRow(1<<40, 100, 10000))
+			},
+		},
+		{
+			name:     "File is too large",
+			global:   Limits{MaxFileSize: 100},
+			fileSize: 101,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(nil, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows(usageColumns).AddRow(0, 0, 0))
+			},
+			expectedErr: ErrFileTooLarge,
+		},
+		{
+			name:     "User override is unlimited",
+			global:   Limits{MaxFileSize: 100},
+			fileSize: 101,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(nil, 0, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows(usageColumns).AddRow(0, 0, 0))
+			},
+		},
+		{
+			name:     "Storage exceeded",
+			fileSize: 100,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(1000, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows(usageColumns).AddRow(950, 0, 0))
+			},
+			expectedErr: ErrStorageExceeded,
+		},
+		{
+			name:     "Too many active requests",
+			global:   Limits{ActiveRequests: 2},
+			fileSize: 100,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(nil, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows(usageColumns).AddRow(0, 2, 0))
+			},
+			expectedErr: ErrActiveRequestsExceeded,
+		},
+		{
+			name:     "Monthly minutes exceeded",
+			global:   Limits{MonthlyMinutes: 60},
+			fileSize: 100,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnRows(sqlmock.NewRows(quotaColumns).AddRow(nil, nil, nil, nil))
+				mock.ExpectQuery("SELECT (.+) FROM videos").
+					WillReturnRows(sqlmock.NewRows(usageColumns).AddRow(0, 0, 60.5))
+			},
+			expectedErr: ErrMonthlyMinutesExceeded,
+		},
+		{
+			name:     "Database error",
+			fileSize: 100,
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users").
+					WillReturnError(errors.New("some error"))
+			},
+			expectedErr: errors.New("some error"),
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(user.NewRepository(db), testCase.global)
+			err := srv.Check(context.Background(), 1, testCase.fileSize)
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+
+			if testCase.expectedErr == nil && err != nil {
+				t.Errorf("Unexpected error: %s\n", err)
+			}
+
+			if testCase.expectedErr != nil && (err == nil || err.Error() != testCase.expectedErr.Error()) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+
+	// the first bucket is refilled in a second, the second one in 1000 seconds
+	rates := map[string]float64{"api:user:1": 1, "api:user:2": 0.001}
+	for key, rate := range rates {
+		if _, _, err := store.Take(context.Background(), key, 10, rate, now); err != nil {
+			t.Fatalf("Unexpected error: %s\n", err)
+		}
+	}
+
+	if _, _, err := store.Take(context.Background(), "api:user:3", 10, 1, now.Add(sweepInterval)); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, ok := store.buckets["api:user:1"]; ok {
+		t.Errorf("Full bucket should be removed\n")
+	}
+
+	if _, ok := store.buckets["api:user:2"]; !ok {
+		t.Errorf("Not full bucket should be kept\n")
+	}
+}
@@ -0,0 +1,138 @@
+// Package ratelimit uses for limiting requests of clients with token buckets.
+// Every client has a bucket per route group, bucket holds Burst tokens and is
+// refilled with Burst tokens per Period, every request takes one token
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/service"
+)
+
+// Route groups with separate limits
+const (
+	// GroupAuth is sign in, token refresh, password reset, email verification and
+	// public share links, limited per ip
+	GroupAuth = "auth"
+	// GroupUploads is creation of requests with video upload
+	GroupUploads = "uploads"
+	// GroupAPI is any request of authenticated user or API key
+	GroupAPI = "api"
+)
+
+// StorePostgres is value of RATE_LIMIT_STORE which shares buckets between replicas
+const StorePostgres = "postgres"
+
+// Limit of route group. Zero Burst disables limit
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// rate returns tokens added to bucket per second
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// DefaultLimits are used for groups which aren't set in env
+var DefaultLimits = map[string]Limit{
+	GroupAuth:    {Burst: 10, Period: time.Minute},
+	GroupUploads: {Burst: 10, Period: time.Minute},
+	GroupAPI:     {Burst: 600, Period: time.Minute},
+}
+
+// Service checks requests of clients against limits of route groups
+type Service struct {
+	store  repository.RateLimitStore
+	limits map[string]Limit
+	now    func() time.Time
+}
+
+// NewService initialize Service
+func NewService(store repository.RateLimitStore, limits map[string]Limit) *Service {
+	return &Service{store: store, limits: limits, now: time.Now}
+}
+
+// NewEnvService initialize Service with limits from RATE_LIMIT_AUTH, RATE_LIMIT_UPLOADS
+// and RATE_LIMIT_API env variables, e.g. 10/1m. Buckets are kept in repo if
+// RATE_LIMIT_STORE is postgres, otherwise in memory of process
+func NewEnvService(repo repository.RateLimitStore) *Service {
+	limits := make(map[string]Limit, len(DefaultLimits))
+	for group, l := range DefaultLimits {
+		limits[group] = envLimit("RATE_LIMIT_"+strings.ToUpper(group), l)
+	}
+
+	store := repo
+	if os.Getenv("RATE_LIMIT_STORE") != StorePostgres {
+		store = NewMemoryStore()
+	}
+
+	return NewService(store, limits)
+}
+
+// Allow takes token from bucket of client in group
+func (srv *Service) Allow(ctx context.Context, group, client string) (*service.RateLimit, error) {
+	l, ok := srv.limits[group]
+	if !ok || l.Burst <= 0 || l.Period <= 0 {
+		return nil, nil
+	}
+
+	rate := l.rate()
+
+	tokens, allowed, err := srv.store.Take(ctx, group+":"+client, l.Burst, rate, srv.now())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &service.RateLimit{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: int(math.Max(math.Floor(tokens), 0)),
+		Reset:     seconds((float64(l.Burst) - tokens) / rate),
+	}
+
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return res, nil
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+
+	return time.Duration(s * float64(time.Second))
+}
+
+// envLimit parses limit in format burst/period, e.g. 10/1m. 0 disables limit
+func envLimit(name string, def Limit) Limit {
+	value := os.Getenv(name)
+	if value == "0" {
+		return Limit{}
+	}
+
+	i := strings.Index(value, "/")
+	if i < 0 {
+		return def
+	}
+
+	burst, err := strconv.Atoi(value[:i])
+	if err != nil || burst < 0 {
+		return def
+	}
+
+	period, err := time.ParseDuration(value[i+1:])
+	if err != nil || period <= 0 {
+		return def
+	}
+
+	return Limit{Burst: burst, Period: period}
+}
@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+type storeError struct{}
+
+func (s *storeError) Take(ctx context.Context, key string, burst int, rate float64, now time.Time) (float64, bool, error) { //nolint:lll
+	return 0, false, errors.New("mock error")
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	srv := NewService(NewMemoryStore(), map[string]Limit{
+		GroupAuth:    {Burst: 2, Period: time.Minute},
+		GroupUploads: {},
+	})
+	srv.now = func() time.Time { return now }
+
+	cases := []struct {
+		name              string
+		group             string
+		client            string
+		after             time.Duration
+		expectedAllowed   bool
+		expectedRemaining int
+		expectedRetry     time.Duration
+		unlimited         bool
+	}{
+		{
+			name:              "First request",
+			group:             GroupAuth,
+			client:            "ip:127.0.0.1",
+			expectedAllowed:   true,
+			expectedRemaining: 1,
+		},
+		{
+			name:            "Last token",
+			group:           GroupAuth,
+			client:          "ip:127.0.0.1",
+			expectedAllowed: true,
+		},
+		{
+			name:          "Empty bucket",
+			group:         GroupAuth,
+			client:        "ip:127.0.0.1",
+			after:         10 * time.Second,
+			expectedRetry: 20 * time.Second,
+		},
+		{
+			name:              "Other client",
+			group:             GroupAuth,
+			client:            "ip:127.0.0.2",
+			expectedAllowed:   true,
+			expectedRemaining: 1,
+		},
+		{
+			name:            "Refilled bucket",
+			group:           GroupAuth,
+			client:          "ip:127.0.0.1",
+			after:           20 * time.Second,
+			expectedAllowed: true,
+		},
+		{
+			name:      "Disabled group",
+			group:     GroupUploads,
+			client:    "user:1",
+			unlimited: true,
+		},
+		{
+			name:      "Unknown group",
+			group:     GroupAPI,
+			client:    "user:1",
+			unlimited: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			now = now.Add(testCase.after)
+
+			res, err := srv.Allow(context.Background(), testCase.group, testCase.client)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+
+			if testCase.unlimited {
+				if res != nil {
+					t.Errorf("Group should be unlimited, got: %+v\n", res)
+				}
+
+				return
+			}
+
+			if res.Allowed != testCase.expectedAllowed {
+				t.Errorf("Invalid allowed, expected: %v, got: %v\n", testCase.expectedAllowed, res.Allowed)
+			}
+
+			if res.Remaining != testCase.expectedRemaining {
+				t.Errorf("Invalid remaining, expected: %d, got: %d\n", testCase.expectedRemaining, res.Remaining)
+			}
+
+			if res.RetryAfter.Round(time.Millisecond) != testCase.expectedRetry {
+				t.Errorf("Invalid retry after, expected: %s, got: %s\n", testCase.expectedRetry, res.RetryAfter)
+			}
+
+			if res.Limit != 2 {
+				t.Errorf("Invalid limit, expected: 2, got: %d\n", res.Limit)
+			}
+		})
+	}
+}
+
+func TestAllowWithStoreError(t *testing.T) {
+	srv := NewService(&storeError{}, DefaultLimits)
+
+	if _, err := srv.Allow(context.Background(), GroupAPI, "user:1"); err == nil {
+		t.Errorf("Should be error\n")
+	}
+}
+
+func TestEnvLimit(t *testing.T) {
+	def := Limit{Burst: 10, Period: time.Minute}
+
+	cases := []struct {
+		name     string
+		value    string
+		expected Limit
+	}{
+		{
+			name:     "Burst and period",
+			value:    "5/30s",
+			expected: Limit{Burst: 5, Period: 30 * time.Second},
+		},
+		{
+			name:     "Disabled",
+			value:    "0",
+			expected: Limit{},
+		},
+		{
+			name:     "Empty",
+			expected: def,
+		},
+		{
+			name:     "Invalid period",
+			value:    "5/minute",
+			expected: def,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			os.Setenv("RATE_LIMIT_TEST", testCase.value)
+			defer os.Unsetenv("RATE_LIMIT_TEST")
+
+			if l := envLimit("RATE_LIMIT_TEST", def); l != testCase.expected {
+				t.Errorf("Invalid limit, expected: %+v, got: %+v\n", testCase.expected, l)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Package rbac describes roles of users and permissions granted to them
+package rbac
+
+// Roles of users
+const (
+	RoleUser     = "user"
+	RoleAdmin    = "admin"
+	RoleReadOnly = "read-only"
+)
+
+// Permissions checked by routes. Permissions of resources are also
+// scopes which can be granted to API key
+const (
+	RequestsRead  = "requests:read"
+	RequestsWrite = "requests:write"
+	VideosRead    = "videos:read"
+	VideosWrite   = "videos:write"
+	APIKeysRead   = "api-keys:read"
+	APIKeysWrite  = "api-keys:write"
+	OrgsRead      = "organizations:read"
+	OrgsWrite     = "organizations:write"
+	Admin         = "admin"
+)
+
+// Scopes lists permissions which can be granted to API key
+var Scopes = []string{RequestsRead, RequestsWrite, VideosRead, VideosWrite, OrgsRead, OrgsWrite}
+
+var roles = map[string][]string{
+	RoleReadOnly: {RequestsRead, VideosRead, APIKeysRead, OrgsRead},
+	RoleUser: {RequestsRead, RequestsWrite, VideosRead, VideosWrite, APIKeysRead, APIKeysWrite,
+		OrgsRead, OrgsWrite},
+	RoleAdmin: {RequestsRead, RequestsWrite, VideosRead, VideosWrite, APIKeysRead, APIKeysWrite,
+		OrgsRead, OrgsWrite, Admin},
+}
+
+// ValidRole returns true if role exists
+func ValidRole(role string) bool {
+	_, ok := roles[role]
+
+	return ok
+}
+
+// ValidScope returns true if scope can be granted to API key
+func ValidScope(scope string) bool {
+	return contains(Scopes, scope)
+}
+
+// Permissions returns permissions of role. Unknown role has no permissions
+func Permissions(role string) []string {
+	return roles[role]
+}
+
+// Allowed reports whether role grants permission. If scopes are not empty
+// (request is made with limited API key) permission also has to be in scopes
+func Allowed(role string, scopes []string, permission string) bool {
+	if !contains(roles[role], permission) {
+		return false
+	}
+
+	return len(scopes) == 0 || contains(scopes, permission)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
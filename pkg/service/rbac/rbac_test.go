@@ -0,0 +1,80 @@
+package rbac
+
+import "testing"
+
+func TestAllowed(t *testing.T) {
+	cases := []struct {
+		name       string
+		role       string
+		scopes     []string
+		permission string
+		expected   bool
+	}{
+		{
+			name:       "User writes requests",
+			role:       RoleUser,
+			permission: RequestsWrite,
+			expected:   true,
+		},
+		{
+			name:       "User is not admin",
+			role:       RoleUser,
+			permission: Admin,
+		},
+		{
+			name:       "Read-only user reads videos",
+			role:       RoleReadOnly,
+			permission: VideosRead,
+			expected:   true,
+		},
+		{
+			name:       "Read-only user writes requests",
+			role:       RoleReadOnly,
+			permission: RequestsWrite,
+		},
+		{
+			name:       "Admin has admin permission",
+			role:       RoleAdmin,
+			permission: Admin,
+			expected:   true,
+		},
+		{
+			name:       "API key with scope",
+			role:       RoleUser,
+			scopes:     []string{RequestsRead, RequestsWrite},
+			permission: RequestsWrite,
+			expected:   true,
+		},
+		{
+			name:       "API key without scope",
+			role:       RoleUser,
+			scopes:     []string{RequestsRead},
+			permission: RequestsWrite,
+		},
+		{
+			name:       "Scope can't extend role",
+			role:       RoleReadOnly,
+			scopes:     []string{RequestsWrite},
+			permission: RequestsWrite,
+		},
+		{
+			name:       "Read-only user manages organizations",
+			role:       RoleReadOnly,
+			permission: OrgsWrite,
+		},
+		{
+			name:       "Unknown role",
+			role:       "guest",
+			permission: RequestsRead,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			allowed := Allowed(testCase.role, testCase.scopes, testCase.permission)
+			if allowed != testCase.expected {
+				t.Errorf("Invalid result, expected: %v, got: %v\n", testCase.expected, allowed)
+			}
+		})
+	}
+}
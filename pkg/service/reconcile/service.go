@@ -0,0 +1,107 @@
+// Package reconcile uses for finding inconsistencies between cloud storage and videos table
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"go.uber.org/zap"
+)
+
+// Report represent result of reconciliation
+type Report struct {
+	// OrphanedObjects are files in cloud which are not referenced by any video
+	OrphanedObjects []service.CloudObject
+	// MissingObjects are videos which files are absent in cloud
+	MissingObjects []*video.Resource
+}
+
+// Service compares files in cloud with videos in db
+type Service struct {
+	vRepo  repository.VideoRepository
+	cloud  service.CloudStorage
+	logger *zap.Logger
+}
+
+// NewService initialize Service
+func NewService(vRepo repository.VideoRepository, cloud service.CloudStorage, logger *zap.Logger) *Service {
+	return &Service{vRepo: vRepo, cloud: cloud, logger: logger}
+}
+
+// Reconcile finds files and videos which were changed more than minAge ago and have no
+// counterpart. Younger ones are skipped because upload may still be in progress.
+// If fix is true orphaned files are deleted from cloud and videos without files are
+// marked as deleted
+func (srv *Service) Reconcile(ctx context.Context, minAge time.Duration, fix bool) (*Report, error) {
+	before := time.Now().Add(-minAge)
+
+	objects, err := srv.cloud.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIDs, err := srv.vRepo.ServiceIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	videos, err := srv.vRepo.ListStored(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool, len(serviceIDs))
+	for _, id := range serviceIDs {
+		referenced[id] = true
+	}
+
+	stored := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		stored[obj.Name] = true
+	}
+
+	report := &Report{
+		OrphanedObjects: make([]service.CloudObject, 0),
+		MissingObjects:  make([]*video.Resource, 0),
+	}
+
+	for _, obj := range objects {
+		if referenced[obj.Name] || obj.LastModified.After(before) {
+			continue
+		}
+
+		report.OrphanedObjects = append(report.OrphanedObjects, obj)
+	}
+
+	for _, v := range videos {
+		if !stored[v.ServiceID] {
+			report.MissingObjects = append(report.MissingObjects, v)
+		}
+	}
+
+	if fix {
+		srv.fix(ctx, report)
+	}
+
+	return report, nil
+}
+
+func (srv *Service) fix(ctx context.Context, report *Report) {
+	for _, obj := range report.OrphanedObjects {
+		if err := srv.cloud.Delete(ctx, obj.Name); err != nil {
+			srv.logger.Error("can't delete orphaned file from cloud", zap.Error(err),
+				zap.String("Service ID", obj.Name))
+		}
+	}
+
+	for _, v := range report.MissingObjects {
+		if err := srv.vRepo.Delete(ctx, v.ID); err != nil {
+			srv.logger.Error("can't delete video without file", zap.Error(err),
+				zap.Int64("Video ID", v.ID))
+		}
+	}
+}
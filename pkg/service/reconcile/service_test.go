@@ -0,0 +1,182 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+)
+
+type cloudMock struct {
+	objects []service.CloudObject
+	deleted []string
+}
+
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
+	return "mock_service_id", nil
+}
+
+func (c *cloudMock) URL(filename string) (string, error) {
+	return filename, nil
+}
+
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	c.deleted = append(c.deleted, filename)
+
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	if c.objects == nil {
+		return nil, errors.New("mock error")
+	}
+
+	return c.objects, nil
+}
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	return &service.CloudObject{Name: filename}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func TestReconcile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	old := time.Now().Add(-48 * time.Hour)
+	objects := []service.CloudObject{
+		{Name: "referenced", LastModified: old},
+		{Name: "orphaned", LastModified: old},
+		{Name: "uploading", LastModified: time.Now()},
+	}
+
+	cases := []struct {
+		name             string
+		objects          []service.CloudObject
+		fix              bool
+		mock             func()
+		expectedOrphaned []string
+		expectedMissing  []int64
+		expectedDeleted  []string
+		errorPresent     bool
+	}{
+		{
+			name:         "Cloud is unavailable",
+			mock:         func() {},
+			errorPresent: true,
+		},
+		{
+			name:    "Should report inconsistencies",
+			objects: objects,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}).
+						AddRow("referenced").AddRow("missing"))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), false).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "service_id"}).
+						AddRow(1, 1, "referenced.mkv", "referenced").
+						AddRow(2, 1, "missing.mkv", "missing"))
+			},
+			expectedOrphaned: []string{"orphaned"},
+			expectedMissing:  []int64{2},
+			expectedDeleted:  []string{},
+		},
+		{
+			name:    "Should fix inconsistencies",
+			objects: objects,
+			fix:     true,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}).
+						AddRow("referenced").AddRow("missing"))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, user_id, name, service_id FROM %s", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), false).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "service_id"}).
+						AddRow(1, 1, "referenced.mkv", "referenced").
+						AddRow(2, 1, "missing.mkv", "missing"))
+
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET deleted_at", video.TableName)).
+					WithArgs(sqlmock.AnyArg(), 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+			},
+			expectedOrphaned: []string{"orphaned"},
+			expectedMissing:  []int64{2},
+			expectedDeleted:  []string{"orphaned"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			cloud := &cloudMock{objects: testCase.objects, deleted: []string{}}
+			srv := NewService(video.NewRepository(db), cloud, logger)
+
+			report, err := srv.Reconcile(context.Background(), time.Hour, testCase.fix)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if err == nil {
+				if len(report.OrphanedObjects) != len(testCase.expectedOrphaned) {
+					t.Fatalf("Invalid orphaned files, expected: %v, got: %v\n",
+						testCase.expectedOrphaned, report.OrphanedObjects)
+				}
+
+				for i, obj := range report.OrphanedObjects {
+					if obj.Name != testCase.expectedOrphaned[i] {
+						t.Errorf("Invalid orphaned file, expected: %s, got: %s\n",
+							testCase.expectedOrphaned[i], obj.Name)
+					}
+				}
+
+				if len(report.MissingObjects) != len(testCase.expectedMissing) {
+					t.Fatalf("Invalid missing files, expected: %v, got: %v\n",
+						testCase.expectedMissing, report.MissingObjects)
+				}
+
+				for i, v := range report.MissingObjects {
+					if v.ID != testCase.expectedMissing[i] {
+						t.Errorf("Invalid video without file, expected: %d, got: %d\n",
+							testCase.expectedMissing[i], v.ID)
+					}
+				}
+
+				if len(cloud.deleted) != len(testCase.expectedDeleted) {
+					t.Errorf("Invalid deleted files, expected: %v, got: %v\n",
+						testCase.expectedDeleted, cloud.deleted)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package request
+
+import "errors"
+
+var (
+	// ErrRequestNotPresent returns if request doesn't exists
+	ErrRequestNotPresent = errors.New("request does not exists")
+	// ErrNotMember returns if request is shared with organization in which user is not a member
+	ErrNotMember = errors.New("user is not a member of organization")
+	// ErrStorageNotSupported returns if compression workers can't read files of storage
+	ErrStorageNotSupported = errors.New("storage can't be read by compression workers")
+)
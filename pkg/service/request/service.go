@@ -3,52 +3,89 @@ package request
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
 	"mime/multipart"
+	"time"
 
 	"github.com/Hargeon/videocmprs/api/query"
 	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
 	"github.com/Hargeon/videocmprs/pkg/repository/request"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
 	"github.com/Hargeon/videocmprs/pkg/service"
+	auditsrv "github.com/Hargeon/videocmprs/pkg/service/audit"
 	"github.com/Hargeon/videocmprs/pkg/service/compress"
 
 	"github.com/google/jsonapi"
 	"go.uber.org/zap"
 )
 
+// StatusQuarantined is status of request which video contains malware
+const StatusQuarantined = "quarantined"
+
 // Service for adding and changing requests
 type Service struct {
 	requestRepo  repository.RequestRepository
 	videoRepo    repository.VideoRepository
 	cloudStorage service.CloudStorage
 	publisher    service.Publisher
+	retention    service.RetentionPolicy
+	quota        service.QuotaChecker
+	scanner      service.Scanner
+	members      repository.MembershipRetriever
+	audit        service.AuditRecorder
 	logger       *zap.Logger
 }
 
 // NewService initialize Service
-func NewService(rRepo repository.RequestRepository, vRepo repository.VideoRepository, cS service.CloudStorage, pb service.Publisher, logger *zap.Logger) *Service {
+func NewService(rRepo repository.RequestRepository, vRepo repository.VideoRepository, cS service.CloudStorage, pb service.Publisher, rp service.RetentionPolicy, qc service.QuotaChecker, scanner service.Scanner, members repository.MembershipRetriever, audit service.AuditRecorder, logger *zap.Logger) *Service { //nolint:lll
 	return &Service{
 		requestRepo:  rRepo,
 		videoRepo:    vRepo,
 		cloudStorage: cS,
 		publisher:    pb,
+		retention:    rp,
+		quota:        qc,
+		scanner:      scanner,
+		members:      members,
+		audit:        audit,
 		logger:       logger,
 	}
 }
 
-// Create function creates request in db, uploads video to cloud, creates video in db
+// Create function checks user quota, creates request in db, uploads video to cloud, creates video in db.
+// Request shared with organization requires user to be its member. Requests aren't
+// accepted if compression workers can't read files of storage
 func (srv *Service) Create(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
 	res, ok := resource.(*request.Resource)
 	if !ok {
 		return nil, errors.New("invalid type assertion *request.Resource in service")
 	}
 
+	if ws, ok := srv.cloudStorage.(service.WorkerStorage); ok && !ws.WorkerReadable() {
+		return nil, ErrStorageNotSupported
+	}
+
 	vid := res.OriginalVideo
 	videoFile := res.VideoRequest
 
-	linkable, err := srv.requestRepo.Create(ctx, resource)
+	if res.OrganizationID != 0 {
+		if err := srv.member(ctx, res.OrganizationID, res.UserID); err != nil {
+			return nil, err
+		}
+
+		vid.OrganizationID = res.OrganizationID
+	}
+
+	// quota is checked under lock of user, so concurrent uploads can't exceed it
+	linkable, err := srv.requestRepo.CreateChecked(ctx, resource, func(ctx context.Context) error {
+		return srv.quota.Check(ctx, res.UserID, vid.Size)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -58,14 +95,30 @@ func (srv *Service) Create(ctx context.Context, resource jsonapi.Linkable) (json
 		return nil, errors.New("invalid type assertion *request.Resource in service")
 	}
 
-	go srv.addVideo(ctx, *req, *vid, *videoFile)
+	srv.audit.Record(ctx, &audit.Event{
+		UserID:       res.UserID,
+		Action:       audit.ActionRequestCreated,
+		ResourceType: request.TableName,
+		ResourceID:   req.ID,
+	})
+
+	// context of request is reused by fiber after response
+	go srv.addVideo(auditsrv.Detach(ctx), *req, *vid, *videoFile)
 
 	return req, nil
 }
 
-// addVideo to cloud and db
+// addVideo scans video for malware and adds it to cloud and db. Cloud file of
+// video with the same content uploaded before by user or organization is reused
 func (srv *Service) addVideo(ctx context.Context, req request.Resource, vid video.Resource, videoFile multipart.FileHeader) {
-	cloudVideoID, err := srv.cloudStorage.Upload(ctx, &videoFile)
+	sum, ok := srv.scan(ctx, req, videoFile)
+	if !ok {
+		return
+	}
+
+	vid.SHA256 = sum
+
+	cloudVideoID, err := srv.upload(ctx, vid, videoFile)
 	if err != nil {
 		srv.logger.Error("can't upload video to cloud", zap.Error(err))
 		// update request status
@@ -81,6 +134,13 @@ func (srv *Service) addVideo(ctx context.Context, req request.Resource, vid vide
 
 	// create video in db
 	vid.ServiceID = cloudVideoID
+
+	expiresAt, err := srv.retention.OriginalExpiresAt(ctx, vid.UserID, time.Now())
+	if err != nil {
+		srv.logger.Error("can't calculate expiration time for original video", zap.Error(err))
+	}
+
+	vid.ExpiresAt = expiresAt
 	videoLinkable, err := srv.videoRepo.Create(ctx, vid.BuildFields())
 
 	if err != nil {
@@ -137,8 +197,113 @@ func (srv *Service) addVideo(ctx context.Context, req request.Resource, vid vide
 	}
 }
 
-// List returns []*request.Resource
+// upload video to cloud unless video with the same content is already stored,
+// returns cloud file name
+func (srv *Service) upload(ctx context.Context, vid video.Resource, videoFile multipart.FileHeader) (string, error) {
+	if vid.SHA256 != "" {
+		serviceID, err := srv.videoRepo.ServiceIDBySHA256(ctx, vid.UserID, vid.OrganizationID, vid.SHA256)
+		if err != nil {
+			srv.logger.Error("can't find video with the same content", zap.Error(err))
+		}
+
+		if serviceID != "" {
+			srv.logger.Info("Video with the same content is already stored", zap.String("Service ID", serviceID),
+				zap.Int64("User ID", vid.UserID))
+
+			return serviceID, nil
+		}
+	}
+
+	return srv.cloudStorage.Upload(ctx, vid.UserID, &videoFile)
+}
+
+// scan video for malware and calculate its hash in the same pass, request with
+// infected video is quarantined. Returns hex encoded SHA-256 of video and false
+// if video must not be uploaded
+func (srv *Service) scan(ctx context.Context, req request.Resource, videoFile multipart.FileHeader) (string, bool) {
+	f, err := videoFile.Open()
+	if err != nil {
+		srv.logger.Error("can't open video", zap.Error(err), zap.Int64("Request ID", req.ID))
+
+		fields := map[string]interface{}{"status": "failed", "details": "Can't read video"}
+		if _, updateErr := srv.requestRepo.Update(ctx, req.ID, fields); updateErr != nil {
+			srv.logger.Error("can't update request status", zap.Error(updateErr))
+		}
+
+		return "", false
+	}
+	defer f.Close()
+
+	return srv.scanContent(ctx, req, f, videoFile.Filename)
+}
+
+// scanContent scans content of video with name, hash of content is empty if it
+// can't be read to the end, so video is uploaded without deduplication
+func (srv *Service) scanContent(ctx context.Context, req request.Resource, r io.Reader, name string) (string, bool) {
+	h := sha256.New()
+	content := io.TeeReader(r, h)
+
+	threat, err := srv.scanner.Scan(ctx, content, name)
+	if err != nil {
+		srv.logger.Error("can't scan video for malware", zap.Error(err), zap.Int64("Request ID", req.ID))
+
+		fields := map[string]interface{}{"status": "failed", "details": "Can't scan video for malware"}
+		if _, updateErr := srv.requestRepo.Update(ctx, req.ID, fields); updateErr != nil {
+			srv.logger.Error("can't update request status", zap.Error(updateErr))
+		}
+
+		return "", false
+	}
+
+	if threat != "" {
+		srv.quarantine(ctx, req, name, threat)
+
+		return "", false
+	}
+
+	// scanner may stop before end of file or be disabled
+	if _, err = io.Copy(io.Discard, content); err != nil {
+		srv.logger.Error("can't calculate hash of video", zap.Error(err), zap.Int64("Request ID", req.ID))
+
+		return "", true
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// quarantine request with infected video
+func (srv *Service) quarantine(ctx context.Context, req request.Resource, name, threat string) {
+	srv.logger.Warn("malware found in video", zap.String("Threat", threat),
+		zap.Int64("Request ID", req.ID), zap.Int64("User ID", req.UserID))
+
+	fields := map[string]interface{}{"status": StatusQuarantined, "details": "Malware detected: " + threat}
+	if _, err := srv.requestRepo.Update(ctx, req.ID, fields); err != nil {
+		srv.logger.Error("can't update request status", zap.Error(err))
+	}
+
+	e := &audit.Event{
+		UserID:       req.UserID,
+		Action:       audit.ActionRequestQuarantined,
+		ResourceType: request.TableName,
+		ResourceID:   req.ID,
+	}
+
+	if details, err := json.Marshal(map[string]string{"threat": threat, "file": name}); err == nil {
+		e.Details = string(details)
+	}
+
+	srv.audit.Record(ctx, e)
+}
+
+// List returns []*request.Resource of user or, if OrganizationID of params is set,
+// of organization in which user is a member
 func (srv *Service) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
+	if params.OrganizationID != 0 {
+		if err := srv.member(ctx, params.OrganizationID, params.RelationID); err != nil {
+			return nil, err
+		}
+	}
+
 	requests, err := srv.requestRepo.List(ctx, params)
 	if err != nil {
 		return nil, err
@@ -155,12 +320,47 @@ func (srv *Service) Retrieve(ctx context.Context, userID, relationID int64) (jso
 	}
 
 	if id == 0 {
-		return nil, errors.New("request does not exists")
+		return nil, ErrRequestNotPresent
 	}
 
 	return srv.requestRepo.Retrieve(ctx, id)
 }
 
+// Delete function check if user can change request and mark it and its videos as deleted
+func (srv *Service) Delete(ctx context.Context, userID, relationID int64) error {
+	id, err := srv.requestRepo.RelationManageable(ctx, userID, relationID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && id == 0) {
+		return ErrRequestNotPresent
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = srv.requestRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	srv.audit.Record(ctx, &audit.Event{
+		UserID:       userID,
+		Action:       audit.ActionRequestDeleted,
+		ResourceType: request.TableName,
+		ResourceID:   id,
+	})
+
+	return nil
+}
+
+// member returns ErrNotMember if user is not a member of organization
+func (srv *Service) member(ctx context.Context, organizationID, userID int64) error {
+	_, err := srv.members.Membership(ctx, organizationID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotMember
+	}
+
+	return err
+}
+
 func (srv *Service) rabbitPublish(res *request.Resource) error {
 	req := compress.NewRequest(res)
 	body, err := json.Marshal(req)
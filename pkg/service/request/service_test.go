@@ -1,13 +1,22 @@
 package request
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/organization"
 	"github.com/Hargeon/videocmprs/pkg/repository/request"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
 	"github.com/Hargeon/videocmprs/pkg/service"
@@ -15,6 +24,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/jsonapi"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type invalidLinkable struct{}
@@ -27,7 +37,7 @@ func (r *invalidLinkable) JSONAPILinks() *jsonapi.Links {
 
 type cloudMock struct{}
 
-func (c *cloudMock) Upload(ctx context.Context, header *multipart.FileHeader) (string, error) {
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
 	if header.Filename == "failed" {
 		return "", errors.New("failed connection")
 	}
@@ -39,6 +49,35 @@ func (c *cloudMock) URL(filename string) (string, error) {
 	return filename, nil
 }
 
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	if filename == "error" {
+		return errors.New("mock error")
+	}
+
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	return &service.CloudObject{Name: filename}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+// apiOnlyCloudMock is storage which files can't be read by compression workers
+type apiOnlyCloudMock struct {
+	cloudMock
+}
+
+func (c *apiOnlyCloudMock) WorkerReadable() bool {
+	return false
+}
+
 type rabbitSuccess struct{}
 
 type rabbitError struct{}
@@ -59,6 +98,77 @@ func (r *rabbitError) Ping() error {
 	return errors.New("mock error")
 }
 
+type retentionMock struct{}
+
+func (r *retentionMock) OriginalExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error) {
+	return nil, nil
+}
+
+func (r *retentionMock) ConvertedExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error) {
+	return nil, nil
+}
+
+type quotaMock struct{}
+
+func (q *quotaMock) Check(ctx context.Context, userID, fileSize int64) error {
+	if fileSize > 1000 {
+		return errors.New("quota exceeded")
+	}
+
+	return nil
+}
+
+type scannerMock struct{}
+
+func (s *scannerMock) Scan(ctx context.Context, r io.Reader, name string) (string, error) {
+	switch name {
+	case "infected":
+		return "Eicar-Test-Signature", nil
+	case "scan_error":
+		return "", errors.New("mock error")
+	default:
+		return "", nil
+	}
+}
+
+// auditMock keeps recorded audit events
+type auditMock struct {
+	events []*audit.Event
+}
+
+func (a *auditMock) Record(ctx context.Context, e *audit.Event) {
+	a.events = append(a.events, e)
+}
+
+// fileHeader returns header of multipart file with name and content
+func fileHeader(t *testing.T, name, content string) *multipart.FileHeader {
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	part, err := writer.CreateFormFile("video", name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if _, err = part.Write([]byte(content)); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, header, err := req.FormFile("video")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	return header
+}
+
 func TestCreate(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -83,6 +193,7 @@ func TestCreate(t *testing.T) {
 		expectedRequestRatioX      int
 		expectedRequestRatioY      int
 		expectedRequestVideoName   string
+		storage                    service.CloudStorage
 		errorPresent               bool
 	}{
 		{
@@ -92,6 +203,25 @@ func TestCreate(t *testing.T) {
 			mock:         func() {},
 			errorPresent: true,
 		},
+		{
+			name: "Organization without membership",
+			resource: &request.Resource{
+				UserID:         1,
+				OrganizationID: 3,
+				Bitrate:        64000,
+				VideoName:      "new_video",
+				OriginalVideo: &video.Resource{
+					Size: 100,
+				},
+			},
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectQuery("SELECT role FROM organization_members").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"role"}))
+			},
+			errorPresent: true,
+		},
 		{
 			name: "Invalid db connection to create request",
 			resource: &request.Resource{
@@ -102,13 +232,54 @@ func TestCreate(t *testing.T) {
 				RatioX:      4,
 				RatioY:      3,
 				VideoName:   "new_video",
+				OriginalVideo: &video.Resource{
+					Size: 100,
+				},
 			},
 			publisher: &rabbitSuccess{},
 			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", request.TableName)).
-					WithArgs(64000, 800, 600, 4, 3, 1, "new_video").
+					WithArgs(64000, 800, 600, 4, 3, 1, "new_video", nil).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+		{
+			name: "Quota exceeded",
+			resource: &request.Resource{
+				UserID:    1,
+				VideoName: "new_video",
+				OriginalVideo: &video.Resource{
+					Size: 1001,
+				},
+			},
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			errorPresent: true,
+		},
+		{
+			name: "Storage can't be read by workers",
+			resource: &request.Resource{
+				UserID:    1,
+				VideoName: "new_video",
+				OriginalVideo: &video.Resource{
+					Size: 100,
+				},
 			},
+			publisher:    &rabbitSuccess{},
+			storage:      new(apiOnlyCloudMock),
+			mock:         func() {},
 			errorPresent: true,
 		},
 	}
@@ -118,8 +289,13 @@ func TestCreate(t *testing.T) {
 			testCase.mock()
 			rRepo := request.NewRepository(db)
 			vRepo := video.NewRepository(db)
-			cs := new(cloudMock)
-			srv := NewService(rRepo, vRepo, cs, testCase.publisher, logger)
+			var cs service.CloudStorage = new(cloudMock)
+			if testCase.storage != nil {
+				cs = testCase.storage
+			}
+
+			recorder := new(auditMock)
+			srv := NewService(rRepo, vRepo, cs, testCase.publisher, new(retentionMock), new(quotaMock), new(scannerMock), organization.NewRepository(db), recorder, logger)
 
 			linkable, err := srv.Create(context.Background(), testCase.resource)
 			if err != nil && !testCase.errorPresent {
@@ -141,6 +317,11 @@ func TestCreate(t *testing.T) {
 						testCase.expectedRequestID, req.ID)
 				}
 
+				if len(recorder.events) != 1 || recorder.events[0].Action != audit.ActionRequestCreated ||
+					recorder.events[0].ResourceID != req.ID {
+					t.Errorf("Creation of request should be recorded in audit log\n")
+				}
+
 				if req.Status != testCase.expectedRequestStatus {
 					t.Errorf("Invalid request status, expected: %s, got: %s\n",
 						testCase.expectedRequestStatus, req.Status)
@@ -190,6 +371,9 @@ func TestCreate(t *testing.T) {
 }
 
 func TestAddVideo(t *testing.T) {
+	// videoSHA256 is hash of "video" content
+	const videoSHA256 = "0cab1c9617404faf2b24e221e189ca5945813e14d3f766345b09ca13bbe28ffc"
+
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
@@ -223,11 +407,13 @@ func TestAddVideo(t *testing.T) {
 				Size:   150000,
 				UserID: 1,
 			},
-			videoFile: multipart.FileHeader{
-				Filename: "failed",
-			},
+			videoFile: *fileHeader(t, "failed", "video"),
 			publisher: &rabbitSuccess{},
 			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs(`Can't upload video to cloud`, "failed", 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
@@ -250,16 +436,18 @@ func TestAddVideo(t *testing.T) {
 				Size:   150000,
 				UserID: 1,
 			},
-			videoFile: multipart.FileHeader{
-				Filename: "failed",
-			},
+			videoFile: *fileHeader(t, "failed", "video"),
 			publisher: &rabbitSuccess{},
 			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs(`Can't upload video to cloud`, "failed", 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -267,13 +455,132 @@ func TestAddVideo(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "failed", "Can't upload video to cloud", 64000, 800, 600, 4, 3, "new_video", nil, nil, nil,
-						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
+			},
+		},
+		{
+			name: "infected video is quarantined",
+			req: request.Resource{
+				UserID:    1,
+				ID:        1,
+				VideoName: "new_video",
+			},
+			vid: video.Resource{
+				Name:   "new_video",
+				Size:   150000,
+				UserID: 1,
+			},
+			videoFile: *fileHeader(t, "infected", "video"),
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
+					WithArgs("Malware detected: Eicar-Test-Signature", "quarantined", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "unavailable scanner fails request",
+			req: request.Resource{
+				UserID:    1,
+				ID:        1,
+				VideoName: "new_video",
+			},
+			vid: video.Resource{
+				Name:   "new_video",
+				Size:   150000,
+				UserID: 1,
+			},
+			videoFile: *fileHeader(t, "scan_error", "video"),
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
+					WithArgs("Can't scan video for malware", "failed", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "unreadable video fails request",
+			req: request.Resource{
+				UserID:    1,
+				ID:        1,
+				VideoName: "new_video",
+			},
+			vid: video.Resource{
+				Name:   "new_video",
+				Size:   150000,
+				UserID: 1,
+			},
+			videoFile: multipart.FileHeader{
+				Filename: "good",
+			},
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
+					WithArgs("Can't read video", "failed", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "video with the same content reuses stored file",
+			req: request.Resource{
+				UserID:    1,
+				ID:        1,
+				VideoName: "new_video",
+			},
+			vid: video.Resource{
+				Name:   "my_name.mkv",
+				Size:   5,
+				UserID: 1,
+			},
+			videoFile: *fileHeader(t, "good", "video"),
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}).AddRow("stored_service_id"))
+
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
+					WithArgs("my_name.mkv", "stored_service_id", videoSHA256, 5, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
+					WithArgs("Can't add video to database", "failed", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "video with new content of organization is uploaded",
+			req: request.Resource{
+				UserID:    1,
+				ID:        1,
+				VideoName: "new_video",
+			},
+			vid: video.Resource{
+				Name:           "my_name.mkv",
+				Size:           5,
+				UserID:         1,
+				OrganizationID: 2,
+			},
+			videoFile: *fileHeader(t, "good", "video"),
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 2, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
+					WithArgs("my_name.mkv", 2, "mock_service_id", videoSHA256, 5, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
+					WithArgs("Can't add video to database", "failed", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 			},
 		},
 		{
@@ -294,13 +601,45 @@ func TestAddVideo(t *testing.T) {
 				UserID:    1,
 				ServiceID: "mock_service_id",
 			},
-			videoFile: multipart.FileHeader{
-				Filename: "good",
+			videoFile: *fileHeader(t, "good", "video"),
+			publisher: &rabbitSuccess{},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
+					WithArgs("my_name.mkv", "mock_service_id", videoSHA256, 1258000, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
+					WithArgs("Can't add video to database", "failed", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 			},
+		},
+		{
+			name: "video with duration from headers",
+			req: request.Resource{
+				UserID:    1,
+				ID:        1,
+				VideoName: "new_video",
+			},
+			vid: video.Resource{
+				Name:      "my_name.mkv",
+				Size:      1258000,
+				UserID:    1,
+				ServiceID: "mock_service_id",
+				Duration:  12.5,
+			},
+			videoFile: *fileHeader(t, "good", "video"),
 			publisher: &rabbitSuccess{},
 			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
-					WithArgs("my_name.mkv", "mock_service_id", 1258000, 1).
+					WithArgs(12.5, "my_name.mkv", "mock_service_id", videoSHA256, 1258000, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
@@ -326,20 +665,22 @@ func TestAddVideo(t *testing.T) {
 				UserID:    1,
 				ServiceID: "mock_service_id",
 			},
-			videoFile: multipart.FileHeader{
-				Filename: "good",
-			},
+			videoFile: *fileHeader(t, "good", "video"),
 			publisher: &rabbitSuccess{},
 			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
-					WithArgs("my_name.mkv", "mock_service_id", 1258000, 1).
+					WithArgs("my_name.mkv", "mock_service_id", videoSHA256, 1258000, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs("Can't add video to database", "failed", 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -347,13 +688,13 @@ func TestAddVideo(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "failed", "Can't add video to database", 64000, 800, 600, 4, 3, "new_video", nil, nil, nil,
-						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 			},
 		},
 		{
@@ -374,26 +715,28 @@ func TestAddVideo(t *testing.T) {
 				UserID:    1,
 				ServiceID: "mock_service_id",
 			},
-			videoFile: multipart.FileHeader{
-				Filename: "good",
-			},
+			videoFile: *fileHeader(t, "good", "video"),
 			publisher: &rabbitSuccess{},
 			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
-					WithArgs("my_name.mkv", "mock_service_id", 1258000, 1).
+					WithArgs("my_name.mkv", "mock_service_id", videoSHA256, 1258000, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).
 						AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 1258000, 0, 0, 0, 0, 0, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 0, 0, 0, 0, 0, "mock_service_id", nil))
 
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs(1, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -401,13 +744,13 @@ func TestAddVideo(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "original_in_review", "", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
-						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 			},
 		},
 		{
@@ -428,26 +771,28 @@ func TestAddVideo(t *testing.T) {
 				UserID:    1,
 				ServiceID: "mock_service_id",
 			},
-			videoFile: multipart.FileHeader{
-				Filename: "good",
-			},
+			videoFile: *fileHeader(t, "good", "video"),
 			publisher: &rabbitError{},
 			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT service_id FROM %s", video.TableName)).
+					WithArgs(videoSHA256, 1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"service_id"}))
+
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", video.TableName)).
-					WithArgs("my_name.mkv", "mock_service_id", 1258000, 1).
+					WithArgs("my_name.mkv", "mock_service_id", videoSHA256, 1258000, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).
 						AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 1258000, 0, 0, 0, 0, 0, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 0, 0, 0, 0, 0, "mock_service_id", nil))
 
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs(1, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -455,19 +800,19 @@ func TestAddVideo(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "original_in_review", "", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
-						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 
 				mock.ExpectQuery(fmt.Sprintf("UPDATE %s", request.TableName)).
 					WithArgs("Failed connection to worker", "failed", 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -475,13 +820,13 @@ func TestAddVideo(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "failed", "Failed connection to worker", 64000, 800, 600, 4, 3, "new_video", 1, "my_name.mkv",
-						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil,
-						nil, nil, nil, nil, nil))
+						1258000, 0, 0, 0, 0, 0, "mock_service_id", nil, nil, nil, nil, nil,
+						nil, nil, nil, nil, nil, nil, nil))
 			},
 		},
 	}
@@ -492,7 +837,7 @@ func TestAddVideo(t *testing.T) {
 			rRepo := request.NewRepository(db)
 			vRepo := video.NewRepository(db)
 			cs := new(cloudMock)
-			srv := NewService(rRepo, vRepo, cs, testCase.publisher, logger)
+			srv := NewService(rRepo, vRepo, cs, testCase.publisher, new(retentionMock), new(quotaMock), new(scannerMock), organization.NewRepository(db), new(auditMock), logger)
 
 			srv.addVideo(context.Background(), testCase.req, testCase.vid, testCase.videoFile)
 
@@ -503,6 +848,38 @@ func TestAddVideo(t *testing.T) {
 	}
 }
 
+func TestScanContent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	core, logs := observer.New(zap.ErrorLevel)
+	srv := NewService(request.NewRepository(db), video.NewRepository(db), new(cloudMock), &rabbitSuccess{},
+		new(retentionMock), new(quotaMock), new(scannerMock), organization.NewRepository(db), new(auditMock),
+		zap.New(core))
+
+	req := request.Resource{ID: 7, UserID: 1}
+
+	sum, ok := srv.scanContent(context.Background(), req, iotest.ErrReader(errors.New("disk error")), "good")
+	if !ok {
+		t.Error("Video should be uploaded when hash can't be calculated\n")
+	}
+
+	if sum != "" {
+		t.Errorf("Hash should be empty, got: %s\n", sum)
+	}
+
+	entries := logs.FilterMessage("can't calculate hash of video").FilterField(zap.Int64("Request ID", 7)).All()
+	if len(entries) != 1 {
+		t.Errorf("Hash error should be logged with request ID, got logs: %v\n", logs.All())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s\n", err)
+	}
+}
+
 func TestList(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -527,7 +904,7 @@ func TestList(t *testing.T) {
 				PageSize:   10,
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -535,10 +912,10 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}))
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}))
 			},
 			expectedLen:  0,
 			errorPresent: false,
@@ -551,7 +928,7 @@ func TestList(t *testing.T) {
 				PageSize:   10,
 			},
 			mock: func() {
-				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -559,13 +936,13 @@ func TestList(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, "", "", 64000, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", 2, "converted_video", 12000, 64000,
-						800, 600, 4, 3, "converted_service_id"))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, 2, "converted_video", 12000, 64000,
+						800, 600, 4, 3, "converted_service_id", nil, nil))
 			},
 			expectedLen:  1,
 			errorPresent: false,
@@ -579,7 +956,7 @@ func TestList(t *testing.T) {
 			rRepo := request.NewRepository(db)
 			vRepo := video.NewRepository(db)
 			cs := new(cloudMock)
-			srv := NewService(rRepo, vRepo, cs, &rabbitSuccess{}, logger)
+			srv := NewService(rRepo, vRepo, cs, &rabbitSuccess{}, new(retentionMock), new(quotaMock), new(scannerMock), organization.NewRepository(db), new(auditMock), logger)
 			res, err := srv.List(context.Background(), testCase.params)
 			if err != nil && !testCase.errorPresent {
 				t.Errorf("Unexpected error: %s\n", err.Error())
@@ -634,10 +1011,10 @@ func TestRetrieve(t *testing.T) {
 			userID: 1,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", request.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id").
+				mock.ExpectQuery("SELECT requests.id, requests.user_id, requests.status, requests.details, requests.bitrate, requests.resolution_x, requests.resolution_y, requests.ratio_x, requests.ratio_y, requests.video_name, origin_video.id, origin_video.name, origin_video.size, origin_video.bitrate, origin_video.resolution_x, origin_video.resolution_y, origin_video.ratio_x, origin_video.ratio_y, origin_video.service_id, origin_video.expires_at, converted_video.id, converted_video.name, converted_video.size, converted_video.bitrate, converted_video.resolution_x, converted_video.resolution_y, converted_video.ratio_x, converted_video.ratio_y, converted_video.service_id, converted_video.expires_at, requests.organization_id FROM requests LEFT JOIN videos AS origin_video ON requests.original_file_id = origin_video.id AND origin_video.deleted_at IS NULL LEFT JOIN videos AS converted_video ON requests.converted_file_id = converted_video.id AND converted_video.deleted_at IS NULL").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"requests.id", "requests.user_id", "requests.status",
 						"requests.details", "requests.bitrate", "requests.resolution_x",
@@ -645,13 +1022,13 @@ func TestRetrieve(t *testing.T) {
 						"requests.video_name", "origin_video.id", "origin_video.name",
 						"origin_video.size", "origin_video.bitrate", "origin_video.resolution_x",
 						"origin_video.resolution_y", "origin_video.ratio_x", "origin_video.ratio_y",
-						"origin_video.service_id", "converted_video.id", "converted_video.name",
+						"origin_video.service_id", "origin_video.expires_at", "converted_video.id", "converted_video.name",
 						"converted_video.size", "converted_video.bitrate", "converted_video.resolution_x",
 						"converted_video.resolution_y", "converted_video.ratio_x",
-						"converted_video.ratio_y", "converted_video.service_id"}).AddRow(
+						"converted_video.ratio_y", "converted_video.service_id", "converted_video.expires_at", "requests.organization_id"}).AddRow(
 						1, 1, "original_in_review", "", 1589875, 800, 600, 4, 3, "new_video", 1, "new_video", 15000,
-						78000, 1200, 800, 6, 5, "new_service_id", 2, "converted_video", 12000, 64000,
-						800, 600, 4, 3, "converted_service_id"))
+						78000, 1200, 800, 6, 5, "new_service_id", nil, 2, "converted_video", 12000, 64000,
+						800, 600, 4, 3, "converted_service_id", nil, nil))
 			},
 			expectedID:          1,
 			expectedStatus:      "original_in_review",
@@ -670,7 +1047,7 @@ func TestRetrieve(t *testing.T) {
 			userID: 1,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", request.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}))
 			},
 			errorPresent: true,
@@ -686,7 +1063,7 @@ func TestRetrieve(t *testing.T) {
 			vRepo := video.NewRepository(db)
 			cs := new(cloudMock)
 
-			srv := NewService(rRepo, vRepo, cs, &rabbitSuccess{}, logger)
+			srv := NewService(rRepo, vRepo, cs, &rabbitSuccess{}, new(retentionMock), new(quotaMock), new(scannerMock), organization.NewRepository(db), new(auditMock), logger)
 			linkable, err := srv.Retrieve(context.Background(), testCase.userID, testCase.id)
 			if err != nil && !testCase.errorPresent {
 				t.Errorf("Unexpected error: %s\n", err.Error())
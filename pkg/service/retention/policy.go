@@ -0,0 +1,97 @@
+// Package retention uses for calculating when stored videos expire
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository"
+)
+
+const day = 24 * time.Hour
+
+// Policy calculates expiration time of videos. User specific settings
+// take precedence over global ones. Zero period means video is kept forever
+type Policy struct {
+	repo      repository.RetentionRetriever
+	original  time.Duration
+	converted time.Duration
+}
+
+// NewPolicy initialize Policy with global retention periods
+func NewPolicy(repo repository.RetentionRetriever, original, converted time.Duration) *Policy {
+	return &Policy{repo: repo, original: original, converted: converted}
+}
+
+// NewEnvPolicy initialize Policy with global retention periods from
+// ORIGINAL_RETENTION_DAYS and CONVERTED_RETENTION_DAYS env variables
+func NewEnvPolicy(repo repository.RetentionRetriever) *Policy {
+	return NewPolicy(repo, envDays("ORIGINAL_RETENTION_DAYS"), envDays("CONVERTED_RETENTION_DAYS"))
+}
+
+// OriginalExpiresAt returns expiration time for original video uploaded at from.
+// Returns nil if video is kept forever
+func (p *Policy) OriginalExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error) {
+	days, err := p.userDays(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return expiresAt(from, p.original, days), nil
+}
+
+// ConvertedExpiresAt returns expiration time for converted video created at from.
+// Returns nil if video is kept forever
+func (p *Policy) ConvertedExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error) {
+	days, err := p.userDays(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return expiresAt(from, p.converted, days), nil
+}
+
+func (p *Policy) userDays(ctx context.Context, userID int64, original bool) (sql.NullInt32, error) {
+	ret, err := p.repo.Retention(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql.NullInt32{}, nil
+	}
+
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+
+	if original {
+		return ret.OriginalDays, nil
+	}
+
+	return ret.ConvertedDays, nil
+}
+
+func expiresAt(from time.Time, global time.Duration, days sql.NullInt32) *time.Time {
+	period := global
+	if days.Valid {
+		period = time.Duration(days.Int32) * day
+	}
+
+	if period <= 0 {
+		return nil
+	}
+
+	t := from.Add(period)
+
+	return &t
+}
+
+func envDays(name string) time.Duration {
+	days, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || days <= 0 {
+		return 0
+	}
+
+	return time.Duration(days) * day
+}
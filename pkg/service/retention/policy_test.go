@@ -0,0 +1,155 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/user"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExpiresAt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	from := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	expectedQuery := fmt.Sprintf("SELECT original_retention_days, converted_retention_days FROM %s", user.TableName)
+
+	cases := []struct {
+		name              string
+		original          time.Duration
+		converted         time.Duration
+		mock              func()
+		expectedOriginal  *time.Time
+		expectedConverted *time.Time
+		errorPresent      bool
+	}{
+		{
+			name: "Without retention",
+			mock: func() {
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}).
+						AddRow(nil, nil))
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}).
+						AddRow(nil, nil))
+			},
+		},
+		{
+			name:      "With global retention",
+			original:  2 * day,
+			converted: 7 * day,
+			mock: func() {
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}).
+						AddRow(nil, nil))
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}).
+						AddRow(nil, nil))
+			},
+			expectedOriginal:  timePtr(from.Add(2 * day)),
+			expectedConverted: timePtr(from.Add(7 * day)),
+		},
+		{
+			name:      "With user retention",
+			original:  2 * day,
+			converted: 7 * day,
+			mock: func() {
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}).
+						AddRow(30, 0))
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}).
+						AddRow(30, 0))
+			},
+			expectedOriginal: timePtr(from.Add(30 * day)),
+		},
+		{
+			name:      "User does not exist",
+			original:  2 * day,
+			converted: 7 * day,
+			mock: func() {
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}))
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"original_retention_days", "converted_retention_days"}))
+			},
+			expectedOriginal:  timePtr(from.Add(2 * day)),
+			expectedConverted: timePtr(from.Add(7 * day)),
+		},
+		{
+			name:     "With bad db connection",
+			original: 2 * day,
+			mock: func() {
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnError(errors.New("mock error"))
+				mock.ExpectQuery(expectedQuery).
+					WithArgs(1).
+					WillReturnError(errors.New("mock error"))
+			},
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			policy := NewPolicy(user.NewRepository(db), testCase.original, testCase.converted)
+
+			original, err := policy.OriginalExpiresAt(context.Background(), 1, from)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if err == nil && testCase.errorPresent {
+				t.Errorf("Should be error\n")
+			}
+
+			if !equalTime(original, testCase.expectedOriginal) {
+				t.Errorf("Invalid original expiration, expected: %v, got: %v\n",
+					testCase.expectedOriginal, original)
+			}
+
+			converted, err := policy.ConvertedExpiresAt(context.Background(), 1, from)
+			if err != nil && !testCase.errorPresent {
+				t.Errorf("Unexpected error: %s\n", err.Error())
+			}
+
+			if !equalTime(converted, testCase.expectedConverted) {
+				t.Errorf("Invalid converted expiration, expected: %v, got: %v\n",
+					testCase.expectedConverted, converted)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
@@ -0,0 +1,151 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize of INSTREAM chunks sent to clamd
+const chunkSize = 64 * 1024
+
+// DefaultClamdTimeout of scanning one file
+const DefaultClamdTimeout = 5 * time.Minute
+
+// Clamd scans files with ClamAV daemon over TCP or unix socket
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamd initialize Clamd. Address is unix socket as unix:///run/clamav/clamd.ctl
+// or TCP address as tcp://localhost:3310 or localhost:3310
+func NewClamd(address string, timeout time.Duration) *Clamd {
+	network := "tcp"
+
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "unix:"):
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	case strings.HasPrefix(address, "/"):
+		network = "unix"
+	default:
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+
+	return &Clamd{network: network, address: address, timeout: timeout}
+}
+
+// Scan streams r to clamd with INSTREAM command, returns name of found
+// signature or empty string if r is clean
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (string, error) {
+	reply, err := c.command(ctx, "INSTREAM", func(conn net.Conn) error {
+		return stream(conn, r)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	reply = strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case reply == "OK":
+		return "", nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSuffix(reply, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// Ping checks that clamd is available
+func (c *Clamd) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "PING", nil)
+	if err != nil {
+		return err
+	}
+
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: %s", reply)
+	}
+
+	return nil
+}
+
+// command sends null terminated command to clamd, writes its body with send
+// and returns reply without terminator
+func (c *Clamd) command(ctx context.Context, name string, send func(conn net.Conn) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err = conn.Write([]byte("z" + name + "\x00")); err != nil {
+		return "", err
+	}
+
+	var sendErr error
+	if send != nil {
+		// clamd replies and closes connection if stream exceeds StreamMaxLength,
+		// so the reply is read even if sending failed
+		sendErr = send(conn)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && (err != io.EOF || reply == "") {
+		if sendErr != nil {
+			return "", sendErr
+		}
+
+		return "", err
+	}
+
+	return strings.TrimSpace(strings.TrimSuffix(reply, "\x00")), nil
+}
+
+// stream writes r as INSTREAM chunks prefixed with size, zero size ends stream
+func stream(w io.Writer, r io.Reader) error {
+	buf := make([]byte, 4+chunkSize)
+
+	for {
+		n, err := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+
+			if _, wErr := w.Write(buf[:4+n]); wErr != nil {
+				return wErr
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write([]byte{0, 0, 0, 0})
+
+	return err
+}
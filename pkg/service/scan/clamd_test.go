@@ -0,0 +1,181 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eicar is EICAR anti-virus test file
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd serves clamd commands on listener, streamed files containing
+// eicar are reported as infected
+func fakeClamd(t *testing.T, l net.Listener) {
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveClamd(conn)
+		}
+	}()
+}
+
+func serveClamd(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch cmd {
+	case "zPING\x00":
+		conn.Write([]byte("PONG\x00")) //nolint:errcheck
+	case "zINSTREAM\x00":
+		body := new(bytes.Buffer)
+		size := make([]byte, 4)
+
+		for {
+			if _, err = io.ReadFull(r, size); err != nil {
+				return
+			}
+
+			n := binary.BigEndian.Uint32(size)
+			if n == 0 {
+				break
+			}
+
+			if _, err = io.CopyN(body, r, int64(n)); err != nil {
+				return
+			}
+		}
+
+		if strings.Contains(body.String(), eicar) {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00")) //nolint:errcheck
+
+			return
+		}
+
+		conn.Write([]byte("stream: OK\x00")) //nolint:errcheck
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00")) //nolint:errcheck
+	}
+}
+
+func TestNewClamd(t *testing.T) {
+	cases := []struct {
+		address         string
+		expectedNetwork string
+		expectedAddress string
+	}{
+		{address: "unix:///run/clamav/clamd.ctl", expectedNetwork: "unix", expectedAddress: "/run/clamav/clamd.ctl"},
+		{address: "unix:clamd.ctl", expectedNetwork: "unix", expectedAddress: "clamd.ctl"},
+		{address: "/run/clamav/clamd.ctl", expectedNetwork: "unix", expectedAddress: "/run/clamav/clamd.ctl"},
+		{address: "tcp://clamav:3310", expectedNetwork: "tcp", expectedAddress: "clamav:3310"},
+		{address: "localhost:3310", expectedNetwork: "tcp", expectedAddress: "localhost:3310"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.address, func(t *testing.T) {
+			c := NewClamd(testCase.address, time.Second)
+			if c.network != testCase.expectedNetwork || c.address != testCase.expectedAddress {
+				t.Errorf("Invalid address, expected: %s %s, got: %s %s\n",
+					testCase.expectedNetwork, testCase.expectedAddress, c.network, c.address)
+			}
+		})
+	}
+}
+
+func TestClamd_Scan(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	fakeClamd(t, tcp)
+
+	unix, err := net.Listen("unix", filepath.Join(t.TempDir(), "clamd.ctl"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	fakeClamd(t, unix)
+
+	// content bigger than one chunk with signature at the end
+	infected := strings.Repeat("a", chunkSize+10) + eicar
+
+	cases := []struct {
+		name           string
+		address        string
+		content        string
+		expectedThreat string
+		errorPresent   bool
+	}{
+		{
+			name:    "Clean file over TCP",
+			address: "tcp://" + tcp.Addr().String(),
+			content: "clean video",
+		},
+		{
+			name:           "Infected file over TCP",
+			address:        tcp.Addr().String(),
+			content:        infected,
+			expectedThreat: "Eicar-Test-Signature",
+		},
+		{
+			name:           "Infected file over unix socket",
+			address:        "unix://" + unix.Addr().String(),
+			content:        eicar,
+			expectedThreat: "Eicar-Test-Signature",
+		},
+		{
+			name:         "Unavailable clamd",
+			address:      "unix://" + filepath.Join(t.TempDir(), "missing.ctl"),
+			content:      "clean video",
+			errorPresent: true,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := NewClamd(testCase.address, time.Second)
+
+			threat, err := c.Scan(context.Background(), strings.NewReader(testCase.content))
+			if (err != nil) != testCase.errorPresent {
+				t.Fatalf("Unexpected error: %v\n", err)
+			}
+
+			if threat != testCase.expectedThreat {
+				t.Errorf("Invalid threat, expected: %q, got: %q\n", testCase.expectedThreat, threat)
+			}
+		})
+	}
+}
+
+func TestClamd_Ping(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	fakeClamd(t, l)
+
+	if err = NewClamd(l.Addr().String(), time.Second).Ping(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s\n", err)
+	}
+}
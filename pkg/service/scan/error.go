@@ -0,0 +1,6 @@
+package scan
+
+import "errors"
+
+// ErrUnavailable returns if scanner can't scan file and scanning fails closed
+var ErrUnavailable = errors.New("malware scanner is unavailable")
@@ -0,0 +1,74 @@
+// Package scan uses for scanning uploaded files for malware before they are
+// stored in cloud and sent to workers
+package scan
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Engine scans content of file, returns name of found threat or empty string if content is clean
+type Engine interface {
+	Scan(ctx context.Context, r io.Reader) (string, error)
+}
+
+// Service scans uploaded files with engine. If engine fails file is accepted
+// when failOpen is set, otherwise ErrUnavailable is returned
+type Service struct {
+	engine   Engine
+	failOpen bool
+	logger   *zap.Logger
+}
+
+// NewService initialize Service. Nil engine accepts every file
+func NewService(engine Engine, failOpen bool, logger *zap.Logger) *Service {
+	return &Service{engine: engine, failOpen: failOpen, logger: logger}
+}
+
+// NewEnvService initialize Service with clamd at CLAMD_ADDRESS, scanning is
+// disabled if it's empty. CLAMD_TIMEOUT limits scanning of one file and
+// SCAN_FAIL_OPEN=true accepts files when clamd is unavailable
+func NewEnvService(logger *zap.Logger) *Service {
+	failOpen, _ := strconv.ParseBool(os.Getenv("SCAN_FAIL_OPEN"))
+
+	address := os.Getenv("CLAMD_ADDRESS")
+	if address == "" {
+		return NewService(nil, failOpen, logger)
+	}
+
+	timeout, err := time.ParseDuration(os.Getenv("CLAMD_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = DefaultClamdTimeout
+	}
+
+	return NewService(NewClamd(address, timeout), failOpen, logger)
+}
+
+// Scan content of file with name, returns name of found threat or empty string
+// if file is clean
+func (srv *Service) Scan(ctx context.Context, r io.Reader, name string) (string, error) {
+	if srv.engine == nil {
+		return "", nil
+	}
+
+	threat, err := srv.engine.Scan(ctx, r)
+	if err == nil {
+		return threat, nil
+	}
+
+	if srv.failOpen {
+		srv.logger.Warn("Malware scan failed, file is accepted", zap.Error(err),
+			zap.String("File", name))
+
+		return "", nil
+	}
+
+	srv.logger.Error("Malware scan failed", zap.Error(err), zap.String("File", name))
+
+	return "", ErrUnavailable
+}
@@ -0,0 +1,86 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type engineMock struct{}
+
+func (e *engineMock) Scan(ctx context.Context, r io.Reader) (string, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	switch string(body) {
+	case "infected":
+		return "Eicar-Test-Signature", nil
+	case "error":
+		return "", errors.New("mock error")
+	default:
+		return "", nil
+	}
+}
+
+func TestScan(t *testing.T) {
+	logger := zap.NewExample()
+	defer logger.Sync()
+
+	cases := []struct {
+		name           string
+		engine         Engine
+		failOpen       bool
+		content        string
+		expectedThreat string
+		expectedErr    error
+	}{
+		{
+			name:    "Disabled scanner",
+			content: "infected",
+		},
+		{
+			name:    "Clean file",
+			engine:  new(engineMock),
+			content: "clean",
+		},
+		{
+			name:           "Infected file",
+			engine:         new(engineMock),
+			content:        "infected",
+			expectedThreat: "Eicar-Test-Signature",
+		},
+		{
+			name:        "Fail closed",
+			engine:      new(engineMock),
+			content:     "error",
+			expectedErr: ErrUnavailable,
+		},
+		{
+			name:     "Fail open",
+			engine:   new(engineMock),
+			failOpen: true,
+			content:  "error",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			srv := NewService(testCase.engine, testCase.failOpen, logger)
+
+			threat, err := srv.Scan(context.Background(), strings.NewReader(testCase.content), "video.mkv")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if threat != testCase.expectedThreat {
+				t.Errorf("Invalid threat, expected: %q, got: %q\n", testCase.expectedThreat, threat)
+			}
+		})
+	}
+}
@@ -3,9 +3,16 @@ package service
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
+	"time"
 
 	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository/apikey"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/idempotency"
+	"github.com/Hargeon/videocmprs/pkg/repository/organization"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
 
 	"github.com/google/jsonapi"
 )
@@ -22,30 +29,256 @@ type RetrieveRelation interface {
 	Retrieve(ctx context.Context, userID, relationID int64) (jsonapi.Linkable, error)
 }
 
+type RelationDeleter interface {
+	Delete(ctx context.Context, userID, relationID int64) error
+}
+
 type Paginator interface {
 	List(ctx context.Context, params *query.Params) ([]interface{}, error)
 }
 
 type Tokenable interface {
 	GenerateToken(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error)
+	Refresh(ctx context.Context, refreshToken string) (jsonapi.Linkable, error)
+	Logout(ctx context.Context, userID int64, refreshToken, jti string, expiresAt time.Time) error
 	Retriever
 }
 
+type TokenDenylist interface {
+	Denied(ctx context.Context, jti string, userID int64) (bool, error)
+}
+
+type APIKey interface {
+	Creator
+	Paginator
+	RelationDeleter
+}
+
+// APIKeyIdentifier returns active API key by its plain value
+type APIKeyIdentifier interface {
+	Identify(ctx context.Context, key string) (*apikey.Resource, error)
+}
+
+// Admin manages requests and users of all users
+type Admin interface {
+	ListRequests(ctx context.Context, params *query.Params, filter *query.RequestFilter) ([]interface{}, error)
+	RetrieveRequest(ctx context.Context, id int64) (jsonapi.Linkable, error)
+	FailRequest(ctx context.Context, id int64, reason string) (jsonapi.Linkable, error)
+	CancelRequest(ctx context.Context, id int64) (jsonapi.Linkable, error)
+	RetryRequest(ctx context.Context, id int64) (jsonapi.Linkable, error)
+	DisableUser(ctx context.Context, id int64, disabled bool) error
+	DeleteUser(ctx context.Context, id int64) error
+	Stats(ctx context.Context) (jsonapi.Linkable, error)
+}
+
+// AuditRecorder appends event to audit log. Failed recording doesn't
+// break audited action
+type AuditRecorder interface {
+	Record(ctx context.Context, e *audit.Event)
+}
+
+// Audit records events and returns them to admins
+type Audit interface {
+	AuditRecorder
+
+	List(ctx context.Context, params *query.Params, filter *query.AuditFilter) ([]interface{}, error)
+	Export(ctx context.Context, filter *query.AuditFilter, w io.Writer) error
+}
+
+// CloudObject represent file stored in cloud
+type CloudObject struct {
+	Name         string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+// CloudStorage keeps files of users. Upload encrypts file with key of user
+// if storage encrypts files with per-user keys
 type CloudStorage interface {
-	Upload(ctx context.Context, header *multipart.FileHeader) (string, error)
+	Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error)
 	URL(filename string) (string, error)
+	Delete(ctx context.Context, filename string) error
+	List(ctx context.Context) ([]CloudObject, error)
+	Stat(ctx context.Context, filename string) (*CloudObject, error)
+	Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error)
+}
+
+// WorkerStorage is implemented by storages which report whether compression
+// workers can read stored files. Workers read originals from S3 by name without
+// encryption keys
+type WorkerStorage interface {
+	WorkerReadable() bool
+}
+
+// URLVerifier checks signature of download url of file served by application
+type URLVerifier interface {
+	Verify(filename, expires, signature string) bool
 }
 
 type Request interface {
 	Creator
 	RetrieveRelation
+	RelationDeleter
 	Paginator
 }
 
+// Organization manages organizations of user and their members
+type Organization interface {
+	Creator
+	Paginator
+	RetrieveRelation
+	RelationDeleter
+
+	Members(ctx context.Context, userID, organizationID int64) ([]interface{}, error)
+	AddMember(ctx context.Context, userID, organizationID int64, member *organization.Member) (jsonapi.Linkable, error)
+	UpdateMember(ctx context.Context, userID, organizationID, memberID int64, role string) error
+	RemoveMember(ctx context.Context, userID, organizationID, memberID int64) error
+}
+
 type Video interface {
 	RetrieveRelation
+	RelationDeleter
 
 	DownloadURL(ctx context.Context, userID, videoID int64) (string, error)
+	Content(ctx context.Context, userID, videoID int64) (*video.Resource, *CloudObject, error)
+	OpenContent(ctx context.Context, vid *video.Resource, offset, length int64) (io.ReadCloser, error)
+}
+
+// ShareLink manages public share links of videos and resolves them
+// to download url of video
+type ShareLink interface {
+	Creator
+	Paginator
+	RelationDeleter
+
+	Resolve(ctx context.Context, token, password string) (string, error)
+}
+
+type RetentionPolicy interface {
+	OriginalExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error)
+	ConvertedExpiresAt(ctx context.Context, userID int64, from time.Time) (*time.Time, error)
+}
+
+type QuotaChecker interface {
+	Check(ctx context.Context, userID, fileSize int64) error
+}
+
+type Quota interface {
+	QuotaChecker
+
+	Usage(ctx context.Context, userID int64) (jsonapi.Linkable, error)
+}
+
+// PasswordHasher hashes and verifies user passwords. Verify reports whether
+// password matches and whether stored hash should be upgraded
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, bool, error)
+}
+
+// Account verifies email of user and resets forgotten password with tokens sent by email
+type Account interface {
+	SendVerification(ctx context.Context, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	PasswordResetEmail(ctx context.Context, token string) (string, error)
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+// MFA manages TOTP two-factor authentication of user. VerifyMFA completes
+// sign in of user with challenge returned by Tokenable.GenerateToken
+type MFA interface {
+	EnrollTOTP(ctx context.Context, userID int64) (jsonapi.Linkable, error)
+	ConfirmTOTP(ctx context.Context, userID int64, code string) (jsonapi.Linkable, error)
+	DisableTOTP(ctx context.Context, userID int64, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (jsonapi.Linkable, error)
+	VerifyMFA(ctx context.Context, challenge, code string) (jsonapi.Linkable, error)
+}
+
+// TokenIssuer issues access and refresh tokens for user authenticated elsewhere.
+// Challenge is issued instead for user with two-factor authentication, it is
+// exchanged for tokens with MFA.VerifyMFA
+type TokenIssuer interface {
+	Issue(ctx context.Context, id int64, email, role string) (jsonapi.Linkable, error)
+	Challenge(ctx context.Context, id int64, email string) (jsonapi.Linkable, error)
+}
+
+// SSO signs in users with external OpenID Connect provider. AuthURL returns
+// url of provider login page and state of login, which browser has to keep.
+// Callback completes login with response of provider and state kept by browser
+type SSO interface {
+	AuthURL(ctx context.Context) (string, string, error)
+	Callback(ctx context.Context, state, browserState, code string) (jsonapi.Linkable, error)
+}
+
+// LoginLimiter protects sign in against password guessing. Check returns how
+// long sign in has to wait, zero allows it
+type LoginLimiter interface {
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	Fail(ctx context.Context, email, ip string) error
+	Succeed(ctx context.Context, email string) error
+}
+
+// VideoInfo is metadata of uploaded video detected before upload
+type VideoInfo struct {
+	// Container is format of file, e.g. mp4, mkv, webm, mov, avi or ts
+	Container string
+	// Codec of video stream, empty if file wasn't probed
+	Codec    string
+	Duration float64
+	Width    int
+	Height   int
+}
+
+// VideoProber checks that uploaded file is a video allowed for compression
+type VideoProber interface {
+	Probe(ctx context.Context, file *multipart.FileHeader) (*VideoInfo, error)
+}
+
+// Scanner checks content of uploaded file with name for malware, returns name
+// of found threat or empty string if file is clean. Content may not be read to
+// the end
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader, name string) (string, error)
+}
+
+// PasswordPolicy checks password of user with email, returns descriptions of
+// violated rules or empty slice if password is accepted
+type PasswordPolicy interface {
+	Check(email, password string) []string
+}
+
+// RateLimit is state of rate limit of client after request
+type RateLimit struct {
+	Allowed bool
+	// Limit is size of bucket, requests allowed in a burst
+	Limit int
+	// Remaining requests allowed right now
+	Remaining int
+	// Reset is time until bucket is full again
+	Reset time.Duration
+	// RetryAfter is time until next request is allowed, zero if it's allowed now
+	RetryAfter time.Duration
+}
+
+// Idempotency makes repeated requests with the same idempotency key return
+// the original response. Begin returns stored key, completed key has response
+type Idempotency interface {
+	Begin(ctx context.Context, userID int64, key, fingerprint string) (*idempotency.Key, error)
+	Complete(ctx context.Context, id int64, status int, contentType string, response []byte) error
+	Release(ctx context.Context, id int64) error
+}
+
+// RateLimiter limits requests of client (user, API key or ip) to route group.
+// Nil RateLimit is returned when group isn't limited
+type RateLimiter interface {
+	Allow(ctx context.Context, group, client string) (*RateLimit, error)
+}
+
+// Mailer sends plain text email
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
 }
 
 type Publisher interface {
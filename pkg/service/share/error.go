@@ -0,0 +1,20 @@
+package share
+
+import "errors"
+
+var (
+	// ErrVideoNotPresent returns if video doesn't exists or user has no access to it
+	ErrVideoNotPresent = errors.New("video does not exists")
+	// ErrVideoUnavailable returns if file of video isn't in cloud or retention period of video is over
+	ErrVideoUnavailable = errors.New("video is not available")
+	// ErrExpiresInPast returns if share link is created with expiration time in the past
+	ErrExpiresInPast = errors.New("expiration time is in the past")
+	// ErrShareLinkNotPresent returns if share link doesn't exists or is already revoked
+	ErrShareLinkNotPresent = errors.New("share link does not exists")
+	// ErrInvalidShareLink returns if share link is unknown, revoked, expired or has no downloads left
+	ErrInvalidShareLink = errors.New("invalid share link")
+	// ErrPasswordRequired returns if share link is protected and password is missing
+	ErrPasswordRequired = errors.New("password required")
+	// ErrInvalidPassword returns if password of share link doesn't match
+	ErrInvalidPassword = errors.New("invalid password")
+)
@@ -0,0 +1,209 @@
+// Package share manages public share links which allow downloading
+// of video without account
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Hargeon/videocmprs/api/query"
+	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/share"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+
+	"github.com/google/jsonapi"
+)
+
+const tokenLen = 32
+
+// Service creates, lists, revokes and resolves share links
+type Service struct {
+	repo   repository.ShareLinkRepository
+	videos repository.VideoRepository
+	cloud  service.CloudStorage
+	hasher service.PasswordHasher
+	audit  service.AuditRecorder
+}
+
+// NewService initialize Service
+func NewService(repo repository.ShareLinkRepository, videos repository.VideoRepository,
+	cloud service.CloudStorage, hasher service.PasswordHasher, audit service.AuditRecorder) *Service {
+	return &Service{repo: repo, videos: videos, cloud: cloud, hasher: hasher, audit: audit}
+}
+
+// Create generates share link for video available to user. Plain token
+// is returned only here
+func (srv *Service) Create(ctx context.Context, resource jsonapi.Linkable) (jsonapi.Linkable, error) {
+	res, ok := resource.(*share.Resource)
+	if !ok {
+		return nil, service.ErrInvalidTypeAssertion
+	}
+
+	if res.Expired(time.Now()) {
+		return nil, ErrExpiresInPast
+	}
+
+	if err := srv.available(ctx, res.UserID, res.VideoID); err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Token = token
+	res.Hash = hashToken(token)
+
+	if res.Password != "" {
+		res.PasswordHash, err = srv.hasher.Hash(res.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Password = ""
+	}
+
+	return srv.repo.Create(ctx, res)
+}
+
+// List returns active share links of user
+func (srv *Service) List(ctx context.Context, params *query.Params) ([]interface{}, error) {
+	return srv.repo.List(ctx, params)
+}
+
+// Delete revokes share link of user
+func (srv *Service) Delete(ctx context.Context, userID, relationID int64) error {
+	revoked, err := srv.repo.Revoke(ctx, userID, relationID)
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return ErrShareLinkNotPresent
+	}
+
+	return nil
+}
+
+// Resolve checks password of share link by plain token, counts download
+// and returns url for downloading video from cloud. Download is recorded
+// in audit log without actor
+func (srv *Service) Resolve(ctx context.Context, token, password string) (string, error) {
+	link, err := srv.repo.RetrieveByHash(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrInvalidShareLink
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if link.Expired(now) || link.Exhausted() {
+		return "", ErrInvalidShareLink
+	}
+
+	if link.Protected {
+		if password == "" {
+			return "", ErrPasswordRequired
+		}
+
+		ok, _, err := srv.hasher.Verify(password, link.PasswordHash)
+		if err != nil {
+			return "", err
+		}
+
+		if !ok {
+			return "", ErrInvalidPassword
+		}
+	}
+
+	if link.ServiceID == "" || (link.VideoExpiresAt != nil && !link.VideoExpiresAt.After(now)) {
+		return "", ErrVideoUnavailable
+	}
+
+	used, err := srv.repo.UseDownload(ctx, link.ID, now)
+	if err != nil {
+		return "", err
+	}
+
+	if !used {
+		return "", ErrInvalidShareLink
+	}
+
+	url, err := srv.cloud.URL(link.ServiceID)
+	if err != nil {
+		return "", err
+	}
+
+	details, err := json.Marshal(map[string]int64{"video_id": link.VideoID})
+	if err != nil {
+		return "", err
+	}
+
+	srv.audit.Record(ctx, &audit.Event{
+		Action:       audit.ActionShareLinkDownload,
+		ResourceType: share.TableName,
+		ResourceID:   link.ID,
+		Details:      string(details),
+	})
+
+	return url, nil
+}
+
+// available returns error if video can't be shared by user or its file
+// can't be downloaded. Members of organization share only their own videos
+func (srv *Service) available(ctx context.Context, userID, videoID int64) error {
+	id, err := srv.videos.RelationManageable(ctx, userID, videoID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && id == 0) {
+		return ErrVideoNotPresent
+	}
+
+	if err != nil {
+		return err
+	}
+
+	v, err := srv.videos.Retrieve(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	vid, ok := v.(*video.Resource)
+	if !ok {
+		return service.ErrInvalidTypeAssertion
+	}
+
+	if vid.ServiceID == "" || vid.Expired() {
+		return ErrVideoUnavailable
+	}
+
+	return nil
+}
+
+// generateToken returns random url safe token
+func generateToken() (string, error) {
+	b := make([]byte, tokenLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns hash of token stored in db. Token has enough entropy,
+// so a fast hash without salt is sufficient
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,299 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
+	"github.com/Hargeon/videocmprs/pkg/repository/share"
+	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/encryption"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var (
+	testArgon2Params = encryption.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+	videoColumns     = []string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y",
+		"service_id", "expires_at"}
+	linkColumns = []string{"id", "user_id", "video_id", "password_hash", "max_downloads", "downloads",
+		"expires_at", "created_at", "service_id", "expires_at"}
+)
+
+type cloudMock struct{}
+
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
+	return "", nil
+}
+
+func (c *cloudMock) URL(filename string) (string, error) {
+	return "https://cloud.com/" + filename, nil
+}
+
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	return &service.CloudObject{Name: filename}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+// auditMock keeps recorded audit events
+type auditMock struct {
+	events []*audit.Event
+}
+
+func (a *auditMock) Record(ctx context.Context, e *audit.Event) {
+	a.events = append(a.events, e)
+}
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name        string
+		resource    *share.Resource
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:     "Protected link",
+			resource: &share.Resource{UserID: 1, VideoID: 3, Password: "secret", MaxDownloads: 2},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(3, 1, 1, "owner", "admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", video.TableName)).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows(videoColumns).AddRow(3, "video.mp4", 100, 0, 0, 0, 0, 0, "service_id", nil))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", share.TableName)).
+					WithArgs(1, 3, sqlmock.AnyArg(), sqlmock.AnyArg(), 2, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+			},
+		},
+		{
+			name:        "Expiration time in the past",
+			resource:    &share.Resource{UserID: 1, VideoID: 3, ExpiresAt: &past},
+			mock:        func() {},
+			expectedErr: ErrExpiresInPast,
+		},
+		{
+			name:     "Video of other user",
+			resource: &share.Resource{UserID: 1, VideoID: 3},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(3, 1, 1, "owner", "admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			expectedErr: ErrVideoNotPresent,
+		},
+		{
+			name:     "Video of other member of organization",
+			resource: &share.Resource{UserID: 1, VideoID: 3},
+			mock: func() {
+				// plain member can read the video, but only owner and admins of organization share it
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+) role IN", video.TableName)).
+					WithArgs(3, 1, 1, "owner", "admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			expectedErr: ErrVideoNotPresent,
+		},
+		{
+			name:     "Video isn't in cloud",
+			resource: &share.Resource{UserID: 1, VideoID: 3},
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(3, 1, 1, "owner", "admin").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", video.TableName)).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows(videoColumns).AddRow(3, "video.mp4", 100, 0, 0, 0, 0, 0, nil, nil))
+			},
+			expectedErr: ErrVideoUnavailable,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			srv := NewService(share.NewRepository(db), video.NewRepository(db), &cloudMock{},
+				encryption.NewPasswordHasher(testArgon2Params), new(auditMock))
+
+			created, err := srv.Create(context.Background(), testCase.resource)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil {
+				link := created.(*share.Resource)
+				if link.Token == "" || link.Password != "" || !link.Protected {
+					t.Errorf("Invalid link, got: %+v\n", link)
+				}
+
+				if testCase.resource.PasswordHash == "" || testCase.resource.Hash != hashToken(link.Token) {
+					t.Errorf("Token and password should be stored as hashes\n")
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	hasher := encryption.NewPasswordHasher(testArgon2Params)
+
+	passwordHash, err := hasher.Hash("secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err)
+	}
+
+	createdAt := time.Now().Add(-time.Hour)
+
+	expectLink := func(passwordHash interface{}, maxDownloads interface{}, downloads int, expiresAt interface{},
+		serviceID interface{}) {
+		mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", share.TableName)).
+			WithArgs(hashToken("token")).
+			WillReturnRows(sqlmock.NewRows(linkColumns).
+				AddRow(1, 5, 3, passwordHash, maxDownloads, downloads, expiresAt, createdAt, serviceID, nil))
+	}
+
+	cases := []struct {
+		name        string
+		password    string
+		mock        func()
+		expectedURL string
+		expectedErr error
+	}{
+		{
+			name: "Should return url",
+			mock: func() {
+				expectLink(nil, 2, 1, nil, "video.mp4")
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads", share.TableName)).
+					WithArgs(1, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedURL: "https://cloud.com/video.mp4",
+		},
+		{
+			name:     "Protected link with password",
+			password: "secret",
+			mock: func() {
+				expectLink(passwordHash, nil, 0, nil, "video.mp4")
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads", share.TableName)).
+					WithArgs(1, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedURL: "https://cloud.com/video.mp4",
+		},
+		{
+			name: "Protected link without password",
+			mock: func() {
+				expectLink(passwordHash, nil, 0, nil, "video.mp4")
+			},
+			expectedErr: ErrPasswordRequired,
+		},
+		{
+			name:     "Protected link with invalid password",
+			password: "other",
+			mock: func() {
+				expectLink(passwordHash, nil, 0, nil, "video.mp4")
+			},
+			expectedErr: ErrInvalidPassword,
+		},
+		{
+			name: "Unknown token",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", share.TableName)).
+					WithArgs(hashToken("token")).
+					WillReturnRows(sqlmock.NewRows(linkColumns))
+			},
+			expectedErr: ErrInvalidShareLink,
+		},
+		{
+			name: "Expired link",
+			mock: func() {
+				expectLink(nil, nil, 0, createdAt, "video.mp4")
+			},
+			expectedErr: ErrInvalidShareLink,
+		},
+		{
+			name: "Link without downloads left",
+			mock: func() {
+				expectLink(nil, 2, 2, nil, "video.mp4")
+			},
+			expectedErr: ErrInvalidShareLink,
+		},
+		{
+			name: "Concurrent download used last one",
+			mock: func() {
+				expectLink(nil, 2, 1, nil, "video.mp4")
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET downloads", share.TableName)).
+					WithArgs(1, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: ErrInvalidShareLink,
+		},
+		{
+			name: "Video isn't in cloud",
+			mock: func() {
+				expectLink(nil, nil, 0, nil, nil)
+			},
+			expectedErr: ErrVideoUnavailable,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			recorder := new(auditMock)
+			srv := NewService(share.NewRepository(db), video.NewRepository(db), &cloudMock{}, hasher, recorder)
+
+			url, err := srv.Resolve(context.Background(), "token", testCase.password)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if url != testCase.expectedURL {
+				t.Errorf("Invalid URL, expected: %s, got: %s\n", testCase.expectedURL, url)
+			}
+
+			recorded := len(recorder.events) == 1 && recorder.events[0].Action == audit.ActionShareLinkDownload &&
+				recorder.events[0].Details == `{"video_id":3}`
+			if recorded != (err == nil) {
+				t.Errorf("Only download should be recorded in audit log, got: %v\n", recorder.events)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
+		})
+	}
+}
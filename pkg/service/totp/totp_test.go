@@ -0,0 +1,98 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is SHA1 secret of RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	cases := []struct {
+		name         string
+		time         int64
+		expectedCode string
+	}{
+		{name: "59", time: 59, expectedCode: "287082"},
+		{name: "1111111109", time: 1111111109, expectedCode: "081804"},
+		{name: "1234567890", time: 1234567890, expectedCode: "005924"},
+		{name: "20000000000", time: 20000000000, expectedCode: "353130"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, Step(time.Unix(testCase.time, 0)))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err.Error())
+			}
+
+			if code != testCase.expectedCode {
+				t.Errorf("Invalid code, expected: %s, got: %s\n", testCase.expectedCode, code)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := Step(now)
+
+	previous, err := Code(rfcSecret, current-1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err.Error())
+	}
+
+	old, err := Code(rfcSecret, current-2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err.Error())
+	}
+
+	cases := []struct {
+		name          string
+		code          string
+		expectedValid bool
+		expectedStep  int64
+	}{
+		{name: "Current step", code: "081804", expectedValid: true, expectedStep: current},
+		{name: "Previous step", code: previous, expectedValid: true, expectedStep: current - 1},
+		{name: "Too old step", code: old},
+		{name: "Invalid code", code: "000000"},
+		{name: "Invalid length", code: "81804"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			step, valid, err := Validate(rfcSecret, testCase.code, now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err.Error())
+			}
+
+			if valid != testCase.expectedValid {
+				t.Errorf("Invalid valid, expected: %v, got: %v\n", testCase.expectedValid, valid)
+			}
+
+			if valid && step != testCase.expectedStep {
+				t.Errorf("Invalid step, expected: %d, got: %d\n", testCase.expectedStep, step)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s\n", err.Error())
+	}
+
+	if _, err = Code(secret, 1); err != nil {
+		t.Errorf("Unexpected error: %s\n", err.Error())
+	}
+
+	uri := URI("videocmprs", "check@check.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/videocmprs:check@check.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("Invalid uri: %s\n", uri)
+	}
+}
@@ -2,23 +2,22 @@ package user
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/Hargeon/videocmprs/pkg/repository"
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
 	"github.com/Hargeon/videocmprs/pkg/service"
-	"github.com/Hargeon/videocmprs/pkg/service/encryption"
 
 	"github.com/google/jsonapi"
 )
 
 type Service struct {
-	repo repository.UserRepository
+	repo   repository.UserRepository
+	hasher service.PasswordHasher
 }
 
 // NewService initialize Service
-func NewService(repo repository.UserRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo repository.UserRepository, hasher service.PasswordHasher) *Service {
+	return &Service{repo: repo, hasher: hasher}
 }
 
 // Create function is hashing password and use repository to create user
@@ -38,8 +37,12 @@ func (srv *Service) Create(ctx context.Context, resource jsonapi.Linkable) (json
 		return nil, service.ErrAlreadyExists
 	}
 
-	hashPass := encryption.GenerateHash([]byte(usr.Password))
-	usr.Password = fmt.Sprintf("%x", hashPass)
+	hashPass, err := srv.hasher.Hash(usr.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	usr.Password = hashPass
 
 	return srv.repo.Create(ctx, usr)
 }
@@ -2,7 +2,9 @@ package user
 
 import (
 	"context"
+	"database/sql/driver"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Hargeon/videocmprs/pkg/repository/user"
@@ -12,6 +14,17 @@ import (
 	"github.com/google/jsonapi"
 )
 
+var testArgon2Params = encryption.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+
+// argon2Hash matches any argon2id password hash
+type argon2Hash struct{}
+
+func (a argon2Hash) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+
+	return ok && strings.HasPrefix(hash, "$argon2id$")
+}
+
 func TestCreate(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -38,14 +51,13 @@ func TestCreate(t *testing.T) {
 					WithArgs("check@check.com").
 					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
 
-				passHash := encryption.GenerateHash([]byte("qjwpeqwpoekpqwe"))
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", user.TableName)).
-					WithArgs("check@check.com", fmt.Sprintf("%x", passHash)).
+					WithArgs("check@check.com", argon2Hash{}).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, email FROM %s", user.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, email, role FROM %s", user.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("1", "check@check.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).AddRow("1", "check@check.com", "user"))
 			},
 			expectedID:    1,
 			expectedEmail: "check@check.com",
@@ -62,9 +74,8 @@ func TestCreate(t *testing.T) {
 					WithArgs("").
 					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
 
-				passHash := encryption.GenerateHash([]byte("qjwpeqwpoekpqwe"))
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", user.TableName)).
-					WithArgs("", fmt.Sprintf("%x", passHash)).
+					WithArgs("", argon2Hash{}).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 			},
 			expectedID:    0,
@@ -78,7 +89,7 @@ func TestCreate(t *testing.T) {
 			testCase := testCase
 			testCase.mock()
 			repo := user.NewRepository(db)
-			srv := NewService(repo)
+			srv := NewService(repo, encryption.NewPasswordHasher(testArgon2Params))
 			linkable, err := srv.Create(context.Background(), testCase.usr)
 			if err != nil && !testCase.errorPresent {
 				t.Errorf("Unexpected error: %s\n", err)
@@ -9,4 +9,6 @@ var (
 	ErrVideoAssertion = errors.New("invalid type assertion *video.Resource")
 	// ErrVideoNotInCloud returns if video doesn't exists in cloud
 	ErrVideoNotInCloud = errors.New("video doesn't exists in cloud")
+	// ErrVideoExpired returns if retention period of video is over
+	ErrVideoExpired = errors.New("video is expired")
 )
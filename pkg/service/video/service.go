@@ -2,10 +2,15 @@ package video
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"io"
 
 	"github.com/Hargeon/videocmprs/pkg/repository"
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
 	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/cloud"
 
 	"github.com/google/jsonapi"
 )
@@ -13,10 +18,11 @@ import (
 type Service struct {
 	repo  repository.VideoRepository
 	cloud service.CloudStorage
+	audit service.AuditRecorder
 }
 
-func NewService(repo repository.VideoRepository, cloud service.CloudStorage) *Service {
-	return &Service{repo: repo, cloud: cloud}
+func NewService(repo repository.VideoRepository, cloud service.CloudStorage, audit service.AuditRecorder) *Service {
+	return &Service{repo: repo, cloud: cloud, audit: audit}
 }
 
 // Retrieve video by userID and videoID
@@ -33,21 +39,121 @@ func (s *Service) Retrieve(ctx context.Context, userID, relationID int64) (jsona
 	return s.repo.Retrieve(ctx, id)
 }
 
-// DownloadURL returns url for downloading video from cloud
+// Delete video by userID and videoID. Video is marked as deleted and removed
+// from cloud after the grace period
+func (s *Service) Delete(ctx context.Context, userID, relationID int64) error {
+	id, err := s.repo.RelationManageable(ctx, userID, relationID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && id == 0) {
+		return ErrVideoNotPresent
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, &audit.Event{
+		UserID:       userID,
+		Action:       audit.ActionVideoDeleted,
+		ResourceType: video.TableName,
+		ResourceID:   id,
+	})
+
+	return nil
+}
+
+// DownloadURL returns url for downloading video from cloud, generated url
+// is recorded in audit log
 func (s *Service) DownloadURL(ctx context.Context, userID, videoID int64) (string, error) {
-	v, err := s.Retrieve(ctx, userID, videoID)
+	vid, err := s.stored(ctx, userID, videoID)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := s.cloud.URL(vid.ServiceID)
 	if err != nil {
 		return "", err
 	}
 
+	s.audit.Record(ctx, &audit.Event{
+		UserID:       userID,
+		Action:       audit.ActionVideoDownloadURL,
+		ResourceType: video.TableName,
+		ResourceID:   vid.ID,
+	})
+
+	return url, nil
+}
+
+// Content returns video with information about its file stored in cloud
+func (s *Service) Content(ctx context.Context, userID, videoID int64) (*video.Resource, *service.CloudObject, error) {
+	vid, err := s.stored(ctx, userID, videoID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obj, err := s.cloud.Stat(ctx, vid.ServiceID)
+	if errors.Is(err, cloud.ErrNotFound) {
+		return nil, nil, ErrVideoNotInCloud
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return vid, obj, nil
+}
+
+// OpenContent returns reader of length bytes of video file starting from offset.
+// Download is recorded in audit log when file is read from the beginning, so
+// players requesting the rest of file by ranges don't add events
+func (s *Service) OpenContent(ctx context.Context, vid *video.Resource, offset, length int64) (io.ReadCloser, error) {
+	body, err := s.cloud.Open(ctx, vid.ServiceID, offset, length)
+	if errors.Is(err, cloud.ErrNotFound) {
+		return nil, ErrVideoNotInCloud
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if offset == 0 {
+		s.audit.Record(ctx, &audit.Event{
+			Action:       audit.ActionVideoDownload,
+			ResourceType: video.TableName,
+			ResourceID:   vid.ID,
+		})
+	}
+
+	return body, nil
+}
+
+// stored returns video of user which file is present in cloud
+func (s *Service) stored(ctx context.Context, userID, videoID int64) (*video.Resource, error) {
+	v, err := s.Retrieve(ctx, userID, videoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVideoNotPresent
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
 	vid, ok := v.(*video.Resource)
 	if !ok {
-		return "", ErrVideoAssertion
+		return nil, ErrVideoAssertion
 	}
 
 	if vid.ServiceID == "" {
-		return "", ErrVideoNotInCloud
+		return nil, ErrVideoNotInCloud
+	}
+
+	if vid.Expired() {
+		return nil, ErrVideoExpired
 	}
 
-	return s.cloud.URL(vid.ServiceID)
+	return vid, nil
 }
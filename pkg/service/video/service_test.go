@@ -4,17 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Hargeon/videocmprs/pkg/repository/audit"
 	"github.com/Hargeon/videocmprs/pkg/repository/video"
+	"github.com/Hargeon/videocmprs/pkg/service"
+	"github.com/Hargeon/videocmprs/pkg/service/cloud"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
 
 type cloudMock struct{}
 
-func (c *cloudMock) Upload(ctx context.Context, header *multipart.FileHeader) (string, error) {
+func (c *cloudMock) Upload(ctx context.Context, userID int64, header *multipart.FileHeader) (string, error) {
 	if header.Filename == "failed" {
 		return "", errors.New("failed connection")
 	}
@@ -30,6 +36,50 @@ func (c *cloudMock) URL(filename string) (string, error) {
 	return filename, nil
 }
 
+func (c *cloudMock) Delete(ctx context.Context, filename string) error {
+	if filename == "error" {
+		return errors.New("mock error")
+	}
+
+	return nil
+}
+
+func (c *cloudMock) List(ctx context.Context) ([]service.CloudObject, error) {
+	return []service.CloudObject{}, nil
+}
+
+const cloudContent = "0123456789"
+
+func (c *cloudMock) Stat(ctx context.Context, filename string) (*service.CloudObject, error) {
+	if filename == "error" {
+		return nil, errors.New("mock error")
+	}
+
+	if filename == "missing" {
+		return nil, cloud.ErrNotFound
+	}
+
+	return &service.CloudObject{
+		Name:         filename,
+		Size:         int64(len(cloudContent)),
+		LastModified: time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC),
+		ETag:         `"mock_etag"`,
+	}, nil
+}
+
+func (c *cloudMock) Open(ctx context.Context, filename string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(cloudContent[offset : offset+length])), nil
+}
+
+// auditMock keeps recorded audit events
+type auditMock struct {
+	events []*audit.Event
+}
+
+func (a *auditMock) Record(ctx context.Context, e *audit.Event) {
+	a.events = append(a.events, e)
+}
+
 func TestRetrieve(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -58,13 +108,13 @@ func TestRetrieve(t *testing.T) {
 			userID: 1,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "mock_service_id"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "mock_service_id", nil))
 			},
 			expectedID:          1,
 			expectedName:        "my_name.mkv",
@@ -83,7 +133,7 @@ func TestRetrieve(t *testing.T) {
 			userID: 1,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}))
 			},
 			errorPresent: true,
@@ -95,7 +145,7 @@ func TestRetrieve(t *testing.T) {
 			testCase := testCase
 			testCase.mock()
 			repo := video.NewRepository(db)
-			srv := NewService(repo, &cloudMock{})
+			srv := NewService(repo, &cloudMock{}, new(auditMock))
 			linkable, err := srv.Retrieve(context.Background(), testCase.userID, testCase.id)
 			if err != nil && !testCase.errorPresent {
 				t.Errorf("Unexpected error: %s\n", err)
@@ -181,7 +231,7 @@ func TestDownloadURL(t *testing.T) {
 			errorPresent: true,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}))
 			},
 		},
@@ -192,13 +242,13 @@ func TestDownloadURL(t *testing.T) {
 			errorPresent: true,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "error"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "error", nil))
 			},
 		},
 		{
@@ -208,7 +258,7 @@ func TestDownloadURL(t *testing.T) {
 			errorPresent: true,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}))
 			},
 		},
@@ -218,13 +268,13 @@ func TestDownloadURL(t *testing.T) {
 			videoID: 1,
 			mock: func() {
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
-					WithArgs(1, 1).
+					WithArgs(1, 1, 1).
 					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id FROM %s", video.TableName)).
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, size, bitrate, resolution_x, resolution_y, ratio_x, ratio_y, service_id, expires_at FROM %s", video.TableName)).
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id"}).
-						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "https://video.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "https://video.com", nil))
 			},
 			expectedURL: "https://video.com",
 		},
@@ -234,7 +284,8 @@ func TestDownloadURL(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mock()
 			repo := video.NewRepository(db)
-			srv := NewService(repo, &cloudMock{})
+			recorder := new(auditMock)
+			srv := NewService(repo, &cloudMock{}, recorder)
 
 			url, err := srv.DownloadURL(context.Background(), testCase.userID, testCase.videoID)
 			if err != nil && !testCase.errorPresent {
@@ -249,6 +300,99 @@ func TestDownloadURL(t *testing.T) {
 				t.Errorf("Invalid URL, expected: %s, got: %s\n",
 					testCase.expectedURL, url)
 			}
+
+			recorded := len(recorder.events) == 1 && recorder.events[0].Action == audit.ActionVideoDownloadURL &&
+				recorder.events[0].UserID == testCase.userID && recorder.events[0].ResourceID == testCase.videoID
+			if recorded != (err == nil) {
+				t.Errorf("Only generated url should be recorded in audit log, got: %v\n", recorder.events)
+			}
+		})
+	}
+}
+
+func TestContent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error when opening a stub db connection, error: %s\n", err)
+	}
+
+	cases := []struct {
+		name         string
+		mock         func()
+		expectedErr  error
+		expectedSize int64
+	}{
+		{
+			name: "Video doesn't exists",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1).
+					WillReturnRows(mock.NewRows([]string{"id"}))
+			},
+			expectedErr: ErrVideoNotPresent,
+		},
+		{
+			name: "Video is not in cloud",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1).
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", video.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, nil, nil))
+			},
+			expectedErr: ErrVideoNotInCloud,
+		},
+		{
+			name: "File is missing in cloud",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1).
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", video.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "missing", nil))
+			},
+			expectedErr: ErrVideoNotInCloud,
+		},
+		{
+			name: "Should return content",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s", video.TableName)).
+					WithArgs(1, 1, 1).
+					WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1))
+
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", video.TableName)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "bitrate", "resolution_x", "resolution_y", "ratio_x", "ratio_y", "service_id", "expires_at"}).
+						AddRow(1, "my_name.mkv", 1258000, 789569, 700, 600, 4, 3, "mock_service_id", nil))
+			},
+			expectedSize: int64(len(cloudContent)),
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mock()
+			repo := video.NewRepository(db)
+			srv := NewService(repo, &cloudMock{}, new(auditMock))
+
+			_, obj, err := srv.Content(context.Background(), 1, 1)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("Invalid error, expected: %v, got: %v\n", testCase.expectedErr, err)
+			}
+
+			if err == nil && obj.Size != testCase.expectedSize {
+				t.Errorf("Invalid size, expected: %d, got: %d\n", testCase.expectedSize, obj.Size)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s\n", err)
+			}
 		})
 	}
 }